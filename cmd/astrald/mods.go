@@ -8,20 +8,27 @@ import (
 	_ "github.com/cryptopunkscc/astrald/mod/agent/src"
 	_ "github.com/cryptopunkscc/astrald/mod/apphost/src"
 	_ "github.com/cryptopunkscc/astrald/mod/data/src"
+	_ "github.com/cryptopunkscc/astrald/mod/dbstore/src"
 	_ "github.com/cryptopunkscc/astrald/mod/discovery/src"
 	_ "github.com/cryptopunkscc/astrald/mod/fs/src"
 	_ "github.com/cryptopunkscc/astrald/mod/fwd/src"
 	_ "github.com/cryptopunkscc/astrald/mod/gateway/src"
 	_ "github.com/cryptopunkscc/astrald/mod/index/src"
 	_ "github.com/cryptopunkscc/astrald/mod/keys/src"
+	_ "github.com/cryptopunkscc/astrald/mod/memstore/src"
+	_ "github.com/cryptopunkscc/astrald/mod/mesh/src"
 	_ "github.com/cryptopunkscc/astrald/mod/policy/src"
 	_ "github.com/cryptopunkscc/astrald/mod/presence/src"
 	_ "github.com/cryptopunkscc/astrald/mod/profile/src"
+	_ "github.com/cryptopunkscc/astrald/mod/quic/src"
 	_ "github.com/cryptopunkscc/astrald/mod/reflectlink/src"
 	_ "github.com/cryptopunkscc/astrald/mod/relay/src"
+	_ "github.com/cryptopunkscc/astrald/mod/s3/src"
+	_ "github.com/cryptopunkscc/astrald/mod/search/src"
 	_ "github.com/cryptopunkscc/astrald/mod/setup/src"
 	_ "github.com/cryptopunkscc/astrald/mod/speedtest/src"
 	_ "github.com/cryptopunkscc/astrald/mod/storage/src"
+	_ "github.com/cryptopunkscc/astrald/mod/tar/src"
 	_ "github.com/cryptopunkscc/astrald/mod/tcp/src"
 	_ "github.com/cryptopunkscc/astrald/mod/tor/src"
 	_ "github.com/cryptopunkscc/astrald/mod/user/src"
@@ -0,0 +1,308 @@
+package data
+
+import (
+	"crypto/sha256"
+	"errors"
+	"github.com/cryptopunkscc/astrald/cslq"
+	"hash"
+	"io"
+)
+
+// TreeBlockSize is the size of a data block covered by a single leaf of a hash tree
+const TreeBlockSize = 64 * 1024
+
+// MaxTreeLeaves limits the size of a decoded hash tree (covers objects up to 4TB)
+const MaxTreeLeaves = 1 << 26
+
+// treeDecodeBatch is the number of leaves allocated up front while decoding. Further leaves are allocated as
+// they arrive, so that memory grows with the data actually received and not with the count claimed by the sender.
+const treeDecodeBatch = 4096
+
+var ErrBlockMismatch = errors.New("block hash mismatch")
+var ErrInvalidTree = errors.New("invalid hash tree")
+
+const (
+	leafPrefix = 0x00
+	nodePrefix = 0x01
+)
+
+// HashTree is a Merkle tree over the blocks of a data object. It is kept outboard, next to the data, and lets
+// readers verify each block as it arrives instead of waiting for the whole object to be hashed.
+//
+// The root of the tree is not part of the data ID, so a tree proves nothing about the ID by itself. A tree
+// can be trusted if it was computed from data that matched its ID (see ResolveTree) or if it matches a TreeID,
+// which names the object by the root of its tree.
+type HashTree struct {
+	Size   uint64
+	Leaves [][32]byte
+}
+
+// Blocks returns the number of blocks of an object of the given size
+func Blocks(size uint64) int {
+	if size == 0 {
+		return 1
+	}
+	return int((size + TreeBlockSize - 1) / TreeBlockSize)
+}
+
+// Root returns the root hash of the tree
+func (tree *HashTree) Root() (root [32]byte) {
+	if len(tree.Leaves) == 0 {
+		return
+	}
+
+	var level = tree.Leaves
+	for len(level) > 1 {
+		var next = make([][32]byte, 0, (len(level)+1)/2)
+		for i := 0; i < len(level); i += 2 {
+			if i+1 == len(level) {
+				next = append(next, level[i])
+				continue
+			}
+			next = append(next, hashNode(level[i], level[i+1]))
+		}
+		level = next
+	}
+
+	return level[0]
+}
+
+// Check returns an error if the number of leaves doesn't match the size
+func (tree *HashTree) Check() error {
+	if len(tree.Leaves) != Blocks(tree.Size) {
+		return ErrInvalidTree
+	}
+	return nil
+}
+
+// VerifyBlock checks a block against its leaf
+func (tree *HashTree) VerifyBlock(index int, block []byte) error {
+	if index < 0 || index >= len(tree.Leaves) {
+		return ErrBlockMismatch
+	}
+	if hashLeaf(block) != tree.Leaves[index] {
+		return ErrBlockMismatch
+	}
+	return nil
+}
+
+// NewVerifier returns a reader that verifies every block read from r. The data read from r has to start at
+// the beginning of the block containing offset. The returned reader starts at offset.
+func (tree *HashTree) NewVerifier(r io.Reader, offset uint64) io.Reader {
+	var aligned = AlignOffset(offset)
+
+	return &verifier{
+		tree:  tree,
+		r:     r,
+		block: int(aligned / TreeBlockSize),
+		pos:   aligned,
+		skip:  int(offset - aligned),
+	}
+}
+
+func (tree *HashTree) MarshalCSLQ(enc *cslq.Encoder) error {
+	if err := enc.Encodef("ql", tree.Size, uint32(len(tree.Leaves))); err != nil {
+		return err
+	}
+	for _, leaf := range tree.Leaves {
+		if err := enc.Encodef("[32]c", leaf); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (tree *HashTree) UnmarshalCSLQ(dec *cslq.Decoder) error {
+	var count uint32
+	if err := dec.Decodef("ql", &tree.Size, &count); err != nil {
+		return err
+	}
+	if count > MaxTreeLeaves || int(count) != Blocks(tree.Size) {
+		return ErrInvalidTree
+	}
+
+	return tree.decodeLeaves(dec, int(count))
+}
+
+// DecodeTree decodes a hash tree of an object of the given size. The size is checked before any leaves are
+// read, so a tree of a different object is rejected without allocating memory for it.
+func DecodeTree(r io.Reader, size uint64) (*HashTree, error) {
+	var dec = cslq.NewDecoder(r)
+	var tree = &HashTree{}
+	var count uint32
+
+	if err := dec.Decodef("ql", &tree.Size, &count); err != nil {
+		return nil, err
+	}
+	if tree.Size != size || count > MaxTreeLeaves || int(count) != Blocks(size) {
+		return nil, ErrInvalidTree
+	}
+
+	if err := tree.decodeLeaves(dec, int(count)); err != nil {
+		return nil, err
+	}
+
+	return tree, nil
+}
+
+// decodeLeaves reads count leaves. Leaves are allocated as they arrive, starting with a small batch.
+func (tree *HashTree) decodeLeaves(dec *cslq.Decoder, count int) error {
+	tree.Leaves = make([][32]byte, 0, min(count, treeDecodeBatch))
+
+	for len(tree.Leaves) < count {
+		var leaf [32]byte
+		if err := dec.Decodef("[32]c", &leaf); err != nil {
+			return err
+		}
+		tree.Leaves = append(tree.Leaves, leaf)
+	}
+
+	return nil
+}
+
+// AlignOffset returns the offset of the block containing offset
+func AlignOffset(offset uint64) uint64 {
+	return offset - offset%TreeBlockSize
+}
+
+// TreeResolver resolves both the ID and the hash tree of the data written to it
+type TreeResolver struct {
+	Resolver
+	block hash.Hash
+	fill  int
+	tree  HashTree
+}
+
+func NewTreeResolver() *TreeResolver {
+	var r = &TreeResolver{
+		Resolver: NewResolver(),
+		block:    sha256.New(),
+	}
+	r.block.Write([]byte{leafPrefix})
+	return r
+}
+
+func (r *TreeResolver) Write(p []byte) (n int, err error) {
+	n, err = r.Resolver.Write(p)
+
+	for len(p) > 0 {
+		var l = min(len(p), TreeBlockSize-r.fill)
+		r.block.Write(p[:l])
+		r.fill += l
+		r.tree.Size += uint64(l)
+		p = p[l:]
+
+		if r.fill == TreeBlockSize {
+			r.closeBlock()
+		}
+	}
+
+	return
+}
+
+// Tree returns the hash tree of the data written so far
+func (r *TreeResolver) Tree() *HashTree {
+	var tree = &HashTree{
+		Size:   r.tree.Size,
+		Leaves: append([][32]byte{}, r.tree.Leaves...),
+	}
+
+	if r.fill > 0 || len(tree.Leaves) == 0 {
+		var leaf [32]byte
+		copy(leaf[:], r.block.Sum(nil))
+		tree.Leaves = append(tree.Leaves, leaf)
+	}
+
+	return tree
+}
+
+func (r *TreeResolver) closeBlock() {
+	var leaf [32]byte
+	copy(leaf[:], r.block.Sum(nil))
+	r.tree.Leaves = append(r.tree.Leaves, leaf)
+
+	r.block.Reset()
+	r.block.Write([]byte{leafPrefix})
+	r.fill = 0
+}
+
+// ResolveTree reads all data from the reader and returns its ID and hash tree
+func ResolveTree(reader io.Reader) (ID, *HashTree, error) {
+	r := NewTreeResolver()
+
+	if _, err := io.Copy(r, reader); err != nil {
+		return ID{}, nil, err
+	}
+
+	return r.Resolve(), r.Tree(), nil
+}
+
+type verifier struct {
+	tree  *HashTree
+	r     io.Reader
+	buf   []byte
+	block int
+	pos   uint64
+	skip  int
+	err   error
+}
+
+func (v *verifier) Read(p []byte) (n int, err error) {
+	if len(v.buf) == 0 {
+		if v.err != nil {
+			return 0, v.err
+		}
+		if v.err = v.next(); v.err != nil {
+			return 0, v.err
+		}
+	}
+
+	n = copy(p, v.buf)
+	v.buf = v.buf[n:]
+	return n, nil
+}
+
+// next reads and verifies the next block
+func (v *verifier) next() error {
+	if v.pos >= v.tree.Size {
+		return io.EOF
+	}
+
+	var size = min(TreeBlockSize, v.tree.Size-v.pos)
+	var block = make([]byte, size)
+
+	if _, err := io.ReadFull(v.r, block); err != nil {
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+		return err
+	}
+
+	if err := v.tree.VerifyBlock(v.block, block); err != nil {
+		return err
+	}
+
+	v.block++
+	v.pos += size
+	v.buf = block[v.skip:]
+	v.skip = 0
+
+	return nil
+}
+
+func hashLeaf(block []byte) (h [32]byte) {
+	var s = sha256.New()
+	s.Write([]byte{leafPrefix})
+	s.Write(block)
+	copy(h[:], s.Sum(nil))
+	return
+}
+
+func hashNode(left, right [32]byte) (h [32]byte) {
+	var s = sha256.New()
+	s.Write([]byte{nodePrefix})
+	s.Write(left[:])
+	s.Write(right[:])
+	copy(h[:], s.Sum(nil))
+	return
+}
@@ -0,0 +1,117 @@
+package data
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/cryptopunkscc/astrald/cslq"
+	"strings"
+)
+
+const treeIDPrefix = "tree1"
+
+var _ cslq.Marshaler = &TreeID{}
+var _ cslq.Unmarshaler = &TreeID{}
+
+// TreeID is the tree-hash variant of ID. It identifies a data object by its size and the root of its hash tree,
+// so any tree that matches the TreeID is bound to the object and can be used to verify blocks of data received
+// from untrusted sources.
+type TreeID struct {
+	Size uint64
+	Root [32]byte
+}
+
+func (id TreeID) Pack() [40]byte {
+	var b [40]byte
+	binary.BigEndian.PutUint64(b[0:8], id.Size)
+	copy(b[8:], id.Root[0:32])
+	return b
+}
+
+func (id TreeID) String() string {
+	packed := id.Pack()
+	enc := zBase32Encoding.EncodeToString(packed[:])
+	enc = strings.TrimLeft(enc, zBase32CharSet[0:1])
+	return treeIDPrefix + enc
+}
+
+// Check returns an error if the tree doesn't match the ID
+func (id TreeID) Check(tree *HashTree) error {
+	if tree.Size != id.Size || tree.Check() != nil || tree.Root() != id.Root {
+		return ErrInvalidTree
+	}
+	return nil
+}
+
+// ID returns the TreeID of the tree
+func (tree *HashTree) ID() TreeID {
+	return TreeID{Size: tree.Size, Root: tree.Root()}
+}
+
+func UnpackTreeID(data [40]byte) (id TreeID) {
+	id.Size = binary.BigEndian.Uint64(data[0:8])
+	copy(id.Root[:], data[8:40])
+	return
+}
+
+func ParseTreeID(s string) (id TreeID, err error) {
+	if !strings.HasPrefix(s, treeIDPrefix) {
+		return TreeID{}, errors.New("invalid prefix")
+	}
+	s = strings.TrimPrefix(s, treeIDPrefix)
+
+	// Pad with missing leading zeros
+	z := 64 - len(s)
+	if z < 0 {
+		return TreeID{}, errors.New("invalid data length")
+	}
+	padded := strings.Repeat(zBase32CharSet[0:1], z) + s
+
+	var data [40]byte
+	n, err := zBase32Encoding.Decode(data[:], []byte(padded))
+	if err != nil {
+		return TreeID{}, err
+	}
+	if n != 40 {
+		return TreeID{}, errors.New("invalid data length")
+	}
+
+	return UnpackTreeID(data), nil
+}
+
+func (id *TreeID) UnmarshalCSLQ(dec *cslq.Decoder) (err error) {
+	var buf [40]byte
+	if err = dec.Decodef("[40]c", &buf); err != nil {
+		return
+	}
+
+	*id = UnpackTreeID(buf)
+
+	return
+}
+
+func (id TreeID) MarshalCSLQ(enc *cslq.Encoder) error {
+	return enc.Encodef("[40]c", id.Pack())
+}
+
+func (id TreeID) MarshalJSON() ([]byte, error) {
+	return []byte(fmt.Sprintf("\"%s\"", id.String())), nil
+}
+
+func (id *TreeID) UnmarshalJSON(b []byte) error {
+	var s string
+	if err := json.NewDecoder(bytes.NewReader(b)).Decode(&s); err != nil {
+		return err
+	}
+
+	parsed, err := ParseTreeID(s)
+	if err != nil {
+		return err
+	}
+
+	*id = parsed
+
+	return nil
+}
@@ -0,0 +1,150 @@
+package data
+
+import (
+	"bytes"
+	"errors"
+	"github.com/cryptopunkscc/astrald/cslq"
+	"io"
+	"math/rand"
+	"testing"
+)
+
+func TestHashTree(t *testing.T) {
+	var content = make([]byte, 5*TreeBlockSize+1234)
+	rand.New(rand.NewSource(1)).Read(content)
+
+	dataID, tree, err := ResolveTree(bytes.NewReader(content))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if dataID != Resolve(content) {
+		t.Fatal("tree resolver returned a wrong id")
+	}
+	if err = tree.Check(); err != nil {
+		t.Fatal(err)
+	}
+
+	// encoding
+	var buf = &bytes.Buffer{}
+	if err = cslq.Encode(buf, "v", tree); err != nil {
+		t.Fatal(err)
+	}
+	var decoded HashTree
+	if err = cslq.Decode(buf, "v", &decoded); err != nil {
+		t.Fatal(err)
+	}
+	if decoded.Root() != tree.Root() {
+		t.Fatal("decoded tree has a different root")
+	}
+
+	// reading from an unaligned offset
+	var offset uint64 = 2*TreeBlockSize + 100
+	r := tree.NewVerifier(bytes.NewReader(content[AlignOffset(offset):]), offset)
+	read, err := io.ReadAll(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(read, content[offset:]) {
+		t.Fatal("verifier returned wrong data")
+	}
+
+	// a corrupted block fails before the rest of the data is read
+	var corrupted = bytes.Clone(content)
+	corrupted[3*TreeBlockSize+7] ^= 0xff
+
+	read, err = io.ReadAll(tree.NewVerifier(bytes.NewReader(corrupted), 0))
+	if !errors.Is(err, ErrBlockMismatch) {
+		t.Fatalf("expected block mismatch, got %v", err)
+	}
+	if len(read) != 3*TreeBlockSize {
+		t.Fatalf("expected %d verified bytes, got %d", 3*TreeBlockSize, len(read))
+	}
+}
+
+func TestHashTreeEmpty(t *testing.T) {
+	_, tree, err := ResolveTree(bytes.NewReader(nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = tree.Check(); err != nil {
+		t.Fatal(err)
+	}
+
+	read, err := io.ReadAll(tree.NewVerifier(bytes.NewReader(nil), 0))
+	if err != nil || len(read) != 0 {
+		t.Fatal("unexpected read from an empty object", err)
+	}
+}
+
+func TestDecodeTree(t *testing.T) {
+	var content = make([]byte, 3*TreeBlockSize)
+	rand.New(rand.NewSource(1)).Read(content)
+
+	_, tree, err := ResolveTree(bytes.NewReader(content))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var buf = &bytes.Buffer{}
+	if err = cslq.Encode(buf, "v", tree); err != nil {
+		t.Fatal(err)
+	}
+	var encoded = buf.Bytes()
+
+	decoded, err := DecodeTree(bytes.NewReader(encoded), tree.Size)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if decoded.Root() != tree.Root() {
+		t.Fatal("decoded tree has a different root")
+	}
+
+	// a tree of another size is rejected after the header
+	if _, err = DecodeTree(bytes.NewReader(encoded[:12]), tree.Size+1); !errors.Is(err, ErrInvalidTree) {
+		t.Fatalf("expected invalid tree, got %v", err)
+	}
+
+	// a header claiming the maximum number of leaves fails on the missing data, not on allocation
+	var header = &bytes.Buffer{}
+	cslq.Encode(header, "ql", uint64(MaxTreeLeaves)*TreeBlockSize, uint32(MaxTreeLeaves))
+
+	var huge HashTree
+	if err = cslq.Decode(header, "v", &huge); err == nil {
+		t.Fatal("expected an error")
+	}
+	if cap(huge.Leaves) > treeDecodeBatch {
+		t.Fatalf("allocated %d leaves before reading them", cap(huge.Leaves))
+	}
+}
+
+func TestTreeID(t *testing.T) {
+	var content = make([]byte, 2*TreeBlockSize+1)
+	rand.New(rand.NewSource(1)).Read(content)
+
+	_, tree, err := ResolveTree(bytes.NewReader(content))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var treeID = tree.ID()
+	parsed, err := ParseTreeID(treeID.String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if parsed != treeID {
+		t.Fatal("parsed tree id doesn't match")
+	}
+	if _, err = ParseTreeID(Resolve(content).String()); err == nil {
+		t.Fatal("parsed a data id as a tree id")
+	}
+
+	if err = treeID.Check(tree); err != nil {
+		t.Fatal(err)
+	}
+
+	// a tree with a modified leaf no longer matches the id
+	tree.Leaves[1][0] ^= 0xff
+	if err = treeID.Check(tree); !errors.Is(err, ErrInvalidTree) {
+		t.Fatalf("expected invalid tree, got %v", err)
+	}
+}
@@ -51,6 +51,16 @@ func (c *ApphostClient) Query(remoteID id.Identity, query string) (conn *Conn, e
 	return s.Query(remoteID, query)
 }
 
+// QueryWithParams sends a query with a set of key/value parameters attached to it
+func (c *ApphostClient) QueryWithParams(remoteID id.Identity, query string, params map[string]string) (conn *Conn, err error) {
+	s, err := c.Session()
+	if err != nil {
+		return nil, err
+	}
+
+	return s.QueryWithParams(remoteID, query, params)
+}
+
 func (c *ApphostClient) QueryName(name string, query string) (conn *Conn, err error) {
 	identity, err := c.Resolve(name)
 	if err != nil {
@@ -89,7 +99,15 @@ func (c *ApphostClient) Register(service string) (l *Listener, err error) {
 		return
 	}
 
-	err = s.Register(service, l.Target())
+	// nodes that don't support query parameters need a new session to register the service
+	l.params = true
+	err = s.RegisterWithParams(service, l.Target())
+	if errors.Is(err, proto.ErrUnknownCommand) {
+		l.params = false
+		if s, err = c.Session(); err == nil {
+			err = s.Register(service, l.Target())
+		}
+	}
 	if err != nil {
 		l.Close()
 		return
@@ -129,6 +147,10 @@ func Query(remoteID id.Identity, query string) (*Conn, error) {
 	return Client.Query(remoteID, query)
 }
 
+func QueryWithParams(remoteID id.Identity, query string, params map[string]string) (*Conn, error) {
+	return Client.QueryWithParams(remoteID, query, params)
+}
+
 func QueryName(name string, query string) (conn *Conn, err error) {
 	return Client.QueryName(name, query)
 }
@@ -11,6 +11,7 @@ type Conn struct {
 	net.Conn
 	remoteID id.Identity
 	query    string
+	params   map[string]string
 }
 
 func (conn Conn) RemoteIdentity() id.Identity {
@@ -24,3 +25,8 @@ func (conn Conn) RemoteAddr() net.Addr {
 func (conn Conn) Query() string {
 	return conn.query
 }
+
+// Params returns the parameters of the query
+func (conn Conn) Params() map[string]string {
+	return conn.params
+}
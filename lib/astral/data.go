@@ -0,0 +1,149 @@
+package astral
+
+import (
+	"github.com/cryptopunkscc/astrald/auth/id"
+	"github.com/cryptopunkscc/astrald/data"
+	"github.com/cryptopunkscc/astrald/mod/storage/proto"
+	"io"
+	"strconv"
+)
+
+// OpenData opens a seekable reader of a data object stored on a remote node. The reader implements io.ReaderAt
+// and io.ReadSeekCloser and fetches only the ranges that are actually read.
+func (c *ApphostClient) OpenData(remoteID id.Identity, dataID data.ID) (*proto.RemoteReader, error) {
+	conn, err := c.Query(remoteID, proto.ReadAtServiceName+"."+dataID.String())
+	if err != nil {
+		return nil, err
+	}
+
+	return proto.NewRemoteReader(conn, dataID.Size), nil
+}
+
+// ReadDataRange streams length bytes of a data object stored on a remote node, starting at offset
+func (c *ApphostClient) ReadDataRange(remoteID id.Identity, dataID data.ID, offset uint64, length uint64) (*Conn, error) {
+	return c.QueryWithParams(remoteID, proto.ReadServiceName+"."+dataID.String(), map[string]string{
+		proto.ParamOffset: strconv.FormatUint(offset, 10),
+		proto.ParamLength: strconv.FormatUint(length, 10),
+	})
+}
+
+// OpenDataVerified opens a seekable reader of a data object stored on a remote node and verifies every block
+// as it arrives. The hash tree is fetched from the remote node and checked against the TreeID, so blocks that
+// don't belong to the object are never returned.
+func (c *ApphostClient) OpenDataVerified(remoteID id.Identity, treeID data.TreeID) (*proto.RemoteReader, error) {
+	tree, err := c.FetchTree(remoteID, treeID)
+	if err != nil {
+		return nil, err
+	}
+
+	conn, err := c.Query(remoteID, proto.ReadAtServiceName+"."+treeID.String())
+	if err != nil {
+		return nil, err
+	}
+
+	return proto.NewVerifiedRemoteReader(conn, tree), nil
+}
+
+// ReadDataVerified streams a data object stored on a remote node and verifies every block as it arrives. The
+// hash tree is fetched from the remote node and checked against the TreeID. Reading fails on the first block
+// that doesn't match.
+func (c *ApphostClient) ReadDataVerified(remoteID id.Identity, treeID data.TreeID) (io.ReadCloser, error) {
+	tree, err := c.FetchTree(remoteID, treeID)
+	if err != nil {
+		return nil, err
+	}
+
+	conn, err := c.Query(remoteID, proto.ReadServiceName+"."+treeID.String())
+	if err != nil {
+		return nil, err
+	}
+
+	return struct {
+		io.Reader
+		io.Closer
+	}{tree.NewVerifier(conn, 0), conn}, nil
+}
+
+// FetchTree fetches the hash tree of a data object from a remote node and checks it against the TreeID
+func (c *ApphostClient) FetchTree(remoteID id.Identity, treeID data.TreeID) (*data.HashTree, error) {
+	conn, err := c.Query(remoteID, proto.TreeServiceName+"."+treeID.String())
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	tree, err := data.DecodeTree(conn, treeID.Size)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = treeID.Check(tree); err != nil {
+		return nil, err
+	}
+
+	return tree, nil
+}
+
+// ReadDataWithTree streams a data object stored on a remote node and verifies every block against the tree as
+// it arrives. The tree has to come from a trusted source. Reading fails on the first block that doesn't match
+// and at the end if the object doesn't match its ID.
+func (c *ApphostClient) ReadDataWithTree(remoteID id.Identity, dataID data.ID, tree *data.HashTree) (io.ReadCloser, error) {
+	if tree.Size != dataID.Size || tree.Check() != nil {
+		return nil, data.ErrInvalidTree
+	}
+
+	conn, err := c.Query(remoteID, proto.ReadServiceName+"."+dataID.String())
+	if err != nil {
+		return nil, err
+	}
+
+	return &verifiedReader{
+		Reader:   tree.NewVerifier(conn, 0),
+		Closer:   conn,
+		dataID:   dataID,
+		resolver: data.NewResolver(),
+	}, nil
+}
+
+func OpenData(remoteID id.Identity, dataID data.ID) (*proto.RemoteReader, error) {
+	return Client.OpenData(remoteID, dataID)
+}
+
+func ReadDataRange(remoteID id.Identity, dataID data.ID, offset uint64, length uint64) (*Conn, error) {
+	return Client.ReadDataRange(remoteID, dataID, offset, length)
+}
+
+func OpenDataVerified(remoteID id.Identity, treeID data.TreeID) (*proto.RemoteReader, error) {
+	return Client.OpenDataVerified(remoteID, treeID)
+}
+
+func ReadDataVerified(remoteID id.Identity, treeID data.TreeID) (io.ReadCloser, error) {
+	return Client.ReadDataVerified(remoteID, treeID)
+}
+
+func FetchTree(remoteID id.Identity, treeID data.TreeID) (*data.HashTree, error) {
+	return Client.FetchTree(remoteID, treeID)
+}
+
+func ReadDataWithTree(remoteID id.Identity, dataID data.ID, tree *data.HashTree) (io.ReadCloser, error) {
+	return Client.ReadDataWithTree(remoteID, dataID, tree)
+}
+
+// verifiedReader checks the ID of the whole object once all blocks were read
+type verifiedReader struct {
+	io.Reader
+	io.Closer
+	dataID   data.ID
+	resolver data.Resolver
+}
+
+func (r *verifiedReader) Read(p []byte) (n int, err error) {
+	n, err = r.Reader.Read(p)
+	r.resolver.Write(p[:n])
+
+	if err == io.EOF && r.resolver.Resolve() != r.dataID {
+		err = data.ErrBlockMismatch
+	}
+
+	return
+}
@@ -0,0 +1,19 @@
+package astral
+
+import (
+	"github.com/cryptopunkscc/astrald/mod/apphost/proto"
+	"github.com/cryptopunkscc/astrald/net"
+)
+
+// RejectError is returned when the target rejects a query. It carries the rejection code (one of
+// net.RejectCode*) and an optional message. It matches both proto.ErrRejected and net.ErrRejected.
+type RejectError struct {
+	net.RejectError
+}
+
+func (e *RejectError) Is(other error) bool {
+	if other == proto.ErrRejected {
+		return true
+	}
+	return e.RejectError.Is(other)
+}
@@ -12,6 +12,7 @@ type Listener struct {
 	listener net.Listener
 	portName string
 	onClose  func()
+	params   bool // queries carry parameters
 }
 
 func newListener(protocol string) (*Listener, error) {
@@ -29,9 +30,16 @@ func (l *Listener) Next() (*QueryData, error) {
 		return nil, err
 	}
 
-	var in proto.InQueryParams
+	var in proto.InQueryWithParams
 
-	if err = cslq.Decode(conn, "v", &in); err != nil {
+	if l.params {
+		err = cslq.Decode(conn, "v", &in)
+	} else {
+		var old proto.InQueryParams
+		err = cslq.Decode(conn, "v", &old)
+		in.Identity, in.Query = old.Identity, old.Query
+	}
+	if err != nil {
 		conn.Close()
 		return nil, err
 	}
@@ -39,6 +47,7 @@ func (l *Listener) Next() (*QueryData, error) {
 	q := &QueryData{
 		conn:     conn,
 		query:    in.Query,
+		params:   in.Params,
 		remoteID: in.Identity,
 	}
 
@@ -4,12 +4,14 @@ import (
 	"github.com/cryptopunkscc/astrald/auth/id"
 	"github.com/cryptopunkscc/astrald/cslq"
 	"github.com/cryptopunkscc/astrald/mod/apphost/proto"
-	"net"
+	"github.com/cryptopunkscc/astrald/net"
+	_net "net"
 )
 
 type QueryData struct {
-	conn     net.Conn
+	conn     _net.Conn
 	query    string
+	params   map[string]string
 	remoteID id.Identity
 }
 
@@ -17,13 +19,28 @@ func (q *QueryData) Query() string {
 	return q.query
 }
 
+// Params returns the parameters of the query
+func (q *QueryData) Params() map[string]string {
+	return q.params
+}
+
 func (q *QueryData) RemoteIdentity() id.Identity {
 	return q.remoteID
 }
 
 func (q *QueryData) Reject() error {
+	return q.RejectWithReason(net.RejectCodeRejected, "")
+}
+
+// RejectWithReason rejects the query and sends the reason of the rejection to the caller
+func (q *QueryData) RejectWithReason(code int, message string) error {
 	defer q.conn.Close()
-	return cslq.Encode(q.conn, "c", 1)
+
+	c := proto.NewConn(q.conn)
+	if err := c.WriteErr(proto.ErrRejected); err != nil {
+		return err
+	}
+	return c.WriteMsg(proto.RejectData{Code: code, Message: message})
 }
 
 func (q *QueryData) Accept() (*Conn, error) {
@@ -31,6 +48,7 @@ func (q *QueryData) Accept() (*Conn, error) {
 		Conn:     q.conn,
 		remoteID: q.remoteID,
 		query:    q.query,
+		params:   q.params,
 	}
 
 	if err := cslq.Encode(q.conn, "c", proto.Success); err != nil {
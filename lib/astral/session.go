@@ -1,10 +1,12 @@
 package astral
 
 import (
+	"errors"
 	"github.com/cryptopunkscc/astrald/auth/id"
 	"github.com/cryptopunkscc/astrald/cslq"
 	"github.com/cryptopunkscc/astrald/mod/apphost/proto"
-	"net"
+	"github.com/cryptopunkscc/astrald/net"
+	_net "net"
 	"strings"
 )
 
@@ -15,7 +17,7 @@ type Session struct {
 	token string
 }
 
-func NewSession(conn net.Conn, token string, addr string) *Session {
+func NewSession(conn _net.Conn, token string, addr string) *Session {
 	return &Session{
 		Endec: cslq.NewEndec(conn),
 		conn:  proto.NewConn(conn),
@@ -25,15 +27,37 @@ func NewSession(conn net.Conn, token string, addr string) *Session {
 }
 
 func (s *Session) Query(remoteID id.Identity, query string) (conn *Conn, err error) {
+	return s.QueryWithParams(remoteID, query, nil)
+}
+
+// QueryWithParams sends a query with a set of key/value parameters attached to it. Nodes that don't support
+// query parameters return proto.ErrUnknownCommand.
+func (s *Session) QueryWithParams(remoteID id.Identity, query string, params map[string]string) (conn *Conn, err error) {
 	if err = s.auth(); err != nil {
 		s.Close()
 		return
 	}
 
-	err = s.invoke(proto.CmdQuery, proto.QueryParams{
-		Identity: remoteID,
-		Query:    query,
-	})
+	if len(params) > 0 {
+		err = s.invoke(proto.CmdQueryParams, proto.QueryWithParams{
+			Identity: remoteID,
+			Query:    query,
+			Params:   params,
+		})
+	} else {
+		err = s.invoke(proto.CmdQuery, proto.QueryParams{
+			Identity: remoteID,
+			Query:    query,
+		})
+	}
+	if errors.Is(err, proto.ErrRejected) {
+		defer s.Close()
+		var data proto.RejectData
+		if s.conn.ReadMsg(&data) != nil {
+			return nil, err
+		}
+		return nil, &RejectError{RejectError: net.RejectError{Code: data.Code, Message: data.Message}}
+	}
 	if err != nil {
 		s.Close()
 		return nil, err
@@ -43,6 +67,7 @@ func (s *Session) Query(remoteID id.Identity, query string) (conn *Conn, err err
 		Conn:     s.conn,
 		remoteID: remoteID,
 		query:    query,
+		params:   params,
 	}, nil
 }
 
@@ -80,11 +105,21 @@ func (s *Session) NodeInfo(identity id.Identity) (info proto.NodeInfoData, err e
 }
 
 func (s *Session) Register(service string, target string) (err error) {
+	return s.register(proto.CmdRegister, service, target)
+}
+
+// RegisterWithParams registers a service that receives query parameters along with queries. Nodes that don't
+// support query parameters return proto.ErrUnknownCommand.
+func (s *Session) RegisterWithParams(service string, target string) (err error) {
+	return s.register(proto.CmdRegisterParams, service, target)
+}
+
+func (s *Session) register(cmd string, service string, target string) (err error) {
 	if err = s.auth(); err != nil {
 		return
 	}
 
-	err = s.invoke(proto.CmdRegister, proto.RegisterParams{
+	err = s.invoke(cmd, proto.RegisterParams{
 		Service: service,
 		Target:  target,
 	})
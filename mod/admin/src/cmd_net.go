@@ -30,6 +30,14 @@ type checkLatency interface {
 	Latency() time.Duration
 }
 
+type checkCompression interface {
+	Compression() (link.CompressionStats, bool)
+}
+
+type checkPaths interface {
+	Paths() ([]link.PathInfo, bool)
+}
+
 func (cmd *CmdNet) Exec(term admin.Terminal, args []string) error {
 	if len(args) < 2 {
 		return cmd.help(term)
@@ -51,9 +59,18 @@ func (cmd *CmdNet) Exec(term admin.Terminal, args []string) error {
 	case "close":
 		return cmd.close(term, args[2:])
 
+	case "path":
+		return cmd.path(term, args[2:])
+
 	case "conns":
 		return cmd.conns(term, args[2:])
 
+	case "history":
+		return cmd.history(term, args[2:])
+
+	case "limits":
+		return cmd.limits(term, args[2:])
+
 	case "routes":
 		return cmd.routes(term, args[2:])
 
@@ -240,6 +257,7 @@ func (cmd *CmdNet) printChainInfo(term admin.Terminal, element any) {
 				)
 			}
 			term.Printf("  Buffer: %d\n", w.BufferSize())
+			term.Printf("  Priority: %s\n", net.PriorityName(w.Priority()))
 
 		case *router.MonitoredWriter:
 			term.Printf("  Identity: %d\n", w.Identity())
@@ -290,6 +308,37 @@ func (cmd *CmdNet) show(term admin.Terminal, args []string) error {
 	if l, ok := l.Link.(checkLatency); ok {
 		term.Printf("Latency:          %v\n", l.Latency().Round(time.Millisecond))
 	}
+	if l, ok := l.Link.(checkCompression); ok {
+		if stats, ok := l.Compression(); ok {
+			term.Printf("Compression:      %v (ratio %.2f, out %v/%v, in %v/%v)\n",
+				stats.Algorithm,
+				stats.Ratio(),
+				log.DataSize(stats.BytesOutWire).HumanReadable(),
+				log.DataSize(stats.BytesOut).HumanReadable(),
+				log.DataSize(stats.BytesInWire).HumanReadable(),
+				log.DataSize(stats.BytesIn).HumanReadable(),
+			)
+		} else {
+			term.Printf("Compression:      none\n")
+		}
+	}
+	if l, ok := l.Link.(checkPaths); ok {
+		if paths, ok := l.Paths(); ok {
+			term.Printf("Paths:\n")
+			for _, p := range paths {
+				var active string
+				if p.Active {
+					active = " (active)"
+				}
+				term.Printf("  %-8s %v idle %v%v\n",
+					p.Network,
+					p.Conn.RemoteEndpoint(),
+					p.Idle.Round(time.Millisecond),
+					active,
+				)
+			}
+		}
+	}
 	term.Printf("Age:              %v (%v)\n",
 		time.Since(l.AddedAt()).Round(time.Second),
 		l.AddedAt(),
@@ -423,7 +472,10 @@ func (cmd *CmdNet) help(term admin.Terminal) error {
 	term.Printf("  close     close a link\n")
 	term.Printf("  link      link a node\n")
 	term.Printf("  unlink    unlink a node\n")
+	term.Printf("  path      resume a link or add a path to it\n")
 	term.Printf("  conns     list all connections\n")
+	term.Printf("  history   show the history of routed queries\n")
+	term.Printf("  limits    show usage of query limits\n")
 	term.Printf("  check     run health check on all links\n")
 	term.Printf("  help      show help\n")
 	return nil
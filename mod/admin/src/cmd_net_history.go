@@ -0,0 +1,104 @@
+package admin
+
+import (
+	"errors"
+	"flag"
+	"github.com/cryptopunkscc/astrald/log"
+	"github.com/cryptopunkscc/astrald/mod/admin"
+	"github.com/cryptopunkscc/astrald/node"
+	"github.com/cryptopunkscc/astrald/node/router"
+	"time"
+)
+
+const defaultHistoryLimit = 50
+
+func (cmd *CmdNet) history(term admin.Terminal, args []string) error {
+	corenode, ok := cmd.mod.node.(*node.CoreNode)
+	if !ok {
+		return errors.New("unsupported node type")
+	}
+
+	var history = corenode.History()
+	if history == nil {
+		return errors.New("routing history is disabled")
+	}
+
+	flags := flag.NewFlagSet("net history", flag.ContinueOnError)
+	flags.SetOutput(term)
+	flags.Usage = func() {
+		term.Printf("Usage:\n\n  net history [options]\n\nOptions:\n")
+		flags.PrintDefaults()
+	}
+	var caller = flags.String("c", "", "show queries made by this caller")
+	var target = flags.String("t", "", "show queries sent to this target")
+	var query = flags.String("q", "", "show queries starting with this prefix")
+	var since = flags.Duration("s", 0, "show queries from this long ago onwards")
+	var errorsOnly = flags.Bool("e", false, "show failed queries only")
+	var limit = flags.Int("n", defaultHistoryLimit, "show at most this many queries")
+	err := flags.Parse(args)
+	if err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return nil
+		}
+		return err
+	}
+
+	var filter = router.HistoryFilter{
+		Query:      *query,
+		ErrorsOnly: *errorsOnly,
+		Limit:      *limit,
+	}
+
+	if *caller != "" {
+		if filter.Caller, err = cmd.mod.node.Resolver().Resolve(*caller); err != nil {
+			return err
+		}
+	}
+	if *target != "" {
+		if filter.Target, err = cmd.mod.node.Resolver().Resolve(*target); err != nil {
+			return err
+		}
+	}
+	if *since > 0 {
+		filter.Since = time.Now().Add(-*since)
+	}
+
+	entries, err := history.Find(filter)
+	if err != nil {
+		return err
+	}
+
+	var f = "%-19s %-20s %-20s %-20s %10s %8s %8s %s\n"
+
+	term.Printf(f,
+		admin.Header("Time"),
+		admin.Header("Caller"),
+		admin.Header("Target"),
+		admin.Header("Query"),
+		admin.Header("Duration"),
+		admin.Header("In"),
+		admin.Header("Out"),
+		admin.Header("Error"),
+	)
+
+	// print oldest first, so that the latest entries end up at the bottom
+	for i := len(entries) - 1; i >= 0; i-- {
+		var e = entries[i]
+		term.Printf(f,
+			e.StartedAt.Format(time.DateTime),
+			e.Caller,
+			e.Target,
+			e.Query,
+			e.Duration.Round(time.Millisecond),
+			log.DataSize(e.BytesIn).HumanReadable(),
+			log.DataSize(e.BytesOut).HumanReadable(),
+			e.Error,
+		)
+	}
+
+	if n := history.Dropped(); n > 0 {
+		term.Printf("%d entries dropped under load\n", n)
+	}
+
+	return nil
+}
@@ -0,0 +1,57 @@
+package admin
+
+import (
+	"errors"
+	"github.com/cryptopunkscc/astrald/log"
+	"github.com/cryptopunkscc/astrald/mod/admin"
+	"github.com/cryptopunkscc/astrald/node"
+	"strconv"
+)
+
+func (cmd *CmdNet) limits(term admin.Terminal, _ []string) error {
+	corenode, ok := cmd.mod.node.(*node.CoreNode)
+	if !ok {
+		return errors.New("unsupported node type")
+	}
+
+	var f = "%-8s %-30s %8s %10s %16s %8s %8s\n"
+
+	term.Printf(f,
+		admin.Header("Type"),
+		admin.Header("Name"),
+		admin.Header("QPS"),
+		admin.Header("Sessions"),
+		admin.Header("Today"),
+		admin.Header("Queries"),
+		admin.Header("Rejected"),
+	)
+
+	for _, u := range corenode.Limiter().Usage() {
+		var kind, name = "service", u.Service
+		if u.Service == "" {
+			kind, name = "caller", term.Sprintf("%s", u.Caller)
+		}
+
+		term.Printf(f,
+			kind,
+			name,
+			formatLimit(strconv.FormatFloat(u.Limit.QPS, 'f', -1, 64), u.Limit.QPS == 0),
+			strconv.Itoa(u.Sessions)+"/"+formatLimit(strconv.Itoa(u.Limit.Sessions), u.Limit.Sessions == 0),
+			log.DataSize(u.BytesToday).HumanReadable()+"/"+formatLimit(
+				log.DataSize(u.Limit.BytesPerDay).HumanReadable(),
+				u.Limit.BytesPerDay == 0,
+			),
+			u.Queries,
+			u.Rejected,
+		)
+	}
+
+	return nil
+}
+
+func formatLimit(s string, unlimited bool) string {
+	if unlimited {
+		return "-"
+	}
+	return s
+}
@@ -0,0 +1,102 @@
+package admin
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"github.com/cryptopunkscc/astrald/mod/admin"
+	"github.com/cryptopunkscc/astrald/net"
+	"github.com/cryptopunkscc/astrald/node/link"
+	"strconv"
+)
+
+func (cmd *CmdNet) path(term admin.Terminal, args []string) error {
+	flags := flag.NewFlagSet("net path <linkID>", flag.ContinueOnError)
+	flags.SetOutput(term)
+	flags.Usage = func() {
+		term.Printf("Usage:\n\n  net path [options] <linkID>\n\nOptions:\n")
+		flags.PrintDefaults()
+	}
+	var network = flags.String("n", "", "add a path via this network only")
+	var timeout = flags.Duration("t", defaultLinkTimeout, "set timeout")
+	var addr = flags.String("a", "", "add a path via this address (requires -n)")
+	err := flags.Parse(args)
+	if err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return nil
+		}
+		return err
+	}
+	args = flags.Args()
+
+	if len(args) < 1 {
+		flags.Usage()
+		return nil
+	}
+
+	lid, err := strconv.Atoi(args[0])
+	if err != nil {
+		return err
+	}
+
+	l, err := cmd.mod.node.Network().Links().Find(lid)
+	if err != nil {
+		return err
+	}
+
+	coreLink, ok := l.Link.(*link.CoreLink)
+	if !ok {
+		return link.ErrNotResumable
+	}
+
+	var endpoints []net.Endpoint
+
+	if *addr != "" {
+		if *network == "" {
+			return errors.New("adding a path via address requires specifying the network")
+		}
+		e, err := cmd.mod.node.Infra().Parse(*network, *addr)
+		if err != nil {
+			return err
+		}
+		endpoints = []net.Endpoint{e}
+	} else {
+		endpoints, err = cmd.mod.node.Tracker().EndpointsByIdentity(l.RemoteIdentity())
+		if err != nil {
+			return err
+		}
+
+		if *network != "" {
+			endpoints = selectEndpoints(endpoints, func(e net.Endpoint) bool {
+				return e.Network() == *network
+			})
+		}
+	}
+
+	if len(endpoints) == 0 {
+		return errors.New("no usable endpoints")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), *timeout)
+	defer cancel()
+
+	for _, e := range endpoints {
+		conn, err := cmd.mod.node.Infra().Dial(ctx, e)
+		if err != nil {
+			continue
+		}
+
+		err = coreLink.AddPath(ctx, conn)
+		if errors.Is(err, link.ErrNotResumable) {
+			return err
+		}
+		if err != nil {
+			continue
+		}
+
+		term.Printf("added path via %s\n", e.Network())
+		return nil
+	}
+
+	return errors.New("no endpoint could be reached")
+}
@@ -54,10 +54,14 @@ func (mod *Module) RouteQuery(ctx context.Context, query net.Query, caller net.S
 	// check if the caller has access to the admin panel
 	if !mod.hasAccess(caller.Identity()) {
 		mod.log.Errorv(1, "denied access to %v", caller.Identity())
-		return net.Reject()
+		return net.RejectWithReason(net.RejectCodeAccessDenied, "")
 	}
 
 	mod.log.Info("%v has accessed the admin panel", caller.Identity())
+
+	// admin sessions are interactive, so don't let them wait behind bulk transfers
+	net.SetPriority(caller, net.PriorityInteractive)
+
 	return net.Accept(query, caller, mod.serve)
 }
 
@@ -2,14 +2,17 @@ package proto
 
 import (
 	"github.com/cryptopunkscc/astrald/auth/id"
+	"github.com/cryptopunkscc/astrald/net"
 )
 
 const (
-	CmdRegister = "register"
-	CmdQuery    = "query"
-	CmdResolve  = "resolve"
-	CmdNodeInfo = "nodeInfo"
-	CmdExec     = "exec"
+	CmdRegister       = "register"
+	CmdRegisterParams = "registerParams"
+	CmdQuery          = "query"
+	CmdQueryParams    = "queryParams"
+	CmdResolve        = "resolve"
+	CmdNodeInfo       = "nodeInfo"
+	CmdExec           = "exec"
 )
 
 type Command struct {
@@ -25,6 +28,13 @@ type QueryParams struct {
 	Query    string      `cslq:"[c]c"`
 }
 
+// QueryWithParams are the arguments of CmdQueryParams
+type QueryWithParams struct {
+	Identity id.Identity `cslq:"v"`
+	Query    string      `cslq:"[c]c"`
+	Params   net.Params  `cslq:"v"`
+}
+
 type RegisterParams struct {
 	Service string `cslq:"[c]c"`
 	Target  string `cslq:"[c]c"`
@@ -51,6 +61,19 @@ type InQueryParams struct {
 	Query    string      `cslq:"[c]c"`
 }
 
+// InQueryWithParams is sent instead of InQueryParams to services registered with CmdRegisterParams
+type InQueryWithParams struct {
+	Identity id.Identity `cslq:"v"`
+	Query    string      `cslq:"[c]c"`
+	Params   net.Params  `cslq:"v"`
+}
+
+// RejectData follows ErrRejected in a query response and describes the reason of the rejection
+type RejectData struct {
+	Code    int    `cslq:"c"`
+	Message string `cslq:"[c]c"`
+}
+
 type ResolveParams struct {
 	Name string `cslq:"[c]c"`
 }
@@ -101,7 +101,7 @@ func (mod *Module) DefaultIdentity() id.Identity {
 	return mod.defaultID
 }
 
-func (mod *Module) addGuestRoute(identity id.Identity, name string, target string) error {
+func (mod *Module) addGuestRoute(identity id.Identity, name string, target string, params bool) error {
 	mod.guestsMu.Lock()
 	defer mod.guestsMu.Unlock()
 
@@ -124,6 +124,7 @@ func (mod *Module) addGuestRoute(identity id.Identity, name string, target strin
 		log:      mod.log,
 		target:   target,
 		identity: identity,
+		params:   params,
 	}
 
 	return guest.AddRoute(name, relay)
@@ -152,7 +153,7 @@ func (mod *Module) removeGuestRoute(identity id.Identity, name string) error {
 	return nil
 }
 
-func (mod *Module) addNodeRoute(name string, target string) error {
+func (mod *Module) addNodeRoute(name string, target string, params bool) error {
 	if len(name) == 0 {
 		return errors.New("invalid name")
 	}
@@ -161,6 +162,7 @@ func (mod *Module) addNodeRoute(name string, target string) error {
 		log:      mod.log,
 		target:   target,
 		identity: mod.node.Identity(),
+		params:   params,
 	}
 
 	return mod.node.LocalRouter().AddRoute(name, relay)
@@ -2,39 +2,56 @@ package apphost
 
 import (
 	"context"
+	"errors"
 	"github.com/cryptopunkscc/astrald/auth/id"
 	"github.com/cryptopunkscc/astrald/log"
 	"github.com/cryptopunkscc/astrald/mod/apphost/proto"
 	"github.com/cryptopunkscc/astrald/net"
 	"io"
+	_net "net"
+	"time"
 )
 
+const rejectReasonTimeout = time.Second
+
 type RelayRouter struct {
 	log      *log.Logger
 	target   string
 	identity id.Identity
+	params   bool // the target accepts query parameters
 }
 
 func (fwd *RelayRouter) RouteQuery(ctx context.Context, query net.Query, caller net.SecureWriteCloser, hints net.Hints) (net.SecureWriteCloser, error) {
 	target, err := proto.Dial(fwd.target)
 	if err != nil {
 		fwd.log.Errorv(2, "%s:%s forward to %s: %s", query.Target(), query.Query(), fwd.target, err)
-		return net.Reject()
+		return net.RejectWithReason(net.RejectCodeUnavailable, "")
 	}
 
 	conn := proto.NewConn(target)
 
-	err = conn.WriteMsg(proto.InQueryParams{
-		Identity: query.Caller(),
-		Query:    query.Query(),
-	})
+	if fwd.params {
+		err = conn.WriteMsg(proto.InQueryWithParams{
+			Identity: query.Caller(),
+			Query:    query.Query(),
+			Params:   query.Params(),
+		})
+	} else {
+		err = conn.WriteMsg(proto.InQueryParams{
+			Identity: query.Caller(),
+			Query:    query.Query(),
+		})
+	}
 	if err != nil {
 		target.Close()
 		return net.Reject()
 	}
 
-	if conn.ReadErr() != nil {
-		target.Close()
+	if err := conn.ReadErr(); err != nil {
+		defer target.Close()
+		if errors.Is(err, proto.ErrRejected) {
+			return fwd.readRejectReason(target)
+		}
 		return net.Reject()
 	}
 
@@ -45,3 +62,16 @@ func (fwd *RelayRouter) RouteQuery(ctx context.Context, query net.Query, caller
 
 	return net.NewSecurePipeWriter(target, query.Target()), nil
 }
+
+// readRejectReason reads the reason of a rejection sent by the app. Apps that don't send a reason are given
+// a moment to close the connection.
+func (fwd *RelayRouter) readRejectReason(target _net.Conn) (net.SecureWriteCloser, error) {
+	var data proto.RejectData
+
+	target.SetReadDeadline(time.Now().Add(rejectReasonTimeout))
+	if err := proto.NewConn(target).ReadMsg(&data); err != nil {
+		return net.Reject()
+	}
+
+	return net.RejectWithReason(data.Code, data.Message)
+}
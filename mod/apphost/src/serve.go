@@ -23,12 +23,18 @@ func (s *Session) Serve(ctx context.Context) error {
 		case proto.CmdQuery:
 			return cslq.Invoke(s, s.query)
 
+		case proto.CmdQueryParams:
+			return cslq.Invoke(s, s.queryWithParams)
+
 		case proto.CmdResolve:
 			return cslq.Invoke(s, s.resolve)
 
 		case proto.CmdRegister:
 			return cslq.Invoke(s, s.register)
 
+		case proto.CmdRegisterParams:
+			return cslq.Invoke(s, s.registerWithParams)
+
 		case proto.CmdNodeInfo:
 			return cslq.Invoke(s, s.nodeInfo)
 
@@ -42,6 +48,13 @@ func (s *Session) Serve(ctx context.Context) error {
 }
 
 func (s *Session) query(params proto.QueryParams) error {
+	return s.queryWithParams(proto.QueryWithParams{
+		Identity: params.Identity,
+		Query:    params.Query,
+	})
+}
+
+func (s *Session) queryWithParams(params proto.QueryWithParams) error {
 	var err error
 	var conn net.SecureConn
 
@@ -50,6 +63,9 @@ func (s *Session) query(params proto.QueryParams) error {
 	}
 
 	var query = net.NewQuery(s.remoteID, params.Identity, params.Query)
+	if len(params.Params) > 0 {
+		query = net.WithParams(query, params.Params)
+	}
 	conn, err = net.Route(s.ctx, s.mod.node.Router(), query)
 
 	if err == nil {
@@ -60,7 +76,11 @@ func (s *Session) query(params proto.QueryParams) error {
 
 	switch {
 	case errors.Is(err, net.ErrRejected):
-		return s.WriteErr(proto.ErrRejected)
+		code, message := net.RejectReason(err)
+		if err := s.WriteErr(proto.ErrRejected); err != nil {
+			return err
+		}
+		return s.WriteMsg(proto.RejectData{Code: code, Message: message})
 
 	case errors.Is(err, &net.ErrRouteNotFound{}):
 		return s.WriteErr(proto.ErrRouteNotFound)
@@ -115,20 +135,29 @@ func (s *Session) exec(params proto.ExecParams) error {
 }
 
 func (s *Session) register(p proto.RegisterParams) error {
+	return s.registerService(p, false)
+}
+
+// registerWithParams registers a service that receives query parameters along with queries
+func (s *Session) registerWithParams(p proto.RegisterParams) error {
+	return s.registerService(p, true)
+}
+
+func (s *Session) registerService(p proto.RegisterParams, params bool) error {
 	s.mod.log.Logv(2, "%s register %s -> %s", s.remoteID, p.Service, p.Target)
 	defer s.Close()
 
 	// if the session is coming from node's identity, register under node's router
 	if s.remoteID.IsEqual(s.mod.node.Identity()) {
-		return s.registerNode(p)
+		return s.registerNode(p, params)
 	}
 
 	// ...otherwise register under guest's router
-	return s.registerGuest(p)
+	return s.registerGuest(p, params)
 }
 
-func (s *Session) registerNode(p proto.RegisterParams) error {
-	err := s.mod.addNodeRoute(p.Service, p.Target)
+func (s *Session) registerNode(p proto.RegisterParams, params bool) error {
+	err := s.mod.addNodeRoute(p.Service, p.Target, params)
 	if err != nil {
 		return err
 	}
@@ -139,8 +168,8 @@ func (s *Session) registerNode(p proto.RegisterParams) error {
 	return s.mod.removeNodeRoute(p.Service)
 }
 
-func (s *Session) registerGuest(p proto.RegisterParams) error {
-	err := s.mod.addGuestRoute(s.remoteID, p.Service, p.Target)
+func (s *Session) registerGuest(p proto.RegisterParams, params bool) error {
+	err := s.mod.addGuestRoute(s.remoteID, p.Service, p.Target, params)
 	if err != nil {
 		return s.WriteErr(proto.ErrAlreadyRegistered)
 	}
@@ -0,0 +1,69 @@
+package data
+
+import (
+	"context"
+	"github.com/cryptopunkscc/astrald/data"
+	"io"
+)
+
+// IdentifyHeaderSize is the number of leading bytes of data passed to identifiers
+const IdentifyHeaderSize = 4096
+
+// Identifier recognizes data and describes what it is. Identifiers run once for every indexed data object and
+// their results are stored, so they can take time to inspect the data.
+type Identifier interface {
+	// Name identifies the identifier. Registering an identifier with a new name runs it for all data
+	// indexed before.
+	Name() string
+
+	// Identify returns nil if it doesn't recognize the data
+	Identify(ctx context.Context, input *IdentifyInput) (*Identification, error)
+}
+
+// DescriptorDecoder is implemented by identifiers that return descriptors with typed data. Stored descriptors
+// are decoded with it before they're returned by DescribeData.
+type DescriptorDecoder interface {
+	DecodeDescriptor(typ string, b []byte) (any, error)
+}
+
+type IdentifyInput struct {
+	DataID data.ID
+
+	// Header holds the first IdentifyHeaderSize bytes of the data, or all of it if it's smaller
+	Header []byte
+
+	// Open reads the data from the offset
+	Open func(offset uint64) (io.ReadCloser, error)
+}
+
+type Identification struct {
+	// Type replaces the type detected from the header if not empty
+	Type string
+
+	Descriptors []Descriptor
+}
+
+const ISODescriptorType = "mod.data.iso"
+
+type ISODescriptor struct {
+	VolumeID string
+}
+
+const SQLiteDescriptorType = "mod.data.sqlite"
+
+type SQLiteDescriptor struct {
+	PageSize int
+	Pages    int
+}
+
+const MediaDescriptorType = "mod.data.media"
+
+type MediaDescriptor struct {
+	Brand  string
+	Tracks []MediaTrack
+}
+
+type MediaTrack struct {
+	Type  string // handler type, like vide, soun or subt
+	Codec string // sample entry type, like avc1, hvc1 or mp4a
+}
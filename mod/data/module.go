@@ -26,6 +26,9 @@ type Module interface {
 	AddDescriber(Describer) error
 	RemoveDescriber(Describer) error
 
+	AddIdentifier(Identifier) error
+	RemoveIdentifier(Identifier) error
+
 	SetLabel(data.ID, string)
 	GetLabel(data.ID) string
 
@@ -58,10 +61,11 @@ type LabelDescriptor struct {
 }
 
 type TypeInfo struct {
-	DataID    data.ID
-	IndexedAt time.Time
-	Header    string
-	Type      string
+	DataID     data.ID
+	IndexedAt  time.Time
+	Header     string
+	Identifier string // name of the identifier that set the type, if any
+	Type       string
 }
 
 type EventDataIdentified TypeInfo
@@ -10,10 +10,11 @@ import (
 )
 
 type dbDataType struct {
-	DataID    string    `gorm:"primaryKey,index"`
-	Header    string    `gorm:"index"`
-	Type      string    `gorm:"index"`
-	IndexedAt time.Time `gorm:"index"`
+	DataID     string    `gorm:"primaryKey,index"`
+	Header     string    `gorm:"index"`
+	Identifier string    `gorm:"index"`
+	Type       string    `gorm:"index"`
+	IndexedAt  time.Time `gorm:"index"`
 }
 
 func (dbDataType) TableName() string {
@@ -113,10 +114,11 @@ func (mod *Module) All(ts time.Time) ([]data.TypeInfo, error) {
 		}
 
 		list = append(list, data.TypeInfo{
-			DataID:    dataID,
-			IndexedAt: row.IndexedAt,
-			Header:    row.Header,
-			Type:      row.Type,
+			DataID:     dataID,
+			IndexedAt:  row.IndexedAt,
+			Header:     row.Header,
+			Identifier: row.Identifier,
+			Type:       row.Type,
 		})
 	}
 
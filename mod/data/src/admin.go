@@ -16,11 +16,12 @@ type Admin struct {
 func NewAdmin(mod *Module) *Admin {
 	var cmd = &Admin{mod: mod}
 	cmd.cmds = map[string]func(admin.Terminal, []string) error{
-		"list":      cmd.list,
-		"index":     cmd.index,
-		"describe":  cmd.describe,
-		"set_label": cmd.setLabel,
-		"get_label": cmd.getLabel,
+		"list":        cmd.list,
+		"index":       cmd.index,
+		"describe":    cmd.describe,
+		"set_label":   cmd.setLabel,
+		"get_label":   cmd.getLabel,
+		"identifiers": cmd.identifiers,
 	}
 	return cmd
 }
@@ -111,6 +112,13 @@ func (cmd *Admin) getLabel(term admin.Terminal, args []string) error {
 	return nil
 }
 
+func (cmd *Admin) identifiers(term admin.Terminal, args []string) error {
+	for _, identifier := range cmd.mod.sortedIdentifiers() {
+		term.Printf("%s\n", identifier.Name())
+	}
+	return nil
+}
+
 func (cmd *Admin) Exec(term admin.Terminal, args []string) error {
 	if len(args) < 2 {
 		return cmd.help(term, []string{})
@@ -125,7 +133,7 @@ func (cmd *Admin) Exec(term admin.Terminal, args []string) error {
 }
 
 func (cmd *Admin) help(term admin.Terminal, _ []string) error {
-	term.Printf("usage: data <list|describe|set_label|get_label>\n")
+	term.Printf("usage: data <list|describe|set_label|get_label|identifiers>\n")
 	return nil
 }
 
@@ -0,0 +1,138 @@
+package data
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"github.com/cryptopunkscc/astrald/mod/data"
+	"io"
+	"strings"
+)
+
+func builtinIdentifiers() []data.Identifier {
+	return []data.Identifier{
+		&tarIdentifier{},
+		&isoIdentifier{},
+		&sqliteIdentifier{},
+		&mp4Identifier{},
+	}
+}
+
+// decodeDescriptor decodes stored descriptors of the built-in types
+func decodeDescriptor(typ string, b []byte) (any, error) {
+	var v any
+	switch typ {
+	case data.ISODescriptorType:
+		v = &data.ISODescriptor{}
+	case data.SQLiteDescriptorType:
+		v = &data.SQLiteDescriptor{}
+	case data.MediaDescriptorType:
+		v = &data.MediaDescriptor{}
+	default:
+		return nil, errors.New("unknown descriptor type")
+	}
+
+	if err := json.Unmarshal(b, v); err != nil {
+		return nil, err
+	}
+
+	// return values, like other describers do
+	switch v := v.(type) {
+	case *data.ISODescriptor:
+		return *v, nil
+	case *data.SQLiteDescriptor:
+		return *v, nil
+	case *data.MediaDescriptor:
+		return *v, nil
+	}
+	return v, nil
+}
+
+// tarIdentifier recognizes POSIX tar archives
+type tarIdentifier struct{}
+
+func (tarIdentifier) Name() string { return "tar" }
+
+func (tarIdentifier) Identify(_ context.Context, input *data.IdentifyInput) (*data.Identification, error) {
+	if len(input.Header) < 263 || string(input.Header[257:262]) != "ustar" {
+		return nil, nil
+	}
+
+	return &data.Identification{Type: "application/x-tar"}, nil
+}
+
+// isoIdentifier recognizes ISO 9660 disc images. The primary volume descriptor is at 32KiB, past the header.
+type isoIdentifier struct{}
+
+const isoDescriptorOffset = 32 * 1024
+
+func (isoIdentifier) Name() string { return "iso9660" }
+
+func (isoIdentifier) Identify(_ context.Context, input *data.IdentifyInput) (*data.Identification, error) {
+	if input.DataID.Size < isoDescriptorOffset+2048 {
+		return nil, nil
+	}
+
+	r, err := input.Open(isoDescriptorOffset)
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+
+	var desc = make([]byte, 2048)
+	if _, err = io.ReadFull(r, desc); err != nil {
+		return nil, err
+	}
+
+	if desc[0] != 1 || string(desc[1:6]) != "CD001" {
+		return nil, nil
+	}
+
+	return &data.Identification{
+		Type: "application/x-iso9660-image",
+		Descriptors: []data.Descriptor{{
+			Type: data.ISODescriptorType,
+			Data: data.ISODescriptor{VolumeID: strings.TrimSpace(string(desc[40:72]))},
+		}},
+	}, nil
+}
+
+func (isoIdentifier) DecodeDescriptor(typ string, b []byte) (any, error) {
+	return decodeDescriptor(typ, b)
+}
+
+// sqliteIdentifier recognizes SQLite databases
+type sqliteIdentifier struct{}
+
+const sqliteMagic = "SQLite format 3\x00"
+
+func (sqliteIdentifier) Name() string { return "sqlite" }
+
+func (sqliteIdentifier) Identify(_ context.Context, input *data.IdentifyInput) (*data.Identification, error) {
+	var h = input.Header
+	if len(h) < 100 || !bytes.HasPrefix(h, []byte(sqliteMagic)) {
+		return nil, nil
+	}
+
+	var pageSize = int(binary.BigEndian.Uint16(h[16:18]))
+	if pageSize == 1 {
+		pageSize = 65536
+	}
+
+	return &data.Identification{
+		Type: "application/vnd.sqlite3",
+		Descriptors: []data.Descriptor{{
+			Type: data.SQLiteDescriptorType,
+			Data: data.SQLiteDescriptor{
+				PageSize: pageSize,
+				Pages:    int(binary.BigEndian.Uint32(h[28:32])),
+			},
+		}},
+	}, nil
+}
+
+func (sqliteIdentifier) DecodeDescriptor(typ string, b []byte) (any, error) {
+	return decodeDescriptor(typ, b)
+}
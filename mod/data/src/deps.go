@@ -25,6 +25,8 @@ func (mod *Module) LoadDependencies() error {
 		return err
 	}
 
+	mod.storage.Pins().AddReferenceChecker(mod)
+
 	// optional
 	mod.fs, _ = modules.Load[fs.Module](mod.node, fs.ModuleName)
 
@@ -14,6 +14,7 @@ func (mod *Module) DescribeData(ctx context.Context, dataID _data.ID, opts *data
 	var descs []data.Descriptor
 
 	descs = append(descs, mod.describe(dataID)...)
+	descs = append(descs, mod.describeIdentified(dataID)...)
 
 	for _, describer := range mod.describers.Clone() {
 		var items = describer.DescribeData(ctx, dataID, nil)
@@ -36,10 +37,15 @@ func (mod *Module) describe(dataID _data.ID) []data.Descriptor {
 
 	row, err := mod.findByDataID(dataID)
 	if err == nil {
+		var method = row.Header
+		if row.Identifier != "" {
+			method = row.Identifier
+		}
+
 		descs = append(descs, data.Descriptor{
 			Type: data.TypeDescriptorType,
 			Data: data.TypeDescriptor{
-				Method: row.Header,
+				Method: method,
 				Type:   row.Type,
 			},
 		})
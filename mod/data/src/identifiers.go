@@ -0,0 +1,260 @@
+package data
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	_data "github.com/cryptopunkscc/astrald/data"
+	"github.com/cryptopunkscc/astrald/mod/data"
+	"github.com/cryptopunkscc/astrald/mod/storage"
+	"io"
+	"slices"
+	"strings"
+	"time"
+)
+
+const identifyTimeout = time.Minute
+
+// dbIdentification is the result of running an identifier on data. A row without a type and descriptors
+// records that the identifier didn't recognize the data.
+type dbIdentification struct {
+	DataID       string `gorm:"primaryKey"`
+	Identifier   string `gorm:"primaryKey"`
+	Type         string
+	Descriptors  []byte
+	IdentifiedAt time.Time
+}
+
+func (dbIdentification) TableName() string { return "identifications" }
+
+// storedDescriptor is the stored form of a descriptor
+type storedDescriptor struct {
+	Type string
+	Data json.RawMessage
+}
+
+func (mod *Module) AddIdentifier(identifier data.Identifier) error {
+	if _, found := mod.identifiers.Get(identifier.Name()); found {
+		return errors.New("identifier already added")
+	}
+	mod.identifiers.Set(identifier.Name(), identifier)
+
+	go mod.reidentify(identifier)
+
+	return nil
+}
+
+func (mod *Module) RemoveIdentifier(identifier data.Identifier) error {
+	if _, found := mod.identifiers.Delete(identifier.Name()); !found {
+		return errors.New("identifier not found")
+	}
+	return nil
+}
+
+// sortedIdentifiers returns identifiers in the order they run. Types reported by later identifiers take
+// precedence.
+func (mod *Module) sortedIdentifiers() []data.Identifier {
+	var list = mod.identifiers.Values()
+	slices.SortFunc(list, func(a, b data.Identifier) int {
+		return strings.Compare(a.Name(), b.Name())
+	})
+	return list
+}
+
+// identify runs the identifier and stores its result. It returns nil if the identifier didn't recognize the data.
+func (mod *Module) identify(identifier data.Identifier, dataID _data.ID, header []byte) (*data.Identification, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), identifyTimeout)
+	defer cancel()
+
+	res, err := identifier.Identify(ctx, &data.IdentifyInput{
+		DataID: dataID,
+		Header: header,
+		Open: func(offset uint64) (io.ReadCloser, error) {
+			return mod.storage.Data().Read(dataID, &storage.ReadOpts{Offset: offset, NoVirtual: true})
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var row = dbIdentification{
+		DataID:       dataID.String(),
+		Identifier:   identifier.Name(),
+		IdentifiedAt: time.Now(),
+	}
+
+	if res != nil {
+		row.Type = res.Type
+
+		var stored []storedDescriptor
+		for _, desc := range res.Descriptors {
+			b, err := json.Marshal(desc.Data)
+			if err != nil {
+				return nil, err
+			}
+			stored = append(stored, storedDescriptor{Type: desc.Type, Data: b})
+		}
+		if row.Descriptors, err = json.Marshal(stored); err != nil {
+			return nil, err
+		}
+	}
+
+	if err = mod.db.Save(&row).Error; err != nil {
+		return nil, err
+	}
+
+	if row.Type != "" {
+		mod.log.Logv(1, "%v identified as %s by %s", dataID, row.Type, identifier.Name())
+	}
+
+	return res, nil
+}
+
+// readHeader returns the first bytes of data passed to identifiers
+func (mod *Module) readHeader(dataID _data.ID) ([]byte, error) {
+	r, err := mod.storage.Data().Read(dataID, &storage.ReadOpts{NoVirtual: true})
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+
+	var header = make([]byte, data.IdentifyHeaderSize)
+	n, err := io.ReadFull(r, header)
+	if err != nil && !errors.Is(err, io.ErrUnexpectedEOF) && !errors.Is(err, io.EOF) {
+		return nil, err
+	}
+
+	return header[:n], nil
+}
+
+// reidentify runs a newly added identifier on data indexed before
+func (mod *Module) reidentify(identifier data.Identifier) {
+	var ctx, cancel = context.WithTimeout(context.Background(), time.Minute)
+	var err = mod.Ready(ctx)
+	cancel()
+	if err != nil {
+		mod.log.Error("identifier %s: %v", identifier.Name(), err)
+		return
+	}
+
+	var rows []dbDataType
+	err = mod.db.
+		Where("data_id NOT IN (?)",
+			mod.db.Model(&dbIdentification{}).Select("data_id").Where("identifier = ?", identifier.Name()),
+		).
+		Find(&rows).Error
+	if err != nil {
+		mod.log.Error("database error: %v", err)
+		return
+	}
+
+	var count int
+	for _, row := range rows {
+		if _, found := mod.identifiers.Get(identifier.Name()); !found {
+			return
+		}
+
+		dataID, err := _data.Parse(row.DataID)
+		if err != nil {
+			continue
+		}
+
+		header, err := mod.readHeader(dataID)
+		if err != nil {
+			continue
+		}
+
+		res, err := mod.identify(identifier, dataID, header)
+		if err != nil {
+			mod.log.Errorv(1, "identifier %s failed on %v: %v", identifier.Name(), dataID, err)
+			continue
+		}
+		if res == nil {
+			continue
+		}
+		count++
+
+		// the new identifier may not be the last to run, so pick the type the way Index would
+		typ, identified, err := mod.identifiedType(dataID)
+		if err != nil {
+			mod.log.Error("database error: %v", err)
+			continue
+		}
+		if typ != "" && (typ != row.Type || identified != row.Identifier) {
+			row.Type, row.Identifier = typ, identified
+			err = mod.db.Model(&dbDataType{}).
+				Where("data_id = ?", row.DataID).
+				Updates(map[string]any{"type": row.Type, "identifier": row.Identifier}).Error
+			if err != nil {
+				mod.log.Error("database error: %v", err)
+				continue
+			}
+		}
+
+		// the identifier may have only added descriptors, so the data is announced even if its type didn't change
+		mod.events.Emit(data.EventDataIdentified{
+			DataID:     dataID,
+			Header:     row.Header,
+			Identifier: row.Identifier,
+			Type:       row.Type,
+			IndexedAt:  row.IndexedAt,
+		})
+	}
+
+	if count > 0 {
+		mod.log.Info("identifier %s re-identified %d objects", identifier.Name(), count)
+	}
+}
+
+// identifiedType returns the type reported by the last identifier in the run order that recognized the data
+func (mod *Module) identifiedType(dataID _data.ID) (typ string, identifier string, err error) {
+	var rows []dbIdentification
+	err = mod.db.Where("data_id = ? and type != ?", dataID.String(), "").Find(&rows).Error
+	if err != nil {
+		return
+	}
+
+	var types = map[string]string{}
+	for _, row := range rows {
+		types[row.Identifier] = row.Type
+	}
+
+	for _, i := range mod.sortedIdentifiers() {
+		if t, found := types[i.Name()]; found {
+			typ, identifier = t, i.Name()
+		}
+	}
+
+	return
+}
+
+// describeIdentified returns descriptors stored by identifiers
+func (mod *Module) describeIdentified(dataID _data.ID) (descs []data.Descriptor) {
+	var rows []dbIdentification
+	mod.db.Where("data_id = ?", dataID.String()).Order("identifier").Find(&rows)
+
+	for _, row := range rows {
+		if len(row.Descriptors) == 0 {
+			continue
+		}
+
+		var stored []storedDescriptor
+		if err := json.Unmarshal(row.Descriptors, &stored); err != nil {
+			continue
+		}
+
+		var decoder, _ = mod.identifiers.Get(row.Identifier)
+
+		for _, s := range stored {
+			var desc = data.Descriptor{Type: s.Type, Data: s.Data}
+			if d, ok := decoder.(data.DescriptorDecoder); ok {
+				if v, err := d.DecodeDescriptor(s.Type, s.Data); err == nil {
+					desc.Data = v
+				}
+			}
+			descs = append(descs, desc)
+		}
+	}
+
+	return
+}
@@ -0,0 +1,260 @@
+package data
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	_data "github.com/cryptopunkscc/astrald/data"
+	"github.com/cryptopunkscc/astrald/log"
+	"github.com/cryptopunkscc/astrald/mod/data"
+	"github.com/cryptopunkscc/astrald/mod/index"
+	"github.com/cryptopunkscc/astrald/mod/storage"
+	"github.com/cryptopunkscc/astrald/node/assets"
+	"github.com/cryptopunkscc/astrald/resources"
+	"io"
+	"testing"
+	"time"
+)
+
+func testInput(b []byte) *data.IdentifyInput {
+	var header = b
+	if len(header) > data.IdentifyHeaderSize {
+		header = header[:data.IdentifyHeaderSize]
+	}
+
+	return &data.IdentifyInput{
+		DataID: _data.Resolve(b),
+		Header: header,
+		Open: func(offset uint64) (io.ReadCloser, error) {
+			return io.NopCloser(bytes.NewReader(b[offset:])), nil
+		},
+	}
+}
+
+func box(typ string, payload ...[]byte) []byte {
+	var p = bytes.Join(payload, nil)
+	var b = binary.BigEndian.AppendUint32(nil, uint32(8+len(p)))
+	return append(append(b, typ...), p...)
+}
+
+func TestSQLiteIdentifier(t *testing.T) {
+	var b = make([]byte, 100)
+	copy(b, sqliteMagic)
+	binary.BigEndian.PutUint16(b[16:], 1)
+	binary.BigEndian.PutUint32(b[28:], 42)
+
+	res, err := sqliteIdentifier{}.Identify(context.Background(), testInput(b))
+	if err != nil || res == nil {
+		t.Fatal("not identified", err)
+	}
+
+	desc := res.Descriptors[0].Data.(data.SQLiteDescriptor)
+	if desc.PageSize != 65536 || desc.Pages != 42 {
+		t.Fatalf("unexpected descriptor %+v", desc)
+	}
+}
+
+func TestTarIdentifier(t *testing.T) {
+	var b = make([]byte, 512)
+	copy(b[257:], "ustar\x0000")
+
+	res, _ := tarIdentifier{}.Identify(context.Background(), testInput(b))
+	if res == nil || res.Type != "application/x-tar" {
+		t.Fatal("tar not identified")
+	}
+
+	res, _ = tarIdentifier{}.Identify(context.Background(), testInput(b[:100]))
+	if res != nil {
+		t.Fatal("short data identified as tar")
+	}
+}
+
+func TestMP4Identifier(t *testing.T) {
+	var hdlr = append(make([]byte, 8), "soun"...)
+	var stsd = append(make([]byte, 8), box("mp4a")...)
+
+	var b = bytes.Join([][]byte{
+		box("ftyp", []byte("M4A "), make([]byte, 4)),
+		box("mdat", make([]byte, 8192)),
+		box("moov",
+			box("trak",
+				box("mdia",
+					box("hdlr", hdlr),
+					box("minf", box("stbl", box("stsd", stsd))),
+				),
+			),
+		),
+	}, nil)
+
+	res, err := mp4Identifier{}.Identify(context.Background(), testInput(b))
+	if err != nil || res == nil {
+		t.Fatal("not identified", err)
+	}
+
+	if res.Type != "audio/mp4" {
+		t.Fatalf("unexpected type %s", res.Type)
+	}
+
+	desc := res.Descriptors[0].Data.(data.MediaDescriptor)
+	if desc.Brand != "M4A" || len(desc.Tracks) != 1 || desc.Tracks[0].Codec != "mp4a" {
+		t.Fatalf("unexpected descriptor %+v", desc)
+	}
+}
+
+func TestMP4IdentifierInvalidSize(t *testing.T) {
+	var ftyp = box("ftyp", []byte("isom"), make([]byte, 4))
+
+	// a 64-bit box size that would wrap the offset back to the start of the file
+	var wrap = append(binary.BigEndian.AppendUint32(nil, 1), "free"...)
+	wrap = binary.BigEndian.AppendUint64(wrap, -uint64(len(ftyp)))
+
+	var b = bytes.Join([][]byte{ftyp, wrap, make([]byte, 4)}, nil)
+
+	if _, err := (mp4Identifier{}).Identify(context.Background(), testInput(b)); err == nil {
+		t.Fatal("expected an error")
+	}
+
+	// a canceled context stops the search
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	b = bytes.Join([][]byte{ftyp, box("free", make([]byte, 16))}, nil)
+	if _, err := (mp4Identifier{}).Identify(ctx, testInput(b)); err != context.Canceled {
+		t.Fatalf("expected context canceled, got %v", err)
+	}
+}
+
+func TestDecodeDescriptor(t *testing.T) {
+	v, err := decodeDescriptor(data.ISODescriptorType, []byte(`{"VolumeID":"DISC"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v.(data.ISODescriptor).VolumeID != "DISC" {
+		t.Fatal("invalid descriptor")
+	}
+}
+
+// testStorage serves objects from memory
+type testStorage struct {
+	storage.Module
+	storage.DataManager
+	objects map[_data.ID][]byte
+}
+
+func (s *testStorage) Data() storage.DataManager { return s }
+
+func (s *testStorage) Read(dataID _data.ID, opts *storage.ReadOpts) (storage.DataReader, error) {
+	b, found := s.objects[dataID]
+	if !found {
+		return nil, storage.ErrNotFound
+	}
+	return &testReader{Reader: bytes.NewReader(b[opts.Offset:])}, nil
+}
+
+type testReader struct {
+	io.Reader
+}
+
+func (r *testReader) Close() error              { return nil }
+func (r *testReader) Info() *storage.ReaderInfo { return &storage.ReaderInfo{} }
+
+type testIndex struct {
+	index.Module
+}
+
+func (testIndex) AddToSet(string, _data.ID) error { return nil }
+
+// typeIdentifier recognizes all data as its type
+type typeIdentifier struct {
+	name string
+	typ  string
+}
+
+func (i *typeIdentifier) Name() string { return i.name }
+
+func (i *typeIdentifier) Identify(context.Context, *data.IdentifyInput) (*data.Identification, error) {
+	return &data.Identification{
+		Type:        i.typ,
+		Descriptors: []data.Descriptor{{Type: i.name, Data: i.name}},
+	}, nil
+}
+
+func TestReidentify(t *testing.T) {
+	db, err := assets.NewCoreAssets(resources.NewMemResources()).OpenDB(data.ModuleName)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = db.AutoMigrate(&dbDataType{}, &dbIdentification{}); err != nil {
+		t.Fatal(err)
+	}
+
+	var content = []byte("content")
+	var dataID = _data.Resolve(content)
+	var mod = &Module{
+		db:      db,
+		log:     log.NewLogger(log.NewLinePrinter(log.NewMonoOutput(io.Discard))),
+		index:   testIndex{},
+		storage: &testStorage{objects: map[_data.ID][]byte{dataID: content}},
+		ready:   make(chan struct{}),
+	}
+	mod.setReady()
+
+	var ctx, cancel = context.WithCancel(context.Background())
+	defer cancel()
+	var events = mod.events.Subscribe(ctx)
+
+	var identified = func() data.EventDataIdentified {
+		t.Helper()
+		for {
+			select {
+			case e := <-events:
+				if e, ok := e.(data.EventDataIdentified); ok {
+					return e
+				}
+			case <-time.After(5 * time.Second):
+				t.Fatal("data not announced")
+			}
+		}
+	}
+
+	mod.identifiers.Set("b", &typeIdentifier{name: "b", typ: "text/b"})
+	if err = mod.Index(dataID); err != nil {
+		t.Fatal(err)
+	}
+	identified()
+
+	// a new identifier that runs earlier doesn't override the type
+	var a = &typeIdentifier{name: "a", typ: "text/a"}
+	mod.identifiers.Set(a.Name(), a)
+	mod.reidentify(a)
+
+	if e := identified(); e.Type != "text/b" || e.Identifier != "b" {
+		t.Fatalf("unexpected identification %+v", e)
+	}
+
+	// descriptors are announced without a type
+	var d = &typeIdentifier{name: "d"}
+	mod.identifiers.Set(d.Name(), d)
+	mod.reidentify(d)
+
+	if e := identified(); e.Type != "text/b" {
+		t.Fatalf("unexpected identification %+v", e)
+	}
+
+	// a new identifier that runs later takes over
+	var c = &typeIdentifier{name: "c", typ: "text/c"}
+	mod.identifiers.Set(c.Name(), c)
+	mod.reidentify(c)
+
+	if e := identified(); e.Type != "text/c" || e.Identifier != "c" {
+		t.Fatalf("unexpected identification %+v", e)
+	}
+
+	row, err := mod.dbDataTypeFindByDataID(dataID.String())
+	if err != nil || row.Type != "text/c" {
+		t.Fatal("type not saved", err)
+	}
+	if len(mod.describeIdentified(dataID)) != 4 {
+		t.Fatal("descriptors not stored")
+	}
+}
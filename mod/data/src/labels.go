@@ -31,3 +31,12 @@ func (mod *Module) GetLabel(id _data.ID) string {
 
 	return label.Label
 }
+
+// IsReferenced keeps labeled data from being garbage collected
+func (mod *Module) IsReferenced(id _data.ID) (bool, error) {
+	var count int64
+
+	err := mod.db.Model(&dbLabel{}).Where("data_id = ? and label != ?", id.String(), "").Count(&count).Error
+
+	return count > 0, err
+}
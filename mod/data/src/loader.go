@@ -33,6 +33,13 @@ func (Loader) Load(node modules.Node, assets assets.Assets, log *log.Logger) (mo
 	if err := mod.db.AutoMigrate(&dbLabel{}); err != nil {
 		return nil, err
 	}
+	if err := mod.db.AutoMigrate(&dbIdentification{}); err != nil {
+		return nil, err
+	}
+
+	for _, identifier := range builtinIdentifiers() {
+		mod.AddIdentifier(identifier)
+	}
 
 	return mod, nil
 }
@@ -27,7 +27,8 @@ type Module struct {
 	events events.Queue
 	db     *gorm.DB
 
-	describers sig.Set[data.Describer]
+	describers  sig.Set[data.Describer]
+	identifiers sig.Map[string, data.Identifier]
 
 	storage storage.Module
 	fs      fs.Module
@@ -70,20 +71,17 @@ func (mod *Module) Index(dataID _data.ID) error {
 		return data.ErrAlreadyIndexed
 	}
 
-	dataReader, err := mod.storage.Data().Read(dataID, nil)
+	firstBytes, err := mod.readHeader(dataID)
 	if err != nil {
 		return err
 	}
 
-	var firstBytes = make([]byte, 512)
-	dataReader.Read(firstBytes)
-	dataReader.Close()
-
 	var (
 		reader     = bytes.NewReader(firstBytes)
 		adc0Header data.ADC0Header
 		dataType   string
 		header     = "mimetype"
+		identified string
 	)
 
 	// detect type either via adc0 or mime
@@ -94,22 +92,35 @@ func (mod *Module) Index(dataID _data.ID) error {
 		dataType = mimetype.Detect(firstBytes).String()
 	}
 
+	// let identifiers refine the type
+	for _, identifier := range mod.sortedIdentifiers() {
+		res, err := mod.identify(identifier, dataID, firstBytes)
+		if err != nil {
+			mod.log.Errorv(1, "identifier %s failed on %v: %v", identifier.Name(), dataID, err)
+			continue
+		}
+		if res != nil && res.Type != "" {
+			dataType, identified = res.Type, identifier.Name()
+		}
+	}
+
 	var indexedAt = time.Now()
 
 	var tx = mod.db.Create(&dbDataType{
-		DataID:    dataID.String(),
-		IndexedAt: indexedAt,
-		Header:    header,
-		Type:      dataType,
+		DataID:     dataID.String(),
+		IndexedAt:  indexedAt,
+		Header:     header,
+		Identifier: identified,
+		Type:       dataType,
 	})
 	if tx.Error != nil {
 		return tx.Error
 	}
 
-	if header != "" {
-		mod.log.Logv(1, "%v indexed as %s (%s)", dataID, dataType, header)
+	if identified != "" {
+		mod.log.Logv(1, "%v indexed as %s (%s)", dataID, dataType, identified)
 	} else {
-		mod.log.Logv(1, "%v indexed as %s", dataID, dataType)
+		mod.log.Logv(1, "%v indexed as %s (%s)", dataID, dataType, header)
 	}
 
 	if err := mod.index.AddToSet(data.IdentifiedDataIndexName, dataID); err != nil {
@@ -117,10 +128,11 @@ func (mod *Module) Index(dataID _data.ID) error {
 	}
 
 	mod.events.Emit(data.EventDataIdentified{
-		DataID:    dataID,
-		Header:    header,
-		Type:      dataType,
-		IndexedAt: indexedAt,
+		DataID:     dataID,
+		Header:     header,
+		Identifier: identified,
+		Type:       dataType,
+		IndexedAt:  indexedAt,
 	})
 
 	return nil
@@ -0,0 +1,175 @@
+package data
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"github.com/cryptopunkscc/astrald/mod/data"
+	"io"
+	"strings"
+)
+
+// maxMoovSize limits the size of the metadata box read into memory
+const maxMoovSize = 16 * 1024 * 1024
+
+// mp4Identifier recognizes ISO base media files (MP4, QuickTime, 3GP, ...) and lists codecs of their tracks.
+// The metadata box can be at the end of the file, so top level boxes are skipped until it's found.
+type mp4Identifier struct{}
+
+func (mp4Identifier) Name() string { return "mp4" }
+
+func (mp4Identifier) Identify(ctx context.Context, input *data.IdentifyInput) (*data.Identification, error) {
+	var h = input.Header
+	if len(h) < 12 || string(h[4:8]) != "ftyp" {
+		return nil, nil
+	}
+
+	var desc = data.MediaDescriptor{Brand: strings.TrimSpace(string(h[8:12]))}
+
+	moov, err := findMoov(ctx, input)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, trak := range childBoxes(moov, "trak") {
+		var track data.MediaTrack
+
+		for _, mdia := range childBoxes(trak, "mdia") {
+			for _, hdlr := range childBoxes(mdia, "hdlr") {
+				if len(hdlr) >= 12 {
+					track.Type = string(hdlr[8:12])
+				}
+			}
+			for _, minf := range childBoxes(mdia, "minf") {
+				for _, stbl := range childBoxes(minf, "stbl") {
+					for _, stsd := range childBoxes(stbl, "stsd") {
+						// version and flags, entry count, then the size and type of the first entry
+						if len(stsd) >= 16 {
+							track.Codec = string(stsd[12:16])
+						}
+					}
+				}
+			}
+		}
+
+		desc.Tracks = append(desc.Tracks, track)
+	}
+
+	return &data.Identification{
+		Type:        mp4Type(desc),
+		Descriptors: []data.Descriptor{{Type: data.MediaDescriptorType, Data: desc}},
+	}, nil
+}
+
+func (mp4Identifier) DecodeDescriptor(typ string, b []byte) (any, error) {
+	return decodeDescriptor(typ, b)
+}
+
+func mp4Type(desc data.MediaDescriptor) string {
+	var video, audio bool
+	for _, t := range desc.Tracks {
+		video = video || t.Type == "vide"
+		audio = audio || t.Type == "soun"
+	}
+
+	switch {
+	case desc.Brand == "qt":
+		return "video/quicktime"
+	case strings.HasPrefix(desc.Brand, "3g"):
+		return "video/3gpp"
+	case audio && !video:
+		return "audio/mp4"
+	}
+	return "video/mp4"
+}
+
+// findMoov returns the payload of the top level moov box
+func findMoov(ctx context.Context, input *data.IdentifyInput) ([]byte, error) {
+	var offset uint64
+
+	for offset+8 <= input.DataID.Size {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		r, err := input.Open(offset)
+		if err != nil {
+			return nil, err
+		}
+
+		size, typ, headerSize, err := readBoxHeader(r)
+		if err != nil {
+			r.Close()
+			return nil, err
+		}
+		if size == 0 {
+			size = input.DataID.Size - offset
+		}
+		if size < headerSize || size > input.DataID.Size-offset {
+			r.Close()
+			return nil, errors.New("invalid box size")
+		}
+
+		if typ == "moov" {
+			defer r.Close()
+			if size-headerSize > maxMoovSize {
+				return nil, errors.New("metadata too large")
+			}
+			var payload = make([]byte, size-headerSize)
+			_, err = io.ReadFull(r, payload)
+			return payload, err
+		}
+
+		r.Close()
+		offset += size
+	}
+
+	return nil, errors.New("metadata not found")
+}
+
+func readBoxHeader(r io.Reader) (size uint64, typ string, headerSize uint64, err error) {
+	var h [8]byte
+	if _, err = io.ReadFull(r, h[:]); err != nil {
+		return
+	}
+
+	size, typ, headerSize = uint64(binary.BigEndian.Uint32(h[:4])), string(h[4:8]), 8
+	if size == 1 {
+		if _, err = io.ReadFull(r, h[:]); err != nil {
+			return
+		}
+		size, headerSize = binary.BigEndian.Uint64(h[:]), 16
+	}
+
+	return
+}
+
+// childBoxes returns payloads of boxes of the given type found directly in the payload
+func childBoxes(payload []byte, typ string) (list [][]byte) {
+	for len(payload) >= 8 {
+		var size = uint64(binary.BigEndian.Uint32(payload[:4]))
+		var headerSize uint64 = 8
+
+		switch size {
+		case 0:
+			size = uint64(len(payload))
+		case 1:
+			if len(payload) < 16 {
+				return
+			}
+			size, headerSize = binary.BigEndian.Uint64(payload[8:16]), 16
+		}
+
+		if size < headerSize || size > uint64(len(payload)) {
+			return
+		}
+
+		if string(payload[4:8]) == typ {
+			list = append(list, payload[headerSize:size])
+		}
+
+		payload = payload[size:]
+	}
+
+	return
+}
@@ -0,0 +1,17 @@
+package dbstore
+
+import (
+	"github.com/cryptopunkscc/astrald/mod/storage"
+)
+
+const ModuleName = "dbstore"
+
+// Module is a store that keeps small objects in the node's database
+type Module interface {
+	storage.Store
+	storage.Reader
+	storage.Lister
+	storage.Deleter
+	storage.CapacityReporter
+	storage.SizeLimiter
+}
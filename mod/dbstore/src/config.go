@@ -0,0 +1,14 @@
+package dbstore
+
+type Config struct {
+	// MaxObjectSize is the size of the largest object kept in the database
+	MaxObjectSize int `yaml:"max_object_size"`
+
+	// Quota limits the total size of objects kept in the database. New objects are stored only when a quota
+	// is configured.
+	Quota int64 `yaml:"quota"`
+}
+
+var defaultConfig = Config{
+	MaxObjectSize: 64 * 1024,
+}
@@ -0,0 +1,20 @@
+package dbstore
+
+import (
+	"time"
+)
+
+type dbObject struct {
+	DataID    string `gorm:"primaryKey"`
+	Size      int64
+	Bytes     []byte
+	CreatedAt time.Time
+}
+
+func (dbObject) TableName() string { return "objects" }
+
+func (mod *Module) dbUsed() int64 {
+	var used int64
+	mod.db.Model(&dbObject{}).Select("coalesce(sum(size), 0)").Scan(&used)
+	return used
+}
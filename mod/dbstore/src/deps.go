@@ -0,0 +1,26 @@
+package dbstore
+
+import (
+	"github.com/cryptopunkscc/astrald/mod/storage"
+	"github.com/cryptopunkscc/astrald/node/modules"
+)
+
+func (mod *Module) LoadDependencies() error {
+	storageModule, err := modules.Load[storage.Module](mod.node, storage.ModuleName)
+	if err != nil {
+		return err
+	}
+
+	// objects stored before the quota was removed stay readable
+	if mod.config.Quota <= 0 && mod.used.Load() == 0 {
+		return nil
+	}
+
+	storageModule.Data().AddReader(storeName, mod)
+
+	if mod.config.Quota > 0 {
+		storageModule.Data().AddStore(storeName, mod)
+	}
+
+	return nil
+}
@@ -0,0 +1,40 @@
+package dbstore
+
+import (
+	"github.com/cryptopunkscc/astrald/log"
+	"github.com/cryptopunkscc/astrald/mod/dbstore"
+	"github.com/cryptopunkscc/astrald/node/assets"
+	"github.com/cryptopunkscc/astrald/node/modules"
+)
+
+type Loader struct{}
+
+func (Loader) Load(node modules.Node, assets assets.Assets, log *log.Logger) (modules.Module, error) {
+	var err error
+	var mod = &Module{
+		node:   node,
+		log:    log,
+		config: defaultConfig,
+	}
+
+	_ = assets.LoadYAML(dbstore.ModuleName, &mod.config)
+
+	mod.db, err = assets.OpenDB(dbstore.ModuleName)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = mod.db.AutoMigrate(&dbObject{}); err != nil {
+		return nil, err
+	}
+
+	mod.used.Store(mod.dbUsed())
+
+	return mod, nil
+}
+
+func init() {
+	if err := modules.RegisterModule(dbstore.ModuleName, Loader{}); err != nil {
+		panic(err)
+	}
+}
@@ -0,0 +1,179 @@
+package dbstore
+
+import (
+	"bytes"
+	"context"
+	"github.com/cryptopunkscc/astrald/data"
+	"github.com/cryptopunkscc/astrald/log"
+	"github.com/cryptopunkscc/astrald/mod/dbstore"
+	"github.com/cryptopunkscc/astrald/mod/storage"
+	"github.com/cryptopunkscc/astrald/node/modules"
+	"gorm.io/gorm"
+	"io"
+	"sync/atomic"
+)
+
+const storeName = "mod.dbstore"
+
+var _ dbstore.Module = &Module{}
+
+type Module struct {
+	node   modules.Node
+	config Config
+	log    *log.Logger
+	db     *gorm.DB
+	used   atomic.Int64
+}
+
+func (mod *Module) Run(ctx context.Context) error {
+	<-ctx.Done()
+	return nil
+}
+
+func (mod *Module) Store(opts *storage.StoreOpts) (storage.DataWriter, error) {
+	if opts == nil {
+		opts = &storage.StoreOpts{}
+	}
+
+	if opts.Alloc > mod.config.MaxObjectSize || mod.used.Load()+int64(opts.Alloc) > mod.config.Quota {
+		return nil, storage.ErrStorageUnavailable
+	}
+
+	return &Writer{mod: mod}, nil
+}
+
+func (mod *Module) Read(dataID data.ID, opts *storage.ReadOpts) (storage.DataReader, error) {
+	if opts == nil {
+		opts = &storage.ReadOpts{}
+	}
+
+	if dataID.Size > uint64(mod.config.MaxObjectSize) || opts.Offset > dataID.Size {
+		return nil, storage.ErrNotFound
+	}
+
+	var row dbObject
+	if err := mod.db.Where("data_id = ?", dataID.String()).First(&row).Error; err != nil {
+		return nil, storage.ErrNotFound
+	}
+
+	return &Reader{Reader: bytes.NewReader(row.Bytes[opts.Offset:])}, nil
+}
+
+func (mod *Module) List() ([]data.ID, error) {
+	var ids []string
+
+	if err := mod.db.Model(&dbObject{}).Pluck("data_id", &ids).Error; err != nil {
+		return nil, err
+	}
+
+	var list = make([]data.ID, 0, len(ids))
+	for _, s := range ids {
+		if dataID, err := data.Parse(s); err == nil {
+			list = append(list, dataID)
+		}
+	}
+
+	return list, nil
+}
+
+func (mod *Module) Delete(dataID data.ID) error {
+	var tx = mod.db.Delete(&dbObject{}, "data_id = ?", dataID.String())
+	if tx.Error != nil {
+		return tx.Error
+	}
+	if tx.RowsAffected == 0 {
+		return storage.ErrNotFound
+	}
+
+	mod.used.Add(-int64(dataID.Size))
+
+	return nil
+}
+
+func (mod *Module) Capacity() (*storage.Capacity, error) {
+	var used = mod.used.Load()
+
+	return &storage.Capacity{
+		Total: uint64(mod.config.Quota),
+		Used:  uint64(used),
+		Free:  uint64(max(mod.config.Quota-used, 0)),
+	}, nil
+}
+
+func (mod *Module) MaxObjectSize() int {
+	return mod.config.MaxObjectSize
+}
+
+// save stores the object if it fits in the quota. Space is reserved before the object is saved, so that
+// concurrent writers cannot exceed the quota together.
+func (mod *Module) save(dataID data.ID, b []byte) error {
+	var size = int64(len(b))
+
+	var count int64
+	if mod.db.Model(&dbObject{}).Where("data_id = ?", dataID.String()).Count(&count); count > 0 {
+		return nil
+	}
+
+	if mod.used.Add(size) > mod.config.Quota {
+		mod.used.Add(-size)
+		return storage.ErrStorageUnavailable
+	}
+
+	var tx = mod.db.Where("data_id = ?", dataID.String()).FirstOrCreate(&dbObject{
+		DataID: dataID.String(),
+		Size:   size,
+		Bytes:  b,
+	})
+	if tx.Error != nil || tx.RowsAffected == 0 {
+		mod.used.Add(-size)
+	}
+
+	return tx.Error
+}
+
+var _ storage.DataReader = &Reader{}
+
+type Reader struct {
+	io.Reader
+}
+
+func (r *Reader) Close() error {
+	return nil
+}
+
+func (r *Reader) Info() *storage.ReaderInfo {
+	return &storage.ReaderInfo{Name: storeName}
+}
+
+var _ storage.DataWriter = &Writer{}
+
+type Writer struct {
+	mod  *Module
+	buf  bytes.Buffer
+	done bool
+}
+
+func (w *Writer) Write(p []byte) (n int, err error) {
+	if w.done || w.buf.Len()+len(p) > w.mod.config.MaxObjectSize {
+		return 0, storage.ErrStorageUnavailable
+	}
+
+	return w.buf.Write(p)
+}
+
+func (w *Writer) Commit() (data.ID, error) {
+	if w.done {
+		return data.ID{}, storage.ErrStorageUnavailable
+	}
+	w.done = true
+
+	var b = w.buf.Bytes()
+	var dataID = data.Resolve(b)
+
+	return dataID, w.mod.save(dataID, b)
+}
+
+func (w *Writer) Discard() error {
+	w.done = true
+	return nil
+}
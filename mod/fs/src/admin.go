@@ -87,19 +87,18 @@ func (adm *Admin) find(term admin.Terminal, args []string) error {
 }
 
 func (adm *Admin) info(term admin.Terminal, args []string) error {
-	f := "%-64s %s\n"
+	f := "%-64s %10s %10s %10s\n"
 
-	term.Printf(f, admin.Header("Store Path"), admin.Header("Free"))
+	term.Printf(f, admin.Header("Store Path"), admin.Header("Used"), admin.Header("Free"), admin.Header("Total"))
 	for _, path := range adm.mod.store.Paths() {
-		var free int
-		usage, _ := DiskUsage(path)
-		if usage != nil {
-			free = int(usage.Free)
+		c, err := adm.mod.store.PathCapacity(path)
+		if err != nil {
+			term.Printf("%-64s %v\n", path, err)
+			continue
 		}
 
-		term.Printf(f, path, log.DataSize(free))
+		term.Printf(f, path, log.DataSize(c.Used), log.DataSize(c.Free), log.DataSize(c.Total))
 	}
-
 	term.Printf("\n%s\n", admin.Header("INDEX PATH"))
 	for _, path := range adm.mod.indexer.watcher.WatchList() {
 		term.Printf("%s\n", path)
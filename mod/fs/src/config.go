@@ -1,8 +1,54 @@
 package fs
 
+import (
+	"errors"
+	"strconv"
+	"strings"
+)
+
 type Config struct {
-	Index []string // list of paths to index for read-only storage
-	Store []string // list of paths to use for read-write storage
+	Index []string          // list of paths to index for read-only storage
+	Store []string          // list of paths to use for read-write storage
+	Quota map[string]string // size limits of read-write paths, for example "/data/astral: 50G"
 }
 
 var defaultConfig = Config{}
+
+// quota returns the quota configured for the path or zero if the path has no quota
+func (cfg *Config) quota(path string) (uint64, error) {
+	s, found := cfg.Quota[path]
+	if !found {
+		return 0, nil
+	}
+
+	return parseSize(s)
+}
+
+// parseSize parses a size in bytes with an optional K, M, G or T suffix (powers of 1024)
+func parseSize(s string) (uint64, error) {
+	s = strings.TrimSuffix(strings.ToUpper(strings.TrimSpace(s)), "B")
+
+	var mul uint64 = 1
+	if len(s) > 0 {
+		switch s[len(s)-1] {
+		case 'K':
+			mul = 1 << 10
+		case 'M':
+			mul = 1 << 20
+		case 'G':
+			mul = 1 << 30
+		case 'T':
+			mul = 1 << 40
+		}
+		if mul > 1 {
+			s = s[:len(s)-1]
+		}
+	}
+
+	v, err := strconv.ParseFloat(strings.TrimSpace(s), 64)
+	if err != nil || v < 0 {
+		return 0, errors.New("invalid size: " + s)
+	}
+
+	return uint64(v * float64(mul)), nil
+}
@@ -12,21 +12,23 @@ import (
 )
 
 type FileWriter struct {
-	path      string
+	dir       *storePath
 	tempID    string
 	file      *os.File
 	resolver  data.Resolver
 	store     *StoreService
+	reserved  uint64
+	size      uint64
 	finalized atomic.Bool
 }
 
-func NewFileWriter(parent *StoreService, path string) (*FileWriter, error) {
+func NewFileWriter(parent *StoreService, dir *storePath, reserved uint64) (*FileWriter, error) {
 	var rbytes = make([]byte, 8)
 	rand.Read(rbytes)
 
 	var tempID = ".tmp." + hex.EncodeToString(rbytes)
 
-	file, err := os.Create(filepath.Join(path, tempID))
+	file, err := os.Create(filepath.Join(dir.path, tempID))
 	if err != nil {
 		return nil, err
 	}
@@ -34,19 +36,31 @@ func NewFileWriter(parent *StoreService, path string) (*FileWriter, error) {
 	resolver := data.NewResolver()
 
 	return &FileWriter{
-		path:     path,
+		dir:      dir,
 		tempID:   tempID,
 		file:     file,
 		resolver: resolver,
 		store:    parent,
+		reserved: reserved,
 	}, nil
 }
 
 func (w *FileWriter) Write(p []byte) (n int, err error) {
+	// grow the reservation when the object outgrows it
+	if end := w.size + uint64(len(p)); end > w.reserved {
+		size, err := w.dir.grow(end-w.reserved, max(end-w.reserved, reserveStep))
+		if err != nil {
+			return 0, err
+		}
+		w.reserved += size
+	}
+
 	n, err = w.file.Write(p)
 
 	if n > 0 {
 		w.resolver.Write(p[:n])
+		w.size += uint64(n)
+		w.dir.wrote(uint64(n))
 	}
 
 	return n, err
@@ -61,14 +75,25 @@ func (w *FileWriter) Commit() (data.ID, error) {
 
 	dataID := w.resolver.Resolve()
 
-	var oldPath = filepath.Join(w.path, w.tempID)
-	var newPath = filepath.Join(w.path, dataID.String())
+	var oldPath = filepath.Join(w.dir.path, w.tempID)
+	var newPath = filepath.Join(w.dir.path, dataID.String())
+
+	// the object is already stored
+	if _, err := os.Stat(newPath); err == nil {
+		os.Remove(oldPath)
+		w.dir.release(w.reserved, w.size, 0)
+		return dataID, nil
+	}
 
 	err := os.Rename(oldPath, newPath)
 	if err != nil {
 		os.Remove(oldPath)
+		w.dir.release(w.reserved, w.size, 0)
+		return dataID, err
 	}
 
+	w.dir.release(w.reserved, w.size, w.size)
+
 	if w.store != nil {
 		w.store.index.AddToSet(nameReadWrite, dataID)
 		w.store.events.Emit(fs.EventFileAdded{
@@ -77,7 +102,7 @@ func (w *FileWriter) Commit() (data.ID, error) {
 		})
 	}
 
-	return dataID, err
+	return dataID, nil
 }
 
 func (w *FileWriter) Discard() error {
@@ -86,6 +111,7 @@ func (w *FileWriter) Discard() error {
 	}
 
 	w.file.Close()
-	os.Remove(filepath.Join(w.path, w.tempID))
+	os.Remove(filepath.Join(w.dir.path, w.tempID))
+	w.dir.release(w.reserved, w.size, 0)
 	return nil
 }
@@ -42,7 +42,9 @@ func (Loader) Load(node modules.Node, assets assets.Assets, log *log.Logger) (mo
 
 	mod.store = NewStoreService(mod)
 	for _, path := range mod.config.Store {
-		mod.store.AddPath(path)
+		if err := mod.addStorePath(path); err != nil {
+			mod.log.Error("error adding writable path %v: %v", path, err)
+		}
 	}
 
 	// if we have file-based resources, use that as writable storage
@@ -51,7 +53,7 @@ func (Loader) Load(node modules.Node, assets assets.Assets, log *log.Logger) (mo
 		dataPath := filepath.Join(fileRes.Root(), "data")
 		err = os.MkdirAll(dataPath, 0700)
 		if err == nil {
-			err = mod.store.AddPath(dataPath)
+			err = mod.addStorePath(dataPath)
 			if err != nil {
 				mod.log.Error("error adding writable data path: %v", err)
 			}
@@ -61,6 +63,15 @@ func (Loader) Load(node modules.Node, assets assets.Assets, log *log.Logger) (mo
 	return mod, nil
 }
 
+func (mod *Module) addStorePath(path string) error {
+	quota, err := mod.config.quota(path)
+	if err != nil {
+		return err
+	}
+
+	return mod.store.AddPath(path, quota)
+}
+
 func init() {
 	if err := modules.RegisterModule(fs.ModuleName, Loader{}); err != nil {
 		panic(err)
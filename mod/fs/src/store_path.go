@@ -0,0 +1,139 @@
+package fs
+
+import (
+	"github.com/cryptopunkscc/astrald/data"
+	"github.com/cryptopunkscc/astrald/mod/storage"
+	"os"
+	"sync"
+)
+
+// reserveStep is the amount of space reserved at a time for objects of unknown size
+const reserveStep = 16 * 1024 * 1024
+
+// storePath is a writable directory with an optional quota. It keeps track of the space used by stored
+// objects and the space reserved by writers in progress.
+type storePath struct {
+	path  string
+	quota uint64
+
+	mu       sync.Mutex
+	used     uint64
+	reserved uint64
+	written  uint64 // part of the reserved space already written to temporary files
+}
+
+func newStorePath(path string, quota uint64) (*storePath, error) {
+	entries, err := os.ReadDir(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var p = &storePath{path: path, quota: quota}
+
+	for _, entry := range entries {
+		if !entry.Type().IsRegular() {
+			continue
+		}
+		if _, err := data.Parse(entry.Name()); err != nil {
+			continue
+		}
+		if info, err := entry.Info(); err == nil {
+			p.used += uint64(info.Size())
+		}
+	}
+
+	return p, nil
+}
+
+func (p *storePath) Capacity() (*storage.Capacity, error) {
+	usage, err := DiskUsage(p.path)
+	if err != nil {
+		return nil, err
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	return p.capacity(usage), nil
+}
+
+// capacity returns the capacity of the path. Bytes already written by writers in progress are no longer
+// available on the disk, so only the unwritten part of reservations is subtracted from the available space.
+func (p *storePath) capacity(usage *DiskUsageInfo) *storage.Capacity {
+	var c = &storage.Capacity{
+		Total: usage.Total,
+		Used:  p.used,
+		Free:  sub(usage.Available, sub(p.reserved, p.written)),
+	}
+
+	if p.quota > 0 {
+		c.Total = p.quota
+		c.Free = min(c.Free, sub(p.quota, p.used+p.reserved))
+	}
+
+	return c
+}
+
+// reserve reserves space for a new object. If the size of the object isn't known, only a part of the free
+// space is reserved and writers grow the reservation as the object is written. It returns the number of
+// reserved bytes.
+func (p *storePath) reserve(alloc int) (uint64, error) {
+	if alloc > 0 {
+		return p.grow(uint64(alloc), uint64(alloc))
+	}
+
+	return p.grow(1, reserveStep)
+}
+
+// grow reserves at least need and at most want bytes. It returns the number of reserved bytes.
+func (p *storePath) grow(need uint64, want uint64) (uint64, error) {
+	usage, err := DiskUsage(p.path)
+	if err != nil {
+		return 0, err
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	var free = p.capacity(usage).Free
+	if free < need {
+		return 0, storage.ErrStorageUnavailable
+	}
+
+	var size = min(max(need, want), free)
+	p.reserved += size
+
+	return size, nil
+}
+
+// wrote marks reserved bytes as written
+func (p *storePath) wrote(n uint64) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.written += n
+}
+
+// release returns the reservation of a finished writer and accounts for the stored object
+func (p *storePath) release(reserved uint64, written uint64, stored uint64) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.reserved = sub(p.reserved, reserved)
+	p.written = sub(p.written, written)
+	p.used += stored
+}
+
+func (p *storePath) removed(size uint64) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.used = sub(p.used, size)
+}
+
+func sub(a, b uint64) uint64 {
+	if b > a {
+		return 0
+	}
+	return a - b
+}
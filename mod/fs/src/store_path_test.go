@@ -0,0 +1,116 @@
+package fs
+
+import (
+	"errors"
+	"github.com/cryptopunkscc/astrald/mod/storage"
+	"testing"
+)
+
+func TestParseSize(t *testing.T) {
+	var tests = map[string]uint64{
+		"100":  100,
+		"4K":   4096,
+		"1.5M": 3 << 19,
+		"10GB": 10 << 30,
+		" 2t ": 2 << 40,
+		"0":    0,
+	}
+
+	for s, expected := range tests {
+		v, err := parseSize(s)
+		if err != nil {
+			t.Fatalf("%q: %v", s, err)
+		}
+		if v != expected {
+			t.Fatalf("%q: expected %d, got %d", s, expected, v)
+		}
+	}
+
+	if _, err := parseSize("lots"); err == nil {
+		t.Fatal("expected an error")
+	}
+}
+
+func TestStorePathQuota(t *testing.T) {
+	p, err := newStorePath(t.TempDir(), 1000)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	w, err := NewFileWriter(nil, p, mustReserve(t, p, 600))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the reserved space is taken until the writer is done
+	if _, err = p.reserve(600); !errors.Is(err, storage.ErrStorageUnavailable) {
+		t.Fatal("expected the quota to be exceeded")
+	}
+
+	// writes past the reservation grow it up to the quota
+	if _, err = w.Write(make([]byte, 700)); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = w.Write(make([]byte, 400)); !errors.Is(err, storage.ErrStorageUnavailable) {
+		t.Fatal("expected the quota to be exceeded")
+	}
+	if _, err = w.Commit(); err != nil {
+		t.Fatal(err)
+	}
+
+	c, err := p.Capacity()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.Used != 700 || c.Free > 300 || c.Total != 1000 {
+		t.Fatalf("unexpected capacity: %+v", c)
+	}
+
+	// used space is restored from the directory
+	p, err = newStorePath(p.path, 1000)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c, _ = p.Capacity(); c.Used != 700 {
+		t.Fatalf("expected 700 bytes used, got %d", c.Used)
+	}
+}
+
+func TestStorePathUnknownSize(t *testing.T) {
+	p, err := newStorePath(t.TempDir(), 3*reserveStep)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// writers of unknown size reserve a bounded amount, so they can share the path
+	var writers []*FileWriter
+	for i := 0; i < 2; i++ {
+		w, err := NewFileWriter(nil, p, mustReserve(t, p, 0))
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer w.Discard()
+		writers = append(writers, w)
+	}
+
+	// the reservation grows as data is written
+	if _, err = writers[0].Write(make([]byte, reserveStep+1)); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = writers[1].Write(make([]byte, reserveStep)); err != nil {
+		t.Fatal(err)
+	}
+
+	// the quota is taken by both writers
+	if _, err = writers[1].Write(make([]byte, 1)); !errors.Is(err, storage.ErrStorageUnavailable) {
+		t.Fatal("expected the quota to be exceeded")
+	}
+}
+
+func mustReserve(t *testing.T, p *storePath, alloc int) uint64 {
+	reserved, err := p.reserve(alloc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return reserved
+}
@@ -1,6 +1,7 @@
 package fs
 
 import (
+	"cmp"
 	"context"
 	"errors"
 	"github.com/cryptopunkscc/astrald/data"
@@ -10,12 +11,15 @@ import (
 	"io"
 	"os"
 	"path/filepath"
+	"slices"
 	"syscall"
 )
 
+var _ storage.CapacityReporter = &StoreService{}
+
 type StoreService struct {
 	*Module
-	paths sig.Set[string]
+	paths sig.Map[string, *storePath]
 }
 
 func NewStoreService(mod *Module) *StoreService {
@@ -32,7 +36,7 @@ func (srv *StoreService) Read(dataID data.ID, opts *storage.ReadOpts) (storage.D
 		opts = &storage.ReadOpts{}
 	}
 
-	for _, dir := range srv.paths.Clone() {
+	for dir := range srv.paths.Clone() {
 		var path = filepath.Join(dir, dataID.String())
 
 		r, err := srv.readPath(path, int(opts.Offset))
@@ -51,7 +55,7 @@ func (srv *StoreService) readPath(path string, offset int) (io.ReadCloser, error
 	}
 
 	if offset > 0 {
-		r, err := f.Seek(io.SeekStart, offset)
+		r, err := f.Seek(int64(offset), io.SeekStart)
 		if err != nil {
 			f.Close()
 			return nil, err
@@ -65,50 +69,127 @@ func (srv *StoreService) readPath(path string, offset int) (io.ReadCloser, error
 	return f, nil
 }
 
+// Store returns a writer to the path with the most free space that can fit the object. Writers grow their
+// reservation as the object is written and fail once the path runs out of space.
 func (srv *StoreService) Store(opts *storage.StoreOpts) (storage.DataWriter, error) {
-	for _, dir := range srv.paths.Clone() {
-		r, err := srv.storePath(dir, opts.Alloc)
-		if err == nil {
-			return r, err
+	var paths = srv.paths.Values()
+
+	slices.SortFunc(paths, func(a, b *storePath) int {
+		ca, _ := a.Capacity()
+		cb, _ := b.Capacity()
+		if ca == nil || cb == nil {
+			return 0
+		}
+		return cmp.Compare(cb.Free, ca.Free)
+	})
+
+	for _, p := range paths {
+		reserved, err := p.reserve(opts.Alloc)
+		if err != nil {
+			continue
 		}
+
+		w, err := NewFileWriter(srv, p, reserved)
+		if err != nil {
+			p.release(reserved, 0, 0)
+			continue
+		}
+
+		return w, nil
 	}
 
-	return nil, errors.New("no space available")
+	return nil, storage.ErrStorageUnavailable
 }
 
-func (srv *StoreService) storePath(path string, alloc int) (storage.DataWriter, error) {
-	usage, err := DiskUsage(path)
-	if err != nil {
-		return nil, err
-	}
+// Capacity returns the combined capacity of all paths
+func (srv *StoreService) Capacity() (*storage.Capacity, error) {
+	var total = &storage.Capacity{}
 
-	if usage.Free < uint64(alloc) {
-		return nil, errors.New("not enough free space")
+	for _, p := range srv.paths.Values() {
+		c, err := p.Capacity()
+		if err != nil {
+			continue
+		}
+		total.Total += c.Total
+		total.Used += c.Used
+		total.Free += c.Free
 	}
 
-	w, err := NewFileWriter(srv, path)
+	return total, nil
+}
+
+// PathCapacity returns the capacity of a single path
+func (srv *StoreService) PathCapacity(path string) (*storage.Capacity, error) {
+	p, found := srv.paths.Get(path)
+	if !found {
+		return nil, storage.ErrNotFound
+	}
 
-	return w, err
+	return p.Capacity()
 }
 
-func (srv *StoreService) AddPath(path string) error {
-	return srv.paths.Add(path)
+// AddPath adds a writable path. A non-zero quota limits the amount of data stored in the path.
+func (srv *StoreService) AddPath(path string, quota uint64) error {
+	p, err := newStorePath(path, quota)
+	if err != nil {
+		return err
+	}
+
+	if !srv.paths.Set(path, p) {
+		return errors.New("path already added")
+	}
+
+	return nil
 }
 
 func (srv *StoreService) RemovePath(path string) error {
-	return srv.paths.Remove(path)
+	if _, found := srv.paths.Delete(path); !found {
+		return storage.ErrNotFound
+	}
+	return nil
 }
 
 func (srv *StoreService) Paths() []string {
-	return srv.paths.Clone()
+	var paths = srv.paths.Keys()
+	slices.Sort(paths)
+	return paths
+}
+
+// List returns IDs of all objects in the store
+func (srv *StoreService) List() ([]data.ID, error) {
+	var list []data.ID
+
+	for dir := range srv.paths.Clone() {
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, entry := range entries {
+			if !entry.Type().IsRegular() {
+				continue
+			}
+
+			// skip temporary and unrelated files
+			dataID, err := data.Parse(entry.Name())
+			if err != nil {
+				continue
+			}
+
+			list = append(list, dataID)
+		}
+	}
+
+	return list, nil
 }
 
 func (srv *StoreService) Delete(dataID data.ID) error {
 	var deleted bool
 
-	for _, dir := range srv.paths.Clone() {
-		err := srv.deletePath(dir, dataID)
+	for dir, p := range srv.paths.Clone() {
+		size, err := srv.deletePath(dir, dataID)
 		if err == nil {
+			p.removed(size)
 			srv.events.Emit(fs.EventFileRemoved{
 				DataID: dataID,
 				Path:   dir,
@@ -118,25 +199,26 @@ func (srv *StoreService) Delete(dataID data.ID) error {
 	}
 
 	if deleted {
+		srv.index.RemoveFromSet(nameReadWrite, dataID)
 		return nil
 	}
 
 	return errors.New("not found")
 }
 
-func (srv *StoreService) deletePath(dir string, dataID data.ID) error {
+func (srv *StoreService) deletePath(dir string, dataID data.ID) (uint64, error) {
 	path := filepath.Join(dir, dataID.String())
 
 	info, err := os.Stat(path)
 	if err != nil {
-		return storage.ErrNotFound
+		return 0, storage.ErrNotFound
 	}
 
 	if !info.Mode().IsRegular() {
-		return storage.ErrNotFound
+		return 0, storage.ErrNotFound
 	}
 
-	return os.Remove(path)
+	return uint64(info.Size()), os.Remove(path)
 }
 
 func DiskUsage(path string) (usage *DiskUsageInfo, err error) {
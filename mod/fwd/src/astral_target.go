@@ -22,9 +22,9 @@ func NewAstralTarget(query net.Query, router net.Router, label string) (*AstralT
 func (t *AstralTarget) RouteQuery(ctx context.Context, query net.Query, src net.SecureWriteCloser, hints net.Hints) (net.SecureWriteCloser, error) {
 	return t.router.RouteQuery(
 		ctx,
-		net.NewQuery(t.query.Caller(), t.query.Target(), t.query.Query()),
+		net.WithParams(net.NewQuery(t.query.Caller(), t.query.Target(), t.query.Query()), query.Params()),
 		net.NewIdentityTranslation(src, t.query.Caller()),
-		net.DefaultHints(),
+		net.DefaultHints().WithPriority(hints.Priority),
 	)
 }
 
@@ -77,11 +77,14 @@ func (srv *RouteService) RouteQuery(ctx context.Context, query net.Query, caller
 		return net.Reject()
 	}
 
-	maskedQuery := net.NewQueryNonce(
-		srv.node.Identity(),
-		targetIdentity,
-		query.Query(),
-		query.Nonce(),
+	maskedQuery := net.WithParams(
+		net.NewQueryNonce(
+			srv.node.Identity(),
+			targetIdentity,
+			query.Query(),
+			query.Nonce(),
+		),
+		query.Params(),
 	)
 
 	maskedCaller := net.NewIdentityTranslation(caller, srv.node.Identity())
@@ -0,0 +1,90 @@
+package index
+
+import (
+	"errors"
+	"strconv"
+	"time"
+)
+
+// Attributes hold metadata of an index entry, such as a file name, a tag or a sort key
+type Attributes map[string]string
+
+// AttrType is the type of attribute values. It decides how values are compared and sorted.
+type AttrType string
+
+const (
+	AttrString = AttrType("string")
+	AttrInt    = AttrType("int")
+	AttrTime   = AttrType("time") // RFC 3339
+)
+
+// AttrDef declares an attribute of entries of an index
+type AttrDef struct {
+	Name     string
+	Type     AttrType
+	Required bool
+}
+
+// Schema lists attributes that entries of an index can have. Indexes without a schema accept any string
+// attributes.
+type Schema []AttrDef
+
+var ErrInvalidAttribute = errors.New("invalid attribute")
+
+// Get returns the definition of the attribute
+func (s Schema) Get(name string) (AttrDef, bool) {
+	for _, def := range s {
+		if def.Name == name {
+			return def, true
+		}
+	}
+	return AttrDef{}, false
+}
+
+// ParseValue checks that the value is valid for the type and returns its numeric form used for comparing
+// int and time values
+func (t AttrType) ParseValue(value string) (int64, error) {
+	switch t {
+	case AttrString, "":
+		return 0, nil
+	case AttrInt:
+		return strconv.ParseInt(value, 10, 64)
+	case AttrTime:
+		ts, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			return 0, err
+		}
+		return ts.UnixNano(), nil
+	}
+	return 0, errors.New("unknown attribute type: " + string(t))
+}
+
+// Query selects and orders entries of an index by their attributes
+type Query struct {
+	Filters []Filter
+
+	// SortBy is the attribute to sort by. Entries are sorted by update time if it's empty or missing.
+	SortBy string
+	Desc   bool
+
+	Limit  int
+	Offset int
+}
+
+type Filter struct {
+	Attribute string
+	Op        Op
+	Value     string
+}
+
+type Op string
+
+const (
+	OpEq     = Op("=")
+	OpNe     = Op("!=")
+	OpLt     = Op("<")
+	OpLe     = Op("<=")
+	OpGt     = Op(">")
+	OpGe     = Op(">=")
+	OpPrefix = Op("prefix")
+)
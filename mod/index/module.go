@@ -1,6 +1,7 @@
 package index
 
 import (
+	"github.com/cryptopunkscc/astrald/auth/id"
 	"github.com/cryptopunkscc/astrald/data"
 	"time"
 )
@@ -8,17 +9,43 @@ import (
 const ModuleName = "index"
 const LocalNodeUnionName = "localnode"
 
+// SystemIndexPrefix starts the names of indexes that modules use to list data they hold. Unlike other sets,
+// these don't keep their data from being garbage collected.
+const SystemIndexPrefix = "mod."
+
 type Module interface {
 	CreateIndex(name string, typ Type) (*Info, error)
 	DeleteIndex(name string) error
 	AddToSet(name string, dataID data.ID) error
+
+	// AddToSetWithAttributes adds data to a set along with attributes of the entry. If the data is already
+	// in the set, its attributes are replaced.
+	AddToSetWithAttributes(name string, dataID data.ID, attrs Attributes) error
 	RemoveFromSet(name string, dataID data.ID) error
 	IndexInfo(name string) (*Info, error)
 	UpdatedSince(name string, since time.Time) ([]Entry, error)
 	Contains(name string, dataID data.ID) (bool, error)
 	Find(dataID data.ID) ([]string, error)
 	GetEntry(name string, dataID data.ID) (*Entry, error)
+
+	// SetSchema sets the attributes that entries of the index can have
+	SetSchema(name string, schema Schema) error
+	Schema(name string) (Schema, error)
+
+	// Query returns entries of the index filtered and sorted by their attributes
+	Query(name string, query *Query) ([]Entry, error)
 	AddToUnion(union string, set string) error
+
+	// Share lets the identity sync the index
+	Share(name string, identity id.Identity) error
+	Unshare(name string, identity id.Identity) error
+
+	// Mirror keeps a local set in sync with an index of a remote node. The set is created if needed.
+	Mirror(name string, remote id.Identity, remoteName string) error
+	Unmirror(name string) error
+
+	// FindRemote returns remote nodes whose mirrored indexes contain the data
+	FindRemote(dataID data.ID) ([]id.Identity, error)
 }
 
 type Info struct {
@@ -29,9 +56,10 @@ type Info struct {
 }
 
 type Entry struct {
-	DataID    data.ID
-	Added     bool
-	UpdatedAt time.Time
+	DataID     data.ID
+	Added      bool
+	UpdatedAt  time.Time
+	Attributes Attributes
 }
 
 type Type string
@@ -0,0 +1,27 @@
+package proto
+
+import "github.com/cryptopunkscc/astrald/data"
+
+const (
+	// SyncServiceName streams entries of an index shared with the caller as a sequence of SyncEntry
+	// messages
+	SyncServiceName = "index.sync"
+
+	// ParamSince limits the stream to entries updated after the given time (unix nanoseconds)
+	ParamSince = "since"
+
+	// ParamFollow keeps the stream open and sends entries as they're updated
+	ParamFollow = "follow"
+)
+
+type SyncEntry struct {
+	DataID     data.ID         `cslq:"v"`
+	Added      bool            `cslq:"c"`
+	UpdatedAt  int64           `cslq:"q"`
+	Attributes []SyncAttribute `cslq:"[c]v"`
+}
+
+type SyncAttribute struct {
+	Name  string `cslq:"[c]c"`
+	Value string `cslq:"[s]c"`
+}
@@ -4,6 +4,7 @@ import (
 	"cmp"
 	"errors"
 	"fmt"
+	"github.com/cryptopunkscc/astrald/auth/id"
 	"github.com/cryptopunkscc/astrald/data"
 	"github.com/cryptopunkscc/astrald/mod/admin"
 	"github.com/cryptopunkscc/astrald/mod/index"
@@ -31,6 +32,14 @@ func NewAdmin(mod *Module) *Admin {
 		"show":      adm.show,
 		"find":      adm.find,
 		"contains":  adm.contains,
+		"schema":    adm.schema,
+		"set":       adm.set,
+		"query":     adm.query,
+		"share":     adm.share,
+		"unshare":   adm.unshare,
+		"mirror":    adm.mirror,
+		"unmirror":  adm.unmirror,
+		"mirrors":   adm.listMirrors,
 		"help":      adm.help,
 	}
 
@@ -196,9 +205,9 @@ func (adm *Admin) show(term admin.Terminal, args []string) error {
 		return err
 	}
 
-	var f = "%-20s %-8s %s\n"
+	var f = "%-20s %-8s %-64s %s\n"
 	term.Printf("\n")
-	term.Printf(f, admin.Header("Updated at"), admin.Header("Status"), admin.Header("DataID"))
+	term.Printf(f, admin.Header("Updated at"), admin.Header("Status"), admin.Header("DataID"), admin.Header("Attributes"))
 	for _, item := range list {
 		var status = "added"
 		if !item.Added {
@@ -209,6 +218,7 @@ func (adm *Admin) show(term admin.Terminal, args []string) error {
 			item.UpdatedAt,
 			status,
 			item.DataID,
+			formatAttributes(item.Attributes),
 		)
 	}
 
@@ -282,6 +292,211 @@ func (adm *Admin) ShortDescription() string {
 	return "manage " + index.ModuleName
 }
 
+// schema shows or sets the schema of an index. Attributes are given as name:type, with a trailing ! for
+// required attributes.
+func (adm *Admin) schema(term admin.Terminal, args []string) error {
+	if len(args) < 1 {
+		return errors.New("missing argument")
+	}
+
+	if len(args) == 1 {
+		schema, err := adm.mod.Schema(args[0])
+		if err != nil {
+			return err
+		}
+
+		var f = "%-20s %-8s %s\n"
+		term.Printf(f, admin.Header("Name"), admin.Header("Type"), admin.Header("Required"))
+		for _, def := range schema {
+			term.Printf(f, def.Name, string(def.Type), strconv.FormatBool(def.Required))
+		}
+		return nil
+	}
+
+	var schema index.Schema
+	for _, arg := range args[1:] {
+		var def index.AttrDef
+		arg, def.Required = strings.CutSuffix(arg, "!")
+
+		name, typ, found := strings.Cut(arg, ":")
+		if !found {
+			typ = string(index.AttrString)
+		}
+		def.Name, def.Type = name, index.AttrType(typ)
+
+		schema = append(schema, def)
+	}
+
+	return adm.mod.SetSchema(args[0], schema)
+}
+
+func (adm *Admin) set(term admin.Terminal, args []string) error {
+	if len(args) < 2 {
+		return errors.New("missing argument")
+	}
+
+	dataID, err := data.Parse(args[1])
+	if err != nil {
+		return err
+	}
+
+	var attrs = index.Attributes{}
+	for _, arg := range args[2:] {
+		name, value, found := strings.Cut(arg, "=")
+		if !found {
+			return errors.New("invalid attribute: " + arg)
+		}
+		attrs[name] = value
+	}
+
+	return adm.mod.AddToSetWithAttributes(args[0], dataID, attrs)
+}
+
+// query lists entries matching filters like year>=2020 or title=prefix:Abbey. Results are sorted with
+// sort:<attr> or sort:-<attr> (descending) and limited with limit:<n>.
+func (adm *Admin) query(term admin.Terminal, args []string) error {
+	if len(args) < 1 {
+		return errors.New("missing argument")
+	}
+
+	var q = &index.Query{}
+
+	for _, arg := range args[1:] {
+		if v, found := strings.CutPrefix(arg, "sort:"); found {
+			q.SortBy, q.Desc = strings.TrimPrefix(v, "-"), strings.HasPrefix(v, "-")
+			continue
+		}
+		if v, found := strings.CutPrefix(arg, "limit:"); found {
+			limit, err := strconv.Atoi(v)
+			if err != nil {
+				return err
+			}
+			q.Limit = limit
+			continue
+		}
+
+		filter, err := parseFilter(arg)
+		if err != nil {
+			return err
+		}
+		q.Filters = append(q.Filters, filter)
+	}
+
+	list, err := adm.mod.Query(args[0], q)
+	if err != nil {
+		return err
+	}
+
+	var f = "%-64s %s\n"
+	term.Printf(f, admin.Header("DataID"), admin.Header("Attributes"))
+	for _, entry := range list {
+		term.Printf(f, entry.DataID, formatAttributes(entry.Attributes))
+	}
+
+	return nil
+}
+
+func parseFilter(s string) (index.Filter, error) {
+	// longer operators go first, so that >= isn't taken for >
+	for _, op := range []index.Op{index.OpNe, index.OpLe, index.OpGe, index.OpEq, index.OpLt, index.OpGt} {
+		name, value, found := strings.Cut(s, string(op))
+		if !found || name == "" {
+			continue
+		}
+
+		if v, found := strings.CutPrefix(value, "prefix:"); found && op == index.OpEq {
+			return index.Filter{Attribute: name, Op: index.OpPrefix, Value: v}, nil
+		}
+
+		return index.Filter{Attribute: name, Op: op, Value: value}, nil
+	}
+
+	return index.Filter{}, errors.New("invalid filter: " + s)
+}
+
+func formatAttributes(attrs index.Attributes) string {
+	var list []string
+	for name, value := range attrs {
+		list = append(list, name+"="+value)
+	}
+	slices.Sort(list)
+
+	return strings.Join(list, " ")
+}
+
+func (adm *Admin) share(term admin.Terminal, args []string) error {
+	if len(args) < 2 {
+		return errors.New("missing argument")
+	}
+
+	identity, err := adm.mod.node.Resolver().Resolve(args[1])
+	if err != nil {
+		return err
+	}
+
+	return adm.mod.Share(args[0], identity)
+}
+
+func (adm *Admin) unshare(term admin.Terminal, args []string) error {
+	if len(args) < 2 {
+		return errors.New("missing argument")
+	}
+
+	identity, err := adm.mod.node.Resolver().Resolve(args[1])
+	if err != nil {
+		return err
+	}
+
+	return adm.mod.Unshare(args[0], identity)
+}
+
+func (adm *Admin) mirror(term admin.Terminal, args []string) error {
+	if len(args) < 2 {
+		return errors.New("missing argument")
+	}
+
+	identity, err := adm.mod.node.Resolver().Resolve(args[1])
+	if err != nil {
+		return err
+	}
+
+	// mirror an index with the same name unless told otherwise
+	var remoteName = args[0]
+	if len(args) >= 3 {
+		remoteName = args[2]
+	}
+
+	return adm.mod.Mirror(args[0], identity, remoteName)
+}
+
+func (adm *Admin) unmirror(term admin.Terminal, args []string) error {
+	if len(args) < 1 {
+		return errors.New("missing argument")
+	}
+
+	return adm.mod.Unmirror(args[0])
+}
+
+func (adm *Admin) listMirrors(term admin.Terminal, _ []string) error {
+	rows, err := adm.mod.dbMirrorFindAll()
+	if err != nil {
+		return err
+	}
+
+	var f = "%-20s %-20s %-20s %v\n"
+	term.Printf(f, admin.Header("Name"), admin.Header("Node"), admin.Header("Remote name"), admin.Header("Synced until"))
+	for _, row := range rows {
+		var remote = row.Remote
+		if identity, err := id.ParsePublicKeyHex(row.Remote); err == nil {
+			remote = adm.mod.node.Resolver().DisplayName(identity)
+		}
+
+		term.Printf(f, row.Index.Name, remote, row.RemoteName, row.SyncedUntil)
+	}
+
+	return nil
+}
+
 func (adm *Admin) help(term admin.Terminal, _ []string) error {
 	term.Printf("usage: %s <command>\n\n", index.ModuleName)
 	term.Printf("commands:\n")
@@ -295,6 +510,14 @@ func (adm *Admin) help(term admin.Terminal, _ []string) error {
 	term.Printf("  contains <name> <[]dataID>    check if an index contains provided data\n")
 	term.Printf("  info <name>                   info info about an index\n")
 	term.Printf("  show <name>                   list all data from an index\n")
+	term.Printf("  schema <name> [attr:type...]  show or set attributes of entries, types: string/int/time\n")
+	term.Printf("  set <name> <dataID> [k=v...]  add data to a set with attributes\n")
+	term.Printf("  query <name> [filter...]      list entries by attributes, e.g. year>=2020 sort:-year limit:10\n")
+	term.Printf("  share <name> <identity>       let an identity sync an index\n")
+	term.Printf("  unshare <name> <identity>     stop sharing an index with an identity\n")
+	term.Printf("  mirror <name> <node> [remote] keep a set in sync with an index of a node\n")
+	term.Printf("  unmirror <name>               stop syncing a set\n")
+	term.Printf("  mirrors                       list mirrored indexes\n")
 	term.Printf("  help                          info help\n")
 	return nil
 }
@@ -0,0 +1,258 @@
+package index
+
+import (
+	"errors"
+	"fmt"
+	"github.com/cryptopunkscc/astrald/data"
+	"github.com/cryptopunkscc/astrald/mod/index"
+	"strings"
+	"time"
+)
+
+func (mod *Module) AddToSetWithAttributes(name string, dataID data.ID, attrs index.Attributes) error {
+	indexRow, err := mod.dbFindIndexByName(name)
+	if err != nil {
+		return err
+	}
+	if index.Type(indexRow.Type) != index.TypeSet {
+		return errors.New("index is not a set")
+	}
+
+	schema, err := mod.dbSchemaFind(indexRow.ID)
+	if err != nil {
+		return err
+	}
+
+	rows, err := attributeRows(schema, attrs)
+	if err != nil {
+		return err
+	}
+
+	return mod.addWithAttributes(indexRow, dataID, rows)
+}
+
+// addWithAttributes adds data to a set or, if it's already there, replaces the entry's attributes and marks
+// the entry as updated
+func (mod *Module) addWithAttributes(indexRow *dbIndex, dataID data.ID, attrs []dbAttribute) error {
+	dataRow, err := mod.dbDataFindOrCreateByDataID(dataID.String())
+	if err != nil {
+		return err
+	}
+
+	if err = mod.dbAttributesReplace(indexRow.ID, dataRow.ID, attrs); err != nil {
+		return err
+	}
+
+	entryRow, err := mod.dbEntryFind(indexRow.ID, dataRow.ID)
+	if err != nil || !entryRow.Added {
+		return mod.addToIndex(indexRow, dataID)
+	}
+
+	entryRow.UpdatedAt = time.Now()
+	var tx = mod.db.
+		Where("data_id = ? and index_id = ?", dataRow.ID, indexRow.ID).
+		Save(entryRow)
+	if tx.Error != nil {
+		return tx.Error
+	}
+
+	mod.events.Emit(index.EventEntryUpdate{
+		IndexName: indexRow.Name,
+		DataID:    dataID,
+		Added:     true,
+		UpdatedAt: entryRow.UpdatedAt,
+	})
+
+	return nil
+}
+
+func (mod *Module) SetSchema(name string, schema index.Schema) error {
+	indexRow, err := mod.dbFindIndexByName(name)
+	if err != nil {
+		return err
+	}
+	if index.Type(indexRow.Type) != index.TypeSet {
+		return errors.New("index is not a set")
+	}
+
+	var seen = map[string]bool{}
+	for _, def := range schema {
+		if def.Name == "" || seen[def.Name] {
+			return fmt.Errorf("%w: invalid or duplicate name %q", index.ErrInvalidAttribute, def.Name)
+		}
+		seen[def.Name] = true
+
+		switch def.Type {
+		case index.AttrString, index.AttrInt, index.AttrTime:
+		default:
+			return fmt.Errorf("%w: unknown type %q", index.ErrInvalidAttribute, def.Type)
+		}
+	}
+
+	return mod.dbSchemaReplace(indexRow.ID, schema)
+}
+
+func (mod *Module) Schema(name string) (index.Schema, error) {
+	indexRow, err := mod.dbFindIndexByName(name)
+	if err != nil {
+		return nil, err
+	}
+
+	return mod.dbSchemaFind(indexRow.ID)
+}
+
+func (mod *Module) Query(name string, query *index.Query) ([]index.Entry, error) {
+	if query == nil {
+		query = &index.Query{}
+	}
+
+	indexRow, err := mod.dbFindIndexByName(name)
+	if err != nil {
+		return nil, err
+	}
+
+	schema, err := mod.dbSchemaFind(indexRow.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	var tx = mod.db.
+		Model(&dbEntry{}).
+		Where("entries.index_id = ? AND entries.added = ?", indexRow.ID, true).
+		Preload("Data")
+
+	for _, f := range query.Filters {
+		sql, arg, err := filterSQL(schema, f)
+		if err != nil {
+			return nil, err
+		}
+		tx = tx.Where(sql, f.Attribute, arg)
+	}
+
+	var dir = "ASC"
+	if query.Desc {
+		dir = "DESC"
+	}
+
+	if query.SortBy != "" {
+		var col = attributeColumn(schema, query.SortBy)
+		tx = tx.
+			Joins("LEFT JOIN attributes s ON s.index_id = entries.index_id AND s.data_id = entries.data_id AND s.name = ?", query.SortBy).
+			Order(fmt.Sprintf("s.%s IS NULL, s.%s %s", col, col, dir))
+	}
+	tx = tx.Order("entries.updated_at " + dir)
+
+	if query.Limit > 0 {
+		tx = tx.Limit(query.Limit)
+	}
+	if query.Offset > 0 {
+		tx = tx.Offset(query.Offset)
+	}
+
+	var rows []dbEntry
+	if err = tx.Find(&rows).Error; err != nil {
+		return nil, err
+	}
+
+	var dataIDs []uint
+	for _, row := range rows {
+		dataIDs = append(dataIDs, row.DataID)
+	}
+
+	attrs, err := mod.dbAttributesFind(indexRow.ID, dataIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	var entries []index.Entry
+	for _, row := range rows {
+		dataID, err := data.Parse(row.Data.DataID)
+		if err != nil {
+			return nil, err
+		}
+
+		entries = append(entries, index.Entry{
+			DataID:     dataID,
+			Added:      row.Added,
+			UpdatedAt:  row.UpdatedAt,
+			Attributes: attrs[row.DataID],
+		})
+	}
+
+	return entries, nil
+}
+
+// attributeRows validates attributes against the schema and converts them to database rows
+func attributeRows(schema index.Schema, attrs index.Attributes) ([]dbAttribute, error) {
+	var rows []dbAttribute
+
+	for name, value := range attrs {
+		var typ = index.AttrString
+
+		if len(schema) > 0 {
+			def, found := schema.Get(name)
+			if !found {
+				return nil, fmt.Errorf("%w: %s is not in the schema", index.ErrInvalidAttribute, name)
+			}
+			typ = def.Type
+		}
+
+		num, err := typ.ParseValue(value)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %s: %v", index.ErrInvalidAttribute, name, err)
+		}
+
+		rows = append(rows, dbAttribute{Name: name, Text: value, Num: num})
+	}
+
+	for _, def := range schema {
+		if _, found := attrs[def.Name]; def.Required && !found {
+			return nil, fmt.Errorf("%w: %s is required", index.ErrInvalidAttribute, def.Name)
+		}
+	}
+
+	return rows, nil
+}
+
+// attributeColumn returns the column that holds comparable values of the attribute
+func attributeColumn(schema index.Schema, name string) string {
+	if def, found := schema.Get(name); found && def.Type != index.AttrString {
+		return "num"
+	}
+	return "text"
+}
+
+// filterSQL returns a condition on entries that matches the filter, with placeholders for the attribute
+// name and the value
+func filterSQL(schema index.Schema, f index.Filter) (string, any, error) {
+	var col = attributeColumn(schema, f.Attribute)
+	var exists = "EXISTS (SELECT 1 FROM attributes a WHERE a.index_id = entries.index_id AND " +
+		"a.data_id = entries.data_id AND a.name = ? AND a." + col + " %s)"
+
+	var arg any = f.Value
+	if col == "num" {
+		def, _ := schema.Get(f.Attribute)
+		num, err := def.Type.ParseValue(f.Value)
+		if err != nil {
+			return "", nil, fmt.Errorf("%w: %s: %v", index.ErrInvalidAttribute, f.Attribute, err)
+		}
+		arg = num
+	}
+
+	switch f.Op {
+	case index.OpEq, index.OpLt, index.OpLe, index.OpGt, index.OpGe:
+		return fmt.Sprintf(exists, string(f.Op)+" ?"), arg, nil
+
+	case index.OpNe:
+		return "NOT " + fmt.Sprintf(exists, "= ?"), arg, nil
+
+	case index.OpPrefix:
+		if col != "text" {
+			return "", nil, errors.New("prefix filter on a non-string attribute")
+		}
+		var escaped = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(f.Value)
+		return fmt.Sprintf(exists, `LIKE ? ESCAPE '\'`), escaped + "%", nil
+	}
+
+	return "", nil, errors.New("unknown operator: " + string(f.Op))
+}
@@ -0,0 +1,144 @@
+package index
+
+import (
+	"errors"
+	"github.com/cryptopunkscc/astrald/data"
+	"github.com/cryptopunkscc/astrald/log"
+	"github.com/cryptopunkscc/astrald/mod/index"
+	"github.com/cryptopunkscc/astrald/node/assets"
+	"github.com/cryptopunkscc/astrald/resources"
+	"io"
+	"testing"
+	"time"
+)
+
+func newTestModule(t *testing.T) *Module {
+	db, err := assets.NewCoreAssets(resources.NewMemResources()).OpenDB(index.ModuleName)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var mod = &Module{
+		db:  db,
+		log: log.NewLogger(log.NewLinePrinter(log.NewMonoOutput(io.Discard))),
+	}
+
+	err = db.AutoMigrate(&dbIndex{}, &dbEntry{}, &dbUnion{}, &dbShare{}, &dbMirror{}, &dbAttribute{}, &dbSchemaAttr{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return mod
+}
+
+func TestAttributes(t *testing.T) {
+	var mod = newTestModule(t)
+
+	if _, err := mod.CreateIndex("music", index.TypeSet); err != nil {
+		t.Fatal(err)
+	}
+
+	err := mod.SetSchema("music", index.Schema{
+		{Name: "title", Type: index.AttrString, Required: true},
+		{Name: "track", Type: index.AttrInt},
+		{Name: "released", Type: index.AttrTime},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var songs = []data.ID{
+		data.Resolve([]byte("one")),
+		data.Resolve([]byte("two")),
+		data.Resolve([]byte("three")),
+	}
+
+	var attrs = []index.Attributes{
+		{"title": "Come Together", "track": "1", "released": "1969-09-26T00:00:00Z"},
+		{"title": "Something", "track": "2", "released": "1969-10-06T00:00:00Z"},
+		{"title": "Octopus's Garden", "track": "10"},
+	}
+
+	for i, dataID := range songs {
+		if err := mod.AddToSetWithAttributes("music", dataID, attrs[i]); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// schema violations
+	var bad = data.Resolve([]byte("bad"))
+	for _, a := range []index.Attributes{
+		{"track": "1"},
+		{"title": "x", "track": "one"},
+		{"title": "x", "genre": "rock"},
+		{"title": "x", "released": "yesterday"},
+	} {
+		if err := mod.AddToSetWithAttributes("music", bad, a); !errors.Is(err, index.ErrInvalidAttribute) {
+			t.Fatalf("expected invalid attribute error for %v, got %v", a, err)
+		}
+	}
+	if err := mod.AddToSet("music", bad); !errors.Is(err, index.ErrInvalidAttribute) {
+		t.Fatalf("expected missing required attribute error, got %v", err)
+	}
+
+	var check = func(q *index.Query, expected ...data.ID) {
+		t.Helper()
+
+		list, err := mod.Query("music", q)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(list) != len(expected) {
+			t.Fatalf("expected %d entries, got %d", len(expected), len(list))
+		}
+		for i := range expected {
+			if list[i].DataID != expected[i] {
+				t.Fatalf("entry %d: expected %v, got %v", i, expected[i], list[i].DataID)
+			}
+		}
+	}
+
+	// ints are sorted as numbers
+	check(&index.Query{SortBy: "track"}, songs[0], songs[1], songs[2])
+	check(&index.Query{SortBy: "track", Desc: true}, songs[2], songs[1], songs[0])
+	check(&index.Query{SortBy: "track", Limit: 1, Offset: 1}, songs[1])
+
+	// entries without the attribute come last
+	check(&index.Query{SortBy: "released", Desc: true}, songs[1], songs[0], songs[2])
+
+	check(&index.Query{Filters: []index.Filter{{Attribute: "track", Op: index.OpGe, Value: "2"}}, SortBy: "track"}, songs[1], songs[2])
+	check(&index.Query{Filters: []index.Filter{{Attribute: "title", Op: index.OpPrefix, Value: "Some"}}}, songs[1])
+	check(&index.Query{Filters: []index.Filter{{Attribute: "released", Op: index.OpNe, Value: "1969-09-26T00:00:00Z"}}, SortBy: "track"}, songs[1], songs[2])
+	check(&index.Query{Filters: []index.Filter{{Attribute: "released", Op: index.OpLt, Value: "1969-10-01T00:00:00Z"}}}, songs[0])
+
+	// updating attributes of an entry replaces them
+	if err := mod.AddToSetWithAttributes("music", songs[2], index.Attributes{"title": "Octopus's Garden", "track": "5"}); err != nil {
+		t.Fatal(err)
+	}
+	check(&index.Query{SortBy: "track"}, songs[0], songs[1], songs[2])
+
+	entry, err := mod.GetEntry("music", songs[2])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if entry.Attributes["track"] != "5" {
+		t.Fatalf("expected updated attributes, got %v", entry.Attributes)
+	}
+
+	entries, err := mod.UpdatedSince("music", time.Time{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 3 || entries[2].DataID != songs[2] {
+		t.Fatal("updated entry should be listed last")
+	}
+
+	// removed entries lose their attributes
+	if err := mod.RemoveFromSet("music", songs[0]); err != nil {
+		t.Fatal(err)
+	}
+	check(&index.Query{SortBy: "track"}, songs[1], songs[2])
+	if entry, _ = mod.GetEntry("music", songs[0]); len(entry.Attributes) != 0 {
+		t.Fatal("removed entry kept its attributes")
+	}
+}
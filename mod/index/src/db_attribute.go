@@ -0,0 +1,112 @@
+package index
+
+import (
+	"github.com/cryptopunkscc/astrald/mod/index"
+	"gorm.io/gorm"
+)
+
+type dbAttribute struct {
+	IndexID uint   `gorm:"primaryKey"`
+	DataID  uint   `gorm:"primaryKey"`
+	Name    string `gorm:"primaryKey"`
+	Text    string
+	Num     int64
+}
+
+func (dbAttribute) TableName() string { return "attributes" }
+
+type dbSchemaAttr struct {
+	IndexID  uint   `gorm:"primaryKey"`
+	Name     string `gorm:"primaryKey"`
+	Type     string
+	Required bool
+	Position int
+}
+
+func (dbSchemaAttr) TableName() string { return "schema_attrs" }
+
+func (mod *Module) dbSchemaFind(indexID uint) (index.Schema, error) {
+	var rows []dbSchemaAttr
+	var tx = mod.db.Where("index_id = ?", indexID).Order("position").Find(&rows)
+	if tx.Error != nil {
+		return nil, tx.Error
+	}
+
+	var schema index.Schema
+	for _, row := range rows {
+		schema = append(schema, index.AttrDef{
+			Name:     row.Name,
+			Type:     index.AttrType(row.Type),
+			Required: row.Required,
+		})
+	}
+
+	return schema, nil
+}
+
+func (mod *Module) dbSchemaReplace(indexID uint, schema index.Schema) error {
+	return mod.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Delete(&dbSchemaAttr{}, "index_id = ?", indexID).Error; err != nil {
+			return err
+		}
+
+		for i, def := range schema {
+			var err = tx.Create(&dbSchemaAttr{
+				IndexID:  indexID,
+				Name:     def.Name,
+				Type:     string(def.Type),
+				Required: def.Required,
+				Position: i,
+			}).Error
+			if err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
+
+func (mod *Module) dbAttributesReplace(indexID uint, dataID uint, attrs []dbAttribute) error {
+	return mod.db.Transaction(func(tx *gorm.DB) error {
+		var err = tx.Delete(&dbAttribute{}, "index_id = ? and data_id = ?", indexID, dataID).Error
+		if err != nil {
+			return err
+		}
+
+		for _, attr := range attrs {
+			attr.IndexID, attr.DataID = indexID, dataID
+			if err = tx.Create(&attr).Error; err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
+
+func (mod *Module) dbAttributesDelete(indexID uint, dataID uint) error {
+	return mod.db.Delete(&dbAttribute{}, "index_id = ? and data_id = ?", indexID, dataID).Error
+}
+
+// dbAttributesFind returns attributes of the given entries of an index, mapped by data row ID
+func (mod *Module) dbAttributesFind(indexID uint, dataIDs []uint) (map[uint]index.Attributes, error) {
+	var rows []dbAttribute
+	var tx = mod.db.Where("index_id = ?", indexID)
+	if dataIDs != nil {
+		tx = tx.Where("data_id IN ?", dataIDs)
+	}
+	if err := tx.Find(&rows).Error; err != nil {
+		return nil, err
+	}
+
+	var attrs = map[uint]index.Attributes{}
+	for _, row := range rows {
+		if attrs[row.DataID] == nil {
+			attrs[row.DataID] = index.Attributes{}
+		}
+		attrs[row.DataID][row.Name] = row.Text
+	}
+
+	return attrs, nil
+}
@@ -0,0 +1,29 @@
+package index
+
+import "time"
+
+type dbMirror struct {
+	IndexID     uint `gorm:"primaryKey"`
+	Index       *dbIndex
+	Remote      string
+	RemoteName  string
+	SyncedUntil time.Time
+}
+
+func (dbMirror) TableName() string { return "mirrors" }
+
+func (mod *Module) dbMirrorFindAll() ([]dbMirror, error) {
+	var rows []dbMirror
+	var tx = mod.db.Preload("Index").Find(&rows)
+	return rows, tx.Error
+}
+
+func (mod *Module) dbMirrorFind(indexID uint) (*dbMirror, error) {
+	var row dbMirror
+	var tx = mod.db.Preload("Index").Where("index_id = ?", indexID).First(&row)
+	return &row, tx.Error
+}
+
+func (mod *Module) dbMirrorSetSyncedUntil(indexID uint, t time.Time) error {
+	return mod.db.Model(&dbMirror{}).Where("index_id = ?", indexID).Update("synced_until", t).Error
+}
@@ -0,0 +1,22 @@
+package index
+
+type dbShare struct {
+	IndexID  uint   `gorm:"primaryKey"`
+	Identity string `gorm:"primaryKey"`
+}
+
+func (dbShare) TableName() string { return "shares" }
+
+func (mod *Module) dbShareCreate(indexID uint, identity string) error {
+	return mod.db.FirstOrCreate(&dbShare{IndexID: indexID, Identity: identity}).Error
+}
+
+func (mod *Module) dbShareDelete(indexID uint, identity string) error {
+	return mod.db.Delete(&dbShare{}, "index_id = ? and identity = ?", indexID, identity).Error
+}
+
+func (mod *Module) dbShareExists(indexID uint, identity string) bool {
+	var count int64
+	mod.db.Model(&dbShare{}).Where("index_id = ? and identity = ?", indexID, identity).Count(&count)
+	return count > 0
+}
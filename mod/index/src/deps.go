@@ -3,10 +3,15 @@ package index
 import (
 	"github.com/cryptopunkscc/astrald/mod/admin"
 	"github.com/cryptopunkscc/astrald/mod/index"
+	"github.com/cryptopunkscc/astrald/mod/storage"
 	"github.com/cryptopunkscc/astrald/node/modules"
 )
 
 func (mod *Module) LoadDependencies() error {
+	if storage, err := modules.Load[storage.Module](mod.node, storage.ModuleName); err == nil {
+		storage.Pins().AddReferenceChecker(mod)
+	}
+
 	if adm, err := modules.Load[admin.Module](mod.node, admin.ModuleName); err == nil {
 		adm.AddCommand(index.ModuleName, NewAdmin(mod))
 	}
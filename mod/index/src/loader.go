@@ -26,7 +26,7 @@ func (Loader) Load(node modules.Node, assets assets.Assets, log *log.Logger) (mo
 		return nil, err
 	}
 
-	err = mod.db.AutoMigrate(&dbIndex{}, &dbEntry{}, &dbUnion{})
+	err = mod.db.AutoMigrate(&dbIndex{}, &dbEntry{}, &dbUnion{}, &dbShare{}, &dbMirror{}, &dbAttribute{}, &dbSchemaAttr{})
 	if err != nil {
 		return nil, err
 	}
@@ -0,0 +1,241 @@
+package index
+
+import (
+	"context"
+	"errors"
+	"github.com/cryptopunkscc/astrald/auth/id"
+	"github.com/cryptopunkscc/astrald/cslq"
+	"github.com/cryptopunkscc/astrald/data"
+	"github.com/cryptopunkscc/astrald/mod/index"
+	"github.com/cryptopunkscc/astrald/mod/index/proto"
+	"github.com/cryptopunkscc/astrald/net"
+	"strconv"
+	"time"
+)
+
+const mirrorRetryInterval = time.Minute
+
+func (mod *Module) Share(name string, identity id.Identity) error {
+	indexRow, err := mod.dbFindIndexByName(name)
+	if err != nil {
+		return err
+	}
+
+	return mod.dbShareCreate(indexRow.ID, identity.PublicKeyHex())
+}
+
+func (mod *Module) Unshare(name string, identity id.Identity) error {
+	indexRow, err := mod.dbFindIndexByName(name)
+	if err != nil {
+		return err
+	}
+
+	return mod.dbShareDelete(indexRow.ID, identity.PublicKeyHex())
+}
+
+func (mod *Module) Mirror(name string, remote id.Identity, remoteName string) error {
+	indexRow, err := mod.dbFindIndexByName(name)
+	if err != nil {
+		if _, err = mod.CreateIndex(name, index.TypeSet); err != nil {
+			return err
+		}
+		if indexRow, err = mod.dbFindIndexByName(name); err != nil {
+			return err
+		}
+	}
+
+	if index.Type(indexRow.Type) != index.TypeSet {
+		return errors.New("index is not a set")
+	}
+
+	if _, err := mod.dbMirrorFind(indexRow.ID); err == nil {
+		return errors.New("index is already a mirror")
+	}
+
+	var row = &dbMirror{
+		IndexID:    indexRow.ID,
+		Remote:     remote.PublicKeyHex(),
+		RemoteName: remoteName,
+	}
+	if err = mod.db.Create(row).Error; err != nil {
+		return err
+	}
+	row.Index = indexRow
+
+	mod.startMirror(row)
+
+	return nil
+}
+
+// FindRemote returns remote nodes whose mirrored indexes contain the data
+func (mod *Module) FindRemote(dataID data.ID) ([]id.Identity, error) {
+	names, err := mod.Find(dataID)
+	if err != nil {
+		return nil, err
+	}
+
+	var list []id.Identity
+	var seen = map[string]bool{}
+
+	for _, name := range names {
+		indexRow, err := mod.dbFindIndexByName(name)
+		if err != nil {
+			continue
+		}
+
+		row, err := mod.dbMirrorFind(indexRow.ID)
+		if err != nil || seen[row.Remote] {
+			continue
+		}
+
+		remote, err := id.ParsePublicKeyHex(row.Remote)
+		if err != nil {
+			continue
+		}
+
+		seen[row.Remote] = true
+		list = append(list, remote)
+	}
+
+	return list, nil
+}
+
+// Unmirror stops syncing the set. Entries synced so far are kept.
+func (mod *Module) Unmirror(name string) error {
+	indexRow, err := mod.dbFindIndexByName(name)
+	if err != nil {
+		return err
+	}
+
+	if cancel, found := mod.mirrors.Delete(indexRow.ID); found {
+		cancel()
+	}
+
+	var tx = mod.db.Delete(&dbMirror{}, "index_id = ?", indexRow.ID)
+	if tx.Error != nil {
+		return tx.Error
+	}
+	if tx.RowsAffected == 0 {
+		return errors.New("index is not a mirror")
+	}
+
+	return nil
+}
+
+func (mod *Module) startMirrors() {
+	rows, err := mod.dbMirrorFindAll()
+	if err != nil {
+		mod.log.Error("error loading mirrors: %v", err)
+		return
+	}
+
+	for _, row := range rows {
+		row := row
+		mod.startMirror(&row)
+	}
+}
+
+func (mod *Module) startMirror(row *dbMirror) {
+	if mod.ctx == nil {
+		return
+	}
+
+	ctx, cancel := context.WithCancel(mod.ctx)
+	if !mod.mirrors.Set(row.IndexID, cancel) {
+		cancel()
+		return
+	}
+
+	go mod.runMirror(ctx, row)
+}
+
+// runMirror follows the remote index and applies its updates to the local set. The stream is resumed from
+// the last synced update whenever it breaks.
+func (mod *Module) runMirror(ctx context.Context, row *dbMirror) {
+	remote, err := id.ParsePublicKeyHex(row.Remote)
+	if err != nil {
+		mod.log.Error("mirror %v: invalid remote: %v", row.Index.Name, err)
+		return
+	}
+
+	for {
+		err := mod.syncMirror(ctx, row, remote)
+		if ctx.Err() != nil {
+			return
+		}
+		mod.log.Errorv(1, "mirror %v: sync with %v:%v stopped: %v", row.Index.Name, remote, row.RemoteName, err)
+
+		select {
+		case <-time.After(mirrorRetryInterval):
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+func (mod *Module) syncMirror(ctx context.Context, row *dbMirror, remote id.Identity) error {
+	var params = net.Params{proto.ParamFollow: "true"}
+	if !row.SyncedUntil.IsZero() {
+		params[proto.ParamSince] = strconv.FormatInt(row.SyncedUntil.UnixNano(), 10)
+	}
+
+	var query = net.WithParams(
+		net.NewQuery(mod.node.Identity(), remote, proto.SyncServiceName+"."+row.RemoteName),
+		params,
+	)
+
+	conn, err := net.Route(ctx, mod.node.Router(), query)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	go func() {
+		<-ctx.Done()
+		conn.Close()
+	}()
+
+	mod.log.Infov(1, "mirror %v: syncing with %v:%v", row.Index.Name, remote, row.RemoteName)
+
+	for {
+		var entry proto.SyncEntry
+		if err := cslq.Decode(conn, "v", &entry); err != nil {
+			return err
+		}
+
+		if entry.Added {
+			mod.applyMirrored(row, &entry)
+		} else {
+			mod.removeFromIndex(row.Index, entry.DataID)
+		}
+
+		var updatedAt = time.Unix(0, entry.UpdatedAt)
+		if updatedAt.After(row.SyncedUntil) {
+			row.SyncedUntil = updatedAt
+			mod.dbMirrorSetSyncedUntil(row.IndexID, updatedAt)
+		}
+	}
+}
+
+// applyMirrored adds a synced entry to the local set with its attributes. Attributes that don't fit the local
+// schema are dropped.
+func (mod *Module) applyMirrored(row *dbMirror, entry *proto.SyncEntry) {
+	var attrs = index.Attributes{}
+	for _, attr := range entry.Attributes {
+		attrs[attr.Name] = attr.Value
+	}
+
+	schema, err := mod.dbSchemaFind(row.IndexID)
+	if err != nil {
+		mod.log.Error("database error: %v", err)
+		return
+	}
+
+	rows, err := attributeRows(schema, attrs)
+	if err != nil {
+		mod.log.Errorv(1, "mirror %v: %v: %v", row.Index.Name, entry.DataID, err)
+		rows = nil
+	}
+
+	mod.addWithAttributes(row.Index, entry.DataID, rows)
+}
@@ -9,6 +9,7 @@ import (
 	"github.com/cryptopunkscc/astrald/node"
 	"github.com/cryptopunkscc/astrald/node/assets"
 	"github.com/cryptopunkscc/astrald/node/events"
+	"github.com/cryptopunkscc/astrald/sig"
 	"github.com/cryptopunkscc/astrald/tasks"
 	"gorm.io/gorm"
 	"time"
@@ -23,9 +24,15 @@ type Module struct {
 	assets assets.Assets
 	events events.Queue
 	db     *gorm.DB
+	ctx    context.Context
+
+	mirrors sig.Map[uint, context.CancelFunc]
 }
 
 func (mod *Module) Run(ctx context.Context) error {
+	mod.ctx = ctx
+	mod.startMirrors()
+
 	return tasks.Group(
 		&Service{Module: mod},
 	).Run(ctx)
@@ -75,6 +82,14 @@ func (mod *Module) DeleteIndex(name string) error {
 		return tx.Error
 	}
 
+	// stop syncing and sharing the index
+	if cancel, found := mod.mirrors.Delete(indexRow.ID); found {
+		cancel()
+	}
+	mod.db.Delete(&dbMirror{}, "index_id = ?", indexRow.ID)
+	mod.db.Delete(&dbShare{}, "index_id = ?", indexRow.ID)
+	mod.db.Delete(&dbSchemaAttr{}, "index_id = ?", indexRow.ID)
+
 	err = mod.dbDeleteIndexByName(name)
 	if err != nil {
 		return err
@@ -94,6 +109,14 @@ func (mod *Module) AddToSet(name string, dataID data.ID) error {
 		return errors.New("index is not a set")
 	}
 
+	schema, err := mod.dbSchemaFind(indexRow.ID)
+	if err != nil {
+		return err
+	}
+	if _, err = attributeRows(schema, nil); err != nil {
+		return err
+	}
+
 	return mod.addToIndex(indexRow, dataID)
 }
 
@@ -144,6 +167,10 @@ func (mod *Module) removeFromIndex(indexRow *dbIndex, dataID data.ID) error {
 		return err
 	}
 
+	if err = mod.dbAttributesDelete(indexRow.ID, dataRow.ID); err != nil {
+		mod.log.Error("database error: %v", err)
+	}
+
 	mod.events.Emit(index.EventEntryUpdate{
 		IndexName: indexRow.Name,
 		DataID:    dataID,
@@ -284,6 +311,11 @@ func (mod *Module) UpdatedSince(name string, since time.Time) ([]index.Entry, er
 		return nil, err
 	}
 
+	attrs, err := mod.dbAttributesFind(indexRow.ID, nil)
+	if err != nil {
+		return nil, err
+	}
+
 	var updates []index.Entry
 
 	for _, row := range rows {
@@ -293,9 +325,10 @@ func (mod *Module) UpdatedSince(name string, since time.Time) ([]index.Entry, er
 		}
 
 		updates = append(updates, index.Entry{
-			DataID:    dataID,
-			Added:     row.Added,
-			UpdatedAt: row.UpdatedAt,
+			DataID:     dataID,
+			Added:      row.Added,
+			UpdatedAt:  row.UpdatedAt,
+			Attributes: attrs[row.DataID],
 		})
 	}
 
@@ -363,9 +396,15 @@ func (mod *Module) GetEntry(name string, dataID data.ID) (*index.Entry, error) {
 		return nil, err
 	}
 
+	attrs, err := mod.dbAttributesFind(indexRow.ID, []uint{dataRow.ID})
+	if err != nil {
+		return nil, err
+	}
+
 	return &index.Entry{
-		DataID:    dataID,
-		Added:     row.Added,
-		UpdatedAt: row.UpdatedAt,
+		DataID:     dataID,
+		Added:      row.Added,
+		UpdatedAt:  row.UpdatedAt,
+		Attributes: attrs[dataRow.ID],
 	}, nil
 }
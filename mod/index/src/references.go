@@ -0,0 +1,22 @@
+package index
+
+import (
+	"github.com/cryptopunkscc/astrald/data"
+	"github.com/cryptopunkscc/astrald/mod/index"
+)
+
+// IsReferenced returns true if the object was added to any set index that isn't a module's listing or
+// a mirror of a remote index
+func (mod *Module) IsReferenced(dataID data.ID) (bool, error) {
+	var count int64
+
+	err := mod.db.Model(&dbEntry{}).
+		Joins("JOIN data ON data.id = entries.data_id").
+		Joins("JOIN indexes ON indexes.id = entries.index_id").
+		Where("data.data_id = ? AND entries.added = ? AND indexes.type = ? AND indexes.name NOT LIKE ?",
+			dataID.String(), true, string(index.TypeSet), index.SystemIndexPrefix+"%").
+		Where("indexes.id NOT IN (?)", mod.db.Model(&dbMirror{}).Select("index_id")).
+		Count(&count).Error
+
+	return count > 0, err
+}
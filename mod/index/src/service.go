@@ -1,11 +1,127 @@
 package index
 
-import "context"
+import (
+	"context"
+	"github.com/cryptopunkscc/astrald/cslq"
+	"github.com/cryptopunkscc/astrald/mod/index"
+	"github.com/cryptopunkscc/astrald/mod/index/proto"
+	"github.com/cryptopunkscc/astrald/net"
+	"strconv"
+	"strings"
+	"time"
+)
 
+const syncServicePrefix = proto.SyncServiceName + "."
+
+// Service lets nodes the index is shared with pull its updates
 type Service struct {
 	*Module
 }
 
 func (srv *Service) Run(ctx context.Context) error {
+	err := srv.node.LocalRouter().AddRoute(syncServicePrefix+"*", srv)
+	if err != nil {
+		return err
+	}
+	defer srv.node.LocalRouter().RemoveRoute(syncServicePrefix + "*")
+
+	<-ctx.Done()
 	return nil
 }
+
+func (srv *Service) RouteQuery(ctx context.Context, query net.Query, caller net.SecureWriteCloser, hints net.Hints) (net.SecureWriteCloser, error) {
+	name, found := strings.CutPrefix(query.Query(), syncServicePrefix)
+	if !found {
+		return net.Reject()
+	}
+
+	indexRow, err := srv.dbFindIndexByName(name)
+	if err != nil {
+		return net.RejectWithReason(net.RejectCodeNotFound, "")
+	}
+
+	if !srv.canSync(indexRow, query) {
+		return net.RejectWithReason(net.RejectCodeAccessDenied, "")
+	}
+
+	var since time.Time
+	if p := query.Params().Get(proto.ParamSince); p != "" {
+		nsec, err := strconv.ParseInt(p, 10, 64)
+		if err != nil {
+			return net.RejectWithReason(net.RejectCodeInvalidQuery, "invalid since")
+		}
+		since = time.Unix(0, nsec)
+	}
+
+	var follow = query.Params().Get(proto.ParamFollow) == "true"
+
+	return net.Accept(query, caller, func(conn net.SecureConn) {
+		defer conn.Close()
+
+		ctx, cancel := context.WithCancel(srv.ctx)
+		defer cancel()
+
+		// subscribe before reading the entries, updates made in between may be sent twice
+		var updates = srv.events.Subscribe(ctx)
+
+		entries, err := srv.UpdatedSince(name, since)
+		if err != nil {
+			return
+		}
+
+		for _, entry := range entries {
+			if err := writeEntry(conn, entry); err != nil {
+				return
+			}
+		}
+
+		if !follow {
+			return
+		}
+
+		// close the stream when the caller goes away
+		go func() {
+			conn.Read(make([]byte, 1))
+			cancel()
+		}()
+
+		for event := range updates {
+			event, ok := event.(index.EventEntryUpdate)
+			if !ok || event.IndexName != name {
+				continue
+			}
+
+			var entry = index.Entry{DataID: event.DataID, Added: event.Added, UpdatedAt: event.UpdatedAt}
+			if event.Added {
+				if e, err := srv.GetEntry(name, event.DataID); err == nil {
+					entry.Attributes = e.Attributes
+				}
+			}
+			if err := writeEntry(conn, entry); err != nil {
+				return
+			}
+		}
+	})
+}
+
+func (srv *Service) canSync(indexRow *dbIndex, query net.Query) bool {
+	if query.Caller().IsEqual(srv.node.Identity()) {
+		return true
+	}
+
+	return srv.dbShareExists(indexRow.ID, query.Caller().PublicKeyHex())
+}
+
+func writeEntry(conn net.SecureConn, entry index.Entry) error {
+	var msg = &proto.SyncEntry{
+		DataID:    entry.DataID,
+		Added:     entry.Added,
+		UpdatedAt: entry.UpdatedAt.UnixNano(),
+	}
+
+	for name, value := range entry.Attributes {
+		msg.Attributes = append(msg.Attributes, proto.SyncAttribute{Name: name, Value: value})
+	}
+
+	return cslq.Encode(conn, "v", msg)
+}
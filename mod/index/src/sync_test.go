@@ -0,0 +1,321 @@
+package index
+
+import (
+	"context"
+	"github.com/cryptopunkscc/astrald/auth/id"
+	"github.com/cryptopunkscc/astrald/cslq"
+	"github.com/cryptopunkscc/astrald/data"
+	"github.com/cryptopunkscc/astrald/mod/index"
+	"github.com/cryptopunkscc/astrald/mod/index/proto"
+	"github.com/cryptopunkscc/astrald/net"
+	"github.com/cryptopunkscc/astrald/node"
+	"github.com/cryptopunkscc/astrald/node/router"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+)
+
+type testNode struct {
+	node.Node
+	identity id.Identity
+	router   *testRouter
+}
+
+func (n *testNode) Identity() id.Identity { return n.identity }
+func (n *testNode) Router() router.Router { return n.router }
+
+// testRouter routes all queries to a single router and keeps track of them, so that tests can break
+// the streams
+type testRouter struct {
+	router.Router
+	target net.Router
+
+	mu      sync.Mutex
+	queries []net.Query
+	callers []net.SecureWriteCloser
+}
+
+func (r *testRouter) RouteQuery(ctx context.Context, query net.Query, caller net.SecureWriteCloser, hints net.Hints) (net.SecureWriteCloser, error) {
+	r.mu.Lock()
+	r.queries = append(r.queries, query)
+	r.callers = append(r.callers, caller)
+	r.mu.Unlock()
+
+	return r.target.RouteQuery(ctx, query, caller, hints)
+}
+
+func (r *testRouter) breakStreams() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, c := range r.callers {
+		c.Close()
+	}
+	r.callers = nil
+}
+
+func (r *testRouter) lastQuery() net.Query {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.queries[len(r.queries)-1]
+}
+
+func newTestNodeModule(t *testing.T, ctx context.Context) *Module {
+	identity, err := id.GenerateIdentity()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var mod = newTestModule(t)
+	mod.node = &testNode{identity: identity}
+	mod.ctx = ctx
+
+	return mod
+}
+
+func readEntries(t *testing.T, conn net.SecureConn, n int) []proto.SyncEntry {
+	t.Helper()
+
+	var list []proto.SyncEntry
+	for i := 0; i < n; i++ {
+		var entry proto.SyncEntry
+		if err := cslq.Decode(conn, "v", &entry); err != nil {
+			t.Fatalf("error reading entry %d: %v", i, err)
+		}
+		list = append(list, entry)
+	}
+
+	return list
+}
+
+func waitFor(t *testing.T, cond func() bool) {
+	t.Helper()
+
+	var deadline = time.Now().Add(5 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatal("timed out")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestSyncService(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var mod = newTestNodeModule(t, ctx)
+	var srv = &Service{Module: mod}
+
+	if _, err := mod.CreateIndex("sync.photos", index.TypeSet); err != nil {
+		t.Fatal(err)
+	}
+
+	var photos = []data.ID{
+		data.Resolve([]byte("one")),
+		data.Resolve([]byte("two")),
+		data.Resolve([]byte("three")),
+	}
+	for _, dataID := range photos {
+		if err := mod.AddToSet("sync.photos", dataID); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := mod.RemoveFromSet("sync.photos", photos[0]); err != nil {
+		t.Fatal(err)
+	}
+
+	reader, _ := id.GenerateIdentity()
+	stranger, _ := id.GenerateIdentity()
+	if err := mod.Share("sync.photos", reader); err != nil {
+		t.Fatal(err)
+	}
+
+	var open = func(caller id.Identity, name string, params net.Params) (net.SecureConn, error) {
+		var query = net.NewQuery(caller, mod.node.Identity(), proto.SyncServiceName+"."+name)
+		return net.Route(ctx, srv, net.WithParams(query, params))
+	}
+
+	// entries are sent in the order of their updates
+	conn, err := open(reader, "sync.photos", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var entries = readEntries(t, conn, 3)
+	for i, dataID := range []data.ID{photos[1], photos[2], photos[0]} {
+		if entries[i].DataID != dataID {
+			t.Fatalf("entry %d: expected %v, got %v", i, dataID, entries[i].DataID)
+		}
+	}
+	if !entries[0].Added || entries[2].Added {
+		t.Fatal("removed entry synced as added or the other way around")
+	}
+
+	// the stream ends without follow
+	var entry proto.SyncEntry
+	if err = cslq.Decode(conn, "v", &entry); err == nil {
+		t.Fatal("expected the stream to end")
+	}
+	conn.Close()
+
+	// since skips entries updated until the given time
+	conn, err = open(reader, "sync.photos", net.Params{
+		proto.ParamSince:  strconv.FormatInt(entries[0].UpdatedAt, 10),
+		proto.ParamFollow: "true",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	if entries = readEntries(t, conn, 2); entries[0].DataID != photos[2] || entries[1].DataID != photos[0] {
+		t.Fatal("unexpected entries since the first update")
+	}
+
+	// follow streams new updates
+	var added = data.Resolve([]byte("four"))
+	if err := mod.AddToSet("sync.photos", added); err != nil {
+		t.Fatal(err)
+	}
+	if entries = readEntries(t, conn, 1); entries[0].DataID != added || !entries[0].Added {
+		t.Fatal("update not streamed")
+	}
+
+	// callers the index isn't shared with are rejected
+	for _, caller := range []id.Identity{stranger, reader} {
+		if caller.IsEqual(reader) {
+			if err := mod.Unshare("sync.photos", reader); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		_, err = open(caller, "sync.photos", nil)
+		if code, _ := net.RejectReason(err); code != net.RejectCodeAccessDenied {
+			t.Fatalf("expected access to be denied, got %v", err)
+		}
+	}
+
+	_, err = open(mod.node.Identity(), "sync.missing", nil)
+	if code, _ := net.RejectReason(err); code != net.RejectCodeNotFound {
+		t.Fatalf("expected index not to be found, got %v", err)
+	}
+}
+
+func TestMirror(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// test modules share the in-memory database, so both sides use their own index names
+	var remote = newTestNodeModule(t, ctx)
+	var mod = newTestNodeModule(t, ctx)
+	var routes = &testRouter{target: &Service{Module: remote}}
+	mod.node.(*testNode).router = routes
+
+	if _, err := remote.CreateIndex("mirror.remote", index.TypeSet); err != nil {
+		t.Fatal(err)
+	}
+	if err := remote.Share("mirror.remote", mod.node.Identity()); err != nil {
+		t.Fatal(err)
+	}
+
+	var photos = []data.ID{
+		data.Resolve([]byte("one")),
+		data.Resolve([]byte("two")),
+		data.Resolve([]byte("three")),
+	}
+	for _, dataID := range photos[:2] {
+		if err := remote.AddToSet("mirror.remote", dataID); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// create the mirror without starting it, so the test can control the sync
+	mod.ctx = nil
+	if err := mod.Mirror("mirror.local", remote.node.Identity(), "mirror.remote"); err != nil {
+		t.Fatal(err)
+	}
+	indexRow, err := mod.dbFindIndexByName("mirror.local")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var contains = func(expected ...data.ID) func() bool {
+		return func() bool {
+			for _, dataID := range photos {
+				entry, err := mod.GetEntry("mirror.local", dataID)
+				var found = err == nil && entry.Added
+				var want bool
+				for _, e := range expected {
+					want = want || e == dataID
+				}
+				if found != want {
+					return false
+				}
+			}
+			return true
+		}
+	}
+
+	var start = func() chan error {
+		row, err := mod.dbMirrorFind(indexRow.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var done = make(chan error, 1)
+		go func() {
+			done <- mod.syncMirror(ctx, row, remote.node.Identity())
+		}()
+		return done
+	}
+
+	var done = start()
+	waitFor(t, contains(photos[0], photos[1]))
+
+	// break the stream
+	routes.breakStreams()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("sync didn't stop after the stream broke")
+	}
+
+	entries, err := remote.UpdatedSince("mirror.remote", time.Time{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var syncedUntil = entries[len(entries)-1].UpdatedAt
+
+	row, err := mod.dbMirrorFind(indexRow.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !row.SyncedUntil.Equal(syncedUntil) {
+		t.Fatalf("expected to be synced until %v, got %v", syncedUntil, row.SyncedUntil)
+	}
+
+	// updates made while the stream was broken are synced when it resumes
+	if err := remote.RemoveFromSet("mirror.remote", photos[0]); err != nil {
+		t.Fatal(err)
+	}
+	if err := remote.AddToSet("mirror.remote", photos[2]); err != nil {
+		t.Fatal(err)
+	}
+
+	done = start()
+	waitFor(t, contains(photos[1], photos[2]))
+
+	var since = routes.lastQuery().Params().Get(proto.ParamSince)
+	if since != strconv.FormatInt(syncedUntil.UnixNano(), 10) {
+		t.Fatalf("sync resumed from %v instead of the last synced update", since)
+	}
+
+	cancel()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("sync didn't stop after the context ended")
+	}
+}
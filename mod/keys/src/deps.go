@@ -22,6 +22,7 @@ func (mod *Module) LoadDependencies() error {
 	}
 
 	mod.data.AddDescriber(mod)
+	mod.storage.Pins().AddReferenceChecker(mod)
 
 	if adm, err := modules.Load[admin.Module](mod.node, admin.ModuleName); err == nil {
 		adm.AddCommand(keys.ModuleName, NewAdmin(mod))
@@ -49,6 +49,19 @@ func (mod *Module) DescribeData(ctx context.Context, dataID _data.ID, opts *data
 	return desc
 }
 
+// IsReferenced keeps indexed private keys from being garbage collected
+func (mod *Module) IsReferenced(dataID _data.ID) (bool, error) {
+	_, err := mod.dbFindByDataID(dataID)
+	switch {
+	case err == nil:
+		return true, nil
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		return false, nil
+	default:
+		return false, err
+	}
+}
+
 var adc0PrivateKey = data.ADC0Header(keys.PrivateKeyDataType)
 
 func (mod *Module) Run(ctx context.Context) error {
@@ -0,0 +1,16 @@
+package memstore
+
+import (
+	"github.com/cryptopunkscc/astrald/mod/storage"
+)
+
+const ModuleName = "memstore"
+
+// Module is a bounded store that keeps data in memory and evicts least recently used objects when it's full
+type Module interface {
+	storage.Store
+	storage.Reader
+	storage.Lister
+	storage.Deleter
+	storage.CapacityReporter
+}
@@ -0,0 +1,9 @@
+package memstore
+
+type Config struct {
+	// Size is the number of bytes the store can hold. Data kept in memory is lost when the node stops,
+	// so the store is only used when a size is configured.
+	Size int64 `yaml:"size"`
+}
+
+var defaultConfig = Config{}
@@ -0,0 +1,24 @@
+package memstore
+
+import (
+	"github.com/cryptopunkscc/astrald/mod/storage"
+	"github.com/cryptopunkscc/astrald/node/modules"
+)
+
+func (mod *Module) LoadDependencies() error {
+	var err error
+
+	mod.storage, err = modules.Load[storage.Module](mod.node, storage.ModuleName)
+	if err != nil {
+		return err
+	}
+
+	if mod.config.Size <= 0 {
+		return nil
+	}
+
+	mod.storage.Data().AddReader(storeName, mod)
+	mod.storage.Data().AddStore(storeName, mod)
+
+	return nil
+}
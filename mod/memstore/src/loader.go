@@ -0,0 +1,31 @@
+package memstore
+
+import (
+	"container/list"
+	"github.com/cryptopunkscc/astrald/log"
+	"github.com/cryptopunkscc/astrald/mod/memstore"
+	"github.com/cryptopunkscc/astrald/node/assets"
+	"github.com/cryptopunkscc/astrald/node/modules"
+)
+
+type Loader struct{}
+
+func (Loader) Load(node modules.Node, assets assets.Assets, log *log.Logger) (modules.Module, error) {
+	var mod = &Module{
+		node:    node,
+		log:     log,
+		config:  defaultConfig,
+		objects: make(map[string]*list.Element),
+		lru:     list.New(),
+	}
+
+	_ = assets.LoadYAML(memstore.ModuleName, &mod.config)
+
+	return mod, nil
+}
+
+func init() {
+	if err := modules.RegisterModule(memstore.ModuleName, Loader{}); err != nil {
+		panic(err)
+	}
+}
@@ -0,0 +1,220 @@
+package memstore
+
+import (
+	"bytes"
+	"container/list"
+	"context"
+	"github.com/cryptopunkscc/astrald/data"
+	"github.com/cryptopunkscc/astrald/log"
+	"github.com/cryptopunkscc/astrald/mod/memstore"
+	"github.com/cryptopunkscc/astrald/mod/storage"
+	"github.com/cryptopunkscc/astrald/node/modules"
+	"io"
+	"sync"
+)
+
+const storeName = "mod.memstore"
+
+var _ memstore.Module = &Module{}
+
+type Module struct {
+	node    modules.Node
+	config  Config
+	log     *log.Logger
+	storage storage.Module
+
+	mu      sync.Mutex
+	objects map[string]*list.Element
+	lru     *list.List
+	used    int64
+}
+
+type object struct {
+	dataID data.ID
+	bytes  []byte
+}
+
+func (mod *Module) Run(ctx context.Context) error {
+	<-ctx.Done()
+	return nil
+}
+
+func (mod *Module) Store(opts *storage.StoreOpts) (storage.DataWriter, error) {
+	if opts == nil {
+		opts = &storage.StoreOpts{}
+	}
+
+	if int64(opts.Alloc) > mod.config.Size {
+		return nil, storage.ErrStorageUnavailable
+	}
+
+	return &Writer{mod: mod, buf: bytes.NewBuffer(make([]byte, 0, max(opts.Alloc, 0)))}, nil
+}
+
+func (mod *Module) Read(dataID data.ID, opts *storage.ReadOpts) (storage.DataReader, error) {
+	if opts == nil {
+		opts = &storage.ReadOpts{}
+	}
+
+	mod.mu.Lock()
+	defer mod.mu.Unlock()
+
+	e, found := mod.objects[dataID.String()]
+	if !found {
+		return nil, storage.ErrNotFound
+	}
+
+	var obj = e.Value.(*object)
+	if opts.Offset > uint64(len(obj.bytes)) {
+		return nil, storage.ErrInvalidOffset
+	}
+
+	mod.lru.MoveToFront(e)
+
+	return &Reader{Reader: bytes.NewReader(obj.bytes[opts.Offset:])}, nil
+}
+
+func (mod *Module) List() ([]data.ID, error) {
+	mod.mu.Lock()
+	defer mod.mu.Unlock()
+
+	var list = make([]data.ID, 0, len(mod.objects))
+	for e := mod.lru.Front(); e != nil; e = e.Next() {
+		list = append(list, e.Value.(*object).dataID)
+	}
+
+	return list, nil
+}
+
+func (mod *Module) Delete(dataID data.ID) error {
+	mod.mu.Lock()
+	defer mod.mu.Unlock()
+
+	e, found := mod.objects[dataID.String()]
+	if !found {
+		return storage.ErrNotFound
+	}
+
+	mod.remove(e)
+
+	return nil
+}
+
+func (mod *Module) Capacity() (*storage.Capacity, error) {
+	mod.mu.Lock()
+	defer mod.mu.Unlock()
+
+	return &storage.Capacity{
+		Total: uint64(mod.config.Size),
+		Used:  uint64(mod.used),
+		Free:  uint64(mod.config.Size - mod.used),
+	}, nil
+}
+
+// add adds an object to the store, evicting least recently used objects that are not in use to make room
+func (mod *Module) add(dataID data.ID, b []byte) error {
+	mod.mu.Lock()
+	defer mod.mu.Unlock()
+
+	if e, found := mod.objects[dataID.String()]; found {
+		mod.lru.MoveToFront(e)
+		return nil
+	}
+
+	var size = int64(len(b))
+
+	for e := mod.lru.Back(); e != nil && mod.used+size > mod.config.Size; {
+		var prev = e.Prev()
+		var obj = e.Value.(*object)
+
+		if !mod.isReferenced(obj.dataID) {
+			mod.log.Logv(2, "evicting %v", obj.dataID)
+			mod.remove(e)
+		}
+
+		e = prev
+	}
+
+	if mod.used+size > mod.config.Size {
+		return storage.ErrStorageUnavailable
+	}
+
+	mod.objects[dataID.String()] = mod.lru.PushFront(&object{dataID: dataID, bytes: b})
+	mod.used += size
+
+	return nil
+}
+
+func (mod *Module) remove(e *list.Element) {
+	var obj = e.Value.(*object)
+
+	mod.lru.Remove(e)
+	delete(mod.objects, obj.dataID.String())
+	mod.used -= int64(len(obj.bytes))
+}
+
+// isReferenced returns true if the object is in use. Objects that can't be checked are kept.
+func (mod *Module) isReferenced(dataID data.ID) bool {
+	if mod.storage == nil {
+		return false
+	}
+
+	referenced, err := mod.storage.Pins().IsReferenced(dataID)
+	if err != nil {
+		mod.log.Errorv(1, "error checking references of %v: %v", dataID, err)
+		return true
+	}
+
+	return referenced
+}
+
+var _ storage.DataReader = &Reader{}
+
+type Reader struct {
+	io.Reader
+}
+
+func (r *Reader) Close() error {
+	return nil
+}
+
+func (r *Reader) Info() *storage.ReaderInfo {
+	return &storage.ReaderInfo{Name: storeName}
+}
+
+var _ storage.DataWriter = &Writer{}
+
+type Writer struct {
+	mod  *Module
+	buf  *bytes.Buffer
+	done bool
+}
+
+func (w *Writer) Write(p []byte) (n int, err error) {
+	if w.done {
+		return 0, storage.ErrStorageUnavailable
+	}
+	if int64(w.buf.Len()+len(p)) > w.mod.config.Size {
+		return 0, storage.ErrStorageUnavailable
+	}
+
+	return w.buf.Write(p)
+}
+
+func (w *Writer) Commit() (data.ID, error) {
+	if w.done {
+		return data.ID{}, storage.ErrStorageUnavailable
+	}
+	w.done = true
+
+	var b = w.buf.Bytes()
+	var dataID = data.Resolve(b)
+
+	return dataID, w.mod.add(dataID, b)
+}
+
+func (w *Writer) Discard() error {
+	w.done = true
+	w.buf = nil
+	return nil
+}
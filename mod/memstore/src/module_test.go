@@ -0,0 +1,69 @@
+package memstore
+
+import (
+	"container/list"
+	"errors"
+	"github.com/cryptopunkscc/astrald/data"
+	"github.com/cryptopunkscc/astrald/log"
+	"github.com/cryptopunkscc/astrald/mod/storage"
+	"io"
+	"testing"
+)
+
+func store(t *testing.T, mod *Module, size int, fill byte) data.ID {
+	var b = make([]byte, size)
+	for i := range b {
+		b[i] = fill
+	}
+
+	w, err := mod.Store(&storage.StoreOpts{Alloc: size})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = w.Write(b); err != nil {
+		t.Fatal(err)
+	}
+	dataID, err := w.Commit()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return dataID
+}
+
+func TestEviction(t *testing.T) {
+	var mod = &Module{
+		config:  Config{Size: 100},
+		objects: make(map[string]*list.Element),
+		lru:     list.New(),
+		log:     log.NewLogger(log.NewLinePrinter(log.NewMonoOutput(io.Discard))),
+	}
+
+	a := store(t, mod, 40, 'a')
+	b := store(t, mod, 40, 'b')
+
+	// reading a makes b the least recently used object
+	if _, err := mod.Read(a, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	c := store(t, mod, 40, 'c')
+
+	if _, err := mod.Read(b, nil); !errors.Is(err, storage.ErrNotFound) {
+		t.Fatal("expected b to be evicted")
+	}
+	for _, dataID := range []data.ID{a, c} {
+		if _, err := mod.Read(dataID, nil); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	capacity, _ := mod.Capacity()
+	if capacity.Used != 80 || capacity.Free != 20 {
+		t.Fatalf("unexpected capacity: %+v", capacity)
+	}
+
+	// objects larger than the store are rejected before writing
+	if _, err := mod.Store(&storage.StoreOpts{Alloc: 101}); !errors.Is(err, storage.ErrStorageUnavailable) {
+		t.Fatal("expected the store to reject a large object")
+	}
+}
@@ -0,0 +1,32 @@
+package mesh
+
+import (
+	"github.com/cryptopunkscc/astrald/auth/id"
+	"time"
+)
+
+const (
+	ModuleName        = "mesh"
+	NetworkName       = "mesh"
+	RoutesServiceName = ".mesh.routes"
+	TunnelServiceName = ".mesh.tunnel"
+
+	// MaxHops is the maximum number of hops a route can have
+	MaxHops = 16
+)
+
+type Module interface {
+	// Routes returns the current routing table
+	Routes() []Route
+	// Update exchanges routing tables with all neighbours and recomputes the routes
+	Update()
+}
+
+// Route describes a multi-hop route to a target
+type Route struct {
+	Target   id.Identity   // final destination of the route
+	Via      id.Identity   // linked neighbour that is the next hop
+	Hops     int           // number of links between the local node and the target
+	Latency  time.Duration // estimated end-to-end latency
+	Priority int           // priority of the route in the node's router
+}
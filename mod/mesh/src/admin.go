@@ -0,0 +1,73 @@
+package mesh
+
+import (
+	"errors"
+	"github.com/cryptopunkscc/astrald/mod/admin"
+	"github.com/cryptopunkscc/astrald/mod/mesh"
+)
+
+type Admin struct {
+	mod  *Module
+	cmds map[string]func(admin.Terminal, []string) error
+}
+
+func NewAdmin(mod *Module) *Admin {
+	var adm = &Admin{mod: mod}
+	adm.cmds = map[string]func(admin.Terminal, []string) error{
+		"routes": adm.routes,
+		"update": adm.update,
+		"help":   adm.help,
+	}
+
+	return adm
+}
+
+func (adm *Admin) Exec(term admin.Terminal, args []string) error {
+	if len(args) < 2 {
+		return adm.routes(term, []string{})
+	}
+
+	cmd, args := args[1], args[2:]
+	if fn, found := adm.cmds[cmd]; found {
+		return fn(term, args)
+	}
+
+	return errors.New("unknown command")
+}
+
+func (adm *Admin) routes(term admin.Terminal, _ []string) error {
+	const f = "%-33s %-33s %6s %10s %8s\n"
+
+	term.Printf(f,
+		admin.Header("Target"),
+		admin.Header("Via"),
+		admin.Header("Hops"),
+		admin.Header("Latency"),
+		admin.Header("Priority"),
+	)
+
+	for _, r := range adm.mod.Routes() {
+		term.Printf(f, r.Target, r.Via, r.Hops, r.Latency.Round(1e6), r.Priority)
+	}
+
+	return nil
+}
+
+func (adm *Admin) update(term admin.Terminal, _ []string) error {
+	adm.mod.Update()
+	term.Printf("update scheduled\n")
+	return nil
+}
+
+func (adm *Admin) ShortDescription() string {
+	return "manage mesh routing"
+}
+
+func (adm *Admin) help(term admin.Terminal, _ []string) error {
+	term.Printf("usage: %s <command>\n\n", mesh.ModuleName)
+	term.Printf("commands:\n")
+	term.Printf("  routes      show the mesh routing table\n")
+	term.Printf("  update      exchange routing tables with neighbours now\n")
+	term.Printf("  help        show help\n")
+	return nil
+}
@@ -0,0 +1,21 @@
+package mesh
+
+import (
+	"github.com/cryptopunkscc/astrald/mod/mesh"
+	"time"
+)
+
+type Config struct {
+	// Enabled turns on multi-hop routing. Nodes with mesh enabled forward tunnels for their neighbours, so it
+	// is off by default.
+	Enabled bool `yaml:"enabled"`
+	// UpdateInterval sets how often routing tables are exchanged with neighbours
+	UpdateInterval time.Duration `yaml:"update_interval"`
+	// MaxHops limits the length of routes accepted by the node
+	MaxHops int `yaml:"max_hops"`
+}
+
+var defaultConfig = Config{
+	UpdateInterval: 30 * time.Second,
+	MaxHops:        mesh.MaxHops,
+}
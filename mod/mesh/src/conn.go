@@ -0,0 +1,41 @@
+package mesh
+
+import (
+	"github.com/cryptopunkscc/astrald/mod/mesh"
+	"github.com/cryptopunkscc/astrald/net"
+)
+
+var _ net.SecureConn = &Conn{}
+
+// Conn is a tunnel through one or more mesh hops
+type Conn struct {
+	net.SecureConn
+	localEndpoint  Endpoint
+	remoteEndpoint Endpoint
+	outbound       bool
+}
+
+func newConn(conn net.SecureConn, localEndpoint Endpoint, remoteEndpoint Endpoint, outbound bool) *Conn {
+	return &Conn{
+		SecureConn:     conn,
+		localEndpoint:  localEndpoint,
+		remoteEndpoint: remoteEndpoint,
+		outbound:       outbound,
+	}
+}
+
+func (conn Conn) LocalEndpoint() net.Endpoint {
+	return conn.localEndpoint
+}
+
+func (conn Conn) RemoteEndpoint() net.Endpoint {
+	return conn.remoteEndpoint
+}
+
+func (conn Conn) Outbound() bool {
+	return conn.outbound
+}
+
+func (Conn) Network() string {
+	return mesh.NetworkName
+}
@@ -0,0 +1,50 @@
+package mesh
+
+import (
+	"bytes"
+	"github.com/cryptopunkscc/astrald/auth/id"
+	"github.com/cryptopunkscc/astrald/cslq"
+	"github.com/cryptopunkscc/astrald/mod/mesh"
+	"github.com/cryptopunkscc/astrald/net"
+)
+
+var _ net.Endpoint = Endpoint{}
+
+// Endpoint describes one end of a mesh tunnel as the neighbour it goes through and the identity it leads to
+type Endpoint struct {
+	via    id.Identity
+	target id.Identity
+}
+
+// NewEndpoint instantiates and returns a new Endpoint
+func NewEndpoint(via id.Identity, target id.Identity) Endpoint {
+	return Endpoint{via: via, target: target}
+}
+
+// Pack returns a binary representation of the address
+func (endpoint Endpoint) Pack() []byte {
+	buf := &bytes.Buffer{}
+
+	if err := cslq.Encode(buf, "vv", endpoint.via, endpoint.target); err != nil {
+		panic(err)
+	}
+
+	return buf.Bytes()
+}
+
+// String returns a text representation of the address
+func (endpoint Endpoint) String() string {
+	return endpoint.via.PublicKeyHex() + ":" + endpoint.target.PublicKeyHex()
+}
+
+func (endpoint Endpoint) Via() id.Identity {
+	return endpoint.via
+}
+
+func (endpoint Endpoint) Target() id.Identity {
+	return endpoint.target
+}
+
+func (endpoint Endpoint) Network() string {
+	return mesh.NetworkName
+}
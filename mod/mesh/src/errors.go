@@ -0,0 +1,5 @@
+package mesh
+
+import "errors"
+
+var ErrNoRoute = errors.New("no route to target")
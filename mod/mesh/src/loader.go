@@ -0,0 +1,36 @@
+package mesh
+
+import (
+	_log "github.com/cryptopunkscc/astrald/log"
+	"github.com/cryptopunkscc/astrald/mod/mesh"
+	"github.com/cryptopunkscc/astrald/node/assets"
+	"github.com/cryptopunkscc/astrald/node/modules"
+)
+
+type Loader struct{}
+
+func (Loader) Load(node modules.Node, assets assets.Assets, log *_log.Logger) (modules.Module, error) {
+	mod := &Module{
+		node:    node,
+		log:     log,
+		config:  defaultConfig,
+		vectors: make(map[string]*vector),
+		routes:  make(map[string]*installedRoute),
+		dials:   make(map[string]*dial),
+		trigger: make(chan struct{}, 1),
+	}
+
+	_ = assets.LoadYAML(mesh.ModuleName, &mod.config)
+
+	if mod.config.MaxHops <= 0 || mod.config.MaxHops > mesh.MaxHops {
+		mod.config.MaxHops = mesh.MaxHops
+	}
+
+	return mod, nil
+}
+
+func init() {
+	if err := modules.RegisterModule(mesh.ModuleName, Loader{}); err != nil {
+		panic(err)
+	}
+}
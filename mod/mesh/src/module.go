@@ -0,0 +1,150 @@
+package mesh
+
+import (
+	"context"
+	"github.com/cryptopunkscc/astrald/auth/id"
+	"github.com/cryptopunkscc/astrald/log"
+	"github.com/cryptopunkscc/astrald/mod/mesh"
+	"github.com/cryptopunkscc/astrald/net"
+	"github.com/cryptopunkscc/astrald/node"
+	"github.com/cryptopunkscc/astrald/node/events"
+	"github.com/cryptopunkscc/astrald/node/network"
+	"github.com/cryptopunkscc/astrald/tasks"
+	"slices"
+	"sync"
+	"time"
+)
+
+var _ mesh.Module = &Module{}
+
+type Module struct {
+	config  Config
+	node    node.Node
+	log     *log.Logger
+	mu      sync.Mutex
+	vectors map[string]*vector
+	routes  map[string]*installedRoute
+	dialMu  sync.Mutex
+	dials   map[string]*dial
+	trigger chan struct{}
+}
+
+func (mod *Module) Run(ctx context.Context) error {
+	if !mod.config.Enabled {
+		<-ctx.Done()
+		return nil
+	}
+
+	defer mod.clearRoutes()
+
+	return tasks.Group(
+		&RoutesService{Module: mod},
+		&TunnelService{Module: mod},
+		events.Runner(mod.node.Events(), mod.onLinkAdded),
+		events.Runner(mod.node.Events(), mod.onLinkRemoved),
+		&tasks.RunFuncAdapter{RunFunc: mod.updateLoop},
+	).Run(ctx)
+}
+
+func (mod *Module) Routes() []mesh.Route {
+	mod.mu.Lock()
+	defer mod.mu.Unlock()
+
+	var list = make([]mesh.Route, 0, len(mod.routes))
+	for _, r := range mod.routes {
+		list = append(list, r.Route)
+	}
+
+	slices.SortFunc(list, func(a, b mesh.Route) int {
+		return b.Priority - a.Priority
+	})
+
+	return list
+}
+
+func (mod *Module) Update() {
+	select {
+	case mod.trigger <- struct{}{}:
+	default:
+	}
+}
+
+func (mod *Module) onLinkAdded(ctx context.Context, event network.EventLinkAdded) error {
+	if net.Network(event.Link) != mesh.NetworkName {
+		mod.Update()
+	}
+	return nil
+}
+
+func (mod *Module) onLinkRemoved(ctx context.Context, event network.EventLinkRemoved) error {
+	if net.Network(event.Link) != mesh.NetworkName {
+		mod.Update()
+	}
+	return nil
+}
+
+func (mod *Module) updateLoop(ctx context.Context) error {
+	var ticker = time.NewTicker(mod.config.UpdateInterval)
+	defer ticker.Stop()
+
+	for {
+		mod.update(ctx)
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		case <-mod.trigger:
+		}
+	}
+}
+
+// neighbours returns the best link to every directly linked identity. Links on the mesh network are skipped,
+// so that routes are only ever built on top of real transports.
+func (mod *Module) neighbours() map[string]*neighbour {
+	var list = make(map[string]*neighbour)
+
+	for _, l := range mod.node.Network().Links().All() {
+		if net.Network(l) == mesh.NetworkName {
+			continue
+		}
+		if !l.LocalIdentity().IsEqual(mod.node.Identity()) {
+			continue
+		}
+
+		var hex = l.RemoteIdentity().PublicKeyHex()
+		var latency = linkLatency(l.Link)
+
+		if n, found := list[hex]; found && n.latency <= latency {
+			continue
+		}
+
+		list[hex] = &neighbour{
+			identity: l.RemoteIdentity(),
+			link:     l,
+			latency:  latency,
+		}
+	}
+
+	return list
+}
+
+// neighbourLink returns the best link to a directly linked identity or nil if there is none
+func (mod *Module) neighbourLink(identity id.Identity) net.Link {
+	if n, found := mod.neighbours()[identity.PublicKeyHex()]; found {
+		return n.link
+	}
+	return nil
+}
+
+// route returns the current route to the target
+func (mod *Module) route(target id.Identity) (mesh.Route, bool) {
+	mod.mu.Lock()
+	defer mod.mu.Unlock()
+
+	r, found := mod.routes[target.PublicKeyHex()]
+	if !found {
+		return mesh.Route{}, false
+	}
+	return r.Route, true
+}
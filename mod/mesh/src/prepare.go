@@ -0,0 +1,16 @@
+package mesh
+
+import (
+	"context"
+	"github.com/cryptopunkscc/astrald/mod/admin"
+	"github.com/cryptopunkscc/astrald/mod/mesh"
+	"github.com/cryptopunkscc/astrald/node/modules"
+)
+
+func (mod *Module) Prepare(ctx context.Context) error {
+	if adm, err := modules.Load[admin.Module](mod.node, admin.ModuleName); err == nil {
+		adm.AddCommand(mesh.ModuleName, NewAdmin(mod))
+	}
+
+	return nil
+}
@@ -0,0 +1,123 @@
+package mesh
+
+import (
+	"context"
+	"github.com/cryptopunkscc/astrald/auth/id"
+	"github.com/cryptopunkscc/astrald/mod/mesh"
+	"github.com/cryptopunkscc/astrald/net"
+	"github.com/cryptopunkscc/astrald/node/link"
+	"strconv"
+	"time"
+)
+
+const dialTimeout = 30 * time.Second
+
+var _ net.Router = &targetRouter{}
+
+// targetRouter routes queries to a single mesh target by opening a link to it through the mesh
+type targetRouter struct {
+	*Module
+	target id.Identity
+}
+
+// dial tracks an attempt to open a mesh link, so that concurrent queries share it
+type dial struct {
+	done chan struct{}
+	link net.Link
+	err  error
+}
+
+func (r *targetRouter) RouteQuery(ctx context.Context, query net.Query, caller net.SecureWriteCloser, hints net.Hints) (net.SecureWriteCloser, error) {
+	l, err := r.linkTo(ctx, r.target)
+	if err != nil {
+		return net.RouteNotFound(r, err)
+	}
+
+	return l.RouteQuery(ctx, query, caller, hints)
+}
+
+// linkTo returns a link to the target, opening a new mesh link if necessary
+func (mod *Module) linkTo(ctx context.Context, target id.Identity) (net.Link, error) {
+	var hex = target.PublicKeyHex()
+
+	mod.dialMu.Lock()
+	d, found := mod.dials[hex]
+	if found {
+		mod.dialMu.Unlock()
+
+		select {
+		case <-d.done:
+			return d.link, d.err
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+
+	// reuse a mesh link opened by an earlier query
+	for _, l := range mod.node.Network().Links().ByRemoteIdentity(target).All() {
+		if net.Network(l) == mesh.NetworkName {
+			mod.dialMu.Unlock()
+			return l, nil
+		}
+	}
+
+	d = &dial{done: make(chan struct{})}
+	mod.dials[hex] = d
+	mod.dialMu.Unlock()
+
+	d.link, d.err = mod.openLink(ctx, target)
+	close(d.done)
+
+	mod.dialMu.Lock()
+	delete(mod.dials, hex)
+	mod.dialMu.Unlock()
+
+	return d.link, d.err
+}
+
+// openLink opens a tunnel to the target along the current route and negotiates a link over it
+func (mod *Module) openLink(ctx context.Context, target id.Identity) (net.Link, error) {
+	ctx, cancel := context.WithTimeout(ctx, dialTimeout)
+	defer cancel()
+
+	r, found := mod.route(target)
+	if !found {
+		return nil, ErrNoRoute
+	}
+
+	via := mod.neighbourLink(r.Via)
+	if via == nil {
+		return nil, ErrNoRoute
+	}
+
+	var query = net.WithParams(
+		net.NewQuery(mod.node.Identity(), r.Via, mesh.TunnelServiceName+"."+target.PublicKeyHex()),
+		net.Params{hopsParam: strconv.Itoa(1)},
+	)
+
+	conn, err := net.Route(ctx, via, query)
+	if err != nil {
+		return nil, err
+	}
+
+	var tunnel = newConn(
+		conn,
+		NewEndpoint(r.Via, mod.node.Identity()),
+		NewEndpoint(r.Via, target),
+		true,
+	)
+
+	l, err := link.Open(ctx, tunnel, target, mod.node.Identity())
+	if err != nil {
+		return nil, err
+	}
+
+	if err := mod.node.Network().AddLink(l); err != nil {
+		l.Close()
+		return nil, err
+	}
+
+	mod.log.Logv(1, "linked with %v via %v (%v hops)", target, r.Via, r.Hops)
+
+	return l, nil
+}
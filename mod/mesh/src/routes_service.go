@@ -0,0 +1,39 @@
+package mesh
+
+import (
+	"context"
+	"github.com/cryptopunkscc/astrald/cslq"
+	"github.com/cryptopunkscc/astrald/mod/mesh"
+	"github.com/cryptopunkscc/astrald/net"
+)
+
+type RoutesService struct {
+	*Module
+}
+
+func (srv *RoutesService) Run(ctx context.Context) error {
+	err := srv.node.LocalRouter().AddRoute(mesh.RoutesServiceName, srv)
+	if err != nil {
+		return err
+	}
+	defer srv.node.LocalRouter().RemoveRoute(mesh.RoutesServiceName)
+
+	<-ctx.Done()
+	return nil
+}
+
+func (srv *RoutesService) RouteQuery(ctx context.Context, query net.Query, caller net.SecureWriteCloser, hints net.Hints) (net.SecureWriteCloser, error) {
+	if hints.Origin != net.OriginNetwork {
+		return net.Reject()
+	}
+
+	var entries = srv.advertise(query.Caller())
+
+	return net.Accept(query, caller, func(conn net.SecureConn) {
+		defer conn.Close()
+
+		if err := cslq.Encode(conn, "[s]v", entries); err != nil {
+			srv.log.Errorv(2, "error sending routes to %v: %v", query.Caller(), err)
+		}
+	})
+}
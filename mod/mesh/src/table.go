@@ -0,0 +1,245 @@
+package mesh
+
+import (
+	"context"
+	"github.com/cryptopunkscc/astrald/auth/id"
+	"github.com/cryptopunkscc/astrald/cslq"
+	"github.com/cryptopunkscc/astrald/mod/mesh"
+	"github.com/cryptopunkscc/astrald/net"
+	"sync"
+	"time"
+)
+
+const fetchTimeout = 15 * time.Second
+
+// unknownLatency is assumed for links that have not measured their latency yet
+const unknownLatency = time.Second
+
+const (
+	maxPriority  = 48                     // just below direct links
+	minPriority  = 21                     // just above relays
+	latencyLimit = 250 * time.Millisecond // routes slower than this lose one priority point
+)
+
+// entry is a single row of a routing table exchanged between neighbours
+type entry struct {
+	Target  id.Identity   `cslq:"v"`
+	Hops    int           `cslq:"c"`
+	Latency time.Duration `cslq:"q"`
+}
+
+// vector is the routing table most recently advertised by a neighbour
+type vector struct {
+	neighbour id.Identity
+	latency   time.Duration
+	entries   []entry
+}
+
+type neighbour struct {
+	identity id.Identity
+	link     net.Link
+	latency  time.Duration
+}
+
+type installedRoute struct {
+	mesh.Route
+	router *targetRouter
+}
+
+// update fetches routing tables from all neighbours and reinstalls the routes
+func (mod *Module) update(ctx context.Context) {
+	var neighbours = mod.neighbours()
+	var vectors = make(map[string]*vector)
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+
+	for hex, n := range neighbours {
+		hex, n := hex, n
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			entries, err := mod.fetchRoutes(ctx, n.link)
+			if err != nil {
+				mod.log.Logv(2, "cannot fetch routes from %v: %v", n.identity, err)
+				return
+			}
+
+			mu.Lock()
+			defer mu.Unlock()
+
+			vectors[hex] = &vector{
+				neighbour: n.identity,
+				latency:   n.latency,
+				entries:   entries,
+			}
+		}()
+	}
+
+	wg.Wait()
+
+	mod.mu.Lock()
+	defer mod.mu.Unlock()
+
+	mod.vectors = vectors
+	mod.apply(mod.compute(neighbours))
+}
+
+func (mod *Module) fetchRoutes(ctx context.Context, link net.Link) ([]entry, error) {
+	ctx, cancel := context.WithTimeout(ctx, fetchTimeout)
+	defer cancel()
+
+	conn, err := net.Route(ctx, link, net.NewQuery(
+		mod.node.Identity(),
+		link.RemoteIdentity(),
+		mesh.RoutesServiceName,
+	))
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	var entries []entry
+
+	err = cslq.Decode(conn, "[s]v", &entries)
+
+	return entries, err
+}
+
+// compute builds the best route to every target advertised by the neighbours. Fewer hops win, latency breaks ties.
+// Caller must hold mod.mu.
+func (mod *Module) compute(neighbours map[string]*neighbour) map[string]mesh.Route {
+	var best = make(map[string]mesh.Route)
+
+	for _, v := range mod.vectors {
+		for _, e := range v.entries {
+			if e.Hops < 1 || e.Target.IsEqual(mod.node.Identity()) {
+				continue
+			}
+
+			var hex = e.Target.PublicKeyHex()
+
+			// directly linked targets are routed by their links
+			if _, found := neighbours[hex]; found {
+				continue
+			}
+
+			var r = mesh.Route{
+				Target:  e.Target,
+				Via:     v.neighbour,
+				Hops:    e.Hops + 1,
+				Latency: v.latency + e.Latency,
+			}
+
+			if r.Hops > mod.config.MaxHops {
+				continue
+			}
+
+			if cur, found := best[hex]; found {
+				if cur.Hops < r.Hops || (cur.Hops == r.Hops && cur.Latency <= r.Latency) {
+					continue
+				}
+			}
+
+			r.Priority = routePriority(r.Hops, r.Latency)
+			best[hex] = r
+		}
+	}
+
+	return best
+}
+
+// apply installs new routes in the node's router and removes stale ones. Caller must hold mod.mu.
+func (mod *Module) apply(routes map[string]mesh.Route) {
+	var router = mod.node.Router()
+	var localID = mod.node.Identity()
+
+	for hex, cur := range mod.routes {
+		if _, found := routes[hex]; found {
+			continue
+		}
+
+		router.RemoveRoute(localID, cur.Target, cur.router)
+		delete(mod.routes, hex)
+
+		mod.log.Logv(1, "route to %v removed", cur.Target)
+	}
+
+	for hex, r := range routes {
+		cur, found := mod.routes[hex]
+		if !found {
+			cur = &installedRoute{router: &targetRouter{Module: mod, target: r.Target}}
+			mod.routes[hex] = cur
+		}
+
+		if found && cur.Via.IsEqual(r.Via) && cur.Hops == r.Hops && cur.Priority == r.Priority {
+			cur.Route = r
+			continue
+		}
+
+		cur.Route = r
+		router.AddRoute(localID, r.Target, cur.router, r.Priority)
+
+		mod.log.Logv(1, "route to %v via %v (%v hops, priority %v)", r.Target, r.Via, r.Hops, r.Priority)
+	}
+}
+
+// advertise returns the routing table to be sent to the caller. Routes learned from the caller are left out
+// (split horizon).
+func (mod *Module) advertise(caller id.Identity) []entry {
+	mod.mu.Lock()
+	defer mod.mu.Unlock()
+
+	var entries = make([]entry, 0)
+
+	// advertise only neighbours that take part in the mesh
+	for _, v := range mod.vectors {
+		if v.neighbour.IsEqual(caller) {
+			continue
+		}
+		entries = append(entries, entry{
+			Target:  v.neighbour,
+			Hops:    1,
+			Latency: v.latency,
+		})
+	}
+
+	for _, r := range mod.routes {
+		if r.Via.IsEqual(caller) || r.Target.IsEqual(caller) || r.Hops >= mod.config.MaxHops {
+			continue
+		}
+		entries = append(entries, entry{
+			Target:  r.Target,
+			Hops:    r.Hops,
+			Latency: r.Latency,
+		})
+	}
+
+	return entries
+}
+
+func (mod *Module) clearRoutes() {
+	mod.mu.Lock()
+	defer mod.mu.Unlock()
+
+	mod.apply(map[string]mesh.Route{})
+}
+
+// routePriority maps a route to a priority in the node's router. Mesh routes rank below direct links and above
+// relays. Every extra hop costs two points and slow routes lose one more, so hop count always decides first.
+func routePriority(hops int, latency time.Duration) int {
+	var p = maxPriority - (hops-2)*2
+	if latency > latencyLimit {
+		p--
+	}
+	return max(p, minPriority)
+}
+
+func linkLatency(link net.Link) time.Duration {
+	if l, ok := link.(interface{ Latency() time.Duration }); ok {
+		if latency := l.Latency(); latency >= 0 {
+			return latency
+		}
+	}
+	return unknownLatency
+}
@@ -0,0 +1,58 @@
+package mesh
+
+import (
+	"bytes"
+	"github.com/cryptopunkscc/astrald/auth/id"
+	"github.com/cryptopunkscc/astrald/cslq"
+	"testing"
+	"time"
+)
+
+func TestEntries(t *testing.T) {
+	target, err := id.GenerateIdentity()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var entries = []entry{
+		{Target: target, Hops: 1, Latency: 20 * time.Millisecond},
+		{Target: target, Hops: 7, Latency: time.Second},
+	}
+
+	var buf = &bytes.Buffer{}
+	if err := cslq.Encode(buf, "[s]v", entries); err != nil {
+		t.Fatal(err)
+	}
+
+	var decoded []entry
+	if err := cslq.Decode(buf, "[s]v", &decoded); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(decoded) != len(entries) {
+		t.Fatalf("expected %d entries, got %d", len(entries), len(decoded))
+	}
+
+	for i := range entries {
+		if !decoded[i].Target.IsEqual(entries[i].Target) ||
+			decoded[i].Hops != entries[i].Hops ||
+			decoded[i].Latency != entries[i].Latency {
+			t.Fatalf("entry %d mismatch: %+v != %+v", i, decoded[i], entries[i])
+		}
+	}
+}
+
+func TestRoutePriority(t *testing.T) {
+	if p := routePriority(2, 0); p != maxPriority {
+		t.Fatalf("expected %d, got %d", maxPriority, p)
+	}
+
+	// fewer hops must win regardless of latency
+	if routePriority(2, time.Second) <= routePriority(3, 0) {
+		t.Fatal("a shorter route ranks below a longer one")
+	}
+
+	if p := routePriority(100, time.Second); p != minPriority {
+		t.Fatalf("expected %d, got %d", minPriority, p)
+	}
+}
@@ -0,0 +1,105 @@
+package mesh
+
+import (
+	"context"
+	"github.com/cryptopunkscc/astrald/auth/id"
+	"github.com/cryptopunkscc/astrald/mod/mesh"
+	"github.com/cryptopunkscc/astrald/net"
+	"github.com/cryptopunkscc/astrald/node/link"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// hopsParam is the query parameter carrying the number of hops a tunnel has already made
+const hopsParam = "hops"
+
+const acceptTimeout = 15 * time.Second
+
+type TunnelService struct {
+	*Module
+}
+
+func (srv *TunnelService) Run(ctx context.Context) error {
+	err := srv.node.LocalRouter().AddRoute(mesh.TunnelServiceName+".*", srv)
+	if err != nil {
+		return err
+	}
+	defer srv.node.LocalRouter().RemoveRoute(mesh.TunnelServiceName + ".*")
+
+	<-ctx.Done()
+	return nil
+}
+
+func (srv *TunnelService) RouteQuery(ctx context.Context, query net.Query, caller net.SecureWriteCloser, hints net.Hints) (net.SecureWriteCloser, error) {
+	targetKey, ok := strings.CutPrefix(query.Query(), mesh.TunnelServiceName+".")
+	if !ok {
+		return net.Reject()
+	}
+
+	// every hop of a tunnel is requested by a linked neighbour
+	if srv.neighbourLink(query.Caller()) == nil {
+		return net.RejectWithReason(net.RejectCodeAccessDenied, "not a neighbour")
+	}
+
+	// check if the target is us
+	if targetKey == srv.node.Identity().PublicKeyHex() {
+		return net.Accept(query, caller, func(conn net.SecureConn) {
+			tunnel := newConn(
+				conn,
+				NewEndpoint(query.Caller(), query.Target()),
+				NewEndpoint(query.Caller(), query.Target()),
+				false,
+			)
+
+			actx, cancel := context.WithTimeout(context.Background(), acceptTimeout)
+			defer cancel()
+
+			l, err := link.Accept(actx, tunnel, srv.node.Identity())
+			if err != nil {
+				srv.log.Errorv(2, "mesh link via %v failed: %v", query.Caller(), err)
+				return
+			}
+
+			err = srv.node.Network().AddLink(l)
+			if err != nil {
+				l.Close()
+			}
+		})
+	}
+
+	target, err := id.ParsePublicKeyHex(targetKey)
+	if err != nil {
+		return net.RejectWithReason(net.RejectCodeInvalidQuery, "invalid target")
+	}
+
+	hops, _ := strconv.Atoi(query.Params().Get(hopsParam))
+	if hops >= srv.config.MaxHops {
+		return net.RejectWithReason(net.RejectCodeRouteNotFound, "hop limit exceeded")
+	}
+
+	// find the next hop
+	var next = srv.neighbourLink(target)
+	if next == nil {
+		if r, found := srv.route(target); found {
+			next = srv.neighbourLink(r.Via)
+		}
+	}
+	if next == nil {
+		return net.RejectWithReason(net.RejectCodeRouteNotFound, "no route to target")
+	}
+
+	var nextQuery = net.WithParams(
+		net.NewQuery(srv.node.Identity(), next.RemoteIdentity(), query.Query()),
+		net.Params{hopsParam: strconv.Itoa(hops + 1)},
+	)
+
+	srv.log.Logv(2, "forwarding a tunnel from %v to %v via %v", query.Caller(), target, next.RemoteIdentity())
+
+	dst, err := next.RouteQuery(ctx, nextQuery, net.NewIdentityTranslation(caller, srv.node.Identity()), hints)
+	if err != nil {
+		return nil, err
+	}
+
+	return net.NewIdentityTranslation(dst, srv.node.Identity()), nil
+}
@@ -188,6 +188,8 @@ func scoreNetwork(network string) int {
 		return 30
 	case "inet", "tcp":
 		return 40
+	case "quic":
+		return 50
 	}
 	return 0
 }
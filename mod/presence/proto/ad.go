@@ -1,12 +1,17 @@
 package proto
 
 import (
+	"errors"
 	"github.com/cryptopunkscc/astrald/auth/id"
 	"github.com/cryptopunkscc/astrald/cslq"
+	"io"
 )
 
 const adFormat = "x61 x70 x00 x00 v [c]c s c"
 
+// quicPortFormat is an optional trailing field. Nodes that don't know about it ignore it.
+const quicPortFormat = "s"
+
 const (
 	FlagDiscover = 1 << uint8(iota)
 )
@@ -16,12 +21,33 @@ type Ad struct {
 	Alias    string
 	Port     int
 	Flags    uint8
+	QUICPort int // 0 if the node doesn't accept QUIC links
 }
 
 func (ad *Ad) MarshalCSLQ(enc *cslq.Encoder) error {
-	return enc.Encodef(adFormat, ad.Identity, ad.Alias, ad.Port, ad.Flags)
+	err := enc.Encodef(adFormat, ad.Identity, ad.Alias, ad.Port, ad.Flags)
+	if err != nil {
+		return err
+	}
+
+	if ad.QUICPort > 0 {
+		return enc.Encodef(quicPortFormat, ad.QUICPort)
+	}
+
+	return nil
 }
 
 func (ad *Ad) UnmarshalCSLQ(dec *cslq.Decoder) error {
-	return dec.Decodef(adFormat, &ad.Identity, &ad.Alias, &ad.Port, &ad.Flags)
+	err := dec.Decodef(adFormat, &ad.Identity, &ad.Alias, &ad.Port, &ad.Flags)
+	if err != nil {
+		return err
+	}
+
+	err = dec.Decodef(quicPortFormat, &ad.QUICPort)
+	if errors.Is(err, io.EOF) {
+		ad.QUICPort = 0
+		return nil
+	}
+
+	return err
 }
@@ -12,6 +12,7 @@ type Ad struct {
 	Identity  id.Identity
 	Alias     string
 	Endpoint  net.Endpoint
+	QUIC      net.Endpoint // nil if the node doesn't advertise a QUIC port
 	Timestamp time.Time
 	Flags     int
 	UDPAddr   *_net.UDPAddr
@@ -24,6 +24,7 @@ func (srv *AnnounceService) Run(ctx context.Context) error {
 	srv.myAd = &proto.Ad{
 		Identity: srv.node.Identity(),
 		Port:     srv.getListenPort(),
+		QUICPort: srv.getQUICPort(),
 		Flags:    proto.FlagDiscover,
 	}
 	srv.myAd.Alias, _ = srv.node.Tracker().GetAlias(srv.node.Identity())
@@ -129,3 +130,11 @@ func (srv *AnnounceService) getListenPort() int {
 
 	return srv.tcp.ListenPort()
 }
+
+func (srv *AnnounceService) getQUICPort() int {
+	if srv.quic == nil {
+		return 0
+	}
+
+	return srv.quic.ListenPort()
+}
@@ -1,6 +1,7 @@
 package presence
 
 import (
+	"github.com/cryptopunkscc/astrald/mod/quic"
 	"github.com/cryptopunkscc/astrald/mod/tcp"
 	"github.com/cryptopunkscc/astrald/node/modules"
 )
@@ -9,6 +10,11 @@ func (mod *Module) LoadDependencies() error {
 	var err error
 
 	mod.tcp, err = modules.Load[tcp.Module](mod.node, tcp.ModuleName)
+	if err != nil {
+		return err
+	}
 
-	return err
+	mod.quic, _ = modules.Load[quic.Module](mod.node, quic.ModuleName)
+
+	return nil
 }
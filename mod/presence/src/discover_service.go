@@ -5,6 +5,7 @@ import (
 	"context"
 	"github.com/cryptopunkscc/astrald/cslq"
 	"github.com/cryptopunkscc/astrald/mod/presence/proto"
+	"github.com/cryptopunkscc/astrald/mod/quic"
 	"net"
 	"strconv"
 	"time"
@@ -48,6 +49,9 @@ func (srv *DiscoverService) Run(ctx context.Context) error {
 
 		if srv.config.AutoAdd {
 			_ = srv.node.Tracker().AddEndpoint(ad.Identity, ad.Endpoint)
+			if ad.QUIC != nil {
+				_ = srv.node.Tracker().AddEndpoint(ad.Identity, ad.QUIC)
+			}
 		}
 
 		if !srv.config.TrustAliases || ad.Alias == "" {
@@ -105,14 +109,23 @@ func (srv *DiscoverService) readAd() (*Ad, error) {
 			panic(err)
 		}
 
-		return &Ad{
+		var ad = &Ad{
 			UDPAddr:   srcAddr,
 			Identity:  msg.Identity,
 			Alias:     msg.Alias,
 			Endpoint:  endpoint,
 			Timestamp: time.Now(),
 			Flags:     int(msg.Flags),
-		}, nil
+		}
+
+		if msg.QUICPort > 0 && srv.quic != nil {
+			quicHostPort := net.JoinHostPort(srcAddr.IP.String(), strconv.Itoa(msg.QUICPort))
+			if e, err := srv.quic.Parse(quic.NetworkName, quicHostPort); err == nil {
+				ad.QUIC = e
+			}
+		}
+
+		return ad, nil
 	}
 }
 
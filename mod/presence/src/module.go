@@ -3,6 +3,7 @@ package presence
 import (
 	"context"
 	"github.com/cryptopunkscc/astrald/log"
+	"github.com/cryptopunkscc/astrald/mod/quic"
 	"github.com/cryptopunkscc/astrald/mod/tcp"
 	"github.com/cryptopunkscc/astrald/node"
 	"github.com/cryptopunkscc/astrald/node/events"
@@ -18,7 +19,8 @@ type Module struct {
 	socket *net.UDPConn
 	events events.Queue
 
-	tcp tcp.Module
+	tcp  tcp.Module
+	quic quic.Module
 
 	Discover *DiscoverService
 	Announce *AnnounceService
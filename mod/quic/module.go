@@ -0,0 +1,24 @@
+package quic
+
+import (
+	"github.com/cryptopunkscc/astrald/net"
+	"github.com/cryptopunkscc/astrald/node/infra"
+	_net "net"
+)
+
+const ModuleName = "quic"
+const NetworkName = "quic"
+
+type Module interface {
+	infra.Dialer
+	infra.Unpacker
+	infra.Parser
+	infra.EndpointLister
+	ListenPort() int
+}
+
+type Endpoint interface {
+	net.Endpoint
+	IP() _net.IP
+	Port() int
+}
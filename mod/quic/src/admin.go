@@ -0,0 +1,27 @@
+package quic
+
+import (
+	"github.com/cryptopunkscc/astrald/mod/admin"
+)
+
+type Admin struct {
+	mod *Module
+}
+
+func NewAdmin(mod *Module) *Admin {
+	var adm = &Admin{mod: mod}
+
+	return adm
+}
+
+func (adm *Admin) Exec(term admin.Terminal, args []string) error {
+	term.Printf("%s %v\n", admin.Header("listen port"), adm.mod.config.ListenPort)
+	for _, e := range adm.mod.Endpoints() {
+		term.Printf("%s %v\n", admin.Header("endpoint"), e)
+	}
+	return nil
+}
+
+func (adm *Admin) ShortDescription() string {
+	return "manage the quic driver"
+}
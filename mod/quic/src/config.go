@@ -0,0 +1,18 @@
+package quic
+
+import "time"
+
+type Config struct {
+	DialTimeout     time.Duration `yaml:"dial_timeout"`
+	IdleTimeout     time.Duration `yaml:"idle_timeout"`
+	KeepAlive       time.Duration `yaml:"keep_alive"`
+	PublicEndpoints []string      `yaml:"public_endpoints"`
+	ListenPort      int           `yaml:"listen_port"`
+}
+
+var defaultConfig = Config{
+	DialTimeout: time.Minute,
+	IdleTimeout: time.Minute,
+	KeepAlive:   15 * time.Second,
+	ListenPort:  1791,
+}
@@ -0,0 +1,84 @@
+package quic
+
+import (
+	"context"
+	"github.com/cryptopunkscc/astrald/net"
+	"github.com/cryptopunkscc/astrald/node/link"
+	"github.com/quic-go/quic-go"
+	"sync"
+	"sync/atomic"
+)
+
+var _ net.Conn = &Conn{}
+var _ link.StreamOpener = &Conn{}
+
+// session is a QUIC connection shared by the streams opened in it. It closes when its last stream closes.
+type session struct {
+	qconn quic.Connection
+	refs  atomic.Int32
+}
+
+func (s *session) release() error {
+	if s.refs.Add(-1) == 0 {
+		return s.qconn.CloseWithError(0, "")
+	}
+	return nil
+}
+
+// Conn is a single bidirectional QUIC stream wrapped into astral's net.Conn. Closing the last Conn of a QUIC
+// connection closes the connection as well.
+type Conn struct {
+	quic.Stream
+	session        *session
+	outbound       bool
+	localEndpoint  Endpoint
+	remoteEndpoint Endpoint
+	closeOnce      sync.Once
+}
+
+func newConn(session *session, stream quic.Stream, outbound bool) *Conn {
+	session.refs.Add(1)
+
+	c := &Conn{
+		Stream:   stream,
+		session:  session,
+		outbound: outbound,
+	}
+
+	c.localEndpoint, _ = Parse(session.qconn.LocalAddr().String())
+	c.remoteEndpoint, _ = Parse(session.qconn.RemoteAddr().String())
+
+	return c
+}
+
+// OpenStream opens a new stream in the same QUIC connection. Links use it to give priority classes their
+// own streams.
+func (conn *Conn) OpenStream(ctx context.Context) (net.Conn, error) {
+	stream, err := conn.session.qconn.OpenStreamSync(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return newConn(conn.session, stream, conn.outbound), nil
+}
+
+func (conn *Conn) Close() (err error) {
+	conn.closeOnce.Do(func() {
+		conn.Stream.CancelRead(0)
+		conn.Stream.Close()
+		err = conn.session.release()
+	})
+	return
+}
+
+func (conn *Conn) LocalEndpoint() net.Endpoint {
+	return conn.localEndpoint
+}
+
+func (conn *Conn) RemoteEndpoint() net.Endpoint {
+	return conn.remoteEndpoint
+}
+
+func (conn *Conn) Outbound() bool {
+	return conn.outbound
+}
@@ -0,0 +1,31 @@
+package quic
+
+import (
+	"context"
+	"github.com/cryptopunkscc/astrald/mod/quic"
+	"github.com/cryptopunkscc/astrald/net"
+	"github.com/cryptopunkscc/astrald/node/infra"
+	_quic "github.com/quic-go/quic-go"
+)
+
+func (mod *Module) Dial(ctx context.Context, endpoint net.Endpoint) (net.Conn, error) {
+	if endpoint.Network() != quic.NetworkName {
+		return nil, infra.ErrUnsupportedNetwork
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, mod.config.DialTimeout)
+	defer cancel()
+
+	qconn, err := _quic.DialAddr(ctx, endpoint.String(), mod.clientTLSConfig(), mod.quicConfig())
+	if err != nil {
+		return nil, err
+	}
+
+	stream, err := qconn.OpenStreamSync(ctx)
+	if err != nil {
+		qconn.CloseWithError(0, "")
+		return nil, err
+	}
+
+	return newConn(&session{qconn: qconn}, stream, true), nil
+}
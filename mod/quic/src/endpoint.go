@@ -0,0 +1,69 @@
+package quic
+
+import (
+	"bytes"
+	"github.com/cryptopunkscc/astrald/cslq"
+	"github.com/cryptopunkscc/astrald/mod/quic"
+	_net "net"
+	"strconv"
+)
+
+const (
+	ipv4 = iota // IPv4 (32 bit)
+	ipv6        // IPv6 (128 bit)
+)
+
+var _ quic.Endpoint = Endpoint{}
+
+type Endpoint struct {
+	ver  int
+	ip   _net.IP
+	port uint16
+}
+
+func (e Endpoint) Pack() []byte {
+	var b = &bytes.Buffer{}
+
+	switch e.ver {
+	case ipv4:
+		cslq.Encode(b, "x00 [4]c s", e.ip[len(e.ip)-4:], e.port)
+	case ipv6:
+		cslq.Encode(b, "x01 [16]c s", e.ip, e.port)
+	}
+
+	return b.Bytes()
+}
+
+func (e Endpoint) String() string {
+	ip := e.ip.String()
+
+	if e.ver == ipv6 {
+		ip = "[" + ip + "]"
+	}
+
+	if e.port != 0 {
+		ip = ip + ":" + strconv.Itoa(int(e.port))
+	}
+	return ip
+}
+
+func (e Endpoint) Network() string {
+	return quic.NetworkName
+}
+
+func (e Endpoint) Port() int {
+	return int(e.port)
+}
+
+func (e Endpoint) IP() _net.IP {
+	return e.ip
+}
+
+// UDPAddr returns the endpoint as a UDP address
+func (e Endpoint) UDPAddr() *_net.UDPAddr {
+	return &_net.UDPAddr{IP: e.ip, Port: int(e.port)}
+}
+
+func (e Endpoint) IsZero() bool {
+	return e.ip == nil
+}
@@ -0,0 +1,17 @@
+package quic
+
+import (
+	"context"
+	"github.com/cryptopunkscc/astrald/mod/admin"
+	"github.com/cryptopunkscc/astrald/mod/quic"
+	"github.com/cryptopunkscc/astrald/node/modules"
+)
+
+func (mod *Module) Prepare(ctx context.Context) error {
+	// inject admin command
+	if adm, err := modules.Load[admin.Module](mod.node, admin.ModuleName); err == nil {
+		adm.AddCommand(quic.ModuleName, NewAdmin(mod))
+	}
+
+	return nil
+}
@@ -0,0 +1,85 @@
+package quic
+
+import (
+	"context"
+	"errors"
+	"github.com/cryptopunkscc/astrald/node/link"
+	_quic "github.com/quic-go/quic-go"
+	"strconv"
+)
+
+type Server struct {
+	*Module
+}
+
+func NewServer(module *Module) *Server {
+	return &Server{Module: module}
+}
+
+func (srv *Server) Run(ctx context.Context) error {
+	// start the listener
+	var addrStr = ":" + strconv.Itoa(srv.config.ListenPort)
+
+	listener, err := _quic.ListenAddr(addrStr, srv.serverTLSConfig(), srv.quicConfig())
+	if err != nil {
+		srv.log.Errorv(0, "failed to start server: %v", err)
+		return err
+	}
+
+	endpoint, _ := Parse(listener.Addr().String())
+
+	srv.log.Info("started server at %v", endpoint)
+	defer srv.log.Info("stopped server at %v", endpoint)
+
+	go func() {
+		<-ctx.Done()
+		listener.Close()
+	}()
+
+	// accept connections
+	for {
+		qconn, err := listener.Accept(ctx)
+		if err != nil {
+			return err
+		}
+
+		go srv.serve(ctx, qconn)
+	}
+}
+
+// serve accepts streams opened by the dialing party. The first stream carries the link, the following ones
+// join it as lanes.
+func (srv *Server) serve(ctx context.Context, qconn _quic.Connection) {
+	var s = &session{qconn: qconn}
+	defer func() {
+		if s.refs.Load() == 0 {
+			qconn.CloseWithError(0, "")
+		}
+	}()
+
+	for {
+		stream, err := qconn.AcceptStream(ctx)
+		if err != nil {
+			return
+		}
+
+		go srv.accept(ctx, newConn(s, stream, false))
+	}
+}
+
+func (srv *Server) accept(ctx context.Context, conn *Conn) {
+	l, err := link.Accept(ctx, conn, srv.node.Identity())
+	switch {
+	case errors.Is(err, link.ErrPathJoined):
+		srv.log.Logv(2, "stream from %v joined a link", conn.RemoteEndpoint())
+		return
+	case err != nil:
+		srv.log.Errorv(1, "handshake failed from %v: %v", conn.RemoteEndpoint(), err)
+		return
+	}
+
+	err = srv.node.Network().AddLink(l)
+	if err != nil {
+		l.Close()
+	}
+}
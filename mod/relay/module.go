@@ -8,10 +8,11 @@ import (
 )
 
 const (
-	ModuleName         = "relay"
-	RelayServiceName   = ".relay"
-	RerouteServiceName = ".reroute"
-	RelayCertType      = "cert.router.relay"
+	ModuleName             = "relay"
+	RelayServiceName       = ".relay"
+	RelayParamsServiceName = ".relay_params" // like RelayServiceName, but the query carries parameters
+	RerouteServiceName     = ".reroute"
+	RelayCertType          = "cert.router.relay"
 )
 
 type Module interface {
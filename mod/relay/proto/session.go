@@ -2,6 +2,7 @@ package proto
 
 import (
 	"github.com/cryptopunkscc/astrald/cslq/rpc"
+	"github.com/cryptopunkscc/astrald/net"
 	"io"
 )
 
@@ -18,6 +19,20 @@ func (s *Session) Query(params *QueryParams) (*QueryResponse, error) {
 		return nil, err
 	}
 
+	return s.readResponse()
+}
+
+// QueryWithParams sends the query followed by its parameters. Only relays serving RelayParamsServiceName
+// expect the parameters.
+func (s *Session) QueryWithParams(params *QueryParams, queryParams net.Params) (*QueryResponse, error) {
+	if err := s.Encodef("vv", params, queryParams); err != nil {
+		return nil, err
+	}
+
+	return s.readResponse()
+}
+
+func (s *Session) readResponse() (*QueryResponse, error) {
 	if err := s.DecodeErr(); err != nil {
 		return nil, err
 	}
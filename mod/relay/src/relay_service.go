@@ -15,11 +15,13 @@ type RelayService struct {
 }
 
 func (srv *RelayService) Run(ctx context.Context) error {
-	err := srv.node.LocalRouter().AddRoute(relay.RelayServiceName, srv)
-	if err != nil {
-		return err
+	for _, name := range []string{relay.RelayServiceName, relay.RelayParamsServiceName} {
+		err := srv.node.LocalRouter().AddRoute(name, srv)
+		if err != nil {
+			return err
+		}
+		defer srv.node.LocalRouter().RemoveRoute(name)
 	}
-	defer srv.node.LocalRouter().RemoveRoute(relay.RelayServiceName)
 
 	<-ctx.Done()
 
@@ -28,13 +30,14 @@ func (srv *RelayService) Run(ctx context.Context) error {
 
 func (srv *RelayService) RouteQuery(ctx context.Context, query net.Query, caller net.SecureWriteCloser, hints net.Hints) (net.SecureWriteCloser, error) {
 	return net.Accept(query, caller, func(conn net.SecureConn) {
-		if err := srv.serve(ctx, conn); err != nil {
+		var withParams = query.Query() == relay.RelayParamsServiceName
+		if err := srv.serve(ctx, conn, withParams); err != nil {
 			srv.log.Errorv(2, "error serving %s: %s", query.Caller(), err)
 		}
 	})
 }
 
-func (srv *RelayService) serve(ctx context.Context, conn net.SecureConn) error {
+func (srv *RelayService) serve(ctx context.Context, conn net.SecureConn, withParams bool) error {
 	defer conn.Close()
 
 	var err error
@@ -47,6 +50,13 @@ func (srv *RelayService) serve(ctx context.Context, conn net.SecureConn) error {
 		return err
 	}
 
+	var queryParams net.Params
+	if withParams {
+		if err = session.Decode(&queryParams); err != nil {
+			return err
+		}
+	}
+
 	// apply caller certificate
 	if len(params.Cert) > 0 {
 		if err = callerIM.Apply(params.Cert); err != nil {
@@ -75,6 +85,9 @@ func (srv *RelayService) serve(ctx context.Context, conn net.SecureConn) error {
 	// create a proxy service
 	redirectCtx, _ := context.WithTimeout(ctx, time.Minute)
 	var realQuery = net.NewQueryNonce(callerIM.identity, params.Target, params.Query, net.Nonce(params.Nonce))
+	if len(queryParams) > 0 {
+		realQuery = net.WithParams(realQuery, queryParams)
+	}
 
 	redirect, err := NewRedirect(redirectCtx, realQuery, conn.RemoteIdentity(), srv.node)
 	if err != nil {
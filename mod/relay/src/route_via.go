@@ -64,11 +64,17 @@ func (mod *Module) RouteVia(
 		query.Query(),
 	)
 
+	// queries with parameters need a relay that supports them
+	var serviceName = relay.RelayServiceName
+	if len(query.Params()) > 0 {
+		serviceName = relay.RelayParamsServiceName
+	}
+
 	// open a relay session
 	relayConn, err := net.RouteWithHints(
 		ctx,
 		mod.node.Router(),
-		net.NewQuery(mod.node.Identity(), relayID, relay.RelayServiceName),
+		net.NewQuery(mod.node.Identity(), relayID, serviceName),
 		net.DefaultHints().SetSilent(),
 	)
 	if err != nil {
@@ -79,7 +85,12 @@ func (mod *Module) RouteVia(
 	var relayService = proto.New(relayConn)
 
 	// query the relay
-	response, err := relayService.Query(queryParams)
+	var response *proto.QueryResponse
+	if len(query.Params()) > 0 {
+		response, err = relayService.QueryWithParams(queryParams, query.Params())
+	} else {
+		response, err = relayService.Query(queryParams)
+	}
 	switch {
 	case errors.Is(err, proto.ErrRejected):
 		return net.Reject()
@@ -108,7 +119,7 @@ func (mod *Module) RouteVia(
 	if !caller.Identity().IsEqual(mod.node.Identity()) {
 		caller = net.NewIdentityTranslation(caller, mod.node.Identity())
 	}
-	proxy, err := mod.node.Router().RouteQuery(ctx, proxyQuery, caller, net.DefaultHints().SetReroute())
+	proxy, err := mod.node.Router().RouteQuery(ctx, proxyQuery, caller, net.DefaultHints().SetReroute().WithPriority(hints.Priority))
 	if err != nil {
 		return nil, err
 	}
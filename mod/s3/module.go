@@ -0,0 +1,21 @@
+package s3
+
+import (
+	"github.com/cryptopunkscc/astrald/mod/storage"
+)
+
+const ModuleName = "s3"
+
+// IndexName is the set index that lists objects held in the bucket
+const IndexName = "mod.s3"
+
+// Module stores data objects in an S3-compatible bucket
+type Module interface {
+	storage.Store
+	storage.Reader
+	storage.Lister
+	storage.Deleter
+
+	// Sync updates the index with the objects found in the bucket
+	Sync() error
+}
@@ -0,0 +1,60 @@
+package s3
+
+import (
+	"errors"
+	"github.com/cryptopunkscc/astrald/mod/admin"
+)
+
+type Admin struct {
+	mod  *Module
+	cmds map[string]func(admin.Terminal, []string) error
+}
+
+func NewAdmin(mod *Module) *Admin {
+	var adm = &Admin{mod: mod}
+	adm.cmds = map[string]func(admin.Terminal, []string) error{
+		"info": adm.info,
+		"sync": adm.sync,
+		"help": adm.help,
+	}
+
+	return adm
+}
+
+func (adm *Admin) Exec(term admin.Terminal, args []string) error {
+	if len(args) < 2 {
+		return adm.help(term, []string{})
+	}
+
+	cmd, args := args[1], args[2:]
+	if fn, found := adm.cmds[cmd]; found {
+		return fn(term, args)
+	}
+
+	return errors.New("unknown command")
+}
+
+func (adm *Admin) info(term admin.Terminal, _ []string) error {
+	term.Printf("endpoint: %v\n", adm.mod.config.Endpoint)
+	term.Printf("bucket:   %v\n", adm.mod.config.Bucket)
+	term.Printf("prefix:   %v\n", adm.mod.config.Prefix)
+
+	return nil
+}
+
+func (adm *Admin) sync(term admin.Terminal, _ []string) error {
+	return adm.mod.Sync()
+}
+
+func (adm *Admin) ShortDescription() string {
+	return "manage the S3 bucket store"
+}
+
+func (adm *Admin) help(term admin.Terminal, _ []string) error {
+	term.Printf("usage: s3 <command>\n\n")
+	term.Printf("commands:\n")
+	term.Printf("  info                       show bucket configuration\n")
+	term.Printf("  sync                       update the index with objects in the bucket\n")
+	term.Printf("  help                       show help\n")
+	return nil
+}
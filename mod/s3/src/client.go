@@ -0,0 +1,546 @@
+package s3
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	unsignedPayload = "UNSIGNED-PAYLOAD"
+	emptyPayload    = "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855"
+	amzDateFormat   = "20060102T150405Z"
+
+	// maxObjectSize is the largest object S3 accepts
+	maxObjectSize = 5 << 40
+
+	// objects larger than multipartThreshold are uploaded in parts of at least minPartSize. S3 rejects
+	// single uploads over 5GB and uploads of more than maxParts parts.
+	multipartThreshold = 64 << 20
+	minPartSize        = 16 << 20
+	maxParts           = 10000
+
+	// ioTimeout limits the time a connection can go without any progress, so that a stalled endpoint
+	// doesn't block reads and writes forever
+	ioTimeout = time.Minute
+
+	// requestTimeout limits requests that don't transfer object data
+	requestTimeout = 30 * time.Second
+)
+
+var errNotFound = errors.New("object not found")
+var errTooLarge = errors.New("object too large")
+
+// client is a minimal S3 API client that signs requests with AWS Signature Version 4
+type client struct {
+	endpoint  *url.URL
+	region    string
+	bucket    string
+	accessKey string
+	secretKey string
+	pathStyle bool
+	http      *http.Client
+	now       func() time.Time
+
+	multipartThreshold int64
+	minPartSize        int64
+}
+
+type object struct {
+	Key  string
+	Size int64
+}
+
+func newClient(cfg Config) (*client, error) {
+	endpoint, err := url.Parse(cfg.Endpoint)
+	if err != nil {
+		return nil, err
+	}
+	if endpoint.Scheme == "" || endpoint.Host == "" {
+		return nil, errors.New("invalid endpoint: " + cfg.Endpoint)
+	}
+	if cfg.Bucket == "" {
+		return nil, errors.New("bucket not set")
+	}
+
+	return &client{
+		endpoint:  endpoint,
+		region:    cfg.Region,
+		bucket:    cfg.Bucket,
+		accessKey: cfg.AccessKey,
+		secretKey: cfg.SecretKey,
+		pathStyle: cfg.PathStyle,
+		http:      &http.Client{Transport: newTransport()},
+		now:       time.Now,
+
+		multipartThreshold: multipartThreshold,
+		minPartSize:        minPartSize,
+	}, nil
+}
+
+// newTransport returns a transport that times out connections that stop making progress
+func newTransport() *http.Transport {
+	var transport = http.DefaultTransport.(*http.Transport).Clone()
+	var dialer = &net.Dialer{Timeout: ioTimeout, KeepAlive: 30 * time.Second}
+
+	transport.DialContext = func(ctx context.Context, network, addr string) (net.Conn, error) {
+		conn, err := dialer.DialContext(ctx, network, addr)
+		if err != nil {
+			return nil, err
+		}
+		return &timeoutConn{Conn: conn, timeout: ioTimeout}, nil
+	}
+	transport.ResponseHeaderTimeout = ioTimeout
+
+	return transport
+}
+
+// timeoutConn fails reads and writes that don't make progress within the timeout
+type timeoutConn struct {
+	net.Conn
+	timeout time.Duration
+}
+
+func (c *timeoutConn) Read(p []byte) (int, error) {
+	c.Conn.SetReadDeadline(time.Now().Add(c.timeout))
+	return c.Conn.Read(p)
+}
+
+func (c *timeoutConn) Write(p []byte) (int, error) {
+	c.Conn.SetWriteDeadline(time.Now().Add(c.timeout))
+	return c.Conn.Write(p)
+}
+
+// get returns the object starting at offset
+func (c *client) get(key string, offset int64) (io.ReadCloser, error) {
+	req, err := c.request(http.MethodGet, key, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	if offset > 0 {
+		req.Header.Set("Range", fmt.Sprintf("bytes=%d-", offset))
+	}
+
+	res, err := c.do(req, emptyPayload)
+	if err != nil {
+		return nil, err
+	}
+
+	switch res.StatusCode {
+	case http.StatusOK, http.StatusPartialContent:
+		return res.Body, nil
+	}
+
+	res.Body.Close()
+	return nil, statusError(res)
+}
+
+// put uploads size bytes read from body
+func (c *client) put(key string, body io.Reader, size int64) error {
+	req, err := c.request(http.MethodPut, key, nil, body)
+	if err != nil {
+		return err
+	}
+	req.ContentLength = size
+
+	res, err := c.do(req, unsignedPayload)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return statusError(res)
+	}
+
+	return nil
+}
+
+// upload uploads size bytes of the object read from r. Objects over the multipart threshold are uploaded
+// in parts.
+func (c *client) upload(key string, r io.ReaderAt, size int64) error {
+	if size > maxObjectSize {
+		return errTooLarge
+	}
+
+	if size <= c.multipartThreshold {
+		return c.put(key, io.NewSectionReader(r, 0, size), size)
+	}
+
+	uploadID, err := c.createMultipart(key)
+	if err != nil {
+		return err
+	}
+
+	var partSize = max(c.minPartSize, (size+maxParts-1)/maxParts)
+	var parts []completedPart
+
+	for offset := int64(0); offset < size; offset += partSize {
+		var part = completedPart{Number: len(parts) + 1}
+
+		part.ETag, err = c.putPart(key, uploadID, part.Number, io.NewSectionReader(r, offset, min(partSize, size-offset)))
+		if err != nil {
+			c.abortMultipart(key, uploadID)
+			return err
+		}
+
+		parts = append(parts, part)
+	}
+
+	if err = c.completeMultipart(key, uploadID, parts); err != nil {
+		c.abortMultipart(key, uploadID)
+		return err
+	}
+
+	return nil
+}
+
+type completedPart struct {
+	Number int    `xml:"PartNumber"`
+	ETag   string `xml:"ETag"`
+}
+
+func (c *client) createMultipart(key string) (string, error) {
+	var query = url.Values{"uploads": {""}}
+
+	res, err := c.doShort(http.MethodPost, key, query, nil)
+	if err != nil {
+		return "", err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return "", statusError(res)
+	}
+
+	var result struct {
+		UploadId string
+	}
+	if err = xml.NewDecoder(res.Body).Decode(&result); err != nil {
+		return "", err
+	}
+	if result.UploadId == "" {
+		return "", errors.New("s3: missing upload id")
+	}
+
+	return result.UploadId, nil
+}
+
+// putPart uploads a part and returns its ETag
+func (c *client) putPart(key string, uploadID string, number int, part *io.SectionReader) (string, error) {
+	var query = url.Values{
+		"partNumber": {strconv.Itoa(number)},
+		"uploadId":   {uploadID},
+	}
+
+	req, err := c.request(http.MethodPut, key, query, part)
+	if err != nil {
+		return "", err
+	}
+	req.ContentLength = part.Size()
+
+	res, err := c.do(req, unsignedPayload)
+	if err != nil {
+		return "", err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return "", statusError(res)
+	}
+
+	return res.Header.Get("ETag"), nil
+}
+
+func (c *client) completeMultipart(key string, uploadID string, parts []completedPart) error {
+	body, err := xml.Marshal(struct {
+		XMLName xml.Name        `xml:"CompleteMultipartUpload"`
+		Parts   []completedPart `xml:"Part"`
+	}{Parts: parts})
+	if err != nil {
+		return err
+	}
+
+	res, err := c.doShort(http.MethodPost, key, url.Values{"uploadId": {uploadID}}, body)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return statusError(res)
+	}
+
+	// S3 can report a failure after it started sending the response
+	var result struct {
+		XMLName xml.Name
+		Message string
+	}
+	if err = xml.NewDecoder(res.Body).Decode(&result); err == nil && result.XMLName.Local == "Error" {
+		return fmt.Errorf("s3: %s", result.Message)
+	}
+
+	return nil
+}
+
+func (c *client) abortMultipart(key string, uploadID string) {
+	res, err := c.doShort(http.MethodDelete, key, url.Values{"uploadId": {uploadID}}, nil)
+	if err == nil {
+		res.Body.Close()
+	}
+}
+
+func (c *client) head(key string) (int64, error) {
+	res, err := c.doShort(http.MethodHead, key, nil, nil)
+	if err != nil {
+		return 0, err
+	}
+	res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return 0, statusError(res)
+	}
+
+	return res.ContentLength, nil
+}
+
+func (c *client) delete(key string) error {
+	res, err := c.doShort(http.MethodDelete, key, nil, nil)
+	if err != nil {
+		return err
+	}
+	res.Body.Close()
+
+	if res.StatusCode != http.StatusNoContent && res.StatusCode != http.StatusOK {
+		return statusError(res)
+	}
+
+	return nil
+}
+
+// list returns all objects with keys starting with prefix
+func (c *client) list(prefix string) ([]object, error) {
+	var objects []object
+	var token string
+
+	for {
+		var query = url.Values{}
+		query.Set("list-type", "2")
+		query.Set("prefix", prefix)
+		if token != "" {
+			query.Set("continuation-token", token)
+		}
+
+		res, err := c.doShort(http.MethodGet, "", query, nil)
+		if err != nil {
+			return nil, err
+		}
+
+		if res.StatusCode != http.StatusOK {
+			res.Body.Close()
+			return nil, statusError(res)
+		}
+
+		var result struct {
+			Contents              []object
+			IsTruncated           bool
+			NextContinuationToken string
+		}
+		err = xml.NewDecoder(res.Body).Decode(&result)
+		res.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+
+		objects = append(objects, result.Contents...)
+
+		if !result.IsTruncated || result.NextContinuationToken == "" {
+			return objects, nil
+		}
+		token = result.NextContinuationToken
+	}
+}
+
+func (c *client) request(method string, key string, query url.Values, body io.Reader) (*http.Request, error) {
+	var u = *c.endpoint
+
+	if c.pathStyle {
+		u.Path = "/" + c.bucket + "/" + key
+	} else {
+		u.Host = c.bucket + "." + u.Host
+		u.Path = "/" + key
+	}
+	u.RawPath = encodePath(u.Path)
+	u.RawQuery = encodeQuery(query)
+
+	return http.NewRequest(method, u.String(), body)
+}
+
+func (c *client) do(req *http.Request, payloadHash string) (*http.Response, error) {
+	c.sign(req, payloadHash)
+	return c.http.Do(req)
+}
+
+// doShort sends a request that doesn't transfer object data. The whole request, including reading the
+// response body, has to finish within requestTimeout.
+func (c *client) doShort(method string, key string, query url.Values, body []byte) (*http.Response, error) {
+	req, err := c.request(method, key, query, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.ContentLength = int64(len(body))
+
+	ctx, cancel := context.WithTimeout(context.Background(), requestTimeout)
+	req = req.WithContext(ctx)
+
+	res, err := c.do(req, hexHash(body))
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+	res.Body = &cancelBody{ReadCloser: res.Body, cancel: cancel}
+
+	return res, nil
+}
+
+// cancelBody releases the context of a request once its response is closed
+type cancelBody struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+func (b *cancelBody) Close() error {
+	defer b.cancel()
+	return b.ReadCloser.Close()
+}
+
+// sign adds AWS Signature Version 4 headers to the request
+func (c *client) sign(req *http.Request, payloadHash string) {
+	var now = c.now().UTC()
+	var amzDate = now.Format(amzDateFormat)
+	var date = amzDate[:8]
+
+	req.Header.Set("X-Amz-Date", amzDate)
+	req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+
+	// collect headers to sign
+	var headers = map[string]string{"host": req.URL.Host}
+	for name, values := range req.Header {
+		var lname = strings.ToLower(name)
+		if lname == "range" || strings.HasPrefix(lname, "x-amz-") {
+			headers[lname] = strings.TrimSpace(strings.Join(values, ","))
+		}
+	}
+
+	var names = make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var canonicalHeaders strings.Builder
+	for _, name := range names {
+		canonicalHeaders.WriteString(name + ":" + headers[name] + "\n")
+	}
+	var signedHeaders = strings.Join(names, ";")
+
+	var canonicalRequest = strings.Join([]string{
+		req.Method,
+		req.URL.EscapedPath(),
+		req.URL.RawQuery,
+		canonicalHeaders.String(),
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+
+	var scope = date + "/" + c.region + "/s3/aws4_request"
+	var stringToSign = strings.Join([]string{
+		"AWS4-HMAC-SHA256",
+		amzDate,
+		scope,
+		hexHash([]byte(canonicalRequest)),
+	}, "\n")
+
+	var key = hmacSHA256([]byte("AWS4"+c.secretKey), date)
+	key = hmacSHA256(key, c.region)
+	key = hmacSHA256(key, "s3")
+	key = hmacSHA256(key, "aws4_request")
+
+	var signature = hex.EncodeToString(hmacSHA256(key, stringToSign))
+
+	req.Header.Set("Authorization", fmt.Sprintf(
+		"AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		c.accessKey, scope, signedHeaders, signature,
+	))
+}
+
+func statusError(res *http.Response) error {
+	if res.StatusCode == http.StatusNotFound {
+		return errNotFound
+	}
+	return fmt.Errorf("s3: %s", res.Status)
+}
+
+// encodePath escapes a path as required by S3, leaving slashes intact
+func encodePath(path string) string {
+	var parts = strings.Split(path, "/")
+	for i, part := range parts {
+		parts[i] = uriEncode(part)
+	}
+	return strings.Join(parts, "/")
+}
+
+// encodeQuery encodes a query with sorted keys, as required by the canonical request
+func encodeQuery(query url.Values) string {
+	var keys = make([]string, 0, len(query))
+	for k := range query {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var parts []string
+	for _, k := range keys {
+		for _, v := range query[k] {
+			parts = append(parts, uriEncode(k)+"="+uriEncode(v))
+		}
+	}
+	return strings.Join(parts, "&")
+}
+
+func uriEncode(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		var ch = s[i]
+		if ch >= 'A' && ch <= 'Z' || ch >= 'a' && ch <= 'z' || ch >= '0' && ch <= '9' ||
+			ch == '-' || ch == '_' || ch == '.' || ch == '~' {
+			b.WriteByte(ch)
+			continue
+		}
+		fmt.Fprintf(&b, "%%%02X", ch)
+	}
+	return b.String()
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	var mac = hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
+
+func hexHash(data []byte) string {
+	var sum = sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,22 @@
+package s3
+
+type Config struct {
+	// Endpoint is the URL of the S3 API, for example https://s3.amazonaws.com or http://localhost:9000.
+	// The module is disabled if it's empty.
+	Endpoint string `yaml:"endpoint"`
+
+	Region    string `yaml:"region"`
+	Bucket    string `yaml:"bucket"`
+	AccessKey string `yaml:"access_key"`
+	SecretKey string `yaml:"secret_key"`
+
+	// Prefix is prepended to the keys of stored objects
+	Prefix string `yaml:"prefix"`
+
+	// PathStyle puts the bucket name in the path instead of the host name, which is what MinIO expects
+	PathStyle bool `yaml:"path_style"`
+}
+
+var defaultConfig = Config{
+	Region: "us-east-1",
+}
@@ -0,0 +1,47 @@
+package s3
+
+import (
+	"github.com/cryptopunkscc/astrald/mod/admin"
+	"github.com/cryptopunkscc/astrald/mod/index"
+	"github.com/cryptopunkscc/astrald/mod/s3"
+	"github.com/cryptopunkscc/astrald/mod/storage"
+	"github.com/cryptopunkscc/astrald/node/modules"
+)
+
+func (mod *Module) LoadDependencies() error {
+	var err error
+
+	// the module stays idle until an endpoint is configured
+	if mod.client == nil {
+		return nil
+	}
+
+	mod.storage, err = modules.Load[storage.Module](mod.node, storage.ModuleName)
+	if err != nil {
+		return err
+	}
+
+	mod.index, err = modules.Load[index.Module](mod.node, index.ModuleName)
+	if err != nil {
+		return err
+	}
+
+	mod.storage.Data().AddReader(storeName, mod)
+	mod.storage.Data().AddStore(storeName, mod)
+
+	// inject admin command
+	if adm, err := modules.Load[admin.Module](mod.node, admin.ModuleName); err == nil {
+		adm.AddCommand(s3.ModuleName, NewAdmin(mod))
+	}
+
+	// create our index if needed
+	if _, err = mod.index.IndexInfo(s3.IndexName); err != nil {
+		_, err = mod.index.CreateIndex(s3.IndexName, index.TypeSet)
+		if err != nil {
+			return err
+		}
+		mod.index.AddToUnion(index.LocalNodeUnionName, s3.IndexName)
+	}
+
+	return nil
+}
@@ -0,0 +1,36 @@
+package s3
+
+import (
+	"github.com/cryptopunkscc/astrald/log"
+	"github.com/cryptopunkscc/astrald/mod/s3"
+	"github.com/cryptopunkscc/astrald/node/assets"
+	"github.com/cryptopunkscc/astrald/node/modules"
+)
+
+type Loader struct{}
+
+func (Loader) Load(node modules.Node, assets assets.Assets, log *log.Logger) (modules.Module, error) {
+	var err error
+	var mod = &Module{
+		node:   node,
+		log:    log,
+		config: defaultConfig,
+	}
+
+	_ = assets.LoadYAML(s3.ModuleName, &mod.config)
+
+	if mod.config.Endpoint != "" {
+		mod.client, err = newClient(mod.config)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return mod, nil
+}
+
+func init() {
+	if err := modules.RegisterModule(s3.ModuleName, Loader{}); err != nil {
+		panic(err)
+	}
+}
@@ -0,0 +1,179 @@
+package s3
+
+import (
+	"bytes"
+	"context"
+	"github.com/cryptopunkscc/astrald/data"
+	"github.com/cryptopunkscc/astrald/log"
+	"github.com/cryptopunkscc/astrald/mod/index"
+	"github.com/cryptopunkscc/astrald/mod/s3"
+	"github.com/cryptopunkscc/astrald/mod/storage"
+	"github.com/cryptopunkscc/astrald/node/modules"
+	"io"
+	"strings"
+	"time"
+)
+
+const storeName = "mod.s3"
+
+var _ s3.Module = &Module{}
+
+type Module struct {
+	node    modules.Node
+	config  Config
+	log     *log.Logger
+	client  *client
+	storage storage.Module
+	index   index.Module
+}
+
+func (mod *Module) Run(ctx context.Context) error {
+	if mod.client == nil {
+		<-ctx.Done()
+		return nil
+	}
+
+	if err := mod.Sync(); err != nil {
+		mod.log.Error("error syncing bucket %v: %v", mod.config.Bucket, err)
+	}
+
+	<-ctx.Done()
+	return nil
+}
+
+func (mod *Module) Store(opts *storage.StoreOpts) (storage.DataWriter, error) {
+	if mod.client == nil || int64(opts.Alloc) > maxObjectSize {
+		return nil, storage.ErrStorageUnavailable
+	}
+
+	return NewWriter(mod)
+}
+
+// Read reads an object from the bucket. Only objects listed in the index are looked up, so that missing
+// objects don't cost a request to the endpoint.
+func (mod *Module) Read(dataID data.ID, opts *storage.ReadOpts) (storage.DataReader, error) {
+	if opts == nil {
+		opts = &storage.ReadOpts{}
+	}
+
+	if mod.client == nil {
+		return nil, storage.ErrNotFound
+	}
+
+	if found, _ := mod.index.Contains(s3.IndexName, dataID); !found {
+		return nil, storage.ErrNotFound
+	}
+
+	if opts.Offset > dataID.Size {
+		return nil, storage.ErrInvalidOffset
+	}
+
+	// a range starting at the end of an object is not satisfiable
+	if opts.Offset == dataID.Size {
+		return &Reader{ReadCloser: io.NopCloser(bytes.NewReader(nil))}, nil
+	}
+
+	r, err := mod.client.get(mod.key(dataID), int64(opts.Offset))
+	switch {
+	case err == errNotFound:
+		mod.index.RemoveFromSet(s3.IndexName, dataID)
+		return nil, storage.ErrNotFound
+	case err != nil:
+		mod.log.Errorv(2, "error reading %v: %v", dataID, err)
+		return nil, storage.ErrNotFound
+	}
+
+	return &Reader{ReadCloser: r}, nil
+}
+
+// List returns IDs of all objects in the bucket under the configured prefix
+func (mod *Module) List() ([]data.ID, error) {
+	if mod.client == nil {
+		return nil, storage.ErrStorageUnavailable
+	}
+
+	objects, err := mod.client.list(mod.config.Prefix)
+	if err != nil {
+		return nil, err
+	}
+
+	var list = make([]data.ID, 0, len(objects))
+	for _, obj := range objects {
+		// skip objects that weren't stored by us
+		dataID, err := data.Parse(strings.TrimPrefix(obj.Key, mod.config.Prefix))
+		if err != nil || dataID.Size != uint64(obj.Size) {
+			continue
+		}
+		list = append(list, dataID)
+	}
+
+	return list, nil
+}
+
+func (mod *Module) Delete(dataID data.ID) error {
+	if mod.client == nil {
+		return storage.ErrStorageUnavailable
+	}
+
+	if err := mod.client.delete(mod.key(dataID)); err != nil {
+		if err == errNotFound {
+			return storage.ErrNotFound
+		}
+		return err
+	}
+
+	mod.index.RemoveFromSet(s3.IndexName, dataID)
+
+	return nil
+}
+
+// Sync adds objects found in the bucket to the index and removes the ones that are gone
+func (mod *Module) Sync() error {
+	if mod.client == nil {
+		return storage.ErrStorageUnavailable
+	}
+
+	list, err := mod.List()
+	if err != nil {
+		return err
+	}
+
+	var inBucket = map[data.ID]struct{}{}
+	var added, removed int
+
+	for _, dataID := range list {
+		inBucket[dataID] = struct{}{}
+
+		if found, _ := mod.index.Contains(s3.IndexName, dataID); found {
+			continue
+		}
+		if err := mod.index.AddToSet(s3.IndexName, dataID); err == nil {
+			added++
+		}
+	}
+
+	entries, err := mod.index.UpdatedSince(s3.IndexName, time.Time{})
+	if err != nil {
+		return err
+	}
+
+	for _, entry := range entries {
+		if !entry.Added {
+			continue
+		}
+		if _, found := inBucket[entry.DataID]; found {
+			continue
+		}
+		if err := mod.index.RemoveFromSet(s3.IndexName, entry.DataID); err == nil {
+			removed++
+		}
+	}
+
+	mod.log.Info("synced bucket %v: %v objects, %v added, %v removed", mod.config.Bucket, len(list), added, removed)
+
+	return nil
+}
+
+func (mod *Module) key(dataID data.ID) string {
+	return mod.config.Prefix + dataID.String()
+}
@@ -0,0 +1,16 @@
+package s3
+
+import (
+	"github.com/cryptopunkscc/astrald/mod/storage"
+	"io"
+)
+
+var _ storage.DataReader = &Reader{}
+
+type Reader struct {
+	io.ReadCloser
+}
+
+func (r *Reader) Info() *storage.ReaderInfo {
+	return &storage.ReaderInfo{Name: storeName}
+}
@@ -0,0 +1,84 @@
+package s3
+
+import (
+	"github.com/cryptopunkscc/astrald/data"
+	"github.com/cryptopunkscc/astrald/mod/s3"
+	"github.com/cryptopunkscc/astrald/mod/storage"
+	"os"
+)
+
+var _ storage.DataWriter = &Writer{}
+
+// Writer buffers written data in a temporary file and uploads it to the bucket on commit, once the ID
+// of the object is known. Large objects are uploaded in parts.
+type Writer struct {
+	mod      *Module
+	file     *os.File
+	resolver data.Resolver
+	size     int64
+}
+
+func NewWriter(mod *Module) (*Writer, error) {
+	file, err := os.CreateTemp("", "astrald-s3-*")
+	if err != nil {
+		return nil, err
+	}
+
+	return &Writer{
+		mod:      mod,
+		file:     file,
+		resolver: data.NewResolver(),
+	}, nil
+}
+
+func (w *Writer) Write(p []byte) (n int, err error) {
+	if w.file == nil {
+		return 0, storage.ErrStorageUnavailable
+	}
+
+	// fail early instead of after the object was written
+	if w.size+int64(len(p)) > maxObjectSize {
+		return 0, storage.ErrStorageUnavailable
+	}
+
+	n, err = w.file.Write(p)
+	w.resolver.Write(p[:n])
+	w.size += int64(n)
+
+	return
+}
+
+func (w *Writer) Commit() (data.ID, error) {
+	if w.file == nil {
+		return data.ID{}, storage.ErrStorageUnavailable
+	}
+	defer w.Discard()
+
+	var dataID = w.resolver.Resolve()
+	var key = w.mod.key(dataID)
+
+	// skip the upload if the bucket already has the object
+	if size, err := w.mod.client.head(key); err != nil || size != int64(dataID.Size) {
+		if err := w.mod.client.upload(key, w.file, int64(dataID.Size)); err != nil {
+			return data.ID{}, err
+		}
+	}
+
+	if err := w.mod.index.AddToSet(s3.IndexName, dataID); err != nil {
+		w.mod.log.Error("error adding %v to index: %v", dataID, err)
+	}
+
+	return dataID, nil
+}
+
+func (w *Writer) Discard() error {
+	if w.file == nil {
+		return nil
+	}
+
+	w.file.Close()
+	err := os.Remove(w.file.Name())
+	w.file = nil
+
+	return err
+}
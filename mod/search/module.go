@@ -0,0 +1,37 @@
+package search
+
+import (
+	"context"
+	"github.com/cryptopunkscc/astrald/auth/id"
+	"github.com/cryptopunkscc/astrald/data"
+)
+
+const ModuleName = "search"
+
+type Module interface {
+	// Search returns data matching the query. See src/README.md for the query syntax.
+	Search(query string, opts *SearchOpts) ([]Result, error)
+
+	// Index updates the searchable fields of the data
+	Index(ctx context.Context, dataID data.ID) error
+}
+
+type SearchOpts struct {
+	// Limit is the maximum number of results. Zero means no limit.
+	Limit int
+
+	// Offset is the number of results to skip
+	Offset int
+
+	// MaxScanned limits the number of indexed documents checked against the caller's access. Zero means
+	// no limit.
+	MaxScanned int
+
+	// Caller limits results to data the identity has access to. Zero means no limit.
+	Caller id.Identity
+}
+
+type Result struct {
+	DataID data.ID
+	Type   string
+}
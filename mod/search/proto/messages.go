@@ -0,0 +1,18 @@
+package proto
+
+import "github.com/cryptopunkscc/astrald/data"
+
+const (
+	// QueryServiceName runs a search and returns the results as a sequence of Result messages. Only data
+	// the caller has access to is returned.
+	QueryServiceName = "search.query"
+
+	ParamQuery  = "q"
+	ParamLimit  = "limit"
+	ParamOffset = "offset"
+)
+
+type Result struct {
+	DataID data.ID `cslq:"v"`
+	Type   string  `cslq:"[c]c"`
+}
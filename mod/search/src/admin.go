@@ -0,0 +1,99 @@
+package search
+
+import (
+	"errors"
+	"github.com/cryptopunkscc/astrald/data"
+	"github.com/cryptopunkscc/astrald/log"
+	"github.com/cryptopunkscc/astrald/mod/admin"
+	"github.com/cryptopunkscc/astrald/mod/search"
+	"strings"
+)
+
+type Admin struct {
+	mod  *Module
+	cmds map[string]func(admin.Terminal, []string) error
+}
+
+func NewAdmin(mod *Module) *Admin {
+	var adm = &Admin{mod: mod}
+	adm.cmds = map[string]func(admin.Terminal, []string) error{
+		"find":    adm.find,
+		"index":   adm.index,
+		"reindex": adm.reindex,
+		"help":    adm.help,
+	}
+
+	return adm
+}
+
+func (adm *Admin) Exec(term admin.Terminal, args []string) error {
+	if len(args) < 2 {
+		return adm.help(term, []string{})
+	}
+
+	cmd, args := args[1], args[2:]
+	if fn, found := adm.cmds[cmd]; found {
+		return fn(term, args)
+	}
+
+	return errors.New("unknown command")
+}
+
+func (adm *Admin) find(term admin.Terminal, args []string) error {
+	if len(args) < 1 {
+		return errors.New("missing argument")
+	}
+
+	results, err := adm.mod.Search(strings.Join(args, " "), &search.SearchOpts{})
+	if err != nil {
+		return err
+	}
+
+	var f = "%-64s %-10s %s\n"
+	term.Printf(f, admin.Header("DataID"), admin.Header("Size"), admin.Header("Type"))
+	for _, result := range results {
+		term.Printf(f, result.DataID, log.DataSize(result.DataID.Size).HumanReadable(), result.Type)
+	}
+
+	term.Printf("%d results\n", len(results))
+
+	return nil
+}
+
+func (adm *Admin) index(term admin.Terminal, args []string) error {
+	if len(args) < 1 {
+		return errors.New("missing argument")
+	}
+
+	dataID, err := data.Parse(args[0])
+	if err != nil {
+		return err
+	}
+
+	return adm.mod.Index(adm.mod.ctx, dataID)
+}
+
+func (adm *Admin) reindex(term admin.Terminal, _ []string) error {
+	count, err := adm.mod.Reindex(adm.mod.ctx)
+	if err != nil {
+		return err
+	}
+
+	term.Printf("indexed %d objects\n", count)
+
+	return nil
+}
+
+func (adm *Admin) ShortDescription() string {
+	return "search indexed data"
+}
+
+func (adm *Admin) help(term admin.Terminal, _ []string) error {
+	term.Printf("usage: %s <command>\n\n", search.ModuleName)
+	term.Printf("commands:\n")
+	term.Printf("  find <query>                  search data, e.g. find name:*.mkv size>1GB\n")
+	term.Printf("  index <dataID>                update search fields of data\n")
+	term.Printf("  reindex                       update search fields of all local data\n")
+	term.Printf("  help                          show help\n")
+	return nil
+}
@@ -0,0 +1,29 @@
+package search
+
+import "github.com/cryptopunkscc/astrald/node/router"
+
+type Config struct {
+	// MaxTextSize is the number of bytes of text data that get indexed
+	MaxTextSize int64 `yaml:"max_text_size"`
+
+	// MaxResults limits the number of results returned to other nodes
+	MaxResults int `yaml:"max_results"`
+
+	// MaxScanned limits the number of indexed documents checked against the access of other nodes in a single
+	// search
+	MaxScanned int `yaml:"max_scanned"`
+
+	// Limit applies to searches of every other node
+	Limit router.Limit `yaml:"limit"`
+}
+
+var defaultConfig = Config{
+	MaxTextSize: 1024 * 1024,
+	MaxResults:  100,
+	MaxScanned:  1000,
+	Limit: router.Limit{
+		QPS:      1,
+		Burst:    10,
+		Sessions: 4,
+	},
+}
@@ -0,0 +1,33 @@
+package search
+
+import (
+	"time"
+)
+
+// dbDocument is an indexed data object
+type dbDocument struct {
+	DataID    string `gorm:"primaryKey"`
+	Size      int64  `gorm:"index"`
+	Type      string
+	IndexedAt time.Time
+}
+
+func (dbDocument) TableName() string { return "documents" }
+
+// dbField holds a whole value of a field, used for exact and wildcard matches
+type dbField struct {
+	DataID string `gorm:"index"`
+	Field  string `gorm:"index:idx_field_value"`
+	Value  string `gorm:"index:idx_field_value"`
+}
+
+func (dbField) TableName() string { return "fields" }
+
+// dbTerm is a word found in a field
+type dbTerm struct {
+	Term   string `gorm:"index"`
+	Field  string
+	DataID string `gorm:"index"`
+}
+
+func (dbTerm) TableName() string { return "terms" }
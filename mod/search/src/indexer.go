@@ -0,0 +1,233 @@
+package search
+
+import (
+	"context"
+	"github.com/cryptopunkscc/astrald/data"
+	_data "github.com/cryptopunkscc/astrald/mod/data"
+	"github.com/cryptopunkscc/astrald/mod/fs"
+	"github.com/cryptopunkscc/astrald/mod/tar"
+	"github.com/cryptopunkscc/astrald/mod/zip"
+	"gorm.io/gorm"
+	"io"
+	"path"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+)
+
+const (
+	FieldType   = "type"
+	FieldLabel  = "label"
+	FieldPath   = "path"
+	FieldName   = "name"
+	FieldMember = "member"
+	FieldText   = "text"
+)
+
+const (
+	minTermLength = 2
+	maxTermLength = 64
+)
+
+// document collects the searchable fields of a data object
+type document struct {
+	dataID data.ID
+	typ    string
+	fields map[string][]string
+	terms  map[string]map[string]struct{}
+}
+
+func newDocument(dataID data.ID) *document {
+	return &document{
+		dataID: dataID,
+		fields: map[string][]string{},
+		terms:  map[string]map[string]struct{}{},
+	}
+}
+
+// add adds a value to the field and its words to the field's terms
+func (doc *document) add(field string, value string) {
+	if value == "" {
+		return
+	}
+	doc.fields[field] = append(doc.fields[field], strings.ToLower(value))
+	doc.addText(field, value)
+}
+
+// addText adds words of the text to the field's terms
+func (doc *document) addText(field string, text string) {
+	for _, term := range tokenize(text) {
+		if doc.terms[field] == nil {
+			doc.terms[field] = map[string]struct{}{}
+		}
+		doc.terms[field][term] = struct{}{}
+	}
+}
+
+func (doc *document) addPath(field string, p string) {
+	doc.add(field, p)
+	doc.fields[FieldName] = append(doc.fields[FieldName], strings.ToLower(path.Base(p)))
+}
+
+// Index extracts searchable fields from the descriptors of the data and replaces its previous entries
+func (mod *Module) Index(ctx context.Context, dataID data.ID) error {
+	var doc = newDocument(dataID)
+
+	for _, desc := range mod.data.DescribeData(ctx, dataID, nil) {
+		switch d := desc.Data.(type) {
+		case _data.TypeDescriptor:
+			doc.typ = baseType(d.Type)
+			doc.add(FieldType, doc.typ)
+
+		case _data.LabelDescriptor:
+			doc.add(FieldLabel, d.Label)
+
+		case fs.FileDescriptor:
+			for _, p := range d.Paths {
+				doc.addPath(FieldPath, p)
+			}
+
+		case zip.ArchiveDescriptor:
+			for _, file := range d.Files {
+				doc.add(FieldMember, file.Path)
+			}
+
+		case zip.MemberDescriptor:
+			for _, m := range d.Memberships {
+				doc.addPath(FieldPath, m.Path)
+			}
+
+		case tar.ArchiveDescriptor:
+			for _, file := range d.Files {
+				doc.add(FieldMember, file.Path)
+			}
+
+		case tar.MemberDescriptor:
+			for _, m := range d.Memberships {
+				doc.addPath(FieldPath, m.Path)
+			}
+		}
+	}
+
+	if strings.HasPrefix(doc.typ, "text/") {
+		if err := mod.indexText(doc); err != nil {
+			mod.log.Errorv(1, "error reading text of %v: %v", dataID, err)
+		}
+	}
+
+	return mod.save(doc)
+}
+
+func (mod *Module) indexText(doc *document) error {
+	r, err := mod.storage.Data().Read(doc.dataID, nil)
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+
+	b, err := io.ReadAll(io.LimitReader(r, mod.config.MaxTextSize))
+	if err != nil {
+		return err
+	}
+
+	// don't index a word cut in half by the limit
+	if int64(len(b)) == mod.config.MaxTextSize {
+		if i := strings.LastIndexFunc(string(b), unicode.IsSpace); i > 0 {
+			b = b[:i]
+		}
+	}
+
+	doc.addText(FieldText, string(b))
+
+	return nil
+}
+
+func (mod *Module) save(doc *document) error {
+	var key = doc.dataID.String()
+
+	return mod.db.Transaction(func(tx *gorm.DB) error {
+		if err := deleteDocument(tx, key); err != nil {
+			return err
+		}
+
+		var err = tx.Create(&dbDocument{
+			DataID:    key,
+			Size:      int64(doc.dataID.Size),
+			Type:      doc.typ,
+			IndexedAt: time.Now(),
+		}).Error
+		if err != nil {
+			return err
+		}
+
+		var fields []dbField
+		for field, values := range doc.fields {
+			for _, value := range values {
+				fields = append(fields, dbField{DataID: key, Field: field, Value: value})
+			}
+		}
+		if len(fields) > 0 {
+			if err = tx.CreateInBatches(fields, 100).Error; err != nil {
+				return err
+			}
+		}
+
+		var terms []dbTerm
+		for field, set := range doc.terms {
+			for term := range set {
+				terms = append(terms, dbTerm{Term: term, Field: field, DataID: key})
+			}
+		}
+		if len(terms) > 0 {
+			if err = tx.CreateInBatches(terms, 500).Error; err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
+
+// remove drops the data from the search index
+func (mod *Module) remove(dataID data.ID) error {
+	return mod.db.Transaction(func(tx *gorm.DB) error {
+		return deleteDocument(tx, dataID.String())
+	})
+}
+
+func deleteDocument(tx *gorm.DB, key string) error {
+	for _, model := range []any{&dbDocument{}, &dbField{}, &dbTerm{}} {
+		if err := tx.Where("data_id = ?", key).Delete(model).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// tokenize splits text into lowercase words made of letters and digits
+func tokenize(text string) (terms []string) {
+	if !utf8.ValidString(text) {
+		text = strings.ToValidUTF8(text, " ")
+	}
+
+	var words = strings.FieldsFunc(text, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+
+	for _, word := range words {
+		var n = utf8.RuneCountInString(word)
+		if n < minTermLength || n > maxTermLength {
+			continue
+		}
+		terms = append(terms, strings.ToLower(word))
+	}
+
+	return
+}
+
+// baseType strips parameters from a mime type
+func baseType(typ string) string {
+	typ, _, _ = strings.Cut(typ, ";")
+	return strings.TrimSpace(typ)
+}
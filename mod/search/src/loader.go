@@ -0,0 +1,42 @@
+package search
+
+import (
+	"github.com/cryptopunkscc/astrald/log"
+	"github.com/cryptopunkscc/astrald/mod/search"
+	"github.com/cryptopunkscc/astrald/node/assets"
+	"github.com/cryptopunkscc/astrald/node/modules"
+	"github.com/cryptopunkscc/astrald/node/router"
+)
+
+type Loader struct{}
+
+func (Loader) Load(node modules.Node, assets assets.Assets, log *log.Logger) (modules.Module, error) {
+	var err error
+	var mod = &Module{
+		node:   node,
+		log:    log,
+		config: defaultConfig,
+	}
+
+	_ = assets.LoadYAML(search.ModuleName, &mod.config)
+
+	mod.limiter = router.NewLimiter()
+	mod.limiter.SetDefaultLimit(mod.config.Limit)
+
+	mod.db, err = assets.OpenDB(search.ModuleName)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = mod.db.AutoMigrate(&dbDocument{}, &dbField{}, &dbTerm{}); err != nil {
+		return nil, err
+	}
+
+	return mod, nil
+}
+
+func init() {
+	if err := modules.RegisterModule(search.ModuleName, Loader{}); err != nil {
+		panic(err)
+	}
+}
@@ -0,0 +1,177 @@
+package search
+
+import (
+	"context"
+	"github.com/cryptopunkscc/astrald/data"
+	"github.com/cryptopunkscc/astrald/log"
+	_data "github.com/cryptopunkscc/astrald/mod/data"
+	"github.com/cryptopunkscc/astrald/mod/index"
+	"github.com/cryptopunkscc/astrald/mod/search"
+	"github.com/cryptopunkscc/astrald/mod/storage"
+	"github.com/cryptopunkscc/astrald/node"
+	"github.com/cryptopunkscc/astrald/node/events"
+	"github.com/cryptopunkscc/astrald/node/router"
+	"github.com/cryptopunkscc/astrald/tasks"
+	"gorm.io/gorm"
+	"time"
+)
+
+var _ search.Module = &Module{}
+
+// searchBatchSize is the number of documents read at once when results are filtered by access
+const searchBatchSize = 100
+
+type Module struct {
+	node    node.Node
+	config  Config
+	log     *log.Logger
+	db      *gorm.DB
+	ctx     context.Context
+	storage storage.Module
+	data    _data.Module
+	index   index.Module
+	limiter *router.Limiter
+}
+
+func (mod *Module) Run(ctx context.Context) error {
+	mod.ctx = ctx
+
+	return tasks.Group(
+		NewQueryService(mod),
+		events.Runner(mod.node.Events(), mod.onEntryUpdate),
+		events.Runner(mod.node.Events(), mod.onDataIdentified),
+	).Run(ctx)
+}
+
+// onEntryUpdate keeps the search index in line with data held by the node
+func (mod *Module) onEntryUpdate(ctx context.Context, event index.EventEntryUpdate) error {
+	if event.IndexName != index.LocalNodeUnionName {
+		return nil
+	}
+
+	var err error
+	if event.Added {
+		err = mod.Index(ctx, event.DataID)
+	} else {
+		err = mod.remove(event.DataID)
+	}
+	if err != nil {
+		mod.log.Errorv(1, "error indexing %v: %v", event.DataID, err)
+	}
+
+	return nil
+}
+
+// onDataIdentified reindexes data once its type is known
+func (mod *Module) onDataIdentified(ctx context.Context, event _data.EventDataIdentified) error {
+	if err := mod.Index(ctx, event.DataID); err != nil {
+		mod.log.Errorv(1, "error indexing %v: %v", event.DataID, err)
+	}
+	return nil
+}
+
+func (mod *Module) Search(query string, opts *search.SearchOpts) ([]search.Result, error) {
+	if opts == nil {
+		opts = &search.SearchOpts{}
+	}
+
+	conds, err := parseQuery(query)
+	if err != nil {
+		return nil, err
+	}
+
+	var tx = mod.db.Model(&dbDocument{}).Order("indexed_at desc, data_id")
+	for _, c := range conds {
+		tx = tx.Where(c.sql, c.args...)
+	}
+
+	// without access checks the database pages the results
+	if opts.Caller.IsZero() {
+		if opts.Limit > 0 {
+			tx = tx.Limit(opts.Limit)
+		}
+		if opts.Offset > 0 {
+			tx = tx.Offset(opts.Offset)
+		}
+
+		var rows []dbDocument
+		if err = tx.Find(&rows).Error; err != nil {
+			return nil, err
+		}
+
+		var results []search.Result
+		for _, row := range rows {
+			if dataID, err := data.Parse(row.DataID); err == nil {
+				results = append(results, search.Result{DataID: dataID, Type: row.Type})
+			}
+		}
+		return results, nil
+	}
+
+	// otherwise rows are read in batches until there are enough results the caller has access to
+	var results []search.Result
+	var skip = opts.Offset
+	tx = tx.Session(&gorm.Session{})
+
+	for scanned := 0; opts.MaxScanned <= 0 || scanned < opts.MaxScanned; scanned += searchBatchSize {
+		var batch = searchBatchSize
+		if opts.MaxScanned > 0 {
+			batch = min(batch, opts.MaxScanned-scanned)
+		}
+
+		var rows []dbDocument
+		if err = tx.Limit(batch).Offset(scanned).Find(&rows).Error; err != nil {
+			return nil, err
+		}
+
+		for _, row := range rows {
+			dataID, err := data.Parse(row.DataID)
+			if err != nil || !mod.storage.Access().Verify(opts.Caller, dataID) {
+				continue
+			}
+
+			if skip > 0 {
+				skip--
+				continue
+			}
+
+			results = append(results, search.Result{DataID: dataID, Type: row.Type})
+			if opts.Limit > 0 && len(results) >= opts.Limit {
+				return results, nil
+			}
+		}
+
+		if len(rows) < batch {
+			break
+		}
+	}
+
+	return results, nil
+}
+
+// Reindex indexes all data held by the node
+func (mod *Module) Reindex(ctx context.Context) (count int, err error) {
+	entries, err := mod.index.UpdatedSince(index.LocalNodeUnionName, time.Time{})
+	if err != nil {
+		return 0, err
+	}
+
+	for _, entry := range entries {
+		if ctx.Err() != nil {
+			return count, ctx.Err()
+		}
+
+		if !entry.Added {
+			mod.remove(entry.DataID)
+			continue
+		}
+
+		if err := mod.Index(ctx, entry.DataID); err != nil {
+			mod.log.Errorv(1, "error indexing %v: %v", entry.DataID, err)
+			continue
+		}
+		count++
+	}
+
+	return count, nil
+}
@@ -0,0 +1,199 @@
+package search
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+var valueFields = map[string]bool{
+	FieldType:   true,
+	FieldLabel:  true,
+	FieldPath:   true,
+	FieldName:   true,
+	FieldMember: true,
+}
+
+// condition is a single SQL condition on the documents table
+type condition struct {
+	sql  string
+	args []any
+}
+
+// parseQuery turns a query into conditions that all have to hold for a document to match
+func parseQuery(q string) ([]condition, error) {
+	words, err := splitQuery(q)
+	if err != nil {
+		return nil, err
+	}
+
+	var conds []condition
+
+	for _, word := range words {
+		var negate bool
+		if len(word) > 1 && word[0] == '-' {
+			negate = true
+			word = word[1:]
+		}
+
+		var wordConds []condition
+
+		switch field, value, found := strings.Cut(word, ":"); {
+		case strings.HasPrefix(word, "size") && len(word) > 4 && strings.ContainsRune("<>=", rune(word[4])):
+			c, err := sizeCondition(word[4:])
+			if err != nil {
+				return nil, err
+			}
+			wordConds = append(wordConds, c)
+
+		case found && valueFields[field]:
+			wordConds = append(wordConds, valueCondition(field, value))
+
+		case found && field == FieldText:
+			wordConds = append(wordConds, termConditions(FieldText, value)...)
+
+		default:
+			wordConds = append(wordConds, termConditions("", word)...)
+		}
+
+		for _, c := range wordConds {
+			if negate {
+				c.sql = "NOT (" + c.sql + ")"
+			}
+			conds = append(conds, c)
+		}
+	}
+
+	if len(conds) == 0 {
+		return nil, errors.New("empty query")
+	}
+
+	return conds, nil
+}
+
+// splitQuery splits the query into words. Double quotes keep spaces within a word.
+func splitQuery(q string) (words []string, err error) {
+	var word strings.Builder
+	var quoted, inWord bool
+
+	for _, r := range q {
+		switch {
+		case r == '"':
+			quoted = !quoted
+			inWord = true
+		case unicode.IsSpace(r) && !quoted:
+			if inWord {
+				words = append(words, word.String())
+				word.Reset()
+				inWord = false
+			}
+		default:
+			word.WriteRune(r)
+			inWord = true
+		}
+	}
+
+	if quoted {
+		return nil, errors.New("unterminated quote")
+	}
+	if inWord {
+		words = append(words, word.String())
+	}
+
+	return
+}
+
+// termConditions matches documents containing all words of the value in the field, or in any field if
+// the field is empty. A trailing asterisk matches words by prefix.
+func termConditions(field string, value string) (conds []condition) {
+	var prefix = strings.HasSuffix(value, "*")
+	var terms = tokenize(value)
+
+	for i, term := range terms {
+		var sql = "data_id IN (SELECT data_id FROM terms WHERE term = ?"
+		var args = []any{term}
+
+		if prefix && i == len(terms)-1 {
+			sql = "data_id IN (SELECT data_id FROM terms WHERE term LIKE ?"
+			args = []any{term + "%"}
+		}
+
+		if field != "" {
+			sql += " AND field = ?"
+			args = append(args, field)
+		}
+
+		conds = append(conds, condition{sql: sql + ")", args: args})
+	}
+
+	return
+}
+
+// valueCondition matches whole values of a field. Values with wildcards (* and ?) are matched as patterns.
+// A type without a subtype matches all its subtypes.
+func valueCondition(field string, value string) condition {
+	value = strings.ToLower(value)
+
+	if field == FieldType && !strings.Contains(value, "/") && !strings.ContainsAny(value, "*?") {
+		value += "/*"
+	}
+
+	if strings.ContainsAny(value, "*?") {
+		return condition{
+			sql:  "data_id IN (SELECT data_id FROM fields WHERE field = ? AND value GLOB ?)",
+			args: []any{field, value},
+		}
+	}
+
+	return condition{
+		sql:  "data_id IN (SELECT data_id FROM fields WHERE field = ? AND value = ?)",
+		args: []any{field, value},
+	}
+}
+
+func sizeCondition(expr string) (condition, error) {
+	var op string
+	for _, o := range []string{">=", "<=", ">", "<", "="} {
+		if strings.HasPrefix(expr, o) {
+			op = o
+			break
+		}
+	}
+
+	size, err := parseSize(expr[len(op):])
+	if err != nil {
+		return condition{}, err
+	}
+
+	return condition{sql: "size " + op + " ?", args: []any{size}}, nil
+}
+
+// parseSize parses sizes like 512, 64K, 1.5GB
+func parseSize(s string) (int64, error) {
+	var v = strings.TrimSuffix(strings.ToUpper(strings.TrimSpace(s)), "B")
+
+	var mul float64 = 1
+	if len(v) > 0 {
+		switch v[len(v)-1] {
+		case 'K':
+			mul = 1 << 10
+		case 'M':
+			mul = 1 << 20
+		case 'G':
+			mul = 1 << 30
+		case 'T':
+			mul = 1 << 40
+		}
+		if mul > 1 {
+			v = v[:len(v)-1]
+		}
+	}
+
+	f, err := strconv.ParseFloat(strings.TrimSpace(v), 64)
+	if err != nil || f < 0 {
+		return 0, errors.New("invalid size: " + s)
+	}
+
+	return int64(f * mul), nil
+}
@@ -0,0 +1,82 @@
+package search
+
+import (
+	"context"
+	"github.com/cryptopunkscc/astrald/cslq"
+	"github.com/cryptopunkscc/astrald/mod/search"
+	"github.com/cryptopunkscc/astrald/mod/search/proto"
+	"github.com/cryptopunkscc/astrald/net"
+	"strconv"
+)
+
+// QueryService runs searches for other nodes. Results are limited to data the caller has access to and every
+// caller is subject to the search limit.
+type QueryService struct {
+	*Module
+}
+
+func NewQueryService(mod *Module) *QueryService {
+	return &QueryService{Module: mod}
+}
+
+func (srv *QueryService) Run(ctx context.Context) error {
+	err := srv.node.LocalRouter().AddRoute(proto.QueryServiceName, srv)
+	if err != nil {
+		return err
+	}
+	defer srv.node.LocalRouter().RemoveRoute(proto.QueryServiceName)
+
+	<-ctx.Done()
+	return nil
+}
+
+func (srv *QueryService) RouteQuery(ctx context.Context, query net.Query, caller net.SecureWriteCloser, hints net.Hints) (net.SecureWriteCloser, error) {
+	var limit = srv.config.MaxResults
+	if p := query.Params().Get(proto.ParamLimit); p != "" {
+		l, err := strconv.Atoi(p)
+		if err != nil || l <= 0 {
+			return net.RejectWithReason(net.RejectCodeInvalidQuery, "invalid limit")
+		}
+		limit = min(limit, l)
+	}
+
+	var offset int
+	if p := query.Params().Get(proto.ParamOffset); p != "" {
+		o, err := strconv.Atoi(p)
+		if err != nil || o < 0 {
+			return net.RejectWithReason(net.RejectCodeInvalidQuery, "invalid offset")
+		}
+		offset = o
+	}
+
+	session, err := srv.limiter.Admit(query)
+	if err != nil {
+		return nil, err
+	}
+
+	results, err := srv.Search(query.Params().Get(proto.ParamQuery), &search.SearchOpts{
+		Limit:      limit,
+		Offset:     offset,
+		MaxScanned: srv.config.MaxScanned,
+		Caller:     query.Caller(),
+	})
+	if err != nil {
+		session.Done(0)
+		return net.RejectWithReason(net.RejectCodeInvalidQuery, err.Error())
+	}
+
+	return net.Accept(query, caller, func(conn net.SecureConn) {
+		defer session.Done(0)
+		defer conn.Close()
+
+		for _, result := range results {
+			err := cslq.Encode(conn, "v", &proto.Result{
+				DataID: result.DataID,
+				Type:   result.Type,
+			})
+			if err != nil {
+				return
+			}
+		}
+	})
+}
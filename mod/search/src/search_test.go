@@ -0,0 +1,121 @@
+package search
+
+import (
+	"github.com/cryptopunkscc/astrald/data"
+	"github.com/cryptopunkscc/astrald/mod/search"
+	"github.com/cryptopunkscc/astrald/node/assets"
+	"github.com/cryptopunkscc/astrald/resources"
+	"slices"
+	"testing"
+)
+
+func TestSearch(t *testing.T) {
+	db, err := assets.NewCoreAssets(resources.NewMemResources()).OpenDB(search.ModuleName)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = db.AutoMigrate(&dbDocument{}, &dbField{}, &dbTerm{}); err != nil {
+		t.Fatal(err)
+	}
+
+	var mod = &Module{db: db, config: defaultConfig}
+
+	var movie = data.ID{Size: 2 << 30, Hash: [32]byte{1}}
+	var invoice = data.ID{Size: 40 << 10, Hash: [32]byte{2}}
+	var notes = data.ID{Size: 300, Hash: [32]byte{3}}
+
+	var doc = newDocument(movie)
+	doc.typ = "video/x-matroska"
+	doc.add(FieldType, doc.typ)
+	doc.addPath(FieldPath, "/home/user/Movies/Big Buck Bunny.mkv")
+	mustSave(t, mod, doc)
+
+	doc = newDocument(invoice)
+	doc.typ = "application/pdf"
+	doc.add(FieldType, doc.typ)
+	doc.add(FieldLabel, "Invoice March")
+	doc.addPath(FieldPath, "/home/user/invoice-2024-03.pdf")
+	mustSave(t, mod, doc)
+
+	doc = newDocument(notes)
+	doc.typ = baseType("text/plain; charset=utf-8")
+	doc.add(FieldType, doc.typ)
+	doc.addPath(FieldPath, "/home/user/notes.txt")
+	doc.addText(FieldText, "Remember to pay the invoice before Friday.")
+	mustSave(t, mod, doc)
+
+	var tests = []struct {
+		query    string
+		expected []data.ID
+	}{
+		{`name:*.mkv size>1GB`, []data.ID{movie}},
+		{`name:*.mkv size<1GB`, nil},
+		{`type:application/pdf invoice`, []data.ID{invoice}},
+		{`invoice`, []data.ID{invoice, notes}},
+		{`text:invoice`, []data.ID{notes}},
+		{`invoice -type:text`, []data.ID{invoice}},
+		{`type:video`, []data.ID{movie}},
+		{`bun*`, []data.ID{movie}},
+		{`label:"invoice march"`, []data.ID{invoice}},
+		{`path:/home/user/*`, []data.ID{movie, invoice, notes}},
+		{`size<=300`, []data.ID{notes}},
+	}
+
+	for _, test := range tests {
+		results, err := mod.Search(test.query, nil)
+		if err != nil {
+			t.Fatalf("%s: %v", test.query, err)
+		}
+
+		var found []data.ID
+		for _, r := range results {
+			found = append(found, r.DataID)
+		}
+
+		if len(found) != len(test.expected) {
+			t.Fatalf("%s: expected %v results, got %v", test.query, len(test.expected), len(found))
+		}
+		for _, dataID := range test.expected {
+			if !slices.Contains(found, dataID) {
+				t.Fatalf("%s: %v missing from results", test.query, dataID)
+			}
+		}
+	}
+
+	// pagination
+	var pages = []struct {
+		limit, offset, expected int
+	}{
+		{2, 0, 2},
+		{2, 2, 1},
+		{0, 1, 2},
+	}
+	for _, page := range pages {
+		results, err := mod.Search(`path:/home/user/*`, &search.SearchOpts{Limit: page.limit, Offset: page.offset})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(results) != page.expected {
+			t.Fatalf("limit %d offset %d: expected %d results, got %d",
+				page.limit, page.offset, page.expected, len(results))
+		}
+	}
+
+	// reindexing replaces old entries
+	if err = mod.remove(movie); err != nil {
+		t.Fatal(err)
+	}
+	if results, _ := mod.Search(`bunny`, nil); len(results) != 0 {
+		t.Fatal("removed data still found")
+	}
+
+	if _, err = mod.Search(`"unterminated`, nil); err == nil {
+		t.Fatal("expected an error for an invalid query")
+	}
+}
+
+func mustSave(t *testing.T, mod *Module, doc *document) {
+	if err := mod.save(doc); err != nil {
+		t.Fatal(err)
+	}
+}
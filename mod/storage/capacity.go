@@ -0,0 +1,29 @@
+package storage
+
+// CapacityReporter is implemented by stores that know how much data they can hold
+type CapacityReporter interface {
+	Capacity() (*Capacity, error)
+}
+
+// SizeLimiter is implemented by stores that only take objects up to a certain size. They are skipped for
+// larger objects and objects of unknown size, and preferred for objects that fit.
+type SizeLimiter interface {
+	MaxObjectSize() int
+}
+
+type Capacity struct {
+	Total uint64 // total size of the store, limited by its quota
+	Used  uint64 // bytes used by stored objects
+	Free  uint64 // bytes that can still be stored
+}
+
+type StoreCapacity struct {
+	Name string
+	Capacity
+}
+
+// CapacityReport sums the capacity of all stores that report it
+type CapacityReport struct {
+	Capacity
+	Stores []StoreCapacity
+}
@@ -13,6 +13,18 @@ type DataManager interface {
 	AddStore(name string, store Store) error
 	RemoveReader(name string) error
 	RemoveStore(name string) error
+
+	// Capacity returns the capacity of all stores
+	Capacity() *CapacityReport
+
+	// Manifest returns the manifest of a data object stored in chunks
+	Manifest(dataID data.ID) (*Manifest, error)
+
+	// HashTree returns the hash tree of a locally stored data object. The tree is built on first use.
+	HashTree(dataID data.ID) (*data.HashTree, error)
+
+	// ResolveTreeID returns the ID of a locally stored data object with the given TreeID
+	ResolveTreeID(treeID data.TreeID) (data.ID, error)
 }
 
 type Reader interface {
@@ -45,5 +57,7 @@ type ReadOpts struct {
 }
 
 type StoreOpts struct {
+	// Alloc is the expected size of the object. Stores without enough free space for it are skipped
+	// before writing begins.
 	Alloc int
 }
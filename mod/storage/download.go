@@ -0,0 +1,16 @@
+package storage
+
+import (
+	"github.com/cryptopunkscc/astrald/auth/id"
+	"github.com/cryptopunkscc/astrald/data"
+)
+
+type DownloadOpts struct {
+	// TreeID of the object. If set, every block is verified against the tree as it arrives, so that a source
+	// serving bad data only loses its own parts. Otherwise the object is only verified once it's complete.
+	TreeID *data.TreeID
+
+	// Sources to download from. If empty, nodes whose mirrored indexes list the object and linked nodes
+	// are asked whether they hold it.
+	Sources []id.Identity
+}
@@ -8,3 +8,5 @@ var ErrInvalidOffset = errors.New("invalid offset")
 var ErrStorageUnavailable = errors.New("storage unavailable")
 var ErrNoVirtual = errors.New("virtual source excluded")
 var ErrAlreadyExists = errors.New("already exists")
+var ErrInvalidManifest = errors.New("invalid manifest")
+var ErrNoSources = errors.New("no sources")
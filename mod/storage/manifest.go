@@ -0,0 +1,12 @@
+package storage
+
+import "github.com/cryptopunkscc/astrald/data"
+
+// ManifestType is the ADC0 type of stored manifests
+const ManifestType = "mod.storage.manifest"
+
+// Manifest lists the chunks of a data object in order. The data object is the concatenation of its chunks.
+type Manifest struct {
+	DataID data.ID   `cslq:"v"`
+	Chunks []data.ID `cslq:"[l]v"`
+}
@@ -1,6 +1,9 @@
 package storage
 
 import (
+	"context"
+	"github.com/cryptopunkscc/astrald/auth/id"
+	"github.com/cryptopunkscc/astrald/data"
 	"github.com/cryptopunkscc/astrald/node/events"
 )
 
@@ -10,4 +13,18 @@ type Module interface {
 	Events() *events.Queue
 	Access() AccessManager
 	Data() DataManager
+	Pins() PinManager
+
+	// GC removes data objects that are not in use from stores that support listing and deleting
+	GC(opts *GCOpts) (*GCReport, error)
+
+	// Fetch copies a data object from a remote node to local storage. Chunked objects are fetched chunk
+	// by chunk, skipping chunks that are already stored locally.
+	Fetch(ctx context.Context, source id.Identity, dataID data.ID) error
+
+	// Download fetches a data object in parallel from several nodes that hold it and pins it with the holder
+	Download(ctx context.Context, dataID data.ID, holder string, opts *DownloadOpts) error
+
+	// UnderReplicated returns objects that have fewer copies across replica nodes than their policy requires
+	UnderReplicated() []ReplicaStatus
 }
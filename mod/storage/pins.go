@@ -0,0 +1,50 @@
+package storage
+
+import (
+	"github.com/cryptopunkscc/astrald/data"
+	"time"
+)
+
+// PinManager tracks which data objects are still in use. An object is in use while it has at least one pin
+// or any of the reference checkers reports it as referenced. Objects not in use are removed by the garbage
+// collector.
+type PinManager interface {
+	ReferenceChecker
+	Pin(dataID data.ID, holder string) error
+	Unpin(dataID data.ID, holder string) error
+	Holders(dataID data.ID) ([]string, error)
+	AddReferenceChecker(checker ReferenceChecker)
+	RemoveReferenceChecker(checker ReferenceChecker)
+}
+
+// DownloadHolder is the pin holder of objects downloaded on request of the node's operator
+const DownloadHolder = "storage.download"
+
+// ReferenceChecker reports whether an object is referenced. Objects are never collected when the check fails.
+type ReferenceChecker interface {
+	IsReferenced(dataID data.ID) (bool, error)
+}
+
+// Lister is implemented by stores that can list the objects they hold
+type Lister interface {
+	List() ([]data.ID, error)
+}
+
+// Deleter is implemented by stores that can remove objects
+type Deleter interface {
+	Delete(dataID data.ID) error
+}
+
+type GCOpts struct {
+	// DryRun only reports what would be removed
+	DryRun bool
+}
+
+type GCReport struct {
+	Scanned    int
+	Failed     int // objects kept because checking whether they're in use failed
+	Freed      []data.ID
+	FreedBytes uint64
+	Duration   time.Duration
+	DryRun     bool
+}
@@ -0,0 +1,12 @@
+package proto
+
+import (
+	"github.com/cryptopunkscc/astrald/cslq/rpc"
+)
+
+var es rpc.ErrorSpace
+
+var (
+	ErrInvalidOffset = es.NewError(0x01, "invalid offset")
+	ErrReadFailed    = es.NewError(0x02, "read failed")
+)
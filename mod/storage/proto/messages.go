@@ -0,0 +1,47 @@
+package proto
+
+// Services that take a data object accept either its data ID or its TreeID. Objects can be found by their TreeID
+// only if the node knows their hash tree.
+const (
+	// ReadServiceName streams a data object. Optional offset and length query parameters limit the stream
+	// to a single range.
+	ReadServiceName = "storage.read"
+
+	// ReadAtServiceName opens a session in which the caller can read any number of ranges of a data object
+	ReadAtServiceName = "storage.readat"
+
+	// ManifestServiceName returns the manifest of a chunked data object
+	ManifestServiceName = "storage.manifest"
+
+	// TreeServiceName returns the hash tree of a data object
+	TreeServiceName = "storage.tree"
+
+	// HasServiceName accepts the query if the node holds the data object
+	HasServiceName = "storage.has"
+
+	// ReplicateServiceName asks a replica node to download a data object from the caller. The optional tree
+	// parameter carries the TreeID of the object. The node responds with a single byte once done, ReplicateOK
+	// if it holds the object.
+	ReplicateServiceName = "storage.replicate"
+
+	ReplicateOK = 0
+
+	ParamOffset = "offset"
+	ParamLength = "length"
+	ParamTree   = "tree"
+
+	// MaxReadAtLength is the maximum number of bytes returned for a single range request
+	MaxReadAtLength = 4 * 1024 * 1024
+)
+
+// ReadAtRequest asks for a range of the data object
+type ReadAtRequest struct {
+	Offset uint64 `cslq:"q"`
+	Length uint32 `cslq:"l"`
+}
+
+// ReadAtResponse precedes the returned bytes. Length is less than requested if the range goes past the end of
+// the data object or exceeds MaxReadAtLength.
+type ReadAtResponse struct {
+	Length uint32 `cslq:"l"`
+}
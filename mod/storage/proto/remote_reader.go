@@ -0,0 +1,176 @@
+package proto
+
+import (
+	"errors"
+	"github.com/cryptopunkscc/astrald/data"
+	"io"
+	"sync"
+)
+
+var _ io.ReaderAt = &RemoteReader{}
+var _ io.ReadSeekCloser = &RemoteReader{}
+
+// RemoteReader reads a data object over a storage.readat session
+type RemoteReader struct {
+	mu      sync.Mutex
+	conn    io.ReadWriteCloser
+	session Session
+	size    uint64
+	pos     int64
+	tree    *data.HashTree
+	block   []byte
+	index   int
+}
+
+// NewRemoteReader returns a reader of a data object of the given size using conn as a storage.readat session
+func NewRemoteReader(conn io.ReadWriteCloser, size uint64) *RemoteReader {
+	return &RemoteReader{
+		conn:    conn,
+		session: NewSession(conn),
+		size:    size,
+	}
+}
+
+// NewVerifiedRemoteReader returns a reader that verifies every block of the data object against the tree before
+// returning any of its bytes. Ranges are fetched in whole blocks, and the last block read is kept for
+// subsequent reads.
+func NewVerifiedRemoteReader(conn io.ReadWriteCloser, tree *data.HashTree) *RemoteReader {
+	var r = NewRemoteReader(conn, tree.Size)
+	r.tree = tree
+	return r
+}
+
+// ReadAt reads len(p) bytes starting at off. It returns io.EOF if the data object ends before p is filled.
+func (r *RemoteReader) ReadAt(p []byte, off int64) (n int, err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.readAt(p, off)
+}
+
+func (r *RemoteReader) Read(p []byte) (n int, err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	n, err = r.readAt(p, r.pos)
+	r.pos += int64(n)
+	if n > 0 && err == io.EOF {
+		err = nil
+	}
+	return
+}
+
+func (r *RemoteReader) Seek(offset int64, whence int) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	switch whence {
+	case io.SeekStart:
+	case io.SeekCurrent:
+		offset += r.pos
+	case io.SeekEnd:
+		offset += int64(r.size)
+	default:
+		return 0, errors.New("invalid whence")
+	}
+
+	if offset < 0 {
+		return 0, ErrInvalidOffset
+	}
+
+	r.pos = offset
+	return offset, nil
+}
+
+// Size returns the size of the data object
+func (r *RemoteReader) Size() uint64 {
+	return r.size
+}
+
+func (r *RemoteReader) Close() error {
+	return r.conn.Close()
+}
+
+func (r *RemoteReader) readAt(p []byte, off int64) (n int, err error) {
+	if off < 0 {
+		return 0, ErrInvalidOffset
+	}
+
+	if r.tree != nil {
+		return r.readVerifiedAt(p, off)
+	}
+
+	for n < len(p) {
+		var pos = uint64(off) + uint64(n)
+		if pos >= r.size {
+			return n, io.EOF
+		}
+
+		var length = uint32(min(len(p)-n, MaxReadAtLength))
+
+		l, err := r.session.ReadAt(pos, length)
+		if err != nil {
+			return n, err
+		}
+		if l == 0 || l > length {
+			return n, io.ErrUnexpectedEOF
+		}
+
+		m, err := io.ReadFull(r.conn, p[n:n+int(l)])
+		n += m
+		if err != nil {
+			return n, err
+		}
+	}
+
+	return n, nil
+}
+
+func (r *RemoteReader) readVerifiedAt(p []byte, off int64) (n int, err error) {
+	for n < len(p) {
+		var pos = uint64(off) + uint64(n)
+		if pos >= r.size {
+			return n, io.EOF
+		}
+
+		var index = int(pos / data.TreeBlockSize)
+		if err = r.readBlock(index); err != nil {
+			return n, err
+		}
+
+		n += copy(p[n:], r.block[pos-data.AlignOffset(pos):])
+	}
+
+	return n, nil
+}
+
+// readBlock fetches and verifies a block unless it's the last block read
+func (r *RemoteReader) readBlock(index int) error {
+	if r.block != nil && r.index == index {
+		return nil
+	}
+	r.block = nil
+
+	var offset = uint64(index) * data.TreeBlockSize
+	var length = min(data.TreeBlockSize, r.size-offset)
+
+	l, err := r.session.ReadAt(offset, uint32(length))
+	if err != nil {
+		return err
+	}
+	if uint64(l) != length {
+		return io.ErrUnexpectedEOF
+	}
+
+	var block = make([]byte, length)
+	if _, err = io.ReadFull(r.conn, block); err != nil {
+		return err
+	}
+
+	if err = r.tree.VerifyBlock(index, block); err != nil {
+		return err
+	}
+
+	r.block, r.index = block, index
+	return nil
+}
@@ -0,0 +1,120 @@
+package proto
+
+import (
+	"bytes"
+	"errors"
+	_data "github.com/cryptopunkscc/astrald/data"
+	"io"
+	"math/rand"
+	"net"
+	"testing"
+)
+
+// serveRanges is a minimal storage.readat server
+func serveRanges(conn net.Conn, data []byte) {
+	defer conn.Close()
+
+	var s = NewSession(conn)
+	for {
+		var req ReadAtRequest
+		if err := s.Decode(&req); err != nil {
+			return
+		}
+
+		if req.Offset > uint64(len(data)) {
+			s.EncodeErr(ErrInvalidOffset)
+			continue
+		}
+
+		var length = min(uint64(req.Length), uint64(len(data))-req.Offset, MaxReadAtLength)
+		s.EncodeErr(nil)
+		s.Encode(ReadAtResponse{Length: uint32(length)})
+		conn.Write(data[req.Offset : req.Offset+length])
+	}
+}
+
+func TestRemoteReader(t *testing.T) {
+	var data = make([]byte, MaxReadAtLength+1000)
+	rand.Read(data)
+
+	client, server := net.Pipe()
+	go serveRanges(server, data)
+
+	var r = NewRemoteReader(client, uint64(len(data)))
+	defer r.Close()
+
+	// a range spanning several requests
+	var buf = make([]byte, MaxReadAtLength+10)
+	n, err := r.ReadAt(buf, 500)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(buf[:n], data[500:500+len(buf)]) {
+		t.Fatal("data mismatch")
+	}
+
+	// a range past the end
+	n, err = r.ReadAt(buf[:100], int64(len(data)-10))
+	if err != io.EOF || n != 10 {
+		t.Fatalf("expected 10 bytes and EOF, got %d, %v", n, err)
+	}
+	if !bytes.Equal(buf[:n], data[len(data)-10:]) {
+		t.Fatal("data mismatch")
+	}
+
+	// seek and read the rest
+	if _, err = r.Seek(-2000, io.SeekEnd); err != nil {
+		t.Fatal(err)
+	}
+	rest, err := io.ReadAll(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(rest, data[len(data)-2000:]) {
+		t.Fatal("data mismatch")
+	}
+}
+
+func TestVerifiedRemoteReader(t *testing.T) {
+	var content = make([]byte, 3*_data.TreeBlockSize+100)
+	rand.Read(content)
+
+	_, tree, err := _data.ResolveTree(bytes.NewReader(content))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	client, server := net.Pipe()
+	go serveRanges(server, content)
+
+	var r = NewVerifiedRemoteReader(client, tree)
+	defer r.Close()
+
+	// a range spanning a block boundary
+	var buf = make([]byte, 1000)
+	n, err := r.ReadAt(buf, _data.TreeBlockSize-500)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(buf[:n], content[_data.TreeBlockSize-500:_data.TreeBlockSize+500]) {
+		t.Fatal("data mismatch")
+	}
+
+	// a corrupted block fails without returning its data
+	var corrupted = bytes.Clone(content)
+	corrupted[2*_data.TreeBlockSize+1] ^= 0xff
+
+	client, server = net.Pipe()
+	go serveRanges(server, corrupted)
+
+	r = NewVerifiedRemoteReader(client, tree)
+	defer r.Close()
+
+	n, err = r.ReadAt(buf, 2*_data.TreeBlockSize-10)
+	if !errors.Is(err, _data.ErrBlockMismatch) {
+		t.Fatalf("expected block mismatch, got %v", err)
+	}
+	if n != 10 {
+		t.Fatalf("expected 10 verified bytes, got %d", n)
+	}
+}
@@ -0,0 +1,32 @@
+package proto
+
+import (
+	"github.com/cryptopunkscc/astrald/cslq/rpc"
+	"io"
+)
+
+type Session struct {
+	*rpc.Session[string]
+}
+
+func NewSession(c io.ReadWriter) Session {
+	return Session{rpc.NewSession[string](c, es)}
+}
+
+// ReadAt requests a range of the data object and returns the number of bytes that follow in the stream
+func (s Session) ReadAt(offset uint64, length uint32) (uint32, error) {
+	if err := s.Encode(ReadAtRequest{Offset: offset, Length: length}); err != nil {
+		return 0, err
+	}
+
+	if err := s.DecodeErr(); err != nil {
+		return 0, err
+	}
+
+	var response ReadAtResponse
+	if err := s.Decode(&response); err != nil {
+		return 0, err
+	}
+
+	return response.Length, nil
+}
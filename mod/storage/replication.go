@@ -0,0 +1,19 @@
+package storage
+
+import (
+	"github.com/cryptopunkscc/astrald/auth/id"
+	"github.com/cryptopunkscc/astrald/data"
+	"time"
+)
+
+// ReplicaHolder is the pin holder of copies stored on behalf of replication
+const ReplicaHolder = "storage.replica"
+
+// ReplicaStatus describes a data object that has fewer copies than its replication policy requires
+type ReplicaStatus struct {
+	DataID    data.ID
+	Copies    int
+	Target    int
+	Holders   []id.Identity
+	CheckedAt time.Time
+}
@@ -2,6 +2,7 @@ package storage
 
 import (
 	"errors"
+	"fmt"
 	"github.com/cryptopunkscc/astrald/data"
 	"github.com/cryptopunkscc/astrald/log"
 	"github.com/cryptopunkscc/astrald/mod/admin"
@@ -12,11 +13,13 @@ import (
 	"reflect"
 	"regexp"
 	"slices"
+	"strconv"
 	"strings"
 	"time"
 )
 
 const defaultAccessDuration = time.Hour * 24 * 365 * 100 // 100 years
+const adminPinHolder = "admin"
 
 type Admin struct {
 	mod  *Module
@@ -26,10 +29,20 @@ type Admin struct {
 func NewAdmin(mod *Module) *Admin {
 	var adm = &Admin{mod: mod}
 	adm.cmds = map[string]func(admin.Terminal, []string) error{
-		"read": adm.read,
-		"get":  adm.get,
-		"info": adm.info,
-		"help": adm.help,
+		"read":     adm.read,
+		"get":      adm.get,
+		"info":     adm.info,
+		"fetch":    adm.fetch,
+		"download": adm.download,
+		"manifest": adm.manifest,
+		"tree":     adm.tree,
+		"pin":      adm.pin,
+		"unpin":    adm.unpin,
+		"pins":     adm.pins,
+		"gc":       adm.gc,
+		"encrypt":  adm.encrypt,
+		"replicas": adm.replicas,
+		"help":     adm.help,
 	}
 
 	return adm
@@ -148,6 +161,236 @@ func (adm *Admin) get(term admin.Terminal, args []string) error {
 	return nil
 }
 
+func (adm *Admin) fetch(term admin.Terminal, args []string) error {
+	if len(args) < 2 {
+		return errors.New("argument missing")
+	}
+
+	source, err := adm.mod.node.Resolver().Resolve(args[0])
+	if err != nil {
+		return err
+	}
+
+	dataID, err := data.Parse(args[1])
+	if err != nil {
+		return err
+	}
+
+	term.Printf("fetching %v from %v...\n", dataID, source)
+
+	err = adm.mod.Fetch(adm.mod.ctx, source, dataID)
+	if err != nil {
+		return err
+	}
+
+	term.Printf("stored %v (%s)\n", dataID, log.DataSize(dataID.Size))
+
+	return nil
+}
+
+func (adm *Admin) download(term admin.Terminal, args []string) error {
+	if len(args) < 1 {
+		return errors.New("argument missing")
+	}
+
+	dataID, err := data.Parse(args[0])
+	if err != nil {
+		return err
+	}
+	args = args[1:]
+
+	var opts = &storage.DownloadOpts{}
+	if len(args) > 0 {
+		if treeID, err := data.ParseTreeID(args[0]); err == nil {
+			opts.TreeID = &treeID
+			args = args[1:]
+		}
+	}
+
+	for _, name := range args {
+		source, err := adm.mod.node.Resolver().Resolve(name)
+		if err != nil {
+			return err
+		}
+		opts.Sources = append(opts.Sources, source)
+	}
+
+	term.Printf("downloading %v...\n", dataID)
+
+	var startedAt = time.Now()
+
+	err = adm.mod.Download(adm.mod.ctx, dataID, storage.DownloadHolder, opts)
+	if err != nil {
+		return err
+	}
+
+	term.Printf("stored %v (%s) in %v\n", dataID, log.DataSize(dataID.Size), time.Since(startedAt).Round(time.Millisecond))
+
+	return nil
+}
+
+func (adm *Admin) tree(term admin.Terminal, args []string) error {
+	if len(args) < 1 {
+		return errors.New("argument missing")
+	}
+
+	dataID, err := data.Parse(args[0])
+	if err != nil {
+		return err
+	}
+
+	tree, err := adm.mod.Data().HashTree(dataID)
+	if err != nil {
+		return err
+	}
+
+	term.Printf("%v\n", tree.ID())
+
+	return nil
+}
+
+func (adm *Admin) manifest(term admin.Terminal, args []string) error {
+	if len(args) < 1 {
+		return errors.New("argument missing")
+	}
+
+	dataID, err := data.Parse(args[0])
+	if err != nil {
+		return err
+	}
+
+	manifest, err := adm.mod.Data().Manifest(dataID)
+	if err != nil {
+		return err
+	}
+
+	var f = "%-6s %-64s %s\n"
+	term.Printf(f, admin.Header("#"), admin.Header("Chunk"), admin.Header("Size"))
+	for i, chunk := range manifest.Chunks {
+		term.Printf(f, strconv.Itoa(i), chunk, log.DataSize(chunk.Size))
+	}
+	term.Printf("%d chunks\n", len(manifest.Chunks))
+
+	return nil
+}
+
+func (adm *Admin) pin(term admin.Terminal, args []string) error {
+	if len(args) < 1 {
+		return errors.New("argument missing")
+	}
+
+	dataID, err := data.Parse(args[0])
+	if err != nil {
+		return err
+	}
+
+	var holder = adminPinHolder
+	if len(args) >= 2 {
+		holder = args[1]
+	}
+
+	return adm.mod.Pins().Pin(dataID, holder)
+}
+
+func (adm *Admin) unpin(term admin.Terminal, args []string) error {
+	if len(args) < 1 {
+		return errors.New("argument missing")
+	}
+
+	dataID, err := data.Parse(args[0])
+	if err != nil {
+		return err
+	}
+
+	var holder = adminPinHolder
+	if len(args) >= 2 {
+		holder = args[1]
+	}
+
+	return adm.mod.Pins().Unpin(dataID, holder)
+}
+
+func (adm *Admin) pins(term admin.Terminal, args []string) error {
+	if len(args) < 1 {
+		return errors.New("argument missing")
+	}
+
+	dataID, err := data.Parse(args[0])
+	if err != nil {
+		return err
+	}
+
+	holders, err := adm.mod.Pins().Holders(dataID)
+	if err != nil {
+		return err
+	}
+	for _, holder := range holders {
+		term.Printf("%s\n", holder)
+	}
+
+	inUse, err := adm.mod.inUse(dataID)
+	if err != nil {
+		return err
+	}
+
+	term.Printf("%d pins, referenced: %v\n", len(holders), inUse)
+
+	return nil
+}
+
+func (adm *Admin) gc(term admin.Terminal, args []string) error {
+	var opts = &storage.GCOpts{}
+
+	for _, arg := range args {
+		switch arg {
+		case "-n", "--dry-run":
+			opts.DryRun = true
+		default:
+			return errors.New("unknown option: " + arg)
+		}
+	}
+
+	report, err := adm.mod.GC(opts)
+	if err != nil {
+		return err
+	}
+
+	for _, dataID := range report.Freed {
+		term.Printf("%v %s\n", dataID, log.DataSize(dataID.Size))
+	}
+
+	var verb = "freed"
+	if report.DryRun {
+		verb = "would free"
+	}
+
+	term.Printf("scanned %d objects, %s %s in %d objects (%v)\n",
+		report.Scanned,
+		verb,
+		log.DataSize(report.FreedBytes),
+		len(report.Freed),
+		report.Duration.Round(time.Millisecond),
+	)
+
+	if report.Failed > 0 {
+		term.Printf("kept %d objects that couldn't be checked\n", report.Failed)
+	}
+
+	return nil
+}
+
+func (adm *Admin) encrypt(term admin.Terminal, args []string) error {
+	if adm.mod.data.encrypted == nil {
+		return errors.New("encryption is disabled")
+	}
+
+	count, size, err := adm.mod.data.encrypted.encryptStored()
+
+	term.Printf("encrypted %s in %d objects\n", log.DataSize(size), count)
+
+	return err
+}
+
 func (adm *Admin) info(term admin.Terminal, args []string) error {
 	var f = "%-32s %s\n"
 	var names []string
@@ -180,6 +423,35 @@ func (adm *Admin) info(term admin.Terminal, args []string) error {
 	}
 	term.Println()
 
+	// show capacity
+	var c = adm.mod.data.Capacity()
+	var cf = "%-32s %10s %10s %10s\n"
+
+	term.Printf(cf, admin.Header("Capacity"), admin.Header("Used"), admin.Header("Free"), admin.Header("Total"))
+	for _, store := range c.Stores {
+		term.Printf(cf, store.Name, log.DataSize(store.Used), log.DataSize(store.Free), log.DataSize(store.Total))
+	}
+	term.Printf(cf, "total", log.DataSize(c.Used), log.DataSize(c.Free), log.DataSize(c.Total))
+
+	return nil
+}
+
+func (adm *Admin) replicas(term admin.Terminal, _ []string) error {
+	var list = adm.mod.UnderReplicated()
+
+	var f = "%-64s %-8s %-20s %s\n"
+	term.Printf(f, admin.Header("DataID"), admin.Header("Copies"), admin.Header("Checked"), admin.Header("Holders"))
+	for _, s := range list {
+		var holders []string
+		for _, h := range s.Holders {
+			holders = append(holders, adm.mod.node.Resolver().DisplayName(h))
+		}
+
+		term.Printf(f, s.DataID, fmt.Sprintf("%d/%d", s.Copies, s.Target), s.CheckedAt.Format(time.DateTime), strings.Join(holders, ", "))
+	}
+
+	term.Printf("%d under-replicated objects\n", len(list))
+
 	return nil
 }
 
@@ -192,6 +464,16 @@ func (adm *Admin) help(term admin.Terminal, _ []string) error {
 	term.Printf("commands:\n")
 	term.Printf("  read [dataID]                             read data by ID (caution - may print binary data)\n")
 	term.Printf("  get <url>                                 download data over http(s)\n")
+	term.Printf("  fetch <node> <dataID>                     fetch data from a node, skipping locally stored chunks\n")
+	term.Printf("  download <dataID> [treeID] [node...]      download data from several nodes in parallel\n")
+	term.Printf("  manifest <dataID>                         list chunks of a chunked object\n")
+	term.Printf("  tree <dataID>                             show the tree ID used to read data verified\n")
+	term.Printf("  pin <dataID> [holder]                     protect data from garbage collection\n")
+	term.Printf("  unpin <dataID> [holder]                   remove a pin\n")
+	term.Printf("  pins <dataID>                             list pins of data\n")
+	term.Printf("  gc [-n]                                   remove unreferenced data (-n: dry run)\n")
+	term.Printf("  encrypt                                   encrypt data stored before encryption was enabled\n")
+	term.Printf("  replicas                                  list data with fewer copies than required\n")
 	term.Printf("  info                                      show info\n")
 	term.Printf("  help                                      show help\n")
 	return nil
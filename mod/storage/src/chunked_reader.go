@@ -0,0 +1,83 @@
+package storage
+
+import (
+	"github.com/cryptopunkscc/astrald/data"
+	"github.com/cryptopunkscc/astrald/mod/storage"
+	"io"
+)
+
+var _ storage.DataReader = &ChunkedReader{}
+
+// ChunkedReader reads a data object by reading its chunks in order
+type ChunkedReader struct {
+	mod       *DataManager
+	chunks    []data.ID
+	noVirtual bool
+	next      int
+	offset    uint64
+	cur       storage.DataReader
+}
+
+func NewChunkedReader(mod *DataManager, chunks []data.ID, opts *storage.ReadOpts) (*ChunkedReader, error) {
+	var r = &ChunkedReader{
+		mod:       mod,
+		chunks:    chunks,
+		noVirtual: opts.NoVirtual,
+		offset:    opts.Offset,
+	}
+
+	// skip chunks before the offset
+	for r.next < len(chunks) && r.offset >= chunks[r.next].Size {
+		r.offset -= chunks[r.next].Size
+		r.next++
+	}
+
+	if r.next == len(chunks) && r.offset > 0 {
+		return nil, storage.ErrInvalidOffset
+	}
+
+	return r, nil
+}
+
+func (r *ChunkedReader) Read(p []byte) (n int, err error) {
+	for {
+		if r.cur == nil {
+			if r.next >= len(r.chunks) {
+				return 0, io.EOF
+			}
+
+			r.cur, err = r.mod.Read(r.chunks[r.next], &storage.ReadOpts{
+				Offset:    r.offset,
+				NoVirtual: r.noVirtual,
+			})
+			if err != nil {
+				return 0, err
+			}
+			r.next++
+			r.offset = 0
+		}
+
+		n, err = r.cur.Read(p)
+		if err == io.EOF {
+			r.cur.Close()
+			r.cur = nil
+			if n > 0 {
+				return n, nil
+			}
+			continue
+		}
+
+		return n, err
+	}
+}
+
+func (r *ChunkedReader) Close() error {
+	if r.cur != nil {
+		return r.cur.Close()
+	}
+	return nil
+}
+
+func (r *ChunkedReader) Info() *storage.ReaderInfo {
+	return &storage.ReaderInfo{Name: "mod.storage.chunks"}
+}
@@ -0,0 +1,85 @@
+package storage
+
+import (
+	"github.com/cryptopunkscc/astrald/data"
+	"github.com/cryptopunkscc/astrald/mod/storage"
+)
+
+var _ storage.DataWriter = &ChunkedWriter{}
+
+// ChunkedWriter stores written data as content-defined chunks. Chunks that are already stored are not stored
+// again. Objects that fit in a single chunk are stored as they are, larger objects get a manifest.
+type ChunkedWriter struct {
+	mod      *DataManager
+	chunker  *chunker
+	resolver data.Resolver
+	chunks   []data.ID
+}
+
+func NewChunkedWriter(mod *DataManager) *ChunkedWriter {
+	var w = &ChunkedWriter{
+		mod:      mod,
+		resolver: data.NewResolver(),
+	}
+	w.chunker = newChunker(w.storeChunk)
+
+	return w
+}
+
+func (w *ChunkedWriter) Write(p []byte) (n int, err error) {
+	w.resolver.Write(p)
+
+	return w.chunker.Write(p)
+}
+
+func (w *ChunkedWriter) Commit() (data.ID, error) {
+	if err := w.chunker.Flush(); err != nil {
+		return data.ID{}, err
+	}
+
+	switch len(w.chunks) {
+	case 0:
+		// store an empty object
+		return w.mod.storePlainBytes(nil)
+
+	case 1:
+		// a single chunk is the object itself
+		return w.chunks[0], nil
+	}
+
+	var manifest = &storage.Manifest{
+		DataID: w.resolver.Resolve(),
+		Chunks: w.chunks,
+	}
+
+	if err := w.mod.saveManifest(manifest); err != nil {
+		return data.ID{}, err
+	}
+
+	return manifest.DataID, nil
+}
+
+// Discard drops the writer. Chunks stored so far are left in the stores.
+func (w *ChunkedWriter) Discard() error {
+	return nil
+}
+
+func (w *ChunkedWriter) storeChunk(chunk []byte) error {
+	var chunkID = data.Resolve(chunk)
+
+	w.mod.pins.touch(chunkID)
+
+	if !w.mod.has(chunkID) {
+		storedID, err := w.mod.storePlainBytes(chunk)
+		if err != nil {
+			return err
+		}
+		if storedID != chunkID {
+			return storage.ErrStorageUnavailable
+		}
+	}
+
+	w.chunks = append(w.chunks, chunkID)
+
+	return nil
+}
@@ -0,0 +1,93 @@
+package storage
+
+// Content-defined chunking with a gear rolling hash. Chunk boundaries depend only on the content, so an edit
+// to a large object changes only the chunks around the edit and the rest deduplicates against stored chunks.
+
+const (
+	minChunkSize = 256 * 1024
+	maxChunkSize = 4 * 1024 * 1024
+	chunkMask    = 1<<20 - 1 // average chunk size of about 1MB over the minimum
+)
+
+// gear maps bytes to random values. The table is generated from a fixed seed, so that all nodes cut data
+// at the same boundaries.
+var gear [256]uint64
+
+func init() {
+	// splitmix64
+	var x uint64 = 0x61737472616c6421
+	for i := range gear {
+		x += 0x9e3779b97f4a7c15
+		z := x
+		z = (z ^ (z >> 30)) * 0xbf58476d1ce4e5b9
+		z = (z ^ (z >> 27)) * 0x94d049bb133111eb
+		gear[i] = z ^ (z >> 31)
+	}
+}
+
+// chunker splits a stream written to it into chunks and passes them to emit. The slice passed to emit is
+// reused after emit returns.
+type chunker struct {
+	buf  []byte
+	hash uint64
+	emit func([]byte) error
+}
+
+func newChunker(emit func([]byte) error) *chunker {
+	return &chunker{emit: emit}
+}
+
+func (c *chunker) Write(p []byte) (n int, err error) {
+	for len(p) > 0 {
+		var cut = c.scan(p)
+		if cut < 0 {
+			c.buf = append(c.buf, p...)
+			return n + len(p), nil
+		}
+
+		c.buf = append(c.buf, p[:cut]...)
+		if err = c.flush(); err != nil {
+			return n, err
+		}
+
+		n += cut
+		p = p[cut:]
+	}
+
+	return n, nil
+}
+
+// Flush emits the remaining buffered data as the last chunk
+func (c *chunker) Flush() error {
+	if len(c.buf) == 0 {
+		return nil
+	}
+	return c.flush()
+}
+
+func (c *chunker) flush() error {
+	err := c.emit(c.buf)
+	c.buf = c.buf[:0]
+	c.hash = 0
+	return err
+}
+
+// scan returns the length of the prefix of p that completes the current chunk or -1 if p doesn't contain
+// a boundary
+func (c *chunker) scan(p []byte) int {
+	var size = len(c.buf)
+
+	for i, b := range p {
+		size++
+		if size < minChunkSize {
+			continue
+		}
+
+		c.hash = (c.hash << 1) + gear[b]
+		if c.hash&chunkMask == 0 || size >= maxChunkSize {
+			return i + 1
+		}
+	}
+
+	return -1
+}
@@ -0,0 +1,109 @@
+package storage
+
+import (
+	"bytes"
+	"github.com/cryptopunkscc/astrald/cslq"
+	_data "github.com/cryptopunkscc/astrald/data"
+	"github.com/cryptopunkscc/astrald/mod/storage"
+	"math/rand"
+	"testing"
+)
+
+func chunk(t *testing.T, data []byte) [][]byte {
+	var chunks [][]byte
+
+	var c = newChunker(func(p []byte) error {
+		chunks = append(chunks, bytes.Clone(p))
+		return nil
+	})
+
+	// write in odd-sized pieces to cross chunk boundaries
+	for p := data; len(p) > 0; {
+		n := min(len(p), 100_003)
+		if _, err := c.Write(p[:n]); err != nil {
+			t.Fatal(err)
+		}
+		p = p[n:]
+	}
+	if err := c.Flush(); err != nil {
+		t.Fatal(err)
+	}
+
+	return chunks
+}
+
+func TestChunker(t *testing.T) {
+	var data = make([]byte, 16*1024*1024)
+	rand.New(rand.NewSource(1)).Read(data)
+
+	chunks := chunk(t, data)
+
+	if !bytes.Equal(bytes.Join(chunks, nil), data) {
+		t.Fatal("chunks don't add up to the input")
+	}
+
+	for i, c := range chunks {
+		if len(c) > maxChunkSize {
+			t.Fatalf("chunk %d too large: %d", i, len(c))
+		}
+		if len(c) < minChunkSize && i != len(chunks)-1 {
+			t.Fatalf("chunk %d too small: %d", i, len(c))
+		}
+	}
+
+	// insert a few bytes in the middle; only the chunks around the edit should change
+	var edited = bytes.Clone(data[:len(data)/2])
+	edited = append(edited, []byte("edit")...)
+	edited = append(edited, data[len(data)/2:]...)
+
+	var known = map[string]bool{}
+	for _, c := range chunks {
+		known[string(c)] = true
+	}
+
+	var changed int
+	for _, c := range chunk(t, edited) {
+		if !known[string(c)] {
+			changed++
+		}
+	}
+
+	if changed > 2 {
+		t.Fatalf("expected at most 2 new chunks, got %d of %d", changed, len(chunks))
+	}
+}
+
+func TestDecodeManifest(t *testing.T) {
+	var content = make([]byte, 10*1024*1024)
+	rand.New(rand.NewSource(1)).Read(content)
+
+	var manifest = &storage.Manifest{DataID: _data.Resolve(content)}
+	for _, c := range chunk(t, content) {
+		manifest.Chunks = append(manifest.Chunks, _data.Resolve(c))
+	}
+
+	var buf = &bytes.Buffer{}
+	if err := cslq.Encode(buf, "v", manifest); err != nil {
+		t.Fatal(err)
+	}
+
+	decoded, err := decodeManifest(bytes.NewReader(buf.Bytes()), manifest.DataID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(decoded.Chunks) != len(manifest.Chunks) {
+		t.Fatal("decoded manifest has different chunks")
+	}
+
+	// a manifest of another object
+	if _, err = decodeManifest(bytes.NewReader(buf.Bytes()), _data.Resolve(nil)); err != storage.ErrInvalidManifest {
+		t.Fatalf("expected invalid manifest, got %v", err)
+	}
+
+	// a chunk count the object cannot have is rejected before reading any chunks
+	buf.Reset()
+	cslq.Encode(buf, "vl", manifest.DataID, uint32(1<<31))
+	if _, err = decodeManifest(buf, manifest.DataID); err != storage.ErrInvalidManifest {
+		t.Fatalf("expected invalid manifest, got %v", err)
+	}
+}
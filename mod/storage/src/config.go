@@ -1,6 +1,49 @@
 package storage
 
+import "time"
+
 type Config struct {
+	// Chunking stores large objects as content-defined chunks, so that data shared between objects
+	// is stored and transferred only once
+	Chunking bool `yaml:"chunking"`
+
+	// Encryption encrypts new objects before they're written to stores
+	Encryption bool `yaml:"encryption"`
+
+	// EncryptionKeyFile is the file with the secret used to derive the encryption key. If not set, the key is
+	// derived from the node's private key. Keep the file on different media than the stores, otherwise
+	// the disk alone is enough to decrypt the data.
+	EncryptionKeyFile string `yaml:"encryption_key_file"`
+
+	// GCInterval runs the garbage collector periodically. Zero disables automatic collection.
+	GCInterval time.Duration `yaml:"gc_interval"`
+
+	// GCGracePeriod protects freshly stored objects from the garbage collector
+	GCGracePeriod time.Duration `yaml:"gc_grace_period"`
+
+	// Replication keeps copies of indexed data on other nodes
+	Replication ConfigReplication `yaml:"replication"`
 }
 
-var defaultConfig = Config{}
+type ConfigReplication struct {
+	// Nodes hold the replicas. They are also trusted to push replicas to this node.
+	Nodes []string `yaml:"nodes"`
+
+	// Policies set the number of copies, including the local one, that data in an index should have
+	Policies []ConfigReplicationPolicy `yaml:"policies"`
+
+	// Interval between checks of all replicated data
+	Interval time.Duration `yaml:"interval"`
+}
+
+type ConfigReplicationPolicy struct {
+	Index  string `yaml:"index"`
+	Copies int    `yaml:"copies"`
+}
+
+var defaultConfig = Config{
+	GCGracePeriod: time.Hour,
+	Replication: ConfigReplication{
+		Interval: time.Hour,
+	},
+}
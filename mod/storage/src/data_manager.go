@@ -5,6 +5,8 @@ import (
 	"github.com/cryptopunkscc/astrald/mod/storage"
 	"github.com/cryptopunkscc/astrald/sig"
 	"io"
+	"slices"
+	"strings"
 )
 
 var _ storage.DataManager = &DataManager{}
@@ -14,8 +16,9 @@ var defaultReadOpts = &storage.ReadOpts{}
 type DataManager struct {
 	*Module
 
-	readers sig.Map[string, storage.Reader]
-	stores  sig.Map[string, storage.Store]
+	readers   sig.Map[string, storage.Reader]
+	stores    sig.Map[string, storage.Store]
+	encrypted *EncryptedStore
 }
 
 func NewDataManager(module *Module) *DataManager {
@@ -34,6 +37,11 @@ func (mod *DataManager) Read(id data.ID, opts *storage.ReadOpts) (storage.DataRe
 		}
 	}
 
+	// reassemble the object from chunks if it has a manifest
+	if chunks := mod.dbFindChunks(id); len(chunks) > 0 {
+		return NewChunkedReader(mod, chunks, opts)
+	}
+
 	return nil, storage.ErrNotFound
 }
 
@@ -51,16 +59,191 @@ func (mod *DataManager) Store(opts *storage.StoreOpts) (storage.DataWriter, erro
 		opts = &storage.StoreOpts{}
 	}
 
-	for _, store := range mod.stores.Clone() {
+	// objects known to fit in a single chunk are stored as they are
+	if mod.config.Chunking && (opts.Alloc <= 0 || opts.Alloc >= minChunkSize) {
+		// chunks may end up in different stores, so check the space available in all of them
+		if !mod.canFit(opts.Alloc) {
+			return nil, storage.ErrStorageUnavailable
+		}
+		return NewDataWriterWrapper(mod.Module, NewChunkedWriter(mod)), nil
+	}
+
+	w, err := mod.storePlain(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	return NewDataWriterWrapper(mod.Module, w), nil
+}
+
+// storePlain returns a writer that stores the object as a whole, encrypted if encryption is enabled
+func (mod *DataManager) storePlain(opts *storage.StoreOpts) (storage.DataWriter, error) {
+	if mod.encrypted != nil {
+		return mod.encrypted.Store(opts)
+	}
+
+	return mod.storeRaw(opts)
+}
+
+// storeRaw returns a writer of the store with the most free space that can fit the object
+func (mod *DataManager) storeRaw(opts *storage.StoreOpts) (storage.DataWriter, error) {
+	for _, store := range mod.selectStores(opts.Alloc) {
 		w, err := store.Store(opts)
 		if err == nil {
-			return NewDataWriterWrapper(mod.Module, w), err
+			return &recentWriter{DataWriter: w, pins: mod.pins}, nil
 		}
 	}
 
 	return nil, storage.ErrStorageUnavailable
 }
 
+// recentWriter marks committed objects as recent, so that the garbage collector leaves them alone
+// until their writer had a chance to reference them
+type recentWriter struct {
+	storage.DataWriter
+	pins *PinManager
+}
+
+func (w *recentWriter) Commit() (data.ID, error) {
+	dataID, err := w.DataWriter.Commit()
+	if err == nil {
+		w.pins.touch(dataID)
+	}
+	return dataID, err
+}
+
+func (mod *DataManager) storePlainBytes(bytes []byte) (data.ID, error) {
+	w, err := mod.storePlain(&storage.StoreOpts{Alloc: len(bytes)})
+	if err != nil {
+		return data.ID{}, err
+	}
+	defer w.Discard()
+
+	if _, err = w.Write(bytes); err != nil {
+		return data.ID{}, err
+	}
+
+	return w.Commit()
+}
+
+// has returns true if the data object can be read from local storage
+func (mod *DataManager) has(dataID data.ID) bool {
+	r, err := mod.Read(dataID, &storage.ReadOpts{NoVirtual: true})
+	if err != nil {
+		return false
+	}
+	r.Close()
+	return true
+}
+
+// selectStores returns stores that can fit alloc bytes. Stores for small objects come first, then stores with
+// the most free space. Stores that don't report their capacity come last.
+func (mod *DataManager) selectStores(alloc int) []storage.Store {
+	type candidate struct {
+		store     storage.Store
+		free      uint64
+		known     bool
+		preferred bool
+	}
+
+	var list []candidate
+	for name, store := range mod.stores.Clone() {
+		var preferred bool
+		if limiter, ok := store.(storage.SizeLimiter); ok {
+			if alloc <= 0 || alloc > limiter.MaxObjectSize() {
+				continue
+			}
+			preferred = true
+		}
+
+		reporter, ok := store.(storage.CapacityReporter)
+		if !ok {
+			list = append(list, candidate{store: store, preferred: preferred})
+			continue
+		}
+
+		c, err := reporter.Capacity()
+		if err != nil {
+			mod.log.Errorv(2, "error getting capacity of %v: %v", name, err)
+			continue
+		}
+		if c.Free == 0 || c.Free < uint64(max(alloc, 0)) {
+			continue
+		}
+		list = append(list, candidate{store: store, free: c.Free, known: true, preferred: preferred})
+	}
+
+	slices.SortStableFunc(list, func(a, b candidate) int {
+		switch {
+		case a.preferred != b.preferred:
+			if a.preferred {
+				return -1
+			}
+			return 1
+		case a.known != b.known:
+			if a.known {
+				return -1
+			}
+			return 1
+		case a.free > b.free:
+			return -1
+		case a.free < b.free:
+			return 1
+		}
+		return 0
+	})
+
+	var stores = make([]storage.Store, 0, len(list))
+	for _, c := range list {
+		stores = append(stores, c.store)
+	}
+
+	return stores
+}
+
+// canFit checks if there's enough free space in all stores together to store alloc bytes
+func (mod *DataManager) canFit(alloc int) bool {
+	if len(mod.selectStores(0)) == 0 {
+		return false
+	}
+
+	// stores that don't report capacity might have enough space
+	var report = mod.Capacity()
+	if len(report.Stores) < mod.stores.Len() {
+		return true
+	}
+
+	return report.Free >= uint64(max(alloc, 0))
+}
+
+// Capacity returns the capacity of all stores that report it
+func (mod *DataManager) Capacity() *storage.CapacityReport {
+	var report = &storage.CapacityReport{}
+
+	for name, store := range mod.stores.Clone() {
+		reporter, ok := store.(storage.CapacityReporter)
+		if !ok {
+			continue
+		}
+
+		c, err := reporter.Capacity()
+		if err != nil {
+			continue
+		}
+
+		report.Total += c.Total
+		report.Used += c.Used
+		report.Free += c.Free
+		report.Stores = append(report.Stores, storage.StoreCapacity{Name: name, Capacity: *c})
+	}
+
+	slices.SortFunc(report.Stores, func(a, b storage.StoreCapacity) int {
+		return strings.Compare(a.Name, b.Name)
+	})
+
+	return report
+}
+
 func (mod *DataManager) StoreBytes(bytes []byte, opts *storage.StoreOpts) (data.ID, error) {
 	if opts == nil {
 		opts = &storage.StoreOpts{Alloc: len(bytes)}
@@ -5,18 +5,22 @@ import (
 	"github.com/cryptopunkscc/astrald/mod/storage"
 )
 
+// DataWriterWrapper emits events for committed objects and saves their hash trees, so that the objects can
+// be found by their TreeID
 type DataWriterWrapper struct {
 	mod *Module
 	storage.DataWriter
+	tree *data.TreeResolver
 }
 
 func NewDataWriterWrapper(mod *Module, dataWriter storage.DataWriter) *DataWriterWrapper {
-	return &DataWriterWrapper{mod: mod, DataWriter: dataWriter}
+	return &DataWriterWrapper{mod: mod, DataWriter: dataWriter, tree: data.NewTreeResolver()}
 }
 
 func (w *DataWriterWrapper) Write(p []byte) (n int, err error) {
-	return w.DataWriter.Write(p)
-
+	n, err = w.DataWriter.Write(p)
+	w.tree.Write(p[:n])
+	return
 }
 
 func (w *DataWriterWrapper) Discard() error {
@@ -26,8 +30,16 @@ func (w *DataWriterWrapper) Discard() error {
 func (w *DataWriterWrapper) Commit() (data.ID, error) {
 	dataID, err := w.DataWriter.Commit()
 	if err != nil {
-		w.mod.events.Emit(storage.EventDataCommitted{DataID: dataID})
+		return dataID, err
+	}
+
+	if w.tree.Resolve() == dataID {
+		if err := w.mod.data.dbSaveHashTree(dataID, w.tree.Tree()); err != nil {
+			w.mod.log.Errorv(1, "error saving hash tree of %v: %v", dataID, err)
+		}
 	}
 
-	return dataID, err
+	w.mod.events.Emit(storage.EventDataCommitted{DataID: dataID})
+
+	return dataID, nil
 }
@@ -1 +1,98 @@
 package storage
+
+import (
+	"github.com/cryptopunkscc/astrald/data"
+	"time"
+)
+
+type dbManifest struct {
+	DataID     string `gorm:"primaryKey"`
+	ManifestID string
+	CreatedAt  time.Time
+}
+
+func (dbManifest) TableName() string { return "manifests" }
+
+type dbChunk struct {
+	DataID  string `gorm:"primaryKey"`
+	Index   int    `gorm:"primaryKey"`
+	ChunkID string `gorm:"index"`
+}
+
+func (dbChunk) TableName() string { return "chunks" }
+
+type dbHashTree struct {
+	DataID    string `gorm:"primaryKey"`
+	Root      []byte `gorm:"index"`
+	Leaves    []byte
+	CreatedAt time.Time
+}
+
+func (dbHashTree) TableName() string { return "hash_trees" }
+
+type dbPin struct {
+	DataID    string `gorm:"primaryKey"`
+	Holder    string `gorm:"primaryKey"`
+	CreatedAt time.Time
+}
+
+func (dbPin) TableName() string { return "pins" }
+
+// dbRecent records when an object was last stored, so that the grace period survives restarts
+type dbRecent struct {
+	DataID   string    `gorm:"primaryKey"`
+	StoredAt time.Time `gorm:"index"`
+}
+
+func (dbRecent) TableName() string { return "recent" }
+
+type dbEncrypted struct {
+	DataID   string `gorm:"primaryKey"`
+	CipherID string `gorm:"index"`
+}
+
+func (dbEncrypted) TableName() string { return "encrypted" }
+
+func (mod *Module) dbAutoMigrate() error {
+	return mod.db.AutoMigrate(
+		&dbManifest{},
+		&dbChunk{},
+		&dbHashTree{},
+		&dbPin{},
+		&dbRecent{},
+		&dbEncrypted{},
+	)
+}
+
+// dbFindCipher returns the ID of the encrypted copy of the object
+func (mod *Module) dbFindCipher(dataID data.ID) (data.ID, bool) {
+	var row dbEncrypted
+
+	if err := mod.db.Where("data_id = ?", dataID.String()).First(&row).Error; err != nil {
+		return data.ID{}, false
+	}
+
+	cipherID, err := data.Parse(row.CipherID)
+
+	return cipherID, err == nil
+}
+
+// dbFindPlain returns the ID of the object of which cipherID is the encrypted copy
+func (mod *Module) dbFindPlain(cipherID data.ID) (data.ID, bool) {
+	var row dbEncrypted
+
+	if err := mod.db.Where("cipher_id = ?", cipherID.String()).First(&row).Error; err != nil {
+		return data.ID{}, false
+	}
+
+	dataID, err := data.Parse(row.DataID)
+
+	return dataID, err == nil
+}
+
+func (mod *Module) dbSaveCipher(dataID data.ID, cipherID data.ID) error {
+	return mod.db.Save(&dbEncrypted{
+		DataID:   dataID.String(),
+		CipherID: cipherID.String(),
+	}).Error
+}
@@ -2,11 +2,13 @@ package storage
 
 import (
 	"github.com/cryptopunkscc/astrald/mod/discovery"
+	"github.com/cryptopunkscc/astrald/mod/index"
 	"github.com/cryptopunkscc/astrald/node/modules"
 )
 
 func (mod *Module) LoadDependencies() error {
 	mod.sdp, _ = modules.Load[discovery.Module](mod.node, discovery.ModuleName)
+	mod.index, _ = modules.Load[index.Module](mod.node, index.ModuleName)
 
 	return nil
 }
@@ -0,0 +1,361 @@
+package storage
+
+import (
+	"context"
+	"github.com/cryptopunkscc/astrald/auth/id"
+	"github.com/cryptopunkscc/astrald/data"
+	"github.com/cryptopunkscc/astrald/mod/storage"
+	"github.com/cryptopunkscc/astrald/mod/storage/proto"
+	"github.com/cryptopunkscc/astrald/net"
+	"io"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const (
+	pieceSize          = 16 * data.TreeBlockSize
+	maxDownloadSources = 8
+	maxSourceFailures  = 3
+	sourceQueryTimeout = 15 * time.Second
+
+	// pieceWindow limits how far ahead of the first missing piece workers can fetch. Pieces are written
+	// to storage in order, so the window bounds the memory used by pieces waiting for their turn.
+	pieceWindow = 2 * maxDownloadSources
+)
+
+// Download fetches a data object from several nodes at once and pins it with the holder. Every source serves
+// a different part of the object. If the TreeID of the object is given, the hash tree is checked against it and
+// every part is verified before it is accepted, so a bad source only loses its own parts, which are then fetched
+// from the remaining sources. Without a TreeID the object can only be verified once it's complete.
+func (mod *Module) Download(ctx context.Context, dataID data.ID, holder string, opts *storage.DownloadOpts) error {
+	if opts == nil {
+		opts = &storage.DownloadOpts{}
+	}
+
+	if mod.data.has(dataID) {
+		return mod.pins.Pin(dataID, holder)
+	}
+
+	if opts.TreeID != nil && opts.TreeID.Size != dataID.Size {
+		return data.ErrInvalidTree
+	}
+
+	if !mod.data.canFit(int(dataID.Size)) {
+		return storage.ErrStorageUnavailable
+	}
+
+	var candidates = opts.Sources
+	if len(candidates) == 0 {
+		candidates = mod.findCandidates(dataID)
+	}
+
+	var sources = mod.holders(ctx, dataID, candidates)
+	if len(sources) == 0 {
+		return storage.ErrNoSources
+	}
+	if len(sources) > maxDownloadSources {
+		sources = sources[:maxDownloadSources]
+	}
+
+	var tree *data.HashTree
+	if opts.TreeID != nil {
+		var err error
+		if tree, err = mod.findTree(ctx, dataID, *opts.TreeID, sources); err != nil {
+			return err
+		}
+	}
+
+	if err := mod.download(ctx, dataID, tree, sources); err != nil {
+		return err
+	}
+
+	return mod.pins.Pin(dataID, holder)
+}
+
+// download fetches the object from the sources straight into storage
+func (mod *Module) download(ctx context.Context, dataID data.ID, tree *data.HashTree, sources []id.Identity) error {
+	w, err := mod.data.Store(&storage.StoreOpts{Alloc: int(dataID.Size)})
+	if err != nil {
+		return err
+	}
+	defer w.Discard()
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var d = &download{
+		mod:      mod,
+		dataID:   dataID,
+		tree:     tree,
+		w:        w,
+		resolver: data.NewResolver(),
+		ready:    make(map[int][]byte),
+		cancel:   cancel,
+	}
+	d.cond = sync.NewCond(&d.mu)
+	for i := max(int((dataID.Size+pieceSize-1)/pieceSize), 1); i > 0; i-- {
+		d.pending = append(d.pending, i-1)
+	}
+
+	if err = d.run(ctx, sources); err != nil {
+		return err
+	}
+
+	if d.resolver.Resolve() != dataID {
+		return data.ErrBlockMismatch
+	}
+
+	_, err = w.Commit()
+
+	return err
+}
+
+// findCandidates returns nodes that may hold the object: remote nodes whose mirrored indexes list it, followed
+// by directly linked nodes
+func (mod *Module) findCandidates(dataID data.ID) (list []id.Identity) {
+	var seen = map[string]bool{mod.node.Identity().PublicKeyHex(): true}
+	var add = func(remoteID id.Identity) {
+		if seen[remoteID.PublicKeyHex()] {
+			return
+		}
+		seen[remoteID.PublicKeyHex()] = true
+		list = append(list, remoteID)
+	}
+
+	if mod.index != nil {
+		remotes, err := mod.index.FindRemote(dataID)
+		if err != nil {
+			mod.log.Errorv(2, "error finding %v in mirrored indexes: %v", dataID, err)
+		}
+		for _, remoteID := range remotes {
+			add(remoteID)
+		}
+	}
+
+	for _, l := range mod.node.Network().Links().All() {
+		add(l.RemoteIdentity())
+	}
+
+	return
+}
+
+// holders asks the nodes whether they hold the object and returns the ones that do, in the order of the nodes
+func (mod *Module) holders(ctx context.Context, dataID data.ID, nodes []id.Identity) []id.Identity {
+	var wg sync.WaitGroup
+	var found = make([]bool, len(nodes))
+
+	ctx, cancel := context.WithTimeout(ctx, sourceQueryTimeout)
+	defer cancel()
+
+	for i, node := range nodes {
+		i, node := i, node
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			conn, err := mod.query(ctx, node, proto.HasServiceName+"."+dataID.String())
+			if err != nil {
+				return
+			}
+			conn.Close()
+
+			found[i] = true
+		}()
+	}
+	wg.Wait()
+
+	var list []id.Identity
+	for i, node := range nodes {
+		if found[i] {
+			list = append(list, node)
+		}
+	}
+
+	return list
+}
+
+// findTree fetches the hash tree of the object from the first source that serves a tree matching the TreeID
+func (mod *Module) findTree(ctx context.Context, dataID data.ID, treeID data.TreeID, sources []id.Identity) (*data.HashTree, error) {
+	ctx, cancel := context.WithTimeout(ctx, sourceQueryTimeout)
+	defer cancel()
+
+	for _, source := range sources {
+		tree, err := mod.fetchTree(ctx, source, dataID)
+		if err == nil {
+			err = treeID.Check(tree)
+		}
+		if err == nil {
+			return tree, nil
+		}
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		mod.log.Errorv(1, "download %v: no valid hash tree from %v: %v", dataID, source, err)
+	}
+
+	return nil, storage.ErrNoSources
+}
+
+type download struct {
+	mod      *Module
+	dataID   data.ID
+	tree     *data.HashTree
+	w        storage.DataWriter
+	resolver data.Resolver
+	cancel   context.CancelFunc
+
+	mu      sync.Mutex
+	cond    *sync.Cond
+	pending []int
+	ready   map[int][]byte
+	written int
+	active  int
+	err     error
+}
+
+// run fetches all pieces using one worker per source
+func (d *download) run(ctx context.Context, sources []id.Identity) error {
+	var total = len(d.pending)
+	var wg sync.WaitGroup
+
+	for _, source := range sources {
+		source := source
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			d.worker(ctx, source)
+		}()
+	}
+	wg.Wait()
+
+	switch {
+	case d.err != nil:
+		return d.err
+	case d.written == total:
+		return nil
+	case ctx.Err() != nil:
+		return ctx.Err()
+	default:
+		return storage.ErrNoSources
+	}
+}
+
+func (d *download) worker(ctx context.Context, source id.Identity) {
+	var failures int
+
+	for failures < maxSourceFailures {
+		piece, ok := d.next()
+		if !ok {
+			return
+		}
+
+		buf, err := d.fetchPiece(ctx, source, piece)
+		if err != nil {
+			d.mod.log.Errorv(1, "download %v: piece %v from %v failed: %v", d.dataID, piece, source, err)
+			d.finish(piece, nil)
+			failures++
+			if ctx.Err() != nil {
+				return
+			}
+			continue
+		}
+
+		d.finish(piece, buf)
+	}
+}
+
+func (d *download) fetchPiece(ctx context.Context, source id.Identity, piece int) ([]byte, error) {
+	var offset = uint64(piece) * pieceSize
+	var length = min(pieceSize, d.dataID.Size-offset)
+
+	var query = net.WithParams(
+		net.NewQuery(d.mod.node.Identity(), source, proto.ReadServiceName+"."+d.dataID.String()),
+		net.Params{
+			proto.ParamOffset: strconv.FormatUint(offset, 10),
+			proto.ParamLength: strconv.FormatUint(length, 10),
+		},
+	)
+
+	conn, err := net.Route(ctx, d.mod.node.Router(), query)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	var buf = make([]byte, length)
+	var src io.Reader = conn
+
+	// pieces are block aligned, so every block of the piece can be verified
+	if d.tree != nil {
+		src = d.tree.NewVerifier(conn, offset)
+	}
+
+	if _, err = io.ReadFull(src, buf); err != nil {
+		return nil, err
+	}
+
+	return buf, nil
+}
+
+// next takes the first piece from the queue that is within the window. While other workers are still fetching
+// pieces, it waits, so that pieces they fail to fetch are picked up.
+func (d *download) next() (int, bool) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	for d.err == nil {
+		var best = -1
+		for i, piece := range d.pending {
+			if piece < d.written+pieceWindow && (best < 0 || piece < d.pending[best]) {
+				best = i
+			}
+		}
+
+		if best >= 0 {
+			var piece = d.pending[best]
+			d.pending = append(d.pending[:best], d.pending[best+1:]...)
+			d.active++
+			return piece, true
+		}
+
+		if len(d.pending) == 0 && d.active == 0 {
+			break
+		}
+		d.cond.Wait()
+	}
+
+	return 0, false
+}
+
+// finish puts a failed piece back in the queue or writes a fetched piece and any pieces following it
+// to storage
+func (d *download) finish(piece int, buf []byte) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.active--
+	defer d.cond.Broadcast()
+
+	if buf == nil {
+		d.pending = append(d.pending, piece)
+		return
+	}
+
+	d.ready[piece] = buf
+	for d.err == nil {
+		buf, found := d.ready[d.written]
+		if !found {
+			break
+		}
+		delete(d.ready, d.written)
+
+		d.resolver.Write(buf)
+		if _, err := d.w.Write(buf); err != nil {
+			d.err = err
+			d.cancel()
+			break
+		}
+		d.written++
+	}
+}
@@ -0,0 +1,68 @@
+package storage
+
+import (
+	"bytes"
+	"github.com/cryptopunkscc/astrald/data"
+	"sync"
+	"testing"
+)
+
+// bufWriter is a DataWriter that keeps written data in a buffer
+type bufWriter struct {
+	bytes.Buffer
+}
+
+func (w *bufWriter) Commit() (data.ID, error) { return data.Resolve(w.Bytes()), nil }
+func (w *bufWriter) Discard() error           { return nil }
+
+func TestDownloadWritesInOrder(t *testing.T) {
+	const pieces = 3 * pieceWindow
+
+	var w = &bufWriter{}
+	var d = &download{
+		w:        w,
+		resolver: data.NewResolver(),
+		ready:    make(map[int][]byte),
+		cancel:   func() {},
+	}
+	d.cond = sync.NewCond(&d.mu)
+	for i := pieces; i > 0; i-- {
+		d.pending = append(d.pending, i-1)
+	}
+
+	// take a full window of pieces, nothing past the window is handed out
+	var taken []int
+	for i := 0; i < pieceWindow; i++ {
+		piece, ok := d.next()
+		if !ok || piece != i {
+			t.Fatalf("expected piece %d, got %d", i, piece)
+		}
+		taken = append(taken, piece)
+	}
+
+	// finish pieces in reverse, so that they can only be written once the first one arrives
+	for i := len(taken) - 1; i >= 0; i-- {
+		d.finish(taken[i], []byte{byte(taken[i])})
+	}
+	if d.written != pieceWindow {
+		t.Fatalf("expected %d pieces written, got %d", pieceWindow, d.written)
+	}
+
+	for i := pieceWindow; i < pieces; i++ {
+		piece, ok := d.next()
+		if !ok {
+			t.Fatal("queue ended early")
+		}
+		d.finish(piece, []byte{byte(piece)})
+	}
+
+	if _, ok := d.next(); ok {
+		t.Fatal("expected the queue to be empty")
+	}
+
+	for i, b := range w.Bytes() {
+		if int(b) != i {
+			t.Fatalf("piece %d written at position %d", b, i)
+		}
+	}
+}
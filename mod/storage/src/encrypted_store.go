@@ -0,0 +1,263 @@
+package storage
+
+import (
+	"crypto/cipher"
+	"crypto/rand"
+	"errors"
+	"github.com/cryptopunkscc/astrald/data"
+	"github.com/cryptopunkscc/astrald/log"
+	"github.com/cryptopunkscc/astrald/mod/storage"
+	"io"
+)
+
+const encryptedReaderName = "mod.storage.encrypted"
+
+var _ storage.Store = &EncryptedStore{}
+var _ storage.Reader = &EncryptedStore{}
+
+// EncryptedStore encrypts objects before they are written to the underlying stores and decrypts them when
+// they're read. Objects keep the ID of their plaintext, the ID of the encrypted copy is kept in the database.
+type EncryptedStore struct {
+	mod *DataManager
+	key []byte
+}
+
+func NewEncryptedStore(mod *DataManager, key []byte) *EncryptedStore {
+	return &EncryptedStore{mod: mod, key: key}
+}
+
+func (s *EncryptedStore) Store(opts *storage.StoreOpts) (storage.DataWriter, error) {
+	var innerOpts = &storage.StoreOpts{}
+	if opts.Alloc > 0 {
+		innerOpts.Alloc = int(encryptedSize(uint64(opts.Alloc)))
+	}
+
+	w, err := s.mod.storeRaw(innerOpts)
+	if err != nil {
+		return nil, err
+	}
+
+	var salt = make([]byte, encSaltSize)
+	if _, err = rand.Read(salt); err != nil {
+		w.Discard()
+		return nil, err
+	}
+
+	enc, err := newEncrypter(w, s.key, salt)
+	if err != nil {
+		w.Discard()
+		return nil, err
+	}
+
+	return &encryptedWriter{
+		store:    s,
+		inner:    w,
+		enc:      enc,
+		resolver: data.NewResolver(),
+	}, nil
+}
+
+func (s *EncryptedStore) Read(dataID data.ID, opts *storage.ReadOpts) (storage.DataReader, error) {
+	if opts == nil {
+		opts = defaultReadOpts
+	}
+
+	cipherID, found := s.mod.dbFindCipher(dataID)
+	if !found {
+		return nil, storage.ErrNotFound
+	}
+
+	if opts.Offset > dataID.Size {
+		return nil, storage.ErrInvalidOffset
+	}
+
+	// read the header
+	r, err := s.mod.Read(cipherID, &storage.ReadOpts{NoVirtual: true})
+	if err != nil {
+		return nil, err
+	}
+
+	var header = make([]byte, encHeaderSize)
+	if _, err = io.ReadFull(r, header); err != nil || string(header[:len(encMagic)]) != encMagic {
+		r.Close()
+		return nil, errDecryptionFailed
+	}
+
+	aead, err := objectCipher(s.key, header[len(encMagic):])
+	if err != nil {
+		r.Close()
+		return nil, err
+	}
+
+	var index = opts.Offset / encSegmentSize
+	var last = max((dataID.Size+encSegmentSize-1)/encSegmentSize, 1) - 1
+
+	// only the final segment is bound to the data ID, so open it first to make sure the object wasn't swapped
+	if index < last {
+		if err = s.checkFinal(cipherID, dataID, aead, last); err != nil {
+			r.Close()
+			return nil, err
+		}
+	}
+
+	// jump to the segment containing the offset
+	if index > 0 {
+		r.Close()
+		r, err = s.mod.Read(cipherID, &storage.ReadOpts{Offset: segmentOffset(index), NoVirtual: true})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return &encryptedReader{
+		DataReader: r,
+		dec: &decrypter{
+			r:      r,
+			aead:   aead,
+			dataID: dataID,
+			index:  index,
+			last:   last,
+			skip:   int(opts.Offset % encSegmentSize),
+		},
+	}, nil
+}
+
+// checkFinal opens the final segment of an encrypted copy of dataID
+func (s *EncryptedStore) checkFinal(cipherID data.ID, dataID data.ID, aead cipher.AEAD, last uint64) error {
+	r, err := s.mod.Read(cipherID, &storage.ReadOpts{Offset: segmentOffset(last), NoVirtual: true})
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+
+	var dec = &decrypter{r: r, aead: aead, dataID: dataID, index: last, last: last}
+
+	return dec.open()
+}
+
+// encryptStored replaces plaintext objects in all stores that can list and delete them with encrypted copies
+func (s *EncryptedStore) encryptStored() (count int, size uint64, err error) {
+	for name, store := range s.mod.stores.Clone() {
+		lister, ok := store.(storage.Lister)
+		if !ok {
+			continue
+		}
+		deleter, ok := store.(storage.Deleter)
+		if !ok {
+			continue
+		}
+
+		list, err := lister.List()
+		if err != nil {
+			return count, size, err
+		}
+
+		for _, dataID := range list {
+			// skip encrypted copies and objects that were already encrypted
+			if _, found := s.mod.dbFindPlain(dataID); found {
+				continue
+			}
+			if _, found := s.mod.dbFindCipher(dataID); found {
+				continue
+			}
+
+			if err := s.encryptObject(dataID); err != nil {
+				s.mod.log.Error("error encrypting %v in %v: %v", dataID, name, err)
+				continue
+			}
+
+			if err := deleter.Delete(dataID); err != nil {
+				s.mod.log.Error("error deleting plaintext %v from %v: %v", dataID, name, err)
+				continue
+			}
+
+			count++
+			size += dataID.Size
+		}
+	}
+
+	if count > 0 {
+		s.mod.log.Info("encrypted %v in %v objects", log.DataSize(size), count)
+	}
+
+	return
+}
+
+func (s *EncryptedStore) encryptObject(dataID data.ID) error {
+	r, err := s.mod.Read(dataID, &storage.ReadOpts{NoVirtual: true})
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+
+	w, err := s.Store(&storage.StoreOpts{Alloc: int(dataID.Size)})
+	if err != nil {
+		return err
+	}
+	defer w.Discard()
+
+	if _, err = io.Copy(w, r); err != nil {
+		return err
+	}
+
+	encryptedID, err := w.Commit()
+	if err != nil {
+		return err
+	}
+	if encryptedID != dataID {
+		return errors.New("stored data doesn't match its id")
+	}
+
+	return nil
+}
+
+type encryptedWriter struct {
+	store    *EncryptedStore
+	inner    storage.DataWriter
+	enc      *encrypter
+	resolver data.Resolver
+}
+
+func (w *encryptedWriter) Write(p []byte) (n int, err error) {
+	n, err = w.enc.Write(p)
+	w.resolver.Write(p[:n])
+	return
+}
+
+func (w *encryptedWriter) Commit() (data.ID, error) {
+	var dataID = w.resolver.Resolve()
+
+	if err := w.enc.Close(dataID); err != nil {
+		return data.ID{}, err
+	}
+
+	cipherID, err := w.inner.Commit()
+	if err != nil {
+		return data.ID{}, err
+	}
+
+	if err = w.store.mod.dbSaveCipher(dataID, cipherID); err != nil {
+		return data.ID{}, err
+	}
+
+	w.store.mod.pins.touch(dataID)
+
+	return dataID, nil
+}
+
+func (w *encryptedWriter) Discard() error {
+	return w.inner.Discard()
+}
+
+type encryptedReader struct {
+	storage.DataReader
+	dec *decrypter
+}
+
+func (r *encryptedReader) Read(p []byte) (n int, err error) {
+	return r.dec.Read(p)
+}
+
+func (r *encryptedReader) Info() *storage.ReaderInfo {
+	return &storage.ReaderInfo{Name: encryptedReaderName}
+}
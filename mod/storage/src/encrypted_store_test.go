@@ -0,0 +1,138 @@
+package storage
+
+import (
+	"bytes"
+	"errors"
+	"github.com/cryptopunkscc/astrald/data"
+	"github.com/cryptopunkscc/astrald/mod/storage"
+	"io"
+	"math/rand"
+	"testing"
+)
+
+func TestEncryptedStore(t *testing.T) {
+	mod, store := newTestModule(t)
+	mod.config.GCGracePeriod = 0
+
+	var key = make([]byte, 32)
+	rand.Read(key)
+	mod.data.encrypted = NewEncryptedStore(mod.data, key)
+	mod.data.readers.Set(encryptedReaderName, mod.data.encrypted)
+
+	var content = make([]byte, 3*encSegmentSize+100)
+	rand.New(rand.NewSource(1)).Read(content)
+
+	dataID, err := mod.data.StoreBytes(content, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// only the encrypted copy is stored
+	if len(store.objects) != 1 {
+		t.Fatalf("expected 1 stored object, got %d", len(store.objects))
+	}
+	for cipherID, b := range store.objects {
+		if cipherID == dataID || bytes.Contains(b, content[:64]) {
+			t.Fatal("object stored in plaintext")
+		}
+		if uint64(len(b)) != encryptedSize(dataID.Size) {
+			t.Fatalf("unexpected encrypted size %d", len(b))
+		}
+	}
+
+	// read from various offsets
+	for _, offset := range []uint64{0, 100, encSegmentSize, 2*encSegmentSize + 7, dataID.Size} {
+		r, err := mod.data.Read(dataID, &storage.ReadOpts{Offset: offset})
+		if err != nil {
+			t.Fatal(err)
+		}
+		b, err := io.ReadAll(r)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(b, content[offset:]) {
+			t.Fatalf("wrong data read at offset %d", offset)
+		}
+	}
+
+	// tampering is detected
+	for _, b := range store.objects {
+		b[len(b)-1] ^= 1
+	}
+	if _, err = readEncrypted(mod, dataID, 0); !errors.Is(err, errDecryptionFailed) {
+		t.Fatalf("expected decryption to fail, got %v", err)
+	}
+	for _, b := range store.objects {
+		b[len(b)-1] ^= 1
+	}
+
+	// the encrypted copy is kept as long as the object is in use
+	mod.pins.Pin(dataID, "test")
+	mod.GC(nil)
+	if !mod.data.has(dataID) {
+		t.Fatal("gc removed an encrypted copy of a pinned object")
+	}
+
+	mod.pins.Unpin(dataID, "test")
+	mod.GC(nil)
+	if mod.data.has(dataID) || len(store.objects) != 0 {
+		t.Fatal("gc left an unused encrypted copy")
+	}
+}
+
+func TestEncryptEmpty(t *testing.T) {
+	mod, _ := newTestModule(t)
+	mod.data.encrypted = NewEncryptedStore(mod.data, make([]byte, 32))
+	mod.data.readers.Set(encryptedReaderName, mod.data.encrypted)
+
+	dataID, err := mod.data.StoreBytes(nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	b, err := mod.data.ReadAll(dataID, nil)
+	if err != nil || len(b) != 0 {
+		t.Fatal("unexpected read of an empty object", err)
+	}
+}
+
+func TestEncryptedSwap(t *testing.T) {
+	mod, store := newTestModule(t)
+	mod.data.encrypted = NewEncryptedStore(mod.data, make([]byte, 32))
+	mod.data.readers.Set(encryptedReaderName, mod.data.encrypted)
+
+	var content = make([]byte, 2*encSegmentSize+100)
+	rand.New(rand.NewSource(1)).Read(content)
+
+	id1, err := mod.data.StoreBytes(content, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	content[len(content)-1] ^= 1
+	id2, err := mod.data.StoreBytes(content, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// swap the encrypted copies
+	cipher1, _ := mod.data.dbFindCipher(id1)
+	cipher2, _ := mod.data.dbFindCipher(id2)
+	store.objects[cipher1], store.objects[cipher2] = store.objects[cipher2], store.objects[cipher1]
+
+	for _, offset := range []uint64{0, encSegmentSize, id1.Size - 1} {
+		_, err := readEncrypted(mod, id1, offset)
+		if !errors.Is(err, errDecryptionFailed) {
+			t.Fatalf("expected decryption at offset %d to fail, got %v", offset, err)
+		}
+	}
+}
+
+func readEncrypted(mod *Module, dataID data.ID, offset uint64) ([]byte, error) {
+	r, err := mod.data.encrypted.Read(dataID, &storage.ReadOpts{Offset: offset})
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+
+	return io.ReadAll(r)
+}
@@ -0,0 +1,203 @@
+package storage
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"github.com/cryptopunkscc/astrald/auth/id"
+	"github.com/cryptopunkscc/astrald/data"
+	"golang.org/x/crypto/hkdf"
+	"io"
+	"os"
+)
+
+// Encrypted objects start with a header made of a magic number and a random salt, which is used to derive
+// a key for the object from the master key. The plaintext is split into segments, each sealed separately
+// with AES-GCM, so that objects can be read from any offset without decrypting what comes before.
+// The nonce of a segment is its index and the last segment is marked in the additional data, so segments
+// can't be reordered or cut off. The additional data of the last segment also holds the ID of the plaintext,
+// which binds the encrypted object to the ID it's stored under, so whole objects can't be swapped either.
+
+const (
+	encMagic       = "AER0"
+	encSaltSize    = 32
+	encHeaderSize  = len(encMagic) + encSaltSize
+	encSegmentSize = 64 * 1024
+	encOverhead    = 16
+)
+
+var errDecryptionFailed = errors.New("decryption failed")
+
+// encryptionKey returns the master key. It is read from the key file if one is configured, otherwise it is
+// derived from the node's private key.
+func encryptionKey(nodeID id.Identity, keyFile string) ([]byte, error) {
+	var secret []byte
+
+	if keyFile != "" {
+		b, err := os.ReadFile(keyFile)
+		if err != nil {
+			return nil, err
+		}
+		secret = b
+	} else {
+		if nodeID.PrivateKey() == nil {
+			return nil, errors.New("node private key missing")
+		}
+		secret = nodeID.PrivateKey().Serialize()
+	}
+
+	var key = make([]byte, 32)
+	_, err := io.ReadFull(hkdf.New(sha256.New, secret, nil, []byte("astral.storage.encryption")), key)
+
+	return key, err
+}
+
+// objectCipher derives the cipher of a single object from the master key and the object's salt
+func objectCipher(masterKey []byte, salt []byte) (cipher.AEAD, error) {
+	var mac = hmac.New(sha256.New, masterKey)
+	mac.Write(salt)
+
+	block, err := aes.NewCipher(mac.Sum(nil))
+	if err != nil {
+		return nil, err
+	}
+
+	return cipher.NewGCM(block)
+}
+
+func segmentNonce(index uint64) []byte {
+	var nonce = make([]byte, 12)
+	binary.BigEndian.PutUint64(nonce[4:], index)
+	return nonce
+}
+
+func segmentAD(final bool, dataID data.ID) []byte {
+	if final {
+		var packed = dataID.Pack()
+		return append([]byte{1}, packed[:]...)
+	}
+	return []byte{0}
+}
+
+// encryptedSize returns the size of an encrypted object with the given plaintext size
+func encryptedSize(size uint64) uint64 {
+	var segments = max((size+encSegmentSize-1)/encSegmentSize, 1)
+	return uint64(encHeaderSize) + size + segments*encOverhead
+}
+
+// segmentOffset returns the offset of a segment in the encrypted object
+func segmentOffset(index uint64) uint64 {
+	return uint64(encHeaderSize) + index*(encSegmentSize+encOverhead)
+}
+
+// encrypter seals written data segment by segment and writes it to the underlying writer
+type encrypter struct {
+	w     io.Writer
+	aead  cipher.AEAD
+	buf   []byte
+	index uint64
+}
+
+func newEncrypter(w io.Writer, masterKey []byte, salt []byte) (*encrypter, error) {
+	aead, err := objectCipher(masterKey, salt)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err = w.Write(append([]byte(encMagic), salt...)); err != nil {
+		return nil, err
+	}
+
+	return &encrypter{w: w, aead: aead, buf: make([]byte, 0, encSegmentSize)}, nil
+}
+
+func (e *encrypter) Write(p []byte) (n int, err error) {
+	for len(p) > 0 {
+		// keep the last segment buffered until Close, so it can be marked as final
+		if len(e.buf) == encSegmentSize {
+			if err = e.seal(false, data.ID{}); err != nil {
+				return
+			}
+		}
+
+		var l = min(len(p), encSegmentSize-len(e.buf))
+		e.buf = append(e.buf, p[:l]...)
+		p = p[l:]
+		n += l
+	}
+
+	return
+}
+
+// Close seals the final segment binding the object to the ID of the plaintext
+func (e *encrypter) Close(dataID data.ID) error {
+	return e.seal(true, dataID)
+}
+
+func (e *encrypter) seal(final bool, dataID data.ID) error {
+	var sealed = e.aead.Seal(nil, segmentNonce(e.index), e.buf, segmentAD(final, dataID))
+	if _, err := e.w.Write(sealed); err != nil {
+		return err
+	}
+
+	e.index++
+	e.buf = e.buf[:0]
+
+	return nil
+}
+
+// decrypter reads and opens segments of an encrypted copy of dataID starting with the segment at index
+type decrypter struct {
+	r      io.Reader
+	aead   cipher.AEAD
+	dataID data.ID
+	index  uint64
+	last   uint64
+	buf    []byte
+	skip   int
+}
+
+func (d *decrypter) Read(p []byte) (n int, err error) {
+	for len(d.buf) == 0 {
+		if d.index > d.last {
+			return 0, io.EOF
+		}
+		if err = d.open(); err != nil {
+			return 0, err
+		}
+	}
+
+	n = copy(p, d.buf)
+	d.buf = d.buf[n:]
+
+	return
+}
+
+func (d *decrypter) open() error {
+	var plainSize = uint64(encSegmentSize)
+	if d.index == d.last {
+		plainSize = d.dataID.Size - d.last*encSegmentSize
+	}
+
+	var sealed = make([]byte, plainSize+encOverhead)
+	if _, err := io.ReadFull(d.r, sealed); err != nil {
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+		return err
+	}
+
+	plain, err := d.aead.Open(sealed[:0], segmentNonce(d.index), sealed, segmentAD(d.index == d.last, d.dataID))
+	if err != nil {
+		return errDecryptionFailed
+	}
+
+	d.index++
+	d.buf = plain[d.skip:]
+	d.skip = 0
+
+	return nil
+}
@@ -0,0 +1,128 @@
+package storage
+
+import (
+	"context"
+	"github.com/cryptopunkscc/astrald/auth/id"
+	"github.com/cryptopunkscc/astrald/data"
+	"github.com/cryptopunkscc/astrald/mod/storage"
+	"github.com/cryptopunkscc/astrald/mod/storage/proto"
+	"github.com/cryptopunkscc/astrald/net"
+	"io"
+)
+
+// Fetch copies a data object from a remote node to local storage. Chunked objects are fetched chunk by chunk,
+// skipping chunks that are already stored locally.
+func (mod *Module) Fetch(ctx context.Context, source id.Identity, dataID data.ID) error {
+	if mod.data.has(dataID) {
+		return nil
+	}
+
+	manifest, err := mod.fetchManifest(ctx, source, dataID)
+	if err != nil {
+		// the object isn't chunked on the remote node, fetch it whole
+		return mod.fetchWhole(ctx, source, dataID)
+	}
+
+	for _, chunkID := range manifest.Chunks {
+		if mod.data.has(chunkID) {
+			continue
+		}
+
+		if err := mod.fetchChunk(ctx, source, chunkID); err != nil {
+			return err
+		}
+	}
+
+	// make sure the chunks add up to the object before saving the manifest
+	r, err := NewChunkedReader(mod.data, manifest.Chunks, &storage.ReadOpts{})
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+
+	resolvedID, err := data.ResolveAll(r)
+	if err != nil {
+		return err
+	}
+	if resolvedID != dataID {
+		return storage.ErrInvalidManifest
+	}
+
+	if err := mod.data.saveManifest(manifest); err != nil {
+		return err
+	}
+
+	mod.events.Emit(storage.EventDataCommitted{DataID: dataID})
+
+	return nil
+}
+
+func (mod *Module) fetchManifest(ctx context.Context, source id.Identity, dataID data.ID) (*storage.Manifest, error) {
+	conn, err := mod.query(ctx, source, proto.ManifestServiceName+"."+dataID.String())
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	return decodeManifest(conn, dataID)
+}
+
+// fetchTree fetches the hash tree of an object from the source. The tree is not bound to the data ID, so it has
+// to be checked against a TreeID before it's used.
+func (mod *Module) fetchTree(ctx context.Context, source id.Identity, dataID data.ID) (*data.HashTree, error) {
+	conn, err := mod.query(ctx, source, proto.TreeServiceName+"."+dataID.String())
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	return data.DecodeTree(conn, dataID.Size)
+}
+
+func (mod *Module) fetchWhole(ctx context.Context, source id.Identity, dataID data.ID) error {
+	w, err := mod.data.Store(&storage.StoreOpts{Alloc: int(dataID.Size)})
+	if err != nil {
+		return err
+	}
+
+	return mod.fetchInto(ctx, source, dataID, w)
+}
+
+func (mod *Module) fetchChunk(ctx context.Context, source id.Identity, chunkID data.ID) error {
+	w, err := mod.data.storePlain(&storage.StoreOpts{Alloc: int(chunkID.Size)})
+	if err != nil {
+		return err
+	}
+
+	return mod.fetchInto(ctx, source, chunkID, w)
+}
+
+// fetchInto reads a data object from the source into the writer and commits it if the data is valid
+func (mod *Module) fetchInto(ctx context.Context, source id.Identity, dataID data.ID, w storage.DataWriter) error {
+	defer w.Discard()
+
+	conn, err := mod.query(ctx, source, proto.ReadServiceName+"."+dataID.String())
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	var resolver = data.NewResolver()
+
+	_, err = io.Copy(io.MultiWriter(w, resolver), io.LimitReader(conn, int64(dataID.Size)))
+	if err != nil {
+		return err
+	}
+
+	if resolver.Resolve() != dataID {
+		return storage.ErrNotFound
+	}
+
+	_, err = w.Commit()
+
+	return err
+}
+
+func (mod *Module) query(ctx context.Context, target id.Identity, query string) (net.SecureConn, error) {
+	return net.Route(ctx, mod.node.Router(), net.NewQuery(mod.node.Identity(), target, query))
+}
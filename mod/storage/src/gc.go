@@ -0,0 +1,137 @@
+package storage
+
+import (
+	"context"
+	"github.com/cryptopunkscc/astrald/data"
+	"github.com/cryptopunkscc/astrald/log"
+	"github.com/cryptopunkscc/astrald/mod/storage"
+	"time"
+)
+
+// GC removes data objects that are not in use from all stores that can list and delete their objects
+func (mod *Module) GC(opts *storage.GCOpts) (*storage.GCReport, error) {
+	if opts == nil {
+		opts = &storage.GCOpts{}
+	}
+
+	mod.gcMu.Lock()
+	defer mod.gcMu.Unlock()
+
+	var report = &storage.GCReport{DryRun: opts.DryRun}
+	var startedAt = time.Now()
+
+	if !opts.DryRun {
+		if err := mod.pins.pruneRecent(); err != nil {
+			mod.log.Error("gc: error pruning stored objects: %v", err)
+		}
+	}
+
+	for name, store := range mod.data.stores.Clone() {
+		lister, ok := store.(storage.Lister)
+		if !ok {
+			continue
+		}
+		deleter, ok := store.(storage.Deleter)
+		if !ok {
+			continue
+		}
+
+		list, err := lister.List()
+		if err != nil {
+			mod.log.Error("gc: error listing %v: %v", name, err)
+			continue
+		}
+
+		for _, dataID := range list {
+			report.Scanned++
+
+			inUse, err := mod.inUse(dataID)
+			if err != nil {
+				mod.log.Error("gc: error checking if %v is in use: %v", dataID, err)
+				report.Failed++
+				continue
+			}
+			if inUse {
+				continue
+			}
+
+			if !opts.DryRun {
+				if err := deleter.Delete(dataID); err != nil {
+					mod.log.Error("gc: error deleting %v from %v: %v", dataID, name, err)
+					continue
+				}
+				mod.data.forget(dataID)
+			}
+
+			report.Freed = append(report.Freed, dataID)
+			report.FreedBytes += dataID.Size
+		}
+	}
+
+	report.Duration = time.Since(startedAt)
+
+	if !opts.DryRun && len(report.Freed) > 0 {
+		mod.log.Info("gc: freed %v in %v objects", log.DataSize(report.FreedBytes), len(report.Freed))
+	}
+	if report.Failed > 0 {
+		mod.log.Error("gc: kept %v objects that couldn't be checked", report.Failed)
+	}
+
+	return report, nil
+}
+
+// inUse checks if the object is pinned or referenced. Chunks, manifests and encrypted copies are in use
+// as long as any object built from them is in use.
+func (mod *Module) inUse(dataID data.ID) (bool, error) {
+	return mod.inUseDepth(dataID, 3)
+}
+
+func (mod *Module) inUseDepth(dataID data.ID, depth int) (bool, error) {
+	recent, err := mod.pins.isRecent(dataID)
+	if recent || err != nil {
+		return recent, err
+	}
+
+	referenced, err := mod.pins.IsReferenced(dataID)
+	if referenced || err != nil {
+		return referenced, err
+	}
+
+	if depth == 0 {
+		return false, nil
+	}
+
+	parents, err := mod.data.dbFindParents(dataID)
+	if err != nil {
+		return false, err
+	}
+
+	for _, parentID := range parents {
+		if inUse, err := mod.inUseDepth(parentID, depth-1); inUse || err != nil {
+			return inUse, err
+		}
+	}
+
+	return false, nil
+}
+
+// gcLoop runs the garbage collector periodically if an interval is configured
+func (mod *Module) gcLoop(ctx context.Context) error {
+	if mod.config.GCInterval <= 0 {
+		return nil
+	}
+
+	var ticker = time.NewTicker(mod.config.GCInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			if _, err := mod.GC(nil); err != nil {
+				mod.log.Error("gc: %v", err)
+			}
+		case <-ctx.Done():
+			return nil
+		}
+	}
+}
@@ -0,0 +1,194 @@
+package storage
+
+import (
+	"bytes"
+	"errors"
+	"github.com/cryptopunkscc/astrald/data"
+	"github.com/cryptopunkscc/astrald/log"
+	"github.com/cryptopunkscc/astrald/mod/storage"
+	"github.com/cryptopunkscc/astrald/node/assets"
+	"github.com/cryptopunkscc/astrald/resources"
+	"io"
+	"sync"
+	"testing"
+)
+
+// memStore is a minimal store that can list and delete its objects
+type memStore struct {
+	mu      sync.Mutex
+	objects map[data.ID][]byte
+}
+
+func (s *memStore) Read(dataID data.ID, opts *storage.ReadOpts) (storage.DataReader, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	b, found := s.objects[dataID]
+	if !found {
+		return nil, storage.ErrNotFound
+	}
+	return &memReader{Reader: bytes.NewReader(b[opts.Offset:])}, nil
+}
+
+func (s *memStore) Store(opts *storage.StoreOpts) (storage.DataWriter, error) {
+	return &memWriter{store: s}, nil
+}
+
+func (s *memStore) List() (list []data.ID, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for dataID := range s.objects {
+		list = append(list, dataID)
+	}
+	return
+}
+
+func (s *memStore) Delete(dataID data.ID) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.objects, dataID)
+	return nil
+}
+
+type memReader struct {
+	io.Reader
+}
+
+func (r *memReader) Close() error              { return nil }
+func (r *memReader) Info() *storage.ReaderInfo { return &storage.ReaderInfo{Name: "mem"} }
+
+type memWriter struct {
+	bytes.Buffer
+	store *memStore
+}
+
+func (w *memWriter) Commit() (data.ID, error) {
+	var dataID = data.Resolve(w.Bytes())
+
+	w.store.mu.Lock()
+	defer w.store.mu.Unlock()
+	w.store.objects[dataID] = w.Bytes()
+
+	return dataID, nil
+}
+
+func (w *memWriter) Discard() error { return nil }
+
+func newTestModule(t *testing.T) (*Module, *memStore) {
+	db, err := assets.NewCoreAssets(resources.NewMemResources()).OpenDB(storage.ModuleName)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var mod = &Module{
+		config: defaultConfig,
+		db:     db,
+		log:    log.NewLogger(log.NewLinePrinter(log.NewMonoOutput(io.Discard))),
+	}
+	mod.access = NewAccessManager(mod)
+	mod.data = NewDataManager(mod)
+	mod.pins = NewPinManager(mod)
+
+	if err = mod.dbAutoMigrate(); err != nil {
+		t.Fatal(err)
+	}
+
+	var store = &memStore{objects: map[data.ID][]byte{}}
+	mod.data.readers.Set("mem", store)
+	mod.data.stores.Set("mem", store)
+
+	return mod, store
+}
+
+type refChecker map[data.ID]bool
+
+func (c refChecker) IsReferenced(dataID data.ID) (bool, error) { return c[dataID], nil }
+
+type failingChecker struct{}
+
+func (failingChecker) IsReferenced(data.ID) (bool, error) { return false, errors.New("database error") }
+
+func TestGC(t *testing.T) {
+	mod, store := newTestModule(t)
+	mod.config.GCGracePeriod = 0
+
+	pinned, _ := mod.data.StoreBytes([]byte("pinned"), nil)
+	referenced, _ := mod.data.StoreBytes([]byte("referenced"), nil)
+	garbage, _ := mod.data.StoreBytes([]byte("garbage"), nil)
+
+	if err := mod.pins.Pin(pinned, "test"); err != nil {
+		t.Fatal(err)
+	}
+	mod.pins.AddReferenceChecker(refChecker{referenced: true})
+
+	// dry run doesn't remove anything
+	report, err := mod.GC(&storage.GCOpts{DryRun: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(report.Freed) != 1 || report.Freed[0] != garbage || len(store.objects) != 3 {
+		t.Fatalf("unexpected dry run result: %+v", report)
+	}
+
+	report, err = mod.GC(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.Scanned != 3 || report.FreedBytes != garbage.Size {
+		t.Fatalf("unexpected report: %+v", report)
+	}
+	if mod.data.has(garbage) || !mod.data.has(pinned) || !mod.data.has(referenced) {
+		t.Fatal("gc removed wrong objects")
+	}
+
+	// unpinned objects are collected
+	if err = mod.pins.Unpin(pinned, "test"); err != nil {
+		t.Fatal(err)
+	}
+	mod.GC(nil)
+	if mod.data.has(pinned) {
+		t.Fatal("unpinned object was not collected")
+	}
+}
+
+func TestGCCheckFailure(t *testing.T) {
+	mod, _ := newTestModule(t)
+	mod.config.GCGracePeriod = 0
+
+	referenced, _ := mod.data.StoreBytes([]byte("referenced"), nil)
+	unchecked, _ := mod.data.StoreBytes([]byte("unchecked"), nil)
+
+	mod.pins.AddReferenceChecker(refChecker{referenced: true})
+	mod.pins.AddReferenceChecker(failingChecker{})
+
+	report, err := mod.GC(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !mod.data.has(unchecked) || !mod.data.has(referenced) {
+		t.Fatal("gc removed an object it couldn't check")
+	}
+	if report.Failed != 1 || len(report.Freed) != 0 {
+		t.Fatalf("unexpected report: %+v", report)
+	}
+}
+
+func TestGCGracePeriod(t *testing.T) {
+	mod, _ := newTestModule(t)
+
+	dataID, _ := mod.data.StoreBytes([]byte("fresh"), nil)
+
+	mod.GC(nil)
+	if !mod.data.has(dataID) {
+		t.Fatal("gc removed a fresh object")
+	}
+
+	// the grace period survives a restart
+	mod.pins = NewPinManager(mod)
+	mod.GC(nil)
+	if !mod.data.has(dataID) {
+		t.Fatal("gc removed a fresh object after a restart")
+	}
+}
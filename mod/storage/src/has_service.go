@@ -0,0 +1,60 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"github.com/cryptopunkscc/astrald/mod/storage"
+	"github.com/cryptopunkscc/astrald/mod/storage/proto"
+	"github.com/cryptopunkscc/astrald/net"
+	"strings"
+)
+
+const hasServicePrefix = proto.HasServiceName + "."
+
+// HasService tells callers with access to a data object whether the node holds it
+type HasService struct {
+	*Module
+}
+
+func NewHasService(module *Module) *HasService {
+	return &HasService{Module: module}
+}
+
+func (srv *HasService) Run(ctx context.Context) error {
+	err := srv.node.LocalRouter().AddRoute(hasServicePrefix+"*", srv)
+	if err != nil {
+		return err
+	}
+	defer srv.node.LocalRouter().RemoveRoute(hasServicePrefix + "*")
+
+	<-ctx.Done()
+	return nil
+}
+
+func (srv *HasService) RouteQuery(ctx context.Context, query net.Query, caller net.SecureWriteCloser, hints net.Hints) (net.SecureWriteCloser, error) {
+	idstr, found := strings.CutPrefix(query.Query(), hasServicePrefix)
+	if !found {
+		return net.Reject()
+	}
+
+	dataID, err := srv.data.resolveRef(idstr)
+	switch {
+	case errors.Is(err, storage.ErrNotFound):
+		// reject unknown tree IDs the same way as denied access, so they can't be used to probe for objects
+		return net.RejectWithReason(net.RejectCodeAccessDenied, "")
+	case err != nil:
+		return net.RejectWithReason(net.RejectCodeInvalidQuery, "invalid data id")
+	}
+
+	if !srv.Access().Verify(query.Caller(), dataID) {
+		return net.RejectWithReason(net.RejectCodeAccessDenied, "")
+	}
+
+	if !srv.data.has(dataID) {
+		return net.RejectWithReason(net.RejectCodeNotFound, "")
+	}
+
+	return net.Accept(query, caller, func(conn net.SecureConn) {
+		conn.Close()
+	})
+}
@@ -0,0 +1,62 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"github.com/cryptopunkscc/astrald/auth/id"
+	"github.com/cryptopunkscc/astrald/data"
+	"github.com/cryptopunkscc/astrald/mod/storage/proto"
+	"github.com/cryptopunkscc/astrald/net"
+	"github.com/cryptopunkscc/astrald/node"
+	"testing"
+)
+
+type testNode struct {
+	node.Node
+	identity id.Identity
+}
+
+func (n *testNode) Identity() id.Identity { return n.identity }
+
+func TestHasServiceTreeIDs(t *testing.T) {
+	mod, _ := newTestModule(t)
+
+	local, _ := id.GenerateIdentity()
+	stranger, _ := id.GenerateIdentity()
+	mod.node = &testNode{identity: local}
+
+	dataID, err := mod.data.StoreBytes([]byte("stored"), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tree, err := mod.data.HashTree(dataID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, unknown, _ := data.ResolveTree(bytes.NewReader([]byte("unknown")))
+
+	var srv = NewHasService(mod)
+	var has = func(caller id.Identity, ref string) int {
+		var query = net.NewQuery(caller, local, proto.HasServiceName+"."+ref)
+		_, err := net.Route(context.Background(), srv, query)
+		if err == nil {
+			return -1
+		}
+		code, _ := net.RejectReason(err)
+		return code
+	}
+
+	if code := has(local, tree.ID().String()); code != -1 {
+		t.Fatalf("expected the tree ID to resolve, got code %d", code)
+	}
+
+	// unknown tree IDs look the same as objects the caller has no access to
+	for _, ref := range []string{tree.ID().String(), unknown.ID().String()} {
+		if code := has(stranger, ref); code != net.RejectCodeAccessDenied {
+			t.Fatalf("expected access to be denied, got code %d", code)
+		}
+	}
+	if code := has(local, unknown.ID().String()); code != net.RejectCodeAccessDenied {
+		t.Fatalf("expected unknown tree ID to be rejected as denied access, got code %d", code)
+	}
+}
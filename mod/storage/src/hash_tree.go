@@ -0,0 +1,111 @@
+package storage
+
+import (
+	"bytes"
+	"github.com/cryptopunkscc/astrald/data"
+	"github.com/cryptopunkscc/astrald/mod/storage"
+)
+
+// HashTree returns the hash tree of a locally stored data object. Trees are built from the stored data on
+// first use, which also verifies the data against its ID.
+func (mod *DataManager) HashTree(dataID data.ID) (*data.HashTree, error) {
+	if tree := mod.dbFindHashTree(dataID); tree != nil {
+		return tree, nil
+	}
+
+	r, err := mod.Read(dataID, &storage.ReadOpts{NoVirtual: true})
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+
+	resolvedID, tree, err := data.ResolveTree(r)
+	if err != nil {
+		return nil, err
+	}
+
+	if resolvedID != dataID {
+		mod.log.Error("stored data %v is corrupted", dataID)
+		return nil, storage.ErrNotFound
+	}
+
+	if err = mod.dbSaveHashTree(dataID, tree); err != nil {
+		mod.log.Errorv(1, "error saving hash tree of %v: %v", dataID, err)
+	}
+
+	return tree, nil
+}
+
+// ResolveTreeID returns the ID of a locally stored data object with the given TreeID. Only objects whose hash
+// tree is known can be found.
+func (mod *DataManager) ResolveTreeID(treeID data.TreeID) (data.ID, error) {
+	var rows []dbHashTree
+
+	err := mod.db.Where("root = ?", treeID.Root[:]).Find(&rows).Error
+	if err != nil {
+		return data.ID{}, err
+	}
+
+	for _, row := range rows {
+		dataID, err := data.Parse(row.DataID)
+		if err != nil || dataID.Size != treeID.Size {
+			continue
+		}
+		if tree := mod.dbFindHashTree(dataID); tree != nil && tree.ID() == treeID {
+			return dataID, nil
+		}
+	}
+
+	return data.ID{}, storage.ErrNotFound
+}
+
+// resolveRef parses a data ID or the TreeID of a locally stored object
+func (mod *DataManager) resolveRef(s string) (data.ID, error) {
+	if dataID, err := data.Parse(s); err == nil {
+		return dataID, nil
+	}
+
+	treeID, err := data.ParseTreeID(s)
+	if err != nil {
+		return data.ID{}, err
+	}
+
+	return mod.ResolveTreeID(treeID)
+}
+
+func (mod *DataManager) dbSaveHashTree(dataID data.ID, tree *data.HashTree) error {
+	var root = tree.Root()
+	var leaves = make([]byte, 0, len(tree.Leaves)*32)
+	for _, leaf := range tree.Leaves {
+		leaves = append(leaves, leaf[:]...)
+	}
+
+	return mod.db.Save(&dbHashTree{
+		DataID: dataID.String(),
+		Root:   root[:],
+		Leaves: leaves,
+	}).Error
+}
+
+func (mod *DataManager) dbFindHashTree(dataID data.ID) *data.HashTree {
+	var row dbHashTree
+
+	err := mod.db.Where("data_id = ?", dataID.String()).First(&row).Error
+	if err != nil {
+		return nil
+	}
+
+	var tree = &data.HashTree{Size: dataID.Size}
+	for leaves := row.Leaves; len(leaves) >= 32; leaves = leaves[32:] {
+		tree.Leaves = append(tree.Leaves, [32]byte(leaves[:32]))
+	}
+
+	// discard trees that don't match the object
+	var root = tree.Root()
+	if tree.Check() != nil || !bytes.Equal(root[:], row.Root) {
+		mod.db.Delete(&row)
+		return nil
+	}
+
+	return tree
+}
@@ -19,6 +19,7 @@ func (Loader) Load(node modules.Node, assets assets.Assets, log *log.Logger) (mo
 
 	mod.access = NewAccessManager(mod)
 	mod.data = NewDataManager(mod)
+	mod.pins = NewPinManager(mod)
 
 	mod.events.SetParent(node.Events())
 
@@ -29,6 +30,25 @@ func (Loader) Load(node modules.Node, assets assets.Assets, log *log.Logger) (mo
 		return nil, err
 	}
 
+	if err = mod.dbAutoMigrate(); err != nil {
+		return nil, err
+	}
+
+	mod.access.AddAccessVerifier(&ChunkAccessVerifier{Module: mod})
+
+	mod.replicator = NewReplicator(mod)
+	mod.access.AddAccessVerifier(mod.replicator)
+
+	if mod.config.Encryption {
+		key, err := encryptionKey(node.Identity(), mod.config.EncryptionKeyFile)
+		if err != nil {
+			return nil, err
+		}
+
+		mod.data.encrypted = NewEncryptedStore(mod.data, key)
+		mod.data.AddReader(encryptedReaderName, mod.data.encrypted)
+	}
+
 	return mod, nil
 }
 
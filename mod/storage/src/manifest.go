@@ -0,0 +1,225 @@
+package storage
+
+import (
+	"github.com/cryptopunkscc/astrald/auth/id"
+	"github.com/cryptopunkscc/astrald/cslq"
+	"github.com/cryptopunkscc/astrald/data"
+	_data "github.com/cryptopunkscc/astrald/mod/data"
+	"github.com/cryptopunkscc/astrald/mod/storage"
+	"gorm.io/gorm"
+	"io"
+)
+
+// Manifest returns the manifest of a data object stored in chunks
+func (mod *DataManager) Manifest(dataID data.ID) (*storage.Manifest, error) {
+	var chunks = mod.dbFindChunks(dataID)
+	if len(chunks) == 0 {
+		return nil, storage.ErrNotFound
+	}
+
+	return &storage.Manifest{DataID: dataID, Chunks: chunks}, nil
+}
+
+// maxChunks returns the largest number of chunks an object of the given size can be split into. Every chunk but
+// the last one is at least minChunkSize long.
+func maxChunks(size uint64) uint64 {
+	return size/minChunkSize + 1
+}
+
+// decodeManifest decodes the manifest of a data object received from another node. The number of chunks is
+// checked against the size of the object before any chunk IDs are allocated.
+func decodeManifest(r io.Reader, dataID data.ID) (*storage.Manifest, error) {
+	var dec = cslq.NewDecoder(r)
+	var manifest = &storage.Manifest{}
+	var count uint32
+
+	if err := dec.Decodef("vl", &manifest.DataID, &count); err != nil {
+		return nil, err
+	}
+	if manifest.DataID != dataID || uint64(count) > maxChunks(dataID.Size) {
+		return nil, storage.ErrInvalidManifest
+	}
+
+	var size uint64
+	manifest.Chunks = make([]data.ID, 0, min(count, 1024))
+	for len(manifest.Chunks) < int(count) {
+		var chunkID data.ID
+		if err := dec.Decodef("v", &chunkID); err != nil {
+			return nil, err
+		}
+
+		size += chunkID.Size
+		if chunkID.Size == 0 || chunkID.Size > maxChunkSize || size > dataID.Size {
+			return nil, storage.ErrInvalidManifest
+		}
+
+		manifest.Chunks = append(manifest.Chunks, chunkID)
+	}
+
+	if size != dataID.Size {
+		return nil, storage.ErrInvalidManifest
+	}
+
+	return manifest, nil
+}
+
+// saveManifest stores the manifest as an ADC0 object and indexes its chunks
+func (mod *DataManager) saveManifest(manifest *storage.Manifest) error {
+	var size uint64
+	for _, chunk := range manifest.Chunks {
+		size += chunk.Size
+	}
+	if size != manifest.DataID.Size {
+		return storage.ErrInvalidManifest
+	}
+
+	w, err := mod.storePlain(&storage.StoreOpts{})
+	if err != nil {
+		return err
+	}
+	defer w.Discard()
+
+	err = cslq.Encode(w, "vv", _data.ADC0Header(storage.ManifestType), manifest)
+	if err != nil {
+		return err
+	}
+
+	manifestID, err := w.Commit()
+	if err != nil {
+		return err
+	}
+
+	mod.pins.touch(manifest.DataID)
+
+	return mod.db.Transaction(func(tx *gorm.DB) error {
+		var dataID = manifest.DataID.String()
+
+		err := tx.Save(&dbManifest{
+			DataID:     dataID,
+			ManifestID: manifestID.String(),
+		}).Error
+		if err != nil {
+			return err
+		}
+
+		err = tx.Delete(&dbChunk{}, "data_id = ?", dataID).Error
+		if err != nil {
+			return err
+		}
+
+		var rows = make([]dbChunk, 0, len(manifest.Chunks))
+		for i, chunk := range manifest.Chunks {
+			rows = append(rows, dbChunk{
+				DataID:  dataID,
+				Index:   i,
+				ChunkID: chunk.String(),
+			})
+		}
+
+		return tx.CreateInBatches(rows, 100).Error
+	})
+}
+
+func (mod *DataManager) dbFindChunks(dataID data.ID) []data.ID {
+	var rows []dbChunk
+
+	err := mod.db.Where("data_id = ?", dataID.String()).Order("`index`").Find(&rows).Error
+	if err != nil {
+		return nil
+	}
+
+	var chunks = make([]data.ID, 0, len(rows))
+	for _, row := range rows {
+		chunkID, err := data.Parse(row.ChunkID)
+		if err != nil {
+			return nil
+		}
+		chunks = append(chunks, chunkID)
+	}
+
+	return chunks
+}
+
+// ChunkAccessVerifier grants access to a chunk to everyone who has access to an object that contains the chunk
+type ChunkAccessVerifier struct {
+	*Module
+}
+
+func (v *ChunkAccessVerifier) Verify(identity id.Identity, chunkID data.ID) bool {
+	var rows []dbChunk
+
+	err := v.db.Where("chunk_id = ?", chunkID.String()).Find(&rows).Error
+	if err != nil {
+		return false
+	}
+
+	for _, row := range rows {
+		dataID, err := data.Parse(row.DataID)
+		if err != nil {
+			continue
+		}
+		if v.access.Verify(identity, dataID) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// dbFindParents returns IDs of objects that are built from the given chunk, described by the given manifest
+// or encrypted as the given object
+func (mod *DataManager) dbFindParents(dataID data.ID) ([]data.ID, error) {
+	var ids, manifests, plain []string
+
+	err := mod.db.Model(&dbChunk{}).Where("chunk_id = ?", dataID.String()).Distinct().Pluck("data_id", &ids).Error
+	if err != nil {
+		return nil, err
+	}
+
+	err = mod.db.Model(&dbManifest{}).Where("manifest_id = ?", dataID.String()).Pluck("data_id", &manifests).Error
+	if err != nil {
+		return nil, err
+	}
+	ids = append(ids, manifests...)
+
+	err = mod.db.Model(&dbEncrypted{}).Where("cipher_id = ?", dataID.String()).Pluck("data_id", &plain).Error
+	if err != nil {
+		return nil, err
+	}
+	ids = append(ids, plain...)
+
+	var parents = make([]data.ID, 0, len(ids))
+	for _, s := range ids {
+		parentID, err := data.Parse(s)
+		if err != nil {
+			return nil, err
+		}
+		parents = append(parents, parentID)
+	}
+
+	return parents, nil
+}
+
+// forget drops everything the module knows about a removed object
+func (mod *DataManager) forget(dataID data.ID) {
+	var s = dataID.String()
+
+	mod.db.Delete(&dbHashTree{}, "data_id = ?", s)
+
+	// a removed encrypted copy takes its plaintext object with it
+	var plain []string
+	mod.db.Model(&dbEncrypted{}).Where("cipher_id = ?", s).Pluck("data_id", &plain)
+	for _, dataID := range plain {
+		mod.db.Delete(&dbEncrypted{}, "data_id = ?", dataID)
+		mod.db.Delete(&dbHashTree{}, "data_id = ?", dataID)
+	}
+
+	// a removed manifest takes its object with it
+	var manifests []string
+	mod.db.Model(&dbManifest{}).Where("manifest_id = ?", s).Pluck("data_id", &manifests)
+	for _, parent := range manifests {
+		mod.db.Delete(&dbChunk{}, "data_id = ?", parent)
+		mod.db.Delete(&dbManifest{}, "data_id = ?", parent)
+		mod.db.Delete(&dbHashTree{}, "data_id = ?", parent)
+	}
+}
@@ -0,0 +1,59 @@
+package storage
+
+import (
+	"context"
+	"github.com/cryptopunkscc/astrald/cslq"
+	"github.com/cryptopunkscc/astrald/data"
+	"github.com/cryptopunkscc/astrald/mod/storage/proto"
+	"github.com/cryptopunkscc/astrald/net"
+	"strings"
+)
+
+const manifestServicePrefix = proto.ManifestServiceName + "."
+
+// ManifestService lets callers fetch chunked objects chunk by chunk
+type ManifestService struct {
+	*Module
+}
+
+func NewManifestService(module *Module) *ManifestService {
+	return &ManifestService{Module: module}
+}
+
+func (srv *ManifestService) Run(ctx context.Context) error {
+	err := srv.node.LocalRouter().AddRoute(manifestServicePrefix+"*", srv)
+	if err != nil {
+		return err
+	}
+	defer srv.node.LocalRouter().RemoveRoute(manifestServicePrefix + "*")
+
+	<-ctx.Done()
+	return nil
+}
+
+func (srv *ManifestService) RouteQuery(ctx context.Context, query net.Query, caller net.SecureWriteCloser, hints net.Hints) (net.SecureWriteCloser, error) {
+	idstr, found := strings.CutPrefix(query.Query(), manifestServicePrefix)
+	if !found {
+		return net.Reject()
+	}
+
+	dataID, err := data.Parse(idstr)
+	if err != nil {
+		return net.RejectWithReason(net.RejectCodeInvalidQuery, "invalid data id")
+	}
+
+	if !srv.Access().Verify(query.Caller(), dataID) {
+		return net.RejectWithReason(net.RejectCodeAccessDenied, "")
+	}
+
+	manifest, err := srv.data.Manifest(dataID)
+	if err != nil {
+		return net.RejectWithReason(net.RejectCodeNotFound, "not chunked")
+	}
+
+	return net.Accept(query, caller, func(conn net.SecureConn) {
+		defer conn.Close()
+
+		cslq.Encode(conn, "v", manifest)
+	})
+}
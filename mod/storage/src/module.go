@@ -4,11 +4,13 @@ import (
 	"context"
 	"github.com/cryptopunkscc/astrald/log"
 	"github.com/cryptopunkscc/astrald/mod/discovery"
+	"github.com/cryptopunkscc/astrald/mod/index"
 	"github.com/cryptopunkscc/astrald/mod/storage"
 	"github.com/cryptopunkscc/astrald/node"
 	"github.com/cryptopunkscc/astrald/node/events"
 	"github.com/cryptopunkscc/astrald/tasks"
 	"gorm.io/gorm"
+	"sync"
 )
 
 var _ storage.Module = &Module{}
@@ -21,15 +23,28 @@ type Module struct {
 	events events.Queue
 	ctx    context.Context
 	sdp    discovery.Module
+	index  index.Module
 
-	access *AccessManager
-	data   *DataManager
+	access     *AccessManager
+	data       *DataManager
+	pins       *PinManager
+	replicator *Replicator
+	gcMu       sync.Mutex
 }
 
 func (mod *Module) Run(ctx context.Context) error {
 	mod.ctx = ctx
 
-	tasks.Group(NewReadService(mod)).Run(ctx)
+	tasks.Group(
+		NewReadService(mod),
+		NewReadAtService(mod),
+		NewManifestService(mod),
+		NewTreeService(mod),
+		NewHasService(mod),
+		NewReplicateService(mod),
+		mod.replicator,
+		&tasks.RunFuncAdapter{RunFunc: mod.gcLoop},
+	).Run(ctx)
 
 	<-ctx.Done()
 
@@ -44,6 +59,14 @@ func (mod *Module) Data() storage.DataManager {
 	return mod.data
 }
 
+func (mod *Module) Pins() storage.PinManager {
+	return mod.pins
+}
+
 func (mod *Module) Events() *events.Queue {
 	return &mod.events
 }
+
+func (mod *Module) UnderReplicated() []storage.ReplicaStatus {
+	return mod.replicator.UnderReplicated()
+}
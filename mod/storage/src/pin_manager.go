@@ -0,0 +1,103 @@
+package storage
+
+import (
+	"github.com/cryptopunkscc/astrald/data"
+	"github.com/cryptopunkscc/astrald/mod/storage"
+	"github.com/cryptopunkscc/astrald/sig"
+	"time"
+)
+
+var _ storage.PinManager = &PinManager{}
+
+type PinManager struct {
+	*Module
+	checkers sig.Set[storage.ReferenceChecker]
+}
+
+func NewPinManager(module *Module) *PinManager {
+	return &PinManager{Module: module}
+}
+
+// Pin marks the object as used by the holder. Pinning an object twice with the same holder has no effect.
+func (mod *PinManager) Pin(dataID data.ID, holder string) error {
+	return mod.db.Save(&dbPin{
+		DataID: dataID.String(),
+		Holder: holder,
+	}).Error
+}
+
+func (mod *PinManager) Unpin(dataID data.ID, holder string) error {
+	var tx = mod.db.Delete(&dbPin{}, "data_id = ? and holder = ?", dataID.String(), holder)
+	if tx.Error != nil {
+		return tx.Error
+	}
+	if tx.RowsAffected == 0 {
+		return storage.ErrNotFound
+	}
+	return nil
+}
+
+// Holders returns the holders of all pins of the object
+func (mod *PinManager) Holders(dataID data.ID) ([]string, error) {
+	var holders []string
+
+	err := mod.db.Model(&dbPin{}).Where("data_id = ?", dataID.String()).Pluck("holder", &holders).Error
+
+	return holders, err
+}
+
+// IsReferenced returns true if the object is pinned or any of the checkers reports it as referenced. An error
+// is returned only if no check found a reference and at least one of them failed.
+func (mod *PinManager) IsReferenced(dataID data.ID) (bool, error) {
+	holders, err := mod.Holders(dataID)
+	if len(holders) > 0 {
+		return true, nil
+	}
+
+	for _, checker := range mod.checkers.Clone() {
+		referenced, cerr := checker.IsReferenced(dataID)
+		if referenced {
+			return true, nil
+		}
+		if cerr != nil {
+			err = cerr
+		}
+	}
+
+	return false, err
+}
+
+func (mod *PinManager) AddReferenceChecker(checker storage.ReferenceChecker) {
+	mod.checkers.Add(checker)
+}
+
+func (mod *PinManager) RemoveReferenceChecker(checker storage.ReferenceChecker) {
+	mod.checkers.Remove(checker)
+}
+
+// touch protects a freshly stored object from the garbage collector for the grace period, so that its
+// writer has time to pin or index it
+func (mod *PinManager) touch(dataID data.ID) {
+	err := mod.db.Save(&dbRecent{
+		DataID:   dataID.String(),
+		StoredAt: time.Now(),
+	}).Error
+	if err != nil {
+		mod.log.Errorv(1, "error saving stored object %v: %v", dataID, err)
+	}
+}
+
+func (mod *PinManager) isRecent(dataID data.ID) (bool, error) {
+	var count int64
+
+	err := mod.db.Model(&dbRecent{}).
+		Where("data_id = ? and stored_at > ?", dataID.String(), time.Now().Add(-mod.config.GCGracePeriod)).
+		Count(&count).Error
+
+	return count > 0, err
+}
+
+// pruneRecent forgets objects stored before the grace period
+func (mod *PinManager) pruneRecent() error {
+	return mod.db.Delete(&dbRecent{}, "stored_at <= ?", time.Now().Add(-mod.config.GCGracePeriod)).Error
+}
@@ -0,0 +1,153 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"github.com/cryptopunkscc/astrald/data"
+	"github.com/cryptopunkscc/astrald/mod/storage"
+	"github.com/cryptopunkscc/astrald/mod/storage/proto"
+	"github.com/cryptopunkscc/astrald/net"
+	"io"
+	"strings"
+)
+
+const readAtServicePrefix = proto.ReadAtServiceName + "."
+
+// ReadAtService serves sessions in which the caller reads arbitrary ranges of a data object
+type ReadAtService struct {
+	*Module
+}
+
+func NewReadAtService(module *Module) *ReadAtService {
+	return &ReadAtService{Module: module}
+}
+
+func (srv *ReadAtService) Run(ctx context.Context) error {
+	err := srv.node.LocalRouter().AddRoute(readAtServicePrefix+"*", srv)
+	if err != nil {
+		return err
+	}
+	defer srv.node.LocalRouter().RemoveRoute(readAtServicePrefix + "*")
+
+	<-ctx.Done()
+	return nil
+}
+
+func (srv *ReadAtService) RouteQuery(ctx context.Context, query net.Query, caller net.SecureWriteCloser, hints net.Hints) (net.SecureWriteCloser, error) {
+	idstr, found := strings.CutPrefix(query.Query(), readAtServicePrefix)
+	if !found {
+		return net.Reject()
+	}
+
+	dataID, err := srv.data.resolveRef(idstr)
+	switch {
+	case errors.Is(err, storage.ErrNotFound):
+		// reject unknown tree IDs the same way as denied access, so they can't be used to probe for objects
+		return net.RejectWithReason(net.RejectCodeAccessDenied, "")
+	case err != nil:
+		srv.log.Errorv(2, "parse error: %v", err)
+		return net.RejectWithReason(net.RejectCodeInvalidQuery, "invalid data id")
+	}
+
+	if !srv.Access().Verify(query.Caller(), dataID) {
+		srv.log.Errorv(2, "access to %v denied for %v", dataID, query.Caller())
+		return net.RejectWithReason(net.RejectCodeAccessDenied, "")
+	}
+
+	r, err := srv.Data().Read(dataID, nil)
+	if err != nil {
+		return net.RejectWithReason(net.RejectCodeNotFound, "")
+	}
+
+	// data transfers should not slow down other traffic on the link
+	net.SetPriority(caller, net.PriorityBulk)
+
+	return net.Accept(query, caller, func(conn net.SecureConn) {
+		defer conn.Close()
+
+		srv.serve(conn, dataID, r)
+	})
+}
+
+// serve answers range requests until the caller closes the session. The reader is kept open between requests,
+// so that sequential reads don't have to reopen the data. If the object has a hash tree, every block is verified
+// before it is sent, so that corrupted data is never served.
+func (srv *ReadAtService) serve(conn net.SecureConn, dataID data.ID, r storage.DataReader) {
+	var session = proto.NewSession(conn)
+	var tree = srv.data.dbFindHashTree(dataID)
+	var src io.Reader = r
+	var pos uint64
+
+	if tree != nil {
+		src = tree.NewVerifier(r, 0)
+	}
+
+	defer func() {
+		if r != nil {
+			r.Close()
+		}
+	}()
+
+	for {
+		var req proto.ReadAtRequest
+		if err := session.Decode(&req); err != nil {
+			return
+		}
+
+		if req.Offset > dataID.Size {
+			if err := session.EncodeErr(proto.ErrInvalidOffset); err != nil {
+				return
+			}
+			continue
+		}
+
+		if r == nil || pos != req.Offset {
+			if r != nil {
+				r.Close()
+			}
+
+			var readOffset = req.Offset
+			if tree != nil {
+				readOffset = data.AlignOffset(req.Offset)
+			}
+
+			var err error
+			r, err = srv.Data().Read(dataID, &storage.ReadOpts{Offset: readOffset})
+			if err != nil {
+				r = nil
+				srv.log.Errorv(2, "error reading %v at %v: %v", dataID, req.Offset, err)
+				if err := session.EncodeErr(proto.ErrReadFailed); err != nil {
+					return
+				}
+				continue
+			}
+
+			src = r
+			if tree != nil {
+				src = tree.NewVerifier(r, req.Offset)
+			}
+			pos = req.Offset
+		}
+
+		var length = min(uint64(req.Length), dataID.Size-req.Offset, proto.MaxReadAtLength)
+
+		if err := session.EncodeErr(nil); err != nil {
+			return
+		}
+		if err := session.Encode(proto.ReadAtResponse{Length: uint32(length)}); err != nil {
+			return
+		}
+
+		// the stream cannot recover from a short read, so end the session
+		n, err := io.CopyN(conn, src, int64(length))
+		pos += uint64(n)
+		if err != nil {
+			if errors.Is(err, data.ErrBlockMismatch) {
+				srv.log.Error("stored data %v is corrupted", dataID)
+			} else {
+				srv.log.Errorv(2, "error reading %v at %v: %v", dataID, req.Offset, err)
+			}
+			return
+		}
+	}
+}
@@ -2,13 +2,17 @@ package storage
 
 import (
 	"context"
+	"errors"
 	"github.com/cryptopunkscc/astrald/data"
+	"github.com/cryptopunkscc/astrald/mod/storage"
+	"github.com/cryptopunkscc/astrald/mod/storage/proto"
 	"github.com/cryptopunkscc/astrald/net"
 	"io"
+	"strconv"
 	"strings"
 )
 
-const readServicePrefix = "storage.read."
+const readServicePrefix = proto.ReadServiceName + "."
 
 type ReadService struct {
 	*Module
@@ -35,26 +39,65 @@ func (srv *ReadService) RouteQuery(ctx context.Context, query net.Query, caller
 		return net.Reject()
 	}
 
-	dataID, err := data.Parse(idstr)
-	if err != nil {
+	dataID, err := srv.data.resolveRef(idstr)
+	switch {
+	case errors.Is(err, storage.ErrNotFound):
+		// reject unknown tree IDs the same way as denied access, so they can't be used to probe for objects
+		return net.RejectWithReason(net.RejectCodeAccessDenied, "")
+	case err != nil:
 		srv.log.Errorv(2, "parse error: %v", err)
-		return net.Reject()
+		return net.RejectWithReason(net.RejectCodeInvalidQuery, "invalid data id")
+	}
+
+	// parse the optional range
+	var offset, length uint64 = 0, dataID.Size
+	if p := query.Params().Get(proto.ParamOffset); p != "" {
+		offset, err = strconv.ParseUint(p, 10, 64)
+		if err != nil || offset > dataID.Size {
+			return net.RejectWithReason(net.RejectCodeInvalidQuery, "invalid offset")
+		}
+		length -= offset
+	}
+	if p := query.Params().Get(proto.ParamLength); p != "" {
+		l, err := strconv.ParseUint(p, 10, 64)
+		if err != nil {
+			return net.RejectWithReason(net.RejectCodeInvalidQuery, "invalid length")
+		}
+		length = min(length, l)
 	}
 
 	if !srv.Access().Verify(query.Caller(), dataID) {
 		srv.log.Errorv(2, "access to %v denied for %v", dataID, query.Caller())
-		return net.Reject()
+		return net.RejectWithReason(net.RejectCodeAccessDenied, "")
 	}
 
-	r, err := srv.Data().Read(dataID, nil)
+	// if the object has a hash tree, verify blocks as they're read, so that corrupted data is never sent
+	var tree = srv.data.dbFindHashTree(dataID)
+	var readOffset = offset
+	if tree != nil {
+		readOffset = data.AlignOffset(offset)
+	}
+
+	r, err := srv.Data().Read(dataID, &storage.ReadOpts{Offset: readOffset})
 	if err != nil {
-		return net.Reject()
+		return net.RejectWithReason(net.RejectCodeNotFound, "")
 	}
 
+	var src io.Reader = r
+	if tree != nil {
+		src = tree.NewVerifier(r, offset)
+	}
+
+	// data transfers should not slow down other traffic on the link
+	net.SetPriority(caller, net.PriorityBulk)
+
 	return net.Accept(query, caller, func(conn net.SecureConn) {
 		defer r.Close()
 		defer conn.Close()
 
-		io.Copy(conn, r)
+		_, err := io.CopyN(conn, src, int64(length))
+		if errors.Is(err, data.ErrBlockMismatch) {
+			srv.log.Error("stored data %v is corrupted", dataID)
+		}
 	})
 }
@@ -0,0 +1,75 @@
+package storage
+
+import (
+	"context"
+	"github.com/cryptopunkscc/astrald/auth/id"
+	"github.com/cryptopunkscc/astrald/data"
+	"github.com/cryptopunkscc/astrald/mod/storage"
+	"github.com/cryptopunkscc/astrald/mod/storage/proto"
+	"github.com/cryptopunkscc/astrald/net"
+	"strings"
+)
+
+const replicateServicePrefix = proto.ReplicateServiceName + "."
+
+// ReplicateService downloads data objects pushed by replica nodes. Downloaded copies are pinned, so that
+// they're not collected even though nothing on this node references them.
+type ReplicateService struct {
+	*Module
+}
+
+func NewReplicateService(module *Module) *ReplicateService {
+	return &ReplicateService{Module: module}
+}
+
+func (srv *ReplicateService) Run(ctx context.Context) error {
+	err := srv.node.LocalRouter().AddRoute(replicateServicePrefix+"*", srv)
+	if err != nil {
+		return err
+	}
+	defer srv.node.LocalRouter().RemoveRoute(replicateServicePrefix + "*")
+
+	<-ctx.Done()
+	return nil
+}
+
+func (srv *ReplicateService) RouteQuery(ctx context.Context, query net.Query, caller net.SecureWriteCloser, hints net.Hints) (net.SecureWriteCloser, error) {
+	idstr, found := strings.CutPrefix(query.Query(), replicateServicePrefix)
+	if !found {
+		return net.Reject()
+	}
+
+	dataID, err := data.Parse(idstr)
+	if err != nil {
+		return net.RejectWithReason(net.RejectCodeInvalidQuery, "invalid data id")
+	}
+
+	if !srv.replicator.isReplicaNode(query.Caller()) {
+		return net.RejectWithReason(net.RejectCodeAccessDenied, "")
+	}
+
+	var opts = &storage.DownloadOpts{Sources: []id.Identity{query.Caller()}}
+	if p := query.Params().Get(proto.ParamTree); p != "" {
+		treeID, err := data.ParseTreeID(p)
+		if err != nil {
+			return net.RejectWithReason(net.RejectCodeInvalidQuery, "invalid tree id")
+		}
+		opts.TreeID = &treeID
+	}
+
+	return net.Accept(query, caller, func(conn net.SecureConn) {
+		defer conn.Close()
+
+		var result byte = proto.ReplicateOK
+
+		err := srv.Download(srv.ctx, dataID, storage.ReplicaHolder, opts)
+		if err != nil {
+			srv.log.Errorv(1, "error replicating %v from %v: %v", dataID, query.Caller(), err)
+			result = 1
+		} else {
+			srv.log.Infov(1, "replicated %v from %v", dataID, query.Caller())
+		}
+
+		conn.Write([]byte{result})
+	})
+}
@@ -0,0 +1,310 @@
+package storage
+
+import (
+	"context"
+	"github.com/cryptopunkscc/astrald/auth/id"
+	"github.com/cryptopunkscc/astrald/data"
+	"github.com/cryptopunkscc/astrald/mod/index"
+	"github.com/cryptopunkscc/astrald/mod/storage"
+	"github.com/cryptopunkscc/astrald/mod/storage/proto"
+	"github.com/cryptopunkscc/astrald/net"
+	"github.com/cryptopunkscc/astrald/node/events"
+	"github.com/cryptopunkscc/astrald/sig"
+	"io"
+	"slices"
+	"sync"
+	"time"
+)
+
+const replicaPushTimeout = 30 * time.Minute
+
+var _ storage.AccessVerifier = &Replicator{}
+
+// Replicator keeps the number of copies of data listed in replicated indexes at the level set by the policy.
+// Every object is checked when it's added to an index and then periodically. Replica nodes that lack
+// the object are asked to download it from this node. If this node lacks the object, it downloads it from
+// the nodes that have it first.
+type Replicator struct {
+	*Module
+	policies map[string]int
+
+	mu      sync.Mutex
+	nodes   []id.Identity
+	pending []data.ID
+	queued  map[data.ID]bool
+	wake    chan struct{}
+	status  sig.Map[data.ID, *storage.ReplicaStatus]
+}
+
+func NewReplicator(mod *Module) *Replicator {
+	var r = &Replicator{
+		Module:   mod,
+		policies: map[string]int{},
+		queued:   map[data.ID]bool{},
+		wake:     make(chan struct{}, 1),
+	}
+
+	for _, p := range mod.config.Replication.Policies {
+		r.policies[p.Index] = max(r.policies[p.Index], p.Copies)
+	}
+
+	return r
+}
+
+func (r *Replicator) Run(ctx context.Context) error {
+	r.resolveNodes()
+
+	if len(r.policies) == 0 || r.index == nil {
+		<-ctx.Done()
+		return nil
+	}
+
+	go events.Handle(ctx, r.node.Events(), func(ctx context.Context, event index.EventEntryUpdate) error {
+		if _, found := r.policies[event.IndexName]; found && event.Added {
+			r.enqueue(event.DataID)
+		}
+		return nil
+	})
+
+	go r.scanLoop(ctx)
+
+	for {
+		select {
+		case <-r.wake:
+		case <-ctx.Done():
+			return nil
+		}
+
+		for {
+			dataID, ok := r.next()
+			if !ok {
+				break
+			}
+			r.check(ctx, dataID)
+		}
+	}
+}
+
+// Verify gives replica nodes access to replicated data, so that they can download it
+func (r *Replicator) Verify(identity id.Identity, dataID data.ID) bool {
+	return r.isReplicaNode(identity) && r.target(dataID) > 0
+}
+
+func (r *Replicator) UnderReplicated() []storage.ReplicaStatus {
+	var list []storage.ReplicaStatus
+	for _, s := range r.status.Values() {
+		list = append(list, *s)
+	}
+
+	slices.SortFunc(list, func(a, b storage.ReplicaStatus) int {
+		return a.CheckedAt.Compare(b.CheckedAt)
+	})
+
+	return list
+}
+
+func (r *Replicator) isReplicaNode(identity id.Identity) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return slices.ContainsFunc(r.nodes, identity.IsEqual)
+}
+
+func (r *Replicator) replicaNodes() []id.Identity {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return slices.Clone(r.nodes)
+}
+
+func (r *Replicator) resolveNodes() {
+	var nodes []id.Identity
+
+	for _, name := range r.config.Replication.Nodes {
+		identity, err := r.node.Resolver().Resolve(name)
+		if err != nil {
+			r.log.Error("replication: cannot resolve %v: %v", name, err)
+			continue
+		}
+		if identity.IsEqual(r.node.Identity()) {
+			continue
+		}
+		nodes = append(nodes, identity)
+	}
+
+	r.mu.Lock()
+	r.nodes = nodes
+	r.mu.Unlock()
+}
+
+// scanLoop periodically queues all replicated data, so that copies lost on other nodes are restored
+func (r *Replicator) scanLoop(ctx context.Context) {
+	for {
+		for name := range r.policies {
+			entries, err := r.index.UpdatedSince(name, time.Time{})
+			if err != nil {
+				r.log.Errorv(1, "replication: error scanning %v: %v", name, err)
+				continue
+			}
+			for _, entry := range entries {
+				if entry.Added {
+					r.enqueue(entry.DataID)
+				}
+			}
+		}
+
+		if r.config.Replication.Interval <= 0 {
+			return
+		}
+
+		select {
+		case <-time.After(r.config.Replication.Interval):
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+func (r *Replicator) enqueue(dataID data.ID) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.queued[dataID] {
+		return
+	}
+	r.queued[dataID] = true
+	r.pending = append(r.pending, dataID)
+
+	select {
+	case r.wake <- struct{}{}:
+	default:
+	}
+}
+
+func (r *Replicator) next() (data.ID, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if len(r.pending) == 0 {
+		return data.ID{}, false
+	}
+
+	var dataID = r.pending[0]
+	r.pending = r.pending[1:]
+	delete(r.queued, dataID)
+
+	return dataID, true
+}
+
+// target returns the number of copies the object should have, which is the highest number required by
+// the policies of indexes that list it
+func (r *Replicator) target(dataID data.ID) int {
+	if r.index == nil {
+		return 0
+	}
+
+	names, err := r.index.Find(dataID)
+	if err != nil {
+		return 0
+	}
+
+	var target int
+	for _, name := range names {
+		if contains, _ := r.index.Contains(name, dataID); contains {
+			target = max(target, r.policies[name])
+		}
+	}
+
+	return min(target, len(r.replicaNodes())+1)
+}
+
+func (r *Replicator) check(ctx context.Context, dataID data.ID) {
+	var target = r.target(dataID)
+	if target == 0 {
+		r.status.Delete(dataID)
+		return
+	}
+
+	var nodes = r.replicaNodes()
+	var holders = r.holders(ctx, dataID, nodes)
+	var local = r.data.has(dataID)
+	var copies = len(holders)
+	if local {
+		copies++
+	}
+
+	// pull a copy first, so that it can be pushed to the remaining nodes
+	if !local && len(holders) > 0 && copies < target {
+		if err := r.Download(ctx, dataID, storage.ReplicaHolder, &storage.DownloadOpts{Sources: holders}); err != nil {
+			r.log.Errorv(1, "replication: error downloading %v: %v", dataID, err)
+		} else {
+			local = true
+			copies++
+		}
+	}
+
+	if local {
+		for _, node := range nodes {
+			if copies >= target {
+				break
+			}
+			if slices.ContainsFunc(holders, node.IsEqual) {
+				continue
+			}
+			if err := r.push(ctx, node, dataID); err != nil {
+				r.log.Errorv(1, "replication: error pushing %v to %v: %v", dataID, node, err)
+				continue
+			}
+			holders = append(holders, node)
+			copies++
+		}
+	}
+
+	if copies >= target {
+		r.status.Delete(dataID)
+		return
+	}
+
+	r.status.Replace(dataID, &storage.ReplicaStatus{
+		DataID:    dataID,
+		Copies:    copies,
+		Target:    target,
+		Holders:   holders,
+		CheckedAt: time.Now(),
+	})
+}
+
+// push asks a node to download the object from this node and waits until it's done
+func (r *Replicator) push(ctx context.Context, node id.Identity, dataID data.ID) error {
+	ctx, cancel := context.WithTimeout(ctx, replicaPushTimeout)
+	defer cancel()
+
+	// send the tree ID, so that the node can verify blocks as they arrive
+	var params = net.Params{}
+	if tree, err := r.data.HashTree(dataID); err == nil {
+		params[proto.ParamTree] = tree.ID().String()
+	}
+
+	var query = net.WithParams(net.NewQuery(r.node.Identity(), node, proto.ReplicateServiceName+"."+dataID.String()), params)
+
+	conn, err := net.Route(ctx, r.node.Router(), query)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	go func() {
+		<-ctx.Done()
+		conn.Close()
+	}()
+
+	var result = make([]byte, 1)
+	if _, err = io.ReadFull(conn, result); err != nil {
+		return err
+	}
+	if result[0] != proto.ReplicateOK {
+		return storage.ErrStorageUnavailable
+	}
+
+	return nil
+}
@@ -0,0 +1,110 @@
+package storage
+
+import (
+	"github.com/cryptopunkscc/astrald/auth/id"
+	"github.com/cryptopunkscc/astrald/data"
+	"github.com/cryptopunkscc/astrald/mod/index"
+	"testing"
+	"time"
+)
+
+// memIndex is an index module that keeps set memberships in memory
+type memIndex struct {
+	index.Module
+	sets map[string]map[data.ID]bool
+}
+
+func (idx *memIndex) Find(dataID data.ID) (names []string, err error) {
+	for name, set := range idx.sets {
+		if set[dataID] {
+			names = append(names, name)
+		}
+	}
+	return
+}
+
+func (idx *memIndex) Contains(name string, dataID data.ID) (bool, error) {
+	return idx.sets[name][dataID], nil
+}
+
+func (idx *memIndex) UpdatedSince(name string, since time.Time) (entries []index.Entry, err error) {
+	for dataID := range idx.sets[name] {
+		entries = append(entries, index.Entry{DataID: dataID, Added: true})
+	}
+	return
+}
+
+func TestReplicatorTarget(t *testing.T) {
+	mod, _ := newTestModule(t)
+
+	var photos = data.Resolve([]byte("photo"))
+	var docs = data.Resolve([]byte("doc"))
+	var other = data.Resolve([]byte("other"))
+
+	mod.index = &memIndex{sets: map[string]map[data.ID]bool{
+		"photos": {photos: true, docs: true},
+		"docs":   {docs: true},
+		"misc":   {other: true},
+	}}
+	mod.config.Replication.Policies = []ConfigReplicationPolicy{
+		{Index: "photos", Copies: 2},
+		{Index: "docs", Copies: 5},
+	}
+
+	var r = NewReplicator(mod)
+	for i := 0; i < 3; i++ {
+		node, err := id.GenerateIdentity()
+		if err != nil {
+			t.Fatal(err)
+		}
+		r.nodes = append(r.nodes, node)
+	}
+
+	if n := r.target(photos); n != 2 {
+		t.Fatalf("expected 2 copies of photos, got %d", n)
+	}
+
+	// the highest policy applies, limited by the number of nodes
+	if n := r.target(docs); n != 4 {
+		t.Fatalf("expected 4 copies of docs, got %d", n)
+	}
+
+	if n := r.target(other); n != 0 {
+		t.Fatalf("expected no replication of unlisted data, got %d", n)
+	}
+
+	stranger, _ := id.GenerateIdentity()
+
+	if !r.Verify(r.nodes[0], photos) {
+		t.Fatal("replica node denied access to replicated data")
+	}
+	if r.Verify(r.nodes[0], other) {
+		t.Fatal("replica node granted access to data that isn't replicated")
+	}
+	if r.Verify(stranger, photos) {
+		t.Fatal("unknown node granted access to replicated data")
+	}
+}
+
+func TestReplicatorQueue(t *testing.T) {
+	mod, _ := newTestModule(t)
+	var r = NewReplicator(mod)
+
+	var a = data.Resolve([]byte("a"))
+	var b = data.Resolve([]byte("b"))
+
+	r.enqueue(a)
+	r.enqueue(b)
+	r.enqueue(a)
+
+	for _, expected := range []data.ID{a, b} {
+		dataID, ok := r.next()
+		if !ok || dataID != expected {
+			t.Fatalf("expected %v, got %v", expected, dataID)
+		}
+	}
+
+	if _, ok := r.next(); ok {
+		t.Fatal("queue should be empty")
+	}
+}
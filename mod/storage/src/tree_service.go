@@ -0,0 +1,64 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"github.com/cryptopunkscc/astrald/cslq"
+	"github.com/cryptopunkscc/astrald/mod/storage"
+	"github.com/cryptopunkscc/astrald/mod/storage/proto"
+	"github.com/cryptopunkscc/astrald/net"
+	"strings"
+)
+
+const treeServicePrefix = proto.TreeServiceName + "."
+
+// TreeService serves hash trees of data objects, so that callers can verify data block by block as they read it
+type TreeService struct {
+	*Module
+}
+
+func NewTreeService(module *Module) *TreeService {
+	return &TreeService{Module: module}
+}
+
+func (srv *TreeService) Run(ctx context.Context) error {
+	err := srv.node.LocalRouter().AddRoute(treeServicePrefix+"*", srv)
+	if err != nil {
+		return err
+	}
+	defer srv.node.LocalRouter().RemoveRoute(treeServicePrefix + "*")
+
+	<-ctx.Done()
+	return nil
+}
+
+func (srv *TreeService) RouteQuery(ctx context.Context, query net.Query, caller net.SecureWriteCloser, hints net.Hints) (net.SecureWriteCloser, error) {
+	idstr, found := strings.CutPrefix(query.Query(), treeServicePrefix)
+	if !found {
+		return net.Reject()
+	}
+
+	dataID, err := srv.data.resolveRef(idstr)
+	switch {
+	case errors.Is(err, storage.ErrNotFound):
+		// reject unknown tree IDs the same way as denied access, so they can't be used to probe for objects
+		return net.RejectWithReason(net.RejectCodeAccessDenied, "")
+	case err != nil:
+		return net.RejectWithReason(net.RejectCodeInvalidQuery, "invalid data id")
+	}
+
+	if !srv.Access().Verify(query.Caller(), dataID) {
+		return net.RejectWithReason(net.RejectCodeAccessDenied, "")
+	}
+
+	tree, err := srv.data.HashTree(dataID)
+	if err != nil {
+		return net.RejectWithReason(net.RejectCodeNotFound, "")
+	}
+
+	return net.Accept(query, caller, func(conn net.SecureConn) {
+		defer conn.Close()
+
+		cslq.Encode(conn, "v", tree)
+	})
+}
@@ -0,0 +1,38 @@
+package tar
+
+import "github.com/cryptopunkscc/astrald/data"
+
+const (
+	FormatTar = "tar"
+	Format7z  = "7z"
+)
+
+const (
+	CompressionNone = ""
+	CompressionGzip = "gzip"
+	CompressionZstd = "zstd"
+)
+
+const ArchiveDescriptorType = "mod.tar.archive"
+
+type ArchiveDescriptor struct {
+	Format      string
+	Compression string
+	Files       []ArchiveFile
+}
+
+type ArchiveFile struct {
+	DataID data.ID
+	Path   string
+}
+
+const MemberDescriptorType = "mod.tar.member"
+
+type MemberDescriptor struct {
+	Memberships []Membership
+}
+
+type Membership struct {
+	ArchiveID data.ID
+	Path      string
+}
@@ -0,0 +1,7 @@
+package tar
+
+import "github.com/cryptopunkscc/astrald/data"
+
+type EventArchiveIndexed struct {
+	DataID data.ID
+}
@@ -0,0 +1,10 @@
+package tar
+
+import "github.com/cryptopunkscc/astrald/data"
+
+const ArchivesIndexName = "mod.tar.archives"
+
+// Module indexes members of tar archives, plain or compressed with gzip or zstd, and of 7z archives.
+type Module interface {
+	Index(archiveID data.ID, reindex bool) error
+}
@@ -0,0 +1,11 @@
+package tar
+
+type Config struct {
+	NoVirtual          bool  // don't automatically index archives from virtual sources (such as zip files)
+	CheckpointInterval int64 // minimum distance between seek checkpoints of compressed archives (in bytes, gzip checkpoints also store 32KiB of output)
+}
+
+var defaultConfig = Config{
+	NoVirtual:          true,
+	CheckpointInterval: 4 * 1024 * 1024,
+}
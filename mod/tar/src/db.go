@@ -0,0 +1,83 @@
+package tar
+
+import (
+	_data "github.com/cryptopunkscc/astrald/data"
+)
+
+type dbArchive struct {
+	ArchiveID   string `gorm:"primaryKey"`
+	Format      string `gorm:"default:tar"`
+	Compression string
+}
+
+func (dbArchive) TableName() string { return "tar_archives" }
+
+// dbMember is a regular file stored in an archive. DataOffset is the position of its contents in the
+// uncompressed tar archive. Members of 7z archives are opened by path and have no offset.
+type dbMember struct {
+	ArchiveID  string `gorm:"primaryKey"`
+	Path       string `gorm:"primaryKey"`
+	FileID     string `gorm:"index"`
+	DataOffset int64
+}
+
+func (dbMember) TableName() string { return "tar_members" }
+
+// dbCheckpoint is a position in a compressed archive from which decompression can be started
+type dbCheckpoint struct {
+	ArchiveID    string `gorm:"primaryKey"`
+	Uncompressed int64  `gorm:"primaryKey"`
+	Compressed   int64
+	Bits         uint8
+	Window       []byte
+}
+
+func (dbCheckpoint) TableName() string { return "tar_checkpoints" }
+
+func (mod *Module) dbFindByFileID(dataID _data.ID) ([]dbMember, error) {
+	var rows []dbMember
+
+	tx := mod.db.Where("file_id = ?", dataID.String()).Find(&rows)
+	if tx.Error != nil {
+		return nil, tx.Error
+	}
+
+	return rows, nil
+}
+
+func (mod *Module) dbFindByArchiveID(dataID _data.ID) ([]dbMember, error) {
+	var rows []dbMember
+
+	tx := mod.db.Where("archive_id = ?", dataID.String()).Order("data_offset, path").Find(&rows)
+	if tx.Error != nil {
+		return nil, tx.Error
+	}
+
+	return rows, nil
+}
+
+func (mod *Module) dbFindArchive(dataID _data.ID) (*dbArchive, error) {
+	var row dbArchive
+
+	tx := mod.db.Where("archive_id = ?", dataID.String()).First(&row)
+	if tx.Error != nil {
+		return nil, tx.Error
+	}
+
+	return &row, nil
+}
+
+// dbFindCheckpoint returns the closest checkpoint preceding the offset in the uncompressed archive
+func (mod *Module) dbFindCheckpoint(dataID _data.ID, offset int64) (*dbCheckpoint, error) {
+	var row dbCheckpoint
+
+	tx := mod.db.
+		Where("archive_id = ? AND uncompressed <= ?", dataID.String(), offset).
+		Order("uncompressed desc").
+		First(&row)
+	if tx.Error != nil {
+		return nil, tx.Error
+	}
+
+	return &row, nil
+}
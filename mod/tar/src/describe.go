@@ -0,0 +1,72 @@
+package tar
+
+import (
+	"context"
+	_data "github.com/cryptopunkscc/astrald/data"
+	"github.com/cryptopunkscc/astrald/mod/data"
+	"github.com/cryptopunkscc/astrald/mod/tar"
+)
+
+func (mod *Module) DescribeData(ctx context.Context, dataID _data.ID, opts *data.DescribeOpts) (desc []data.Descriptor) {
+	desc = append(desc, mod.describeArchive(dataID)...)
+	desc = append(desc, mod.describeMember(dataID)...)
+
+	return
+}
+
+func (mod *Module) describeArchive(dataID _data.ID) []data.Descriptor {
+	archive, err := mod.dbFindArchive(dataID)
+	if err != nil {
+		return nil
+	}
+
+	rows, _ := mod.dbFindByArchiveID(dataID)
+
+	var desc = tar.ArchiveDescriptor{
+		Format:      archive.Format,
+		Compression: archive.Compression,
+	}
+
+	for _, row := range rows {
+		fileID, err := _data.Parse(row.FileID)
+		if err != nil {
+			continue
+		}
+
+		desc.Files = append(desc.Files, tar.ArchiveFile{
+			DataID: fileID,
+			Path:   row.Path,
+		})
+	}
+
+	return []data.Descriptor{{
+		Type: tar.ArchiveDescriptorType,
+		Data: desc,
+	}}
+}
+
+func (mod *Module) describeMember(dataID _data.ID) []data.Descriptor {
+	rows, _ := mod.dbFindByFileID(dataID)
+	if len(rows) == 0 {
+		return nil
+	}
+
+	var desc tar.MemberDescriptor
+
+	for _, row := range rows {
+		archiveID, err := _data.Parse(row.ArchiveID)
+		if err != nil {
+			continue
+		}
+
+		desc.Memberships = append(desc.Memberships, tar.Membership{
+			ArchiveID: archiveID,
+			Path:      row.Path,
+		})
+	}
+
+	return []data.Descriptor{{
+		Type: tar.MemberDescriptorType,
+		Data: desc,
+	}}
+}
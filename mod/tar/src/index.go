@@ -0,0 +1,199 @@
+package tar
+
+import (
+	_tar "archive/tar"
+	"bufio"
+	"bytes"
+	"errors"
+	"github.com/cryptopunkscc/astrald/data"
+	"github.com/cryptopunkscc/astrald/mod/index"
+	"github.com/cryptopunkscc/astrald/mod/tar"
+	"gorm.io/gorm"
+	"io"
+	"path/filepath"
+)
+
+func (mod *Module) Index(archiveID data.ID, reindex bool) error {
+	if mod.isIndexed(archiveID) && !reindex {
+		return errors.New("already indexed")
+	}
+
+	mod.log.Logv(1, "indexing %v", archiveID)
+
+	r, err := mod.storage.Data().Read(archiveID, nil)
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+
+	var buf = bufio.NewReader(r)
+	header, _ := buf.Peek(len(sevenZipMagic))
+	var format, compression = tar.FormatTar, detectCompression(header)
+	if bytes.HasPrefix(header, sevenZipMagic) {
+		format, compression = tar.Format7z, tar.CompressionNone
+	}
+
+	// forget the previous index, checkpoints are saved while the archive is read
+	err = mod.db.Transaction(func(tx *gorm.DB) error {
+		for _, table := range []any{&dbArchive{}, &dbMember{}, &dbCheckpoint{}} {
+			err := tx.Where("archive_id = ?", archiveID.String()).Delete(table).Error
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	var members []dbMember
+	switch format {
+	case tar.Format7z:
+		members, err = scan7z(&readerAt{storage: mod.storage, dataID: archiveID}, int64(archiveID.Size))
+	default:
+		members, err = mod.scanTar(archiveID, buf, compression)
+	}
+	if err != nil {
+		return err
+	}
+	for i := range members {
+		members[i].ArchiveID = archiveID.String()
+	}
+
+	err = mod.db.Transaction(func(tx *gorm.DB) error {
+		err := tx.Create(&dbArchive{
+			ArchiveID:   archiveID.String(),
+			Format:      format,
+			Compression: compression,
+		}).Error
+		if err != nil {
+			return err
+		}
+
+		if len(members) > 0 {
+			return tx.CreateInBatches(members, 100).Error
+		}
+
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	var indexName = "mod.tar.archive." + archiveID.String()
+	_, err = mod.index.CreateIndex(indexName, index.TypeSet)
+	if err != nil {
+		mod.log.Error("error creating index %v: %v", indexName, err)
+	} else {
+		err = mod.index.AddToUnion(index.LocalNodeUnionName, indexName)
+		if err != nil {
+			mod.log.Error("error adding %v to localnode union: %v", indexName, err)
+		}
+	}
+
+	for _, member := range members {
+		fileID, _ := data.Parse(member.FileID)
+
+		mod.data.SetLabel(fileID, filepath.Base(member.Path))
+
+		mod.log.Infov(1, "indexed %s (%v)", member.Path, fileID)
+
+		mod.index.AddToSet(indexName, fileID)
+	}
+
+	err = mod.index.AddToSet(tar.ArchivesIndexName, archiveID)
+	if err != nil {
+		mod.log.Error("error adding archive to %v index: %v", tar.ArchivesIndexName, err)
+	}
+
+	mod.events.Emit(tar.EventArchiveIndexed{DataID: archiveID})
+
+	return nil
+}
+
+func (mod *Module) isIndexed(dataID data.ID) bool {
+	var count int64
+	tx := mod.db.Model(&dbArchive{}).Where("archive_id = ?", dataID.String()).Count(&count)
+	if tx.Error != nil {
+		mod.log.Errorv(2, "database error: %v", tx.Error)
+		return false
+	}
+
+	return count > 0
+}
+
+// scanTar reads members of a tar archive and saves checkpoints of its compressed stream
+func (mod *Module) scanTar(archiveID data.ID, r io.Reader, compression string) ([]dbMember, error) {
+	var save func(checkpoint) error
+	if compression != tar.CompressionNone {
+		save = func(cp checkpoint) error {
+			return mod.db.Create(&dbCheckpoint{
+				ArchiveID:    archiveID.String(),
+				Uncompressed: cp.Uncompressed,
+				Compressed:   cp.Compressed,
+				Bits:         cp.Bits,
+				Window:       cp.Window,
+			}).Error
+		}
+	}
+
+	s, err := newStream(r, compression, mod.config.CheckpointInterval, save)
+	if err != nil {
+		return nil, err
+	}
+	defer s.Close()
+
+	var members memberList
+	var reader = _tar.NewReader(s)
+
+	for {
+		hdr, err := reader.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		// only regular files are stored contiguously in the archive
+		if hdr.Typeflag != _tar.TypeReg {
+			continue
+		}
+
+		var offset = s.Offset()
+
+		fileID, err := data.ResolveAll(reader)
+		if err != nil {
+			return nil, err
+		}
+
+		members.add(dbMember{
+			Path:       hdr.Name,
+			FileID:     fileID.String(),
+			DataOffset: offset,
+		})
+	}
+
+	return members.list, nil
+}
+
+// memberList collects members of an archive in order. A path added to the archive again replaces
+// the earlier copy.
+type memberList struct {
+	list  []dbMember
+	paths map[string]int
+}
+
+func (l *memberList) add(member dbMember) {
+	if i, found := l.paths[member.Path]; found {
+		l.list[i] = member
+		return
+	}
+
+	if l.paths == nil {
+		l.paths = map[string]int{}
+	}
+	l.paths[member.Path] = len(l.list)
+	l.list = append(l.list, member)
+}
@@ -0,0 +1,60 @@
+package tar
+
+import (
+	"context"
+	"github.com/cryptopunkscc/astrald/data"
+	"github.com/cryptopunkscc/astrald/mod/storage"
+	"sync"
+	"time"
+)
+
+// archiveTypes are types of data that can contain an archive
+var archiveTypes = []string{
+	"application/x-tar",
+	"application/gzip",
+	"application/zstd",
+	"application/x-7z-compressed",
+}
+
+type IndexerService struct {
+	*Module
+}
+
+func (srv *IndexerService) Run(ctx context.Context) error {
+	var wg sync.WaitGroup
+
+	for _, typ := range archiveTypes {
+		typ := typ
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for event := range srv.data.SubscribeType(ctx, typ, time.Time{}) {
+				if err := srv.autoIndex(event.DataID); err != nil {
+					srv.log.Errorv(2, "error indexing %v: %v", event.DataID, err)
+				}
+			}
+		}()
+	}
+
+	wg.Wait()
+
+	return nil
+}
+
+func (srv *IndexerService) autoIndex(archiveID data.ID) error {
+	if srv.isIndexed(archiveID) {
+		return nil
+	}
+
+	// check if the file is accessible
+	found, err := srv.storage.Data().Read(
+		archiveID,
+		&storage.ReadOpts{NoVirtual: srv.config.NoVirtual},
+	)
+	if err != nil {
+		return err
+	}
+	found.Close()
+
+	return srv.Index(archiveID, false)
+}
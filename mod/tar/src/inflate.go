@@ -0,0 +1,591 @@
+package tar
+
+import (
+	"compress/gzip"
+	"errors"
+	"hash/crc32"
+	"io"
+	"sync"
+)
+
+const (
+	windowSize = 1 << 15 // deflate window size
+	windowMask = windowSize - 1
+	maxBits    = 15 // maximum length of a huffman code
+	fastBits   = 9  // length of codes decoded with a single table lookup
+)
+
+var errInvalidDeflate = errors.New("invalid deflate data")
+
+var (
+	lengthBase  = [...]uint16{3, 4, 5, 6, 7, 8, 9, 10, 11, 13, 15, 17, 19, 23, 27, 31, 35, 43, 51, 59, 67, 83, 99, 115, 131, 163, 195, 227, 258}
+	lengthExtra = [...]uint8{0, 0, 0, 0, 0, 0, 0, 0, 1, 1, 1, 1, 2, 2, 2, 2, 3, 3, 3, 3, 4, 4, 4, 4, 5, 5, 5, 5, 0}
+	distBase    = [...]uint16{1, 2, 3, 4, 5, 7, 9, 13, 17, 25, 33, 49, 65, 97, 129, 193, 257, 385, 513, 769, 1025, 1537, 2049, 3073, 4097, 6145, 8193, 12289, 16385, 24577}
+	distExtra   = [...]uint8{0, 0, 0, 0, 1, 1, 2, 2, 3, 3, 4, 4, 5, 5, 6, 6, 7, 7, 8, 8, 9, 9, 10, 10, 11, 11, 12, 12, 13, 13}
+	lengthOrder = [...]uint8{16, 17, 18, 0, 8, 7, 9, 6, 10, 5, 11, 4, 12, 3, 13, 2, 14, 1, 15}
+)
+
+var fixedLit, fixedDist huffman
+var fixedOnce sync.Once
+
+func initFixed() {
+	var lengths [288]uint8
+	for i := range lengths {
+		switch {
+		case i < 144:
+			lengths[i] = 8
+		case i < 256:
+			lengths[i] = 9
+		case i < 280:
+			lengths[i] = 7
+		default:
+			lengths[i] = 8
+		}
+	}
+	fixedLit.init(lengths[:])
+
+	for i := range lengths[:30] {
+		lengths[i] = 5
+	}
+	fixedDist.init(lengths[:30])
+}
+
+// huffman is a canonical huffman code. Codes up to fastBits long are decoded with a lookup table,
+// longer codes one bit at a time.
+type huffman struct {
+	count  [maxBits + 1]uint16
+	symbol [288]uint16
+	fast   [1 << fastBits]uint16 // code length << 9 | symbol, indexed by bit-reversed code
+}
+
+func (h *huffman) init(lengths []uint8) error {
+	h.count = [maxBits + 1]uint16{}
+	for _, l := range lengths {
+		h.count[l]++
+	}
+	h.count[0] = 0
+
+	var left = 1
+	for l := 1; l <= maxBits; l++ {
+		left = left<<1 - int(h.count[l])
+		if left < 0 {
+			return errInvalidDeflate
+		}
+	}
+
+	var offs [maxBits + 1]uint16
+	for l := 1; l < maxBits; l++ {
+		offs[l+1] = offs[l] + h.count[l]
+	}
+	for sym, l := range lengths {
+		if l != 0 {
+			h.symbol[offs[l]] = uint16(sym)
+			offs[l]++
+		}
+	}
+
+	h.fast = [1 << fastBits]uint16{}
+	var code, index int
+	for l := 1; l <= fastBits; l++ {
+		for i := 0; i < int(h.count[l]); i++ {
+			var entry = uint16(l)<<9 | h.symbol[index]
+			for j := reverse(code, l); j < len(h.fast); j += 1 << l {
+				h.fast[j] = entry
+			}
+			code++
+			index++
+		}
+		code <<= 1
+	}
+
+	return nil
+}
+
+func reverse(code int, length int) (r int) {
+	for i := 0; i < length; i++ {
+		r = r<<1 | code&1
+		code >>= 1
+	}
+	return
+}
+
+const (
+	stateHeader = iota
+	stateBlock
+	stateStored
+	stateCodes
+	stateCopy
+	stateTrailer
+)
+
+// inflater decompresses a (possibly multi-member) gzip stream. Unlike compress/gzip it knows the exact
+// bit position of every deflate block, so decompression can be resumed at a block boundary given the
+// output preceding it (the method of zlib's zran example).
+type inflater struct {
+	src    io.ByteReader
+	srcErr error
+	in     int64 // bytes read from src
+	buf    uint64
+	nbits  uint
+
+	hist      [windowSize]byte // last bytes of output
+	out       int64            // bytes of output
+	memberOut int64            // bytes of output of the current member
+
+	state     int
+	final     bool
+	stored    int
+	lit, dist *huffman
+	dyn       [2]huffman
+	copyLen   int
+	copyDist  int64
+
+	crc      uint32
+	inMember bool
+	verify   bool // false if the member wasn't decompressed from its beginning
+	err      error
+
+	// checkpoint is called at gzip member and deflate block boundaries
+	checkpoint func(cp checkpoint, window func() []byte)
+}
+
+func newInflater(src io.ByteReader) *inflater {
+	fixedOnce.Do(initFixed)
+	return &inflater{src: src}
+}
+
+// resumeInflater returns an inflater resuming decompression at a checkpoint. Checkpoints without
+// a window are at the beginning of a gzip member.
+func resumeInflater(src io.ByteReader, cp checkpoint) (*inflater, error) {
+	var f = newInflater(src)
+	if len(cp.Window) == 0 {
+		return f, nil
+	}
+	if len(cp.Window) > windowSize {
+		return nil, errors.New("invalid checkpoint window")
+	}
+
+	for _, b := range cp.Window {
+		f.emit(b)
+	}
+	f.memberOut = f.out
+	f.inMember = true
+	f.state = stateBlock
+
+	if _, err := f.bits(uint(cp.Bits)); err != nil {
+		return nil, err
+	}
+
+	return f, nil
+}
+
+func (f *inflater) Read(p []byte) (n int, err error) {
+	if f.err != nil {
+		return 0, f.err
+	}
+
+	n, err = f.read(p)
+	if err != nil {
+		f.err = err
+		if n > 0 {
+			err = nil
+		}
+	}
+
+	return
+}
+
+func (f *inflater) read(p []byte) (n int, err error) {
+	var start int // beginning of the output of the current member in p
+	defer func() {
+		if f.inMember {
+			f.crc = crc32.Update(f.crc, crc32.IEEETable, p[start:n])
+		}
+	}()
+
+	for n < len(p) {
+		switch f.state {
+		case stateHeader:
+			if !f.more() {
+				if f.srcErr != nil && f.srcErr != io.EOF {
+					return n, f.srcErr
+				}
+				return n, io.EOF
+			}
+			f.mark(nil)
+			if err = f.readHeader(); err != nil {
+				return
+			}
+			start, f.crc, f.memberOut = n, 0, 0
+			f.inMember, f.verify, f.final = true, true, false
+			f.state = stateBlock
+
+		case stateBlock:
+			if f.final {
+				f.state = stateTrailer
+				continue
+			}
+			if f.memberOut > 0 {
+				f.mark(f.window)
+			}
+			if err = f.readBlockHeader(); err != nil {
+				return
+			}
+
+		case stateStored:
+			var b uint32
+			if b, err = f.bits(8); err != nil {
+				return
+			}
+			p[n] = byte(b)
+			f.emit(byte(b))
+			n++
+			if f.stored--; f.stored == 0 {
+				f.state = stateBlock
+			}
+
+		case stateCodes:
+			if err = f.decodeSymbol(p, &n); err != nil {
+				return
+			}
+
+		case stateCopy:
+			for f.copyLen > 0 && n < len(p) {
+				var b = f.hist[(f.out-f.copyDist)&windowMask]
+				p[n] = b
+				f.emit(b)
+				n++
+				f.copyLen--
+			}
+			if f.copyLen == 0 {
+				f.state = stateCodes
+			}
+
+		case stateTrailer:
+			f.crc = crc32.Update(f.crc, crc32.IEEETable, p[start:n])
+			f.inMember = false
+
+			f.bits(f.nbits % 8)
+			var sum, size uint32
+			if sum, err = f.bits(32); err != nil {
+				return
+			}
+			if size, err = f.bits(32); err != nil {
+				return
+			}
+			if f.verify && (sum != f.crc || size != uint32(f.memberOut)) {
+				return n, gzip.ErrChecksum
+			}
+			f.state = stateHeader
+		}
+	}
+
+	return
+}
+
+// mark reports a checkpoint at the current position
+func (f *inflater) mark(window func() []byte) {
+	if f.checkpoint == nil {
+		return
+	}
+
+	var pos = f.in*8 - int64(f.nbits)
+	f.checkpoint(checkpoint{
+		Compressed:   pos / 8,
+		Bits:         uint8(pos % 8),
+		Uncompressed: f.out,
+	}, window)
+}
+
+// window returns the output of the current member the next block may refer to
+func (f *inflater) window() []byte {
+	var w = make([]byte, min(f.memberOut, windowSize))
+	for i := range w {
+		w[i] = f.hist[(f.out-int64(len(w)-i))&windowMask]
+	}
+	return w
+}
+
+func (f *inflater) emit(b byte) {
+	f.hist[f.out&windowMask] = b
+	f.out++
+	f.memberOut++
+}
+
+func (f *inflater) readHeader() error {
+	var h [10]byte
+	for i := range h {
+		b, err := f.bits(8)
+		if err != nil {
+			return err
+		}
+		h[i] = byte(b)
+	}
+	if h[0] != 0x1f || h[1] != 0x8b || h[2] != 8 {
+		return gzip.ErrHeader
+	}
+
+	var flags = h[3]
+	if flags&0x04 != 0 { // FEXTRA
+		size, err := f.bits(16)
+		if err != nil {
+			return err
+		}
+		if _, err = f.skip(int(size)); err != nil {
+			return err
+		}
+	}
+	for _, flag := range []byte{0x08, 0x10} { // FNAME, FCOMMENT
+		if flags&flag == 0 {
+			continue
+		}
+		for {
+			b, err := f.bits(8)
+			if err != nil {
+				return err
+			}
+			if b == 0 {
+				break
+			}
+		}
+	}
+	if flags&0x02 != 0 { // FHCRC
+		if _, err := f.bits(16); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (f *inflater) readBlockHeader() error {
+	h, err := f.bits(3)
+	if err != nil {
+		return err
+	}
+	f.final = h&1 != 0
+
+	switch h >> 1 {
+	case 0:
+		f.bits(f.nbits % 8)
+		v, err := f.bits(32)
+		if err != nil {
+			return err
+		}
+		if uint16(v) != ^uint16(v>>16) {
+			return errInvalidDeflate
+		}
+		f.stored = int(uint16(v))
+		f.state = stateStored
+		if f.stored == 0 {
+			f.state = stateBlock
+		}
+
+	case 1:
+		f.lit, f.dist = &fixedLit, &fixedDist
+		f.state = stateCodes
+
+	case 2:
+		if err = f.readDynamic(); err != nil {
+			return err
+		}
+		f.lit, f.dist = &f.dyn[0], &f.dyn[1]
+		f.state = stateCodes
+
+	default:
+		return errInvalidDeflate
+	}
+
+	return nil
+}
+
+func (f *inflater) readDynamic() error {
+	h, err := f.bits(14)
+	if err != nil {
+		return err
+	}
+	var nlit, ndist, nlen = int(h&0x1f) + 257, int(h>>5&0x1f) + 1, int(h>>10) + 4
+	if nlit > 286 || ndist > 30 {
+		return errInvalidDeflate
+	}
+
+	var lengths [286 + 30]uint8
+	for i := 0; i < nlen; i++ {
+		l, err := f.bits(3)
+		if err != nil {
+			return err
+		}
+		lengths[lengthOrder[i]] = uint8(l)
+	}
+
+	var lencode = &f.dyn[0]
+	if err = lencode.init(lengths[:19]); err != nil {
+		return err
+	}
+
+	lengths = [286 + 30]uint8{}
+	for i := 0; i < nlit+ndist; {
+		sym, err := f.decode(lencode)
+		if err != nil {
+			return err
+		}
+		if sym < 16 {
+			lengths[i] = uint8(sym)
+			i++
+			continue
+		}
+
+		var value uint8
+		var repeat uint32
+		switch sym {
+		case 16:
+			if i == 0 {
+				return errInvalidDeflate
+			}
+			value = lengths[i-1]
+			repeat, err = f.bits(2)
+			repeat += 3
+		case 17:
+			repeat, err = f.bits(3)
+			repeat += 3
+		default:
+			repeat, err = f.bits(7)
+			repeat += 11
+		}
+		if err != nil {
+			return err
+		}
+		if i+int(repeat) > nlit+ndist {
+			return errInvalidDeflate
+		}
+		for ; repeat > 0; repeat-- {
+			lengths[i] = value
+			i++
+		}
+	}
+
+	if lengths[256] == 0 {
+		return errInvalidDeflate
+	}
+	if err = f.dyn[0].init(lengths[:nlit]); err != nil {
+		return err
+	}
+	return f.dyn[1].init(lengths[nlit : nlit+ndist])
+}
+
+func (f *inflater) decodeSymbol(p []byte, n *int) error {
+	sym, err := f.decode(f.lit)
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case sym < 256:
+		p[*n] = byte(sym)
+		f.emit(byte(sym))
+		*n++
+		return nil
+
+	case sym == 256:
+		f.state = stateBlock
+		return nil
+
+	case sym > 285:
+		return errInvalidDeflate
+	}
+
+	sym -= 257
+	extra, err := f.bits(uint(lengthExtra[sym]))
+	if err != nil {
+		return err
+	}
+	var length = int(lengthBase[sym]) + int(extra)
+
+	sym, err = f.decode(f.dist)
+	if err != nil {
+		return err
+	}
+	if sym >= len(distBase) {
+		return errInvalidDeflate
+	}
+	if extra, err = f.bits(uint(distExtra[sym])); err != nil {
+		return err
+	}
+	var dist = int64(distBase[sym]) + int64(extra)
+	if dist > f.memberOut {
+		return errInvalidDeflate
+	}
+
+	f.copyLen, f.copyDist = length, dist
+	f.state = stateCopy
+	return nil
+}
+
+// decode reads a symbol encoded with the huffman code
+func (f *inflater) decode(h *huffman) (int, error) {
+	f.fill(fastBits)
+	if e := h.fast[f.buf&(1<<fastBits-1)]; e != 0 && uint(e>>9) <= f.nbits {
+		f.buf >>= e >> 9
+		f.nbits -= uint(e >> 9)
+		return int(e & 0x1ff), nil
+	}
+
+	var code, first, index int
+	for l := 1; l <= maxBits; l++ {
+		b, err := f.bits(1)
+		if err != nil {
+			return 0, err
+		}
+		code |= int(b)
+
+		var count = int(h.count[l])
+		if code-first < count {
+			return int(h.symbol[index+code-first]), nil
+		}
+		index += count
+		first = (first + count) << 1
+		code <<= 1
+	}
+
+	return 0, errInvalidDeflate
+}
+
+// bits reads n bits (up to 32) from the input
+func (f *inflater) bits(n uint) (uint32, error) {
+	if f.fill(n); f.nbits < n {
+		return 0, unexpected(f.srcErr)
+	}
+
+	var v = uint32(f.buf & (1<<n - 1))
+	f.buf >>= n
+	f.nbits -= n
+	return v, nil
+}
+
+func (f *inflater) skip(n int) (int, error) {
+	for i := 0; i < n; i++ {
+		if _, err := f.bits(8); err != nil {
+			return i, err
+		}
+	}
+	return n, nil
+}
+
+// fill tries to buffer at least n bits of input
+func (f *inflater) fill(n uint) {
+	for f.nbits < n && f.srcErr == nil {
+		var b byte
+		if b, f.srcErr = f.src.ReadByte(); f.srcErr != nil {
+			return
+		}
+		f.in++
+		f.buf |= uint64(b) << f.nbits
+		f.nbits += 8
+	}
+}
+
+// more checks if there's more input
+func (f *inflater) more() bool {
+	f.fill(8)
+	return f.nbits >= 8
+}
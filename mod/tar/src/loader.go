@@ -0,0 +1,42 @@
+package tar
+
+import (
+	"github.com/cryptopunkscc/astrald/log"
+	"github.com/cryptopunkscc/astrald/node/assets"
+	"github.com/cryptopunkscc/astrald/node/modules"
+)
+
+const ModuleName = "tar"
+
+type Loader struct{}
+
+func (Loader) Load(node modules.Node, assets assets.Assets, log *log.Logger) (modules.Module, error) {
+	var err error
+	var mod = &Module{
+		node:   node,
+		config: defaultConfig,
+		log:    log,
+	}
+
+	mod.events.SetParent(node.Events())
+
+	_ = assets.LoadYAML(ModuleName, &mod.config)
+
+	mod.db, err = assets.OpenDB(ModuleName)
+	if err != nil {
+		return nil, err
+	}
+
+	err = mod.db.AutoMigrate(&dbArchive{}, &dbMember{}, &dbCheckpoint{})
+	if err != nil {
+		return nil, err
+	}
+
+	return mod, err
+}
+
+func init() {
+	if err := modules.RegisterModule(ModuleName, Loader{}); err != nil {
+		panic(err)
+	}
+}
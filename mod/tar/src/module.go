@@ -0,0 +1,135 @@
+package tar
+
+import (
+	"context"
+	"github.com/cryptopunkscc/astrald/auth/id"
+	_data "github.com/cryptopunkscc/astrald/data"
+	"github.com/cryptopunkscc/astrald/log"
+	"github.com/cryptopunkscc/astrald/mod/data"
+	"github.com/cryptopunkscc/astrald/mod/index"
+	"github.com/cryptopunkscc/astrald/mod/storage"
+	"github.com/cryptopunkscc/astrald/mod/tar"
+	"github.com/cryptopunkscc/astrald/node"
+	"github.com/cryptopunkscc/astrald/node/events"
+	"github.com/cryptopunkscc/astrald/streams"
+	"github.com/cryptopunkscc/astrald/tasks"
+	"gorm.io/gorm"
+)
+
+type Module struct {
+	config Config
+	node   node.Node
+	events events.Queue
+	log    *log.Logger
+
+	db      *gorm.DB
+	data    data.Module
+	storage storage.Module
+	index   index.Module
+}
+
+func (mod *Module) Run(ctx context.Context) error {
+	return tasks.Group(
+		&IndexerService{Module: mod},
+	).Run(ctx)
+}
+
+func (mod *Module) Read(dataID _data.ID, opts *storage.ReadOpts) (storage.DataReader, error) {
+	if opts == nil {
+		opts = &storage.ReadOpts{}
+	}
+
+	if opts.Offset > dataID.Size {
+		return nil, storage.ErrInvalidOffset
+	}
+
+	rows, err := mod.dbFindByFileID(dataID)
+	if err != nil {
+		return nil, err
+	}
+	if len(rows) == 0 {
+		return nil, storage.ErrNotFound
+	}
+
+	if opts.NoVirtual {
+		return nil, storage.ErrNoVirtual
+	}
+
+	var row = rows[0]
+
+	archiveID, err := _data.Parse(row.ArchiveID)
+	if err != nil {
+		return nil, err
+	}
+
+	archive, err := mod.dbFindArchive(archiveID)
+	if err != nil {
+		return nil, storage.ErrNotFound
+	}
+
+	if archive.Format == tar.Format7z {
+		return mod.read7z(archiveID, row.Path, dataID, opts)
+	}
+
+	// uncompressed archives can be read directly at the offset
+	var target = row.DataOffset + int64(opts.Offset)
+	var cp = checkpoint{Compressed: target, Uncompressed: target}
+
+	if archive.Compression != tar.CompressionNone {
+		row, err := mod.dbFindCheckpoint(archiveID, target)
+		if err != nil {
+			return nil, storage.ErrNotFound
+		}
+		cp = checkpoint{
+			Compressed:   row.Compressed,
+			Bits:         row.Bits,
+			Uncompressed: row.Uncompressed,
+			Window:       row.Window,
+		}
+	}
+
+	r, err := mod.storage.Data().Read(archiveID, &storage.ReadOpts{Offset: uint64(cp.Compressed)})
+	if err != nil {
+		return nil, err
+	}
+
+	dec, err := newDecompressor(r, archive.Compression, cp)
+	if err != nil {
+		r.Close()
+		return nil, err
+	}
+
+	if err := streams.Skip(dec, uint64(target-cp.Uncompressed)); err != nil {
+		dec.Close()
+		r.Close()
+		return nil, err
+	}
+
+	return &Reader{
+		ReadCloser: &streams.LimitedReader{
+			ReadCloser: &readCloser{Reader: dec, closers: []func() error{dec.Close, r.Close}},
+			Limit:      dataID.Size - opts.Offset,
+		},
+		name: "mod.tar",
+	}, nil
+}
+
+func (mod *Module) Verify(identity id.Identity, dataID _data.ID) bool {
+	rows, err := mod.dbFindByFileID(dataID)
+	if err != nil {
+		return false
+	}
+
+	for _, row := range rows {
+		archiveID, err := _data.Parse(row.ArchiveID)
+		if err != nil {
+			continue
+		}
+
+		if mod.storage.Access().Verify(identity, archiveID) {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,32 @@
+package tar
+
+import (
+	"github.com/cryptopunkscc/astrald/mod/storage"
+	"io"
+)
+
+var _ storage.DataReader = &Reader{}
+
+type Reader struct {
+	io.ReadCloser
+	name string
+}
+
+func (r *Reader) Info() *storage.ReaderInfo {
+	return &storage.ReaderInfo{Name: r.name}
+}
+
+// readCloser closes the decompressor along with the archive reader it reads from
+type readCloser struct {
+	io.Reader
+	closers []func() error
+}
+
+func (r *readCloser) Close() (err error) {
+	for _, fn := range r.closers {
+		if e := fn(); e != nil && err == nil {
+			err = e
+		}
+	}
+	return
+}
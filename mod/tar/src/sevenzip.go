@@ -0,0 +1,112 @@
+package tar
+
+import (
+	"errors"
+	"github.com/bodgit/sevenzip"
+	"github.com/cryptopunkscc/astrald/data"
+	"github.com/cryptopunkscc/astrald/mod/storage"
+	"github.com/cryptopunkscc/astrald/streams"
+	"io"
+)
+
+var sevenZipMagic = []byte{'7', 'z', 0xbc, 0xaf, 0x27, 0x1c}
+
+// 7z members are usually packed into solid blocks, which can't be read from the middle, so instead of
+// offsets and checkpoints members are found by path and decompressed from the start of their block.
+
+// scan7z reads members of a 7z archive
+func scan7z(r io.ReaderAt, size int64) ([]dbMember, error) {
+	archive, err := sevenzip.NewReader(r, size)
+	if err != nil {
+		return nil, err
+	}
+
+	var members memberList
+
+	for _, f := range archive.File {
+		if !f.Mode().IsRegular() {
+			continue
+		}
+
+		fileID, err := resolve7z(f)
+		if err != nil {
+			return nil, err
+		}
+
+		members.add(dbMember{
+			Path:   f.Name,
+			FileID: fileID.String(),
+		})
+	}
+
+	return members.list, nil
+}
+
+func resolve7z(f *sevenzip.File) (data.ID, error) {
+	r, err := f.Open()
+	if err != nil {
+		return data.ID{}, err
+	}
+	defer r.Close()
+
+	return data.ResolveAll(r)
+}
+
+// open7z opens the member of a 7z archive stored under the path. If the path was added to the
+// archive more than once, the last copy is opened.
+func open7z(r io.ReaderAt, size int64, path string) (io.ReadCloser, error) {
+	archive, err := sevenzip.NewReader(r, size)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := len(archive.File) - 1; i >= 0; i-- {
+		if f := archive.File[i]; f.Name == path && f.Mode().IsRegular() {
+			return f.Open()
+		}
+	}
+
+	return nil, storage.ErrNotFound
+}
+
+func (mod *Module) read7z(archiveID data.ID, path string, dataID data.ID, opts *storage.ReadOpts) (storage.DataReader, error) {
+	var r = &readerAt{storage: mod.storage, dataID: archiveID}
+
+	f, err := open7z(r, int64(archiveID.Size), path)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := streams.Skip(f, opts.Offset); err != nil {
+		f.Close()
+		return nil, err
+	}
+
+	return &Reader{
+		ReadCloser: &streams.LimitedReader{
+			ReadCloser: f,
+			Limit:      dataID.Size - opts.Offset,
+		},
+		name: "mod.tar",
+	}, nil
+}
+
+// readerAt reads stored data at any offset
+type readerAt struct {
+	storage storage.Module
+	dataID  data.ID
+}
+
+func (r *readerAt) ReadAt(p []byte, off int64) (n int, err error) {
+	f, err := r.storage.Data().Read(r.dataID, &storage.ReadOpts{Offset: uint64(off)})
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+
+	n, err = io.ReadFull(f, p)
+	if errors.Is(err, io.ErrUnexpectedEOF) {
+		err = io.EOF
+	}
+	return
+}
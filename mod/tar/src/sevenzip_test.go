@@ -0,0 +1,144 @@
+package tar
+
+import (
+	"bytes"
+	"encoding/binary"
+	"github.com/cryptopunkscc/astrald/data"
+	"hash/crc32"
+	"io"
+	"testing"
+	"unicode/utf16"
+)
+
+type testMember struct {
+	name    string
+	content []byte
+}
+
+// write7z writes a 7z archive storing the members uncompressed in a single solid block
+func write7z(members []testMember) []byte {
+	var packed bytes.Buffer
+	for _, m := range members {
+		packed.Write(m.content)
+	}
+
+	var h bytes.Buffer
+	h.Write([]byte{0x01, 0x04}) // header, main streams info
+
+	h.WriteByte(0x06) // pack info
+	write7zNumber(&h, 0)
+	write7zNumber(&h, 1)
+	h.WriteByte(0x09)
+	write7zNumber(&h, uint64(packed.Len()))
+	h.WriteByte(0x00)
+
+	h.Write([]byte{0x07, 0x0b}) // unpack info, one folder with the copy coder
+	write7zNumber(&h, 1)
+	h.Write([]byte{0x00, 0x01, 0x01, 0x00})
+	h.WriteByte(0x0c)
+	write7zNumber(&h, uint64(packed.Len()))
+	h.WriteByte(0x00)
+
+	h.Write([]byte{0x08, 0x0d}) // substreams info
+	write7zNumber(&h, uint64(len(members)))
+	h.WriteByte(0x09)
+	for _, m := range members[:len(members)-1] {
+		write7zNumber(&h, uint64(len(m.content)))
+	}
+	h.Write([]byte{0x0a, 0x01})
+	for _, m := range members {
+		binary.Write(&h, binary.LittleEndian, crc32.ChecksumIEEE(m.content))
+	}
+	h.Write([]byte{0x00, 0x00})
+
+	var names bytes.Buffer
+	names.WriteByte(0x00)
+	for _, m := range members {
+		for _, c := range utf16.Encode([]rune(m.name + "\x00")) {
+			binary.Write(&names, binary.LittleEndian, c)
+		}
+	}
+
+	h.WriteByte(0x05) // files info
+	write7zNumber(&h, uint64(len(members)))
+	h.WriteByte(0x11)
+	write7zNumber(&h, uint64(names.Len()))
+	h.Write(names.Bytes())
+	h.Write([]byte{0x00, 0x00})
+
+	var start = make([]byte, 20)
+	binary.LittleEndian.PutUint64(start[0:], uint64(packed.Len()))
+	binary.LittleEndian.PutUint64(start[8:], uint64(h.Len()))
+	binary.LittleEndian.PutUint32(start[16:], crc32.ChecksumIEEE(h.Bytes()))
+
+	var out bytes.Buffer
+	out.Write(sevenZipMagic)
+	out.Write([]byte{0, 4})
+	binary.Write(&out, binary.LittleEndian, crc32.ChecksumIEEE(start))
+	out.Write(start)
+	out.Write(packed.Bytes())
+	out.Write(h.Bytes())
+
+	return out.Bytes()
+}
+
+func write7zNumber(w *bytes.Buffer, v uint64) {
+	var first, mask byte = 0, 0x80
+	var i int
+	for ; i < 8; i++ {
+		if v < 1<<(7*(i+1)) {
+			first |= byte(v >> (8 * i))
+			break
+		}
+		first |= mask
+		mask >>= 1
+	}
+
+	w.WriteByte(first)
+	for ; i > 0; i-- {
+		w.WriteByte(byte(v))
+		v >>= 8
+	}
+}
+
+func Test7z(t *testing.T) {
+	var members = []testMember{
+		{"dir/first.txt", bytes.Repeat([]byte("first"), 100)},
+		{"dir/second.txt", bytes.Repeat([]byte("second"), 300)},
+		{"dir/first.txt", bytes.Repeat([]byte("replaced"), 50)},
+	}
+	var archive = write7z(members)
+	var r = bytes.NewReader(archive)
+
+	list, err := scan7z(r, int64(len(archive)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(list) != 2 {
+		t.Fatalf("expected 2 members, got %d", len(list))
+	}
+
+	// a path added again replaces the earlier copy
+	for i, m := range []testMember{members[2], members[1]} {
+		if list[i].Path != m.name || list[i].FileID != data.Resolve(m.content).String() {
+			t.Fatalf("member %d: unexpected %s (%s)", i, list[i].Path, list[i].FileID)
+		}
+
+		f, err := open7z(r, int64(len(archive)), m.name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		content, err := io.ReadAll(f)
+		f.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(content, m.content) {
+			t.Fatalf("member %d: content mismatch", i)
+		}
+	}
+
+	if _, err := open7z(r, int64(len(archive)), "dir/missing.txt"); err == nil {
+		t.Fatal("expected missing member not to be found")
+	}
+}
@@ -0,0 +1,351 @@
+package tar
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"github.com/cryptopunkscc/astrald/mod/tar"
+	"github.com/klauspost/compress/zstd"
+	"io"
+)
+
+var (
+	gzipMagic = []byte{0x1f, 0x8b}
+	zstdMagic = []byte{0x28, 0xb5, 0x2f, 0xfd}
+)
+
+// detectCompression returns the compression of a stream starting with the header
+func detectCompression(header []byte) string {
+	switch {
+	case bytes.HasPrefix(header, gzipMagic):
+		return tar.CompressionGzip
+	case bytes.HasPrefix(header, zstdMagic), isSkippableFrame(header):
+		return tar.CompressionZstd
+	}
+	return tar.CompressionNone
+}
+
+// checkpoint is a position from which decompression can be resumed. Checkpoints inside a gzip member
+// are at a deflate block starting Bits bits into the Compressed byte and need the Window of output
+// preceding the block.
+type checkpoint struct {
+	Compressed   int64
+	Bits         uint8
+	Uncompressed int64
+	Window       []byte
+}
+
+// stream decompresses an archive and passes checkpoints at least interval bytes of output apart to save
+// as soon as they are reached, so that windows of a large archive don't pile up in memory.
+// Gzip archives get checkpoints at deflate block boundaries, so any gzip archive can be read from
+// the middle. Zstd blocks depend on the state of the whole frame, so zstd archives only get
+// checkpoints at frame boundaries - an archive compressed as a single frame has to be decompressed
+// from the beginning.
+type stream struct {
+	src      *countingReader
+	seg      io.Reader
+	next     func() (io.Reader, error)
+	out      int64
+	interval int64
+	save     func(checkpoint) error
+	saved    bool
+	last     int64 // uncompressed offset of the last saved checkpoint
+	err      error
+	close    func()
+}
+
+func newStream(r io.Reader, compression string, interval int64, save func(checkpoint) error) (*stream, error) {
+	var s = &stream{
+		src:      &countingReader{r: bufio.NewReader(r)},
+		interval: interval,
+		save:     save,
+		close:    func() {},
+	}
+
+	switch compression {
+	case tar.CompressionNone:
+		var done bool
+		s.next = func() (io.Reader, error) {
+			if done {
+				return nil, io.EOF
+			}
+			done = true
+			return s.src, nil
+		}
+
+	case tar.CompressionGzip:
+		var f = newInflater(s.src)
+		f.checkpoint = s.checkpoint
+		s.next = func() (io.Reader, error) {
+			if f == nil {
+				return nil, io.EOF
+			}
+			var r = f
+			f = nil
+			return r, nil
+		}
+
+	case tar.CompressionZstd:
+		dec, err := zstd.NewReader(nil, zstd.WithDecoderConcurrency(1))
+		if err != nil {
+			return nil, err
+		}
+		s.close = dec.Close
+		s.next = func() (io.Reader, error) {
+			frame, err := nextZstdFrame(s.src)
+			if err != nil {
+				return nil, err
+			}
+			return dec, dec.Reset(frame)
+		}
+
+	default:
+		return nil, errors.New("unsupported compression")
+	}
+
+	return s, nil
+}
+
+func (s *stream) Read(p []byte) (n int, err error) {
+	for {
+		if s.err != nil {
+			return 0, s.err
+		}
+
+		if s.seg == nil {
+			if _, err = s.src.r.Peek(1); err != nil {
+				return 0, err
+			}
+
+			s.checkpoint(checkpoint{Compressed: s.src.n, Uncompressed: s.out}, nil)
+
+			if s.seg, err = s.next(); err != nil {
+				return 0, err
+			}
+		}
+
+		n, err = s.seg.Read(p)
+		s.out += int64(n)
+
+		if s.err != nil {
+			return n, s.err
+		}
+
+		if err == io.EOF {
+			s.seg = nil
+			if n == 0 {
+				continue
+			}
+			err = nil
+		}
+
+		return
+	}
+}
+
+// Offset returns the number of uncompressed bytes read so far
+func (s *stream) Offset() int64 {
+	return s.out
+}
+
+func (s *stream) Close() error {
+	s.close()
+	return nil
+}
+
+// checkpoint saves the checkpoint if it's far enough from the previous one. The window is only
+// copied for saved checkpoints.
+func (s *stream) checkpoint(cp checkpoint, window func() []byte) {
+	if s.save == nil || s.err != nil {
+		return
+	}
+
+	if s.saved && (cp.Uncompressed == s.last || cp.Uncompressed-s.last < s.interval) {
+		return
+	}
+
+	if window != nil {
+		cp.Window = window()
+	}
+
+	if s.err = s.save(cp); s.err == nil {
+		s.saved, s.last = true, cp.Uncompressed
+	}
+}
+
+// newDecompressor returns a reader decompressing a stream that starts at the checkpoint
+func newDecompressor(r io.Reader, compression string, cp checkpoint) (io.ReadCloser, error) {
+	switch compression {
+	case tar.CompressionNone:
+		return io.NopCloser(r), nil
+
+	case tar.CompressionGzip:
+		f, err := resumeInflater(bufio.NewReader(r), cp)
+		if err != nil {
+			return nil, err
+		}
+		return io.NopCloser(f), nil
+
+	case tar.CompressionZstd:
+		dec, err := zstd.NewReader(r, zstd.WithDecoderConcurrency(1))
+		if err != nil {
+			return nil, err
+		}
+		return dec.IOReadCloser(), nil
+	}
+
+	return nil, errors.New("unsupported compression")
+}
+
+// countingReader counts bytes consumed from the underlying reader. It implements io.ByteReader, so that
+// decompressors don't buffer input past the end of a segment.
+type countingReader struct {
+	r *bufio.Reader
+	n int64
+}
+
+func (c *countingReader) Read(p []byte) (n int, err error) {
+	n, err = c.r.Read(p)
+	c.n += int64(n)
+	return
+}
+
+func (c *countingReader) ReadByte() (b byte, err error) {
+	b, err = c.r.ReadByte()
+	if err == nil {
+		c.n++
+	}
+	return
+}
+
+// nextZstdFrame skips skippable frames and returns a reader limited to the next zstd frame
+func nextZstdFrame(src *countingReader) (io.Reader, error) {
+	for {
+		magic, err := src.r.Peek(8)
+		if err != nil && len(magic) < 4 {
+			return nil, io.EOF
+		}
+
+		if !isSkippableFrame(magic) {
+			break
+		}
+		if len(magic) < 8 {
+			return nil, io.ErrUnexpectedEOF
+		}
+
+		var size = int64(binary.LittleEndian.Uint32(magic[4:])) + 8
+		n, err := io.CopyN(io.Discard, src, size)
+		if n < size {
+			return nil, io.ErrUnexpectedEOF
+		}
+	}
+
+	return newZstdFrame(src)
+}
+
+// isSkippableFrame checks if the header starts a zstd skippable frame
+func isSkippableFrame(header []byte) bool {
+	return len(header) >= 4 && binary.LittleEndian.Uint32(header)&0xfffffff0 == 0x184d2a50
+}
+
+// zstdFrame passes through the bytes of a single zstd frame and returns io.EOF at its end
+type zstdFrame struct {
+	src      *countingReader
+	buf      []byte // headers read, but not yet passed through
+	left     int64  // bytes of the current block left to pass through
+	checksum int
+	last     bool
+	done     bool
+}
+
+func newZstdFrame(src *countingReader) (*zstdFrame, error) {
+	var h = make([]byte, 5)
+	if _, err := io.ReadFull(src, h); err != nil {
+		return nil, unexpected(err)
+	}
+	if !bytes.Equal(h[:4], zstdMagic) {
+		return nil, errors.New("invalid zstd frame")
+	}
+
+	var desc = h[4]
+	var single = desc&0x20 != 0
+	var size = []int{0, 1, 2, 4}[desc&3]
+	if !single {
+		size++ // window descriptor
+	}
+	switch desc >> 6 {
+	case 0:
+		if single {
+			size++
+		}
+	case 1:
+		size += 2
+	case 2:
+		size += 4
+	case 3:
+		size += 8
+	}
+
+	var f = &zstdFrame{src: src, buf: make([]byte, 5+size)}
+	copy(f.buf, h)
+	if _, err := io.ReadFull(src, f.buf[5:]); err != nil {
+		return nil, unexpected(err)
+	}
+	if desc&0x04 != 0 {
+		f.checksum = 4
+	}
+
+	return f, nil
+}
+
+func (f *zstdFrame) Read(p []byte) (n int, err error) {
+	for len(f.buf) == 0 && f.left == 0 {
+		switch {
+		case f.done:
+			return 0, io.EOF
+
+		case f.last:
+			f.done = true
+			f.left = int64(f.checksum)
+
+		default:
+			var h = make([]byte, 3)
+			if _, err = io.ReadFull(f.src, h); err != nil {
+				return 0, unexpected(err)
+			}
+
+			var v = uint32(h[0]) | uint32(h[1])<<8 | uint32(h[2])<<16
+			f.buf, f.last, f.left = h, v&1 != 0, int64(v>>3)
+
+			switch (v >> 1) & 3 {
+			case 1: // RLE block stores a single byte
+				f.left = 1
+			case 3:
+				return 0, errors.New("invalid zstd block")
+			}
+		}
+	}
+
+	if len(f.buf) > 0 {
+		n = copy(p, f.buf)
+		f.buf = f.buf[n:]
+		return
+	}
+
+	if int64(len(p)) > f.left {
+		p = p[:f.left]
+	}
+
+	n, err = f.src.Read(p)
+	f.left -= int64(n)
+
+	return n, unexpected(err)
+}
+
+func unexpected(err error) error {
+	if err == io.EOF {
+		return io.ErrUnexpectedEOF
+	}
+	return err
+}
@@ -0,0 +1,262 @@
+package tar
+
+import (
+	_tar "archive/tar"
+	"bytes"
+	"compress/gzip"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"github.com/cryptopunkscc/astrald/mod/tar"
+	"github.com/cryptopunkscc/astrald/streams"
+	"github.com/klauspost/compress/zstd"
+	"io"
+	"math/rand"
+	"testing"
+)
+
+const testChunkSize = 3000
+
+func testArchive(t *testing.T) (archive []byte, files map[string][]byte) {
+	var buf bytes.Buffer
+	var w = _tar.NewWriter(&buf)
+
+	files = map[string][]byte{}
+	for i := 0; i < 5; i++ {
+		var name = fmt.Sprintf("dir/file%d.txt", i)
+		var content = bytes.Repeat([]byte(name), 200*(i+1))
+		files[name] = content
+
+		w.WriteHeader(&_tar.Header{Name: name, Mode: 0644, Size: int64(len(content))})
+		w.Write(content)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	return buf.Bytes(), files
+}
+
+// compress compresses the archive in chunks, so that the result has many segments
+func compress(t *testing.T, archive []byte, compression string) []byte {
+	var out bytes.Buffer
+
+	for len(archive) > 0 {
+		var chunk = archive[:min(len(archive), testChunkSize)]
+		archive = archive[len(chunk):]
+
+		switch compression {
+		case tar.CompressionNone:
+			out.Write(chunk)
+
+		case tar.CompressionGzip:
+			w := gzip.NewWriter(&out)
+			w.Write(chunk)
+			w.Close()
+
+		case tar.CompressionZstd:
+			// prepend a skippable frame
+			out.Write(binary.LittleEndian.AppendUint32(nil, 0x184d2a50))
+			out.Write(binary.LittleEndian.AppendUint32(nil, 3))
+			out.Write([]byte{1, 2, 3})
+
+			enc, err := zstd.NewWriter(nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			out.Write(enc.EncodeAll(chunk, nil))
+			enc.Close()
+		}
+	}
+
+	return out.Bytes()
+}
+
+func TestStreamCheckpoints(t *testing.T) {
+	archive, files := testArchive(t)
+
+	for _, compression := range []string{tar.CompressionNone, tar.CompressionGzip, tar.CompressionZstd} {
+		t.Run("compression="+compression, func(t *testing.T) {
+			var compressed = compress(t, archive, compression)
+
+			if c := detectCompression(compressed); c != compression {
+				t.Fatalf("detected %q", c)
+			}
+
+			var checkpoints []checkpoint
+			s, err := newStream(bytes.NewReader(compressed), compression, testChunkSize, collect(&checkpoints))
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer s.Close()
+
+			var offsets = map[string]int64{}
+			var reader = _tar.NewReader(s)
+			for {
+				hdr, err := reader.Next()
+				if err == io.EOF {
+					break
+				}
+				if err != nil {
+					t.Fatal(err)
+				}
+				offsets[hdr.Name] = s.Offset()
+			}
+
+			if compression != tar.CompressionNone && len(checkpoints) < 2 {
+				t.Fatalf("expected multiple checkpoints, got %d", len(checkpoints))
+			}
+
+			for name, content := range files {
+				var offset = offsets[name]
+				if !bytes.Equal(archive[offset:offset+int64(len(content))], content) {
+					t.Fatalf("invalid offset of %s", name)
+				}
+
+				// read the file starting from the closest checkpoint
+				var cp = checkpoint{Compressed: offset, Uncompressed: offset}
+				if compression != tar.CompressionNone {
+					for _, c := range checkpoints {
+						if c.Uncompressed <= offset {
+							cp = c
+						}
+					}
+				}
+
+				dec, err := newDecompressor(bytes.NewReader(compressed[cp.Compressed:]), compression, cp)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if err := streams.Skip(dec, uint64(offset-cp.Uncompressed)); err != nil {
+					t.Fatal(err)
+				}
+
+				var read = make([]byte, len(content))
+				if _, err := io.ReadFull(dec, read); err != nil {
+					t.Fatal(err)
+				}
+				dec.Close()
+
+				if !bytes.Equal(read, content) {
+					t.Fatalf("invalid content of %s", name)
+				}
+			}
+		})
+	}
+}
+
+func TestInflate(t *testing.T) {
+	var rnd = rand.New(rand.NewSource(1))
+	var random = make([]byte, 200000)
+	rnd.Read(random)
+
+	var text []byte
+	for len(text) < 500000 {
+		text = fmt.Appendf(text, "line %d of %d\n", rnd.Intn(1000), rnd.Intn(100))
+	}
+
+	for _, input := range [][]byte{nil, random, text} {
+		for _, level := range []int{gzip.NoCompression, gzip.HuffmanOnly, gzip.BestSpeed, gzip.BestCompression} {
+			var buf bytes.Buffer
+			w, _ := gzip.NewWriterLevel(&buf, level)
+			w.Name, w.Comment, w.Extra = "name", "comment", []byte{1, 2, 3}
+			w.Write(input)
+			w.Close()
+
+			out, err := io.ReadAll(newInflater(bytes.NewReader(buf.Bytes())))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(out, input) {
+				t.Fatalf("invalid output at level %d", level)
+			}
+
+			// corrupt the checksum
+			var b = bytes.Clone(buf.Bytes())
+			b[len(b)-5] ^= 1
+			if _, err = io.ReadAll(newInflater(bytes.NewReader(b))); !errors.Is(err, gzip.ErrChecksum) {
+				t.Fatalf("expected checksum error, got %v", err)
+			}
+		}
+	}
+}
+
+func TestGzipCheckpoints(t *testing.T) {
+	var archive []byte
+	for len(archive) < 1<<20 {
+		archive = fmt.Appendf(archive, "record %d\n", len(archive)%7919)
+	}
+
+	// a single gzip member
+	var buf bytes.Buffer
+	w := gzip.NewWriter(&buf)
+	w.Write(archive)
+	w.Close()
+	var compressed = buf.Bytes()
+
+	var checkpoints []checkpoint
+	s, err := newStream(bytes.NewReader(compressed), tar.CompressionGzip, 64*1024, collect(&checkpoints))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if out, err := io.ReadAll(s); err != nil || !bytes.Equal(out, archive) {
+		t.Fatal("invalid output", err)
+	}
+	if len(checkpoints) < 8 {
+		t.Fatalf("expected multiple checkpoints, got %d", len(checkpoints))
+	}
+
+	for _, cp := range checkpoints {
+		var offset = min(cp.Uncompressed+100, int64(len(archive)))
+
+		dec, err := newDecompressor(bytes.NewReader(compressed[cp.Compressed:]), tar.CompressionGzip, cp)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := streams.Skip(dec, uint64(offset-cp.Uncompressed)); err != nil {
+			t.Fatal(err)
+		}
+
+		read, err := io.ReadAll(dec)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(read, archive[offset:]) {
+			t.Fatalf("invalid content from checkpoint at %d", cp.Uncompressed)
+		}
+	}
+}
+
+// collect returns a save function that appends checkpoints to the list
+func collect(list *[]checkpoint) func(checkpoint) error {
+	return func(cp checkpoint) error {
+		*list = append(*list, cp)
+		return nil
+	}
+}
+
+func TestStreamSaveError(t *testing.T) {
+	var archive = make([]byte, 1<<20)
+	rand.New(rand.NewSource(1)).Read(archive)
+
+	var buf bytes.Buffer
+	w := gzip.NewWriter(&buf)
+	w.Write(archive)
+	w.Close()
+
+	var failed = errors.New("save failed")
+	var saved int
+	s, err := newStream(&buf, tar.CompressionGzip, 64*1024, func(checkpoint) error {
+		if saved++; saved > 2 {
+			return failed
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err = io.ReadAll(s); !errors.Is(err, failed) {
+		t.Fatalf("expected the save error, got %v", err)
+	}
+}
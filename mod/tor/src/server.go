@@ -3,6 +3,7 @@ package tor
 import (
 	"context"
 	"encoding/base64"
+	"errors"
 	"github.com/cryptopunkscc/astrald/mod/tor/tc"
 	"github.com/cryptopunkscc/astrald/node/link"
 	"github.com/cryptopunkscc/astrald/sig"
@@ -56,7 +57,11 @@ func (srv *Server) Run(ctx context.Context) error {
 
 		go func() {
 			l, err := link.Accept(ctx, conn, srv.node.Identity())
-			if err != nil {
+			switch {
+			case errors.Is(err, link.ErrPathJoined):
+				srv.log.Logv(1, "new path from %v joined a link", conn.RemoteEndpoint())
+				return
+			case err != nil:
 				srv.log.Errorv(1, "handshake failed from %v: %v", conn.RemoteEndpoint(), err)
 				return
 			}
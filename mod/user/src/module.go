@@ -129,7 +129,10 @@ func (mod *Module) checkCert(relayID id.Identity, certBytes []byte) error {
 		return errors.New("relay mismatch")
 	}
 
-	mod.storage.Data().StoreBytes(certBytes, nil)
+	certID, err := mod.storage.Data().StoreBytes(certBytes, nil)
+	if err != nil {
+		return err
+	}
 
-	return nil
+	return mod.storage.Pins().Pin(certID, user.ModuleName)
 }
@@ -3,6 +3,7 @@ package zip
 import (
 	"github.com/cryptopunkscc/astrald/data"
 	"github.com/cryptopunkscc/astrald/mod/storage"
+	"io"
 )
 
 type readerAt struct {
@@ -17,5 +18,5 @@ func (r *readerAt) ReadAt(p []byte, off int64) (n int, err error) {
 	}
 	defer f.Close()
 
-	return f.Read(p)
+	return io.ReadFull(f, p)
 }
@@ -41,12 +41,7 @@ func (mux *FrameMux) Run(ctx context.Context) error {
 			return err
 		}
 
-		handler := mux.portHandler(frame.Port)
-		if handler != nil {
-			handler(frame)
-		} else if mux.defaultHandler != nil {
-			mux.defaultHandler(frame)
-		}
+		mux.dispatch(frame)
 
 		select {
 		case <-ctx.Done():
@@ -56,6 +51,21 @@ func (mux *FrameMux) Run(ctx context.Context) error {
 	}
 }
 
+// Dispatch passes a frame received over another transport to the handler of its port. It lets frames of
+// a single FrameMux travel over several transports.
+func (mux *FrameMux) Dispatch(port int, data []byte) {
+	mux.dispatch(Frame{Mux: mux, Port: port, Data: data})
+}
+
+func (mux *FrameMux) dispatch(frame Frame) {
+	handler := mux.portHandler(frame.Port)
+	if handler != nil {
+		handler(frame)
+	} else if mux.defaultHandler != nil {
+		mux.defaultHandler(frame)
+	}
+}
+
 func (mux *FrameMux) portHandler(port int) HandlerFunc {
 	mux.mu.Lock()
 	defer mux.mu.Unlock()
@@ -11,6 +11,89 @@ import (
 // ErrRejected - the query was rejected by the target
 var ErrRejected = errors.New("query rejected")
 
+// Rejection codes tell the caller why the query was rejected
+const (
+	RejectCodeRejected      = 0x01 // no reason given
+	RejectCodeRouteNotFound = 0x02 // the target has no route for the query
+	RejectCodeAccessDenied  = 0x03 // the caller is not allowed to make the query
+	RejectCodeNotFound      = 0x04 // the requested resource does not exist
+	RejectCodeUnavailable   = 0x05 // the service is overloaded or temporarily unavailable
+	RejectCodeRateLimited   = 0x06 // the caller exceeded its quota
+	RejectCodeInvalidQuery  = 0x07 // the query or its parameters are malformed
+)
+
+// MaxRejectMessageLen is the maximum length of a rejection message
+const MaxRejectMessageLen = 255
+
+// RejectError - the query was rejected by the target for the provided reason. It matches ErrRejected.
+type RejectError struct {
+	Code    int
+	Message string
+}
+
+func (e *RejectError) Error() string {
+	if e.Message != "" {
+		return fmt.Sprintf("query rejected (%s): %s", RejectCodeName(e.Code), e.Message)
+	}
+	return fmt.Sprintf("query rejected (%s)", RejectCodeName(e.Code))
+}
+
+func (e *RejectError) Is(other error) bool {
+	if other == ErrRejected {
+		return true
+	}
+	o, ok := other.(*RejectError)
+	return ok && o.Code == e.Code
+}
+
+// RejectWithReason rejects the query with the provided code and message
+func RejectWithReason(code int, message string) (SecureWriteCloser, error) {
+	if len(message) > MaxRejectMessageLen {
+		message = message[:MaxRejectMessageLen]
+	}
+	return nil, &RejectError{Code: code, Message: message}
+}
+
+// RejectReason returns the rejection code and message of a routing error. Errors other than RejectError are
+// reported as RejectCodeRouteNotFound or RejectCodeRejected without a message.
+func RejectReason(err error) (code int, message string) {
+	var rerr *RejectError
+	switch {
+	case errors.As(err, &rerr):
+		message = rerr.Message
+		if len(message) > MaxRejectMessageLen {
+			message = message[:MaxRejectMessageLen]
+		}
+		return rerr.Code, message
+	case errors.Is(err, &ErrRouteNotFound{}):
+		return RejectCodeRouteNotFound, ""
+	default:
+		return RejectCodeRejected, ""
+	}
+}
+
+// RejectCodeName returns a human-readable name of a rejection code
+func RejectCodeName(code int) string {
+	switch code {
+	case RejectCodeRejected:
+		return "rejected"
+	case RejectCodeRouteNotFound:
+		return "route not found"
+	case RejectCodeAccessDenied:
+		return "access denied"
+	case RejectCodeNotFound:
+		return "not found"
+	case RejectCodeUnavailable:
+		return "unavailable"
+	case RejectCodeRateLimited:
+		return "rate limited"
+	case RejectCodeInvalidQuery:
+		return "invalid query"
+	default:
+		return fmt.Sprintf("code %d", code)
+	}
+}
+
 // ErrAborted - query was aborted and routing did not finish
 var ErrAborted = errors.New("query aborted")
 
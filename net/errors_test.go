@@ -0,0 +1,29 @@
+package net
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func TestRejectReason(t *testing.T) {
+	_, err := RejectWithReason(RejectCodeAccessDenied, "no access")
+	err = fmt.Errorf("routing: %w", err)
+
+	if !errors.Is(err, ErrRejected) {
+		t.Fatal("reject error does not match ErrRejected")
+	}
+
+	code, message := RejectReason(err)
+	if code != RejectCodeAccessDenied || message != "no access" {
+		t.Fatalf("unexpected reason: %d %q", code, message)
+	}
+
+	if code, _ := RejectReason(ErrRejected); code != RejectCodeRejected {
+		t.Fatalf("expected code %d, got %d", RejectCodeRejected, code)
+	}
+
+	if code, _ := RejectReason(&ErrRouteNotFound{}); code != RejectCodeRouteNotFound {
+		t.Fatalf("expected code %d, got %d", RejectCodeRouteNotFound, code)
+	}
+}
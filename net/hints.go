@@ -1,12 +1,13 @@
 package net
 
 type Hints struct {
-	Origin  string // Origin denotes the where the query originated (OriginLocal or OriginNetwork)
-	Silent  bool   // Silent tells the router to not log the query
-	Reroute bool   // Reroute allows a nonce to reenter the router event though it's already en route
-	Update  bool   // Update tells the monitored router to update query details for the nonce when rerouting
-	Extra   map[string]any
-	_       struct{}
+	Origin   string // Origin denotes the where the query originated (OriginLocal or OriginNetwork)
+	Silent   bool   // Silent tells the router to not log the query
+	Reroute  bool   // Reroute allows a nonce to reenter the router event though it's already en route
+	Update   bool   // Update tells the monitored router to update query details for the nonce when rerouting
+	Priority int    // Priority sets the priority class of the connection's traffic (see PriorityNormal)
+	Extra    map[string]any
+	_        struct{}
 }
 
 func DefaultHints() Hints {
@@ -37,6 +38,12 @@ func (hints Hints) SetUpdate() Hints {
 	return clone
 }
 
+func (hints Hints) WithPriority(priority int) Hints {
+	var clone = hints.clone()
+	clone.Priority = priority
+	return clone
+}
+
 func (hints Hints) WithValue(key string, val any) Hints {
 	var clone = hints.clone()
 	if clone.Extra == nil {
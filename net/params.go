@@ -0,0 +1,99 @@
+package net
+
+import (
+	"errors"
+	"github.com/cryptopunkscc/astrald/cslq"
+	"slices"
+)
+
+// MaxParams is the maximum number of parameters a query can carry
+const MaxParams = 255
+
+// MaxParamKeyLen is the maximum length of a parameter key
+const MaxParamKeyLen = 255
+
+// MaxParamValueLen is the maximum length of a parameter value
+const MaxParamValueLen = 65535
+
+var ErrTooManyParams = errors.New("too many query parameters")
+var ErrParamTooLong = errors.New("query parameter too long")
+
+// Params is a set of optional key/value parameters attached to a query
+type Params map[string]string
+
+// Get returns the value of the parameter or an empty string if the parameter is not set
+func (p Params) Get(key string) string {
+	return p[key]
+}
+
+// Has returns true if the parameter is set
+func (p Params) Has(key string) bool {
+	_, found := p[key]
+	return found
+}
+
+// Clone returns a copy of the parameters
+func (p Params) Clone() Params {
+	if p == nil {
+		return nil
+	}
+	var c = make(Params, len(p))
+	for k, v := range p {
+		c[k] = v
+	}
+	return c
+}
+
+func (p Params) MarshalCSLQ(enc *cslq.Encoder) error {
+	if len(p) > MaxParams {
+		return ErrTooManyParams
+	}
+
+	var keys = make([]string, 0, len(p))
+	for k := range p {
+		keys = append(keys, k)
+	}
+	slices.Sort(keys)
+
+	if err := enc.Encodef("c", len(keys)); err != nil {
+		return err
+	}
+
+	for _, k := range keys {
+		if len(k) > MaxParamKeyLen || len(p[k]) > MaxParamValueLen {
+			return ErrParamTooLong
+		}
+	}
+
+	for _, k := range keys {
+		if err := enc.Encodef("[c]c [s]c", k, p[k]); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (p *Params) UnmarshalCSLQ(dec *cslq.Decoder) error {
+	var count int
+
+	if err := dec.Decodef("c", &count); err != nil {
+		return err
+	}
+
+	if count == 0 {
+		*p = nil
+		return nil
+	}
+
+	*p = make(Params, count)
+	for i := 0; i < count; i++ {
+		var k, v string
+		if err := dec.Decodef("[c]c [s]c", &k, &v); err != nil {
+			return err
+		}
+		(*p)[k] = v
+	}
+
+	return nil
+}
@@ -0,0 +1,57 @@
+package net
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"github.com/cryptopunkscc/astrald/cslq"
+	"strings"
+	"testing"
+)
+
+func TestParamsCSLQ(t *testing.T) {
+	var buf = &bytes.Buffer{}
+	var params = Params{"offset": "1024", "format": "json"}
+
+	if err := cslq.Encode(buf, "v", params); err != nil {
+		t.Fatal(err)
+	}
+
+	var decoded Params
+	if err := cslq.Decode(buf, "v", &decoded); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(decoded) != len(params) {
+		t.Fatalf("expected %d params, got %d", len(params), len(decoded))
+	}
+	for k, v := range params {
+		if decoded.Get(k) != v {
+			t.Fatalf("param %s: expected %q, got %q", k, v, decoded.Get(k))
+		}
+	}
+}
+
+func TestParamsLimit(t *testing.T) {
+	var params = Params{}
+	for i := 0; i <= MaxParams; i++ {
+		params[fmt.Sprint(i)] = ""
+	}
+
+	err := cslq.Encode(&bytes.Buffer{}, "v", params)
+	if !errors.Is(err, ErrTooManyParams) {
+		t.Fatalf("expected %v, got %v", ErrTooManyParams, err)
+	}
+}
+
+func TestParamsLength(t *testing.T) {
+	for _, params := range []Params{
+		{strings.Repeat("k", MaxParamKeyLen+1): ""},
+		{"k": strings.Repeat("v", MaxParamValueLen+1)},
+	} {
+		err := cslq.Encode(&bytes.Buffer{}, "v", params)
+		if !errors.Is(err, ErrParamTooLong) {
+			t.Fatalf("expected %v, got %v", ErrParamTooLong, err)
+		}
+	}
+}
@@ -0,0 +1,38 @@
+package net
+
+// Priority classes of query traffic. When several connections share a link, frames of higher priority
+// connections are sent ahead of frames of lower priority ones.
+const (
+	PriorityNormal      = 0 // default priority
+	PriorityInteractive = 1 // latency-sensitive sessions like shells or admin consoles
+	PriorityBulk        = 2 // background transfers that should yield to other traffic
+)
+
+// Prioritizer is implemented by writers that can schedule their traffic according to a priority class
+type Prioritizer interface {
+	Priority() int
+	SetPriority(priority int)
+}
+
+// SetPriority sets the priority of the final output of the writer chain. It returns false if the output doesn't
+// support priorities.
+func SetPriority(w SecureWriteCloser, priority int) bool {
+	if p, ok := FinalOutput(w).(Prioritizer); ok {
+		p.SetPriority(priority)
+		return true
+	}
+	return false
+}
+
+// PriorityName returns a human-readable name of the priority class
+func PriorityName(priority int) string {
+	switch priority {
+	case PriorityNormal:
+		return "normal"
+	case PriorityInteractive:
+		return "interactive"
+	case PriorityBulk:
+		return "bulk"
+	}
+	return "unknown"
+}
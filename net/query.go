@@ -17,6 +17,7 @@ type Query interface {
 	Caller() id.Identity
 	Target() id.Identity
 	Query() string
+	Params() Params
 }
 
 var _ Query = &basicQuery{}
@@ -26,6 +27,7 @@ type basicQuery struct {
 	caller id.Identity
 	target id.Identity
 	query  string
+	params Params
 }
 
 func NewQuery(caller id.Identity, target id.Identity, query string) Query {
@@ -59,6 +61,21 @@ func (q *basicQuery) Query() string {
 	return q.query
 }
 
+func (q *basicQuery) Params() Params {
+	return q.params
+}
+
+// WithParams returns a copy of the query with the provided parameters
+func WithParams(query Query, params Params) Query {
+	return &basicQuery{
+		nonce:  query.Nonce(),
+		caller: query.Caller(),
+		target: query.Target(),
+		query:  query.Query(),
+		params: params.Clone(),
+	}
+}
+
 func (n Nonce) String() string {
 	return fmt.Sprintf("%016x", uint64(n))
 }
@@ -1,11 +1,38 @@
 package node
 
+import "github.com/cryptopunkscc/astrald/node/router"
+
 const configName = "node"
 
 type Config struct {
 	Identity      string   `yaml:"identity"`
 	Modules       []string `yaml:"modules"`
 	LogRouteTrace bool     `yaml:"log_route_trace"`
+
+	// CompressNetworks lists networks over which outbound links should be compressed
+	CompressNetworks []string `yaml:"compress_networks"`
+
+	// Resume makes outbound links resumable over a new transport after their transport drops. Resumable links
+	// frame, acknowledge and heartbeat all traffic, which is too costly on slow networks, so it's opt-in.
+	Resume bool `yaml:"resume"`
+
+	// Multipath enables multipath transports for outbound links, which allows links to survive
+	// the loss of a transport
+	Multipath bool `yaml:"multipath"`
+
+	// History sets how many routed queries are kept in the routing history. Zero disables the history.
+	History int `yaml:"history"`
+
+	// Limits sets limits for queries coming from the network
+	Limits LimitsConfig `yaml:"limits"`
+}
+
+type LimitsConfig struct {
+	Default  router.Limit            `yaml:"default"`  // applies to every caller without its own limit
+	Callers  map[string]router.Limit `yaml:"callers"`  // caller identity or alias -> limit
+	Services map[string]router.Limit `yaml:"services"` // service name (or prefix ending with *) -> limit
 }
 
-var defaultConfig = Config{}
+var defaultConfig = Config{
+	History: 10000,
+}
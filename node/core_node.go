@@ -7,6 +7,7 @@ import (
 	"github.com/cryptopunkscc/astrald/node/assets"
 	"github.com/cryptopunkscc/astrald/node/events"
 	"github.com/cryptopunkscc/astrald/node/infra"
+	"github.com/cryptopunkscc/astrald/node/link"
 	"github.com/cryptopunkscc/astrald/node/modules"
 	"github.com/cryptopunkscc/astrald/node/network"
 	"github.com/cryptopunkscc/astrald/node/resolver"
@@ -119,9 +120,58 @@ func NewCoreNode(nodeID id.Identity, res resources.Resources) (*CoreNode, error)
 
 	node.router.SetLogRouteTrace(node.config.LogRouteTrace)
 
+	node.setupLimits()
+
+	if node.config.History > 0 {
+		if err := node.setupHistory(); err != nil {
+			node.log.Error("routing history unavailable: %v", err)
+		}
+	}
+
+	if node.config.CompressNetworks != nil {
+		link.CompressNetworks = node.config.CompressNetworks
+	}
+	link.ResumeEnabled = node.config.Resume
+	link.MultipathEnabled = node.config.Multipath
+
 	return node, nil
 }
 
+func (node *CoreNode) setupLimits() {
+	var limiter = node.router.Limiter()
+
+	limiter.SetDefaultLimit(node.config.Limits.Default)
+
+	for name, limit := range node.config.Limits.Callers {
+		caller, err := node.resolver.Resolve(name)
+		if err != nil {
+			node.log.Error("config error: cannot resolve %s: %v", name, err)
+			continue
+		}
+		limiter.SetCallerLimit(caller, limit)
+	}
+
+	for name, limit := range node.config.Limits.Services {
+		limiter.SetServiceLimit(name, limit)
+	}
+}
+
+func (node *CoreNode) setupHistory() error {
+	db, err := node.assets.OpenDB(router.HistoryDatabaseName)
+	if err != nil {
+		return err
+	}
+
+	history, err := router.NewHistory(db, node.config.History)
+	if err != nil {
+		return err
+	}
+
+	node.router.SetHistory(history)
+
+	return nil
+}
+
 func (node *CoreNode) checkNodeAlias() error {
 	alias, err := node.tracker.GetAlias(node.identity)
 	if (err != nil) && (!errors.Is(err, gorm.ErrRecordNotFound)) {
@@ -147,6 +197,16 @@ func (node *CoreNode) Conns() *router.ConnSet {
 	return node.router.Conns()
 }
 
+// Limiter returns the limiter enforced on queries coming from the network
+func (node *CoreNode) Limiter() *router.Limiter {
+	return node.router.Limiter()
+}
+
+// History returns the history of routed queries or nil if the history is disabled
+func (node *CoreNode) History() *router.History {
+	return node.router.History()
+}
+
 // Infra returns node's infrastructure component
 func (node *CoreNode) Infra() infra.Infra {
 	return node.infra
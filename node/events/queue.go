@@ -28,14 +28,19 @@ func (q *Queue) Emit(event Event) {
 }
 
 // Subscribe returns a channel, which will receive events from the queue until the context ends. Channel will be
-// closed afterwards.
+// closed afterwards. Only events emitted after Subscribe returns are received.
 func (q *Queue) Subscribe(ctx context.Context) <-chan Event {
 	var ch = make(chan Event)
+	var events = q.getQueue().Subscribe(ctx)
 
 	go func() {
 		defer close(ch)
-		for e := range q.getQueue().Subscribe(ctx) {
-			ch <- e
+		for e := range events {
+			select {
+			case ch <- e:
+			case <-ctx.Done():
+				return
+			}
 		}
 	}()
 
@@ -0,0 +1,190 @@
+package link
+
+import (
+	"bytes"
+	"compress/flate"
+	"encoding/binary"
+	"github.com/cryptopunkscc/astrald/mux"
+	"io"
+	"slices"
+	"sync/atomic"
+)
+
+const featureDeflate = "deflate"
+
+// CompressNetworks lists the networks over which Open will request link compression. Compression costs CPU time,
+// so it's only worth it on slow networks.
+var CompressNetworks = []string{"tor", "gw"}
+
+// CompressionStats holds byte counters of a compressed link
+type CompressionStats struct {
+	Algorithm    string
+	BytesOut     int64 // bytes written to the link before compression
+	BytesOutWire int64 // bytes written to the transport after compression
+	BytesIn      int64 // bytes read from the link after decompression
+	BytesInWire  int64 // bytes read from the transport before decompression
+}
+
+// Ratio returns the ratio of bytes sent over the wire to uncompressed bytes in both directions or 1 if no
+// data has been transferred yet.
+func (stats CompressionStats) Ratio() float64 {
+	var raw = stats.BytesOut + stats.BytesIn
+	if raw == 0 {
+		return 1
+	}
+	return float64(stats.BytesOutWire+stats.BytesInWire) / float64(raw)
+}
+
+// frameHeaderSize is the size of the port and length header of a mux frame
+const frameHeaderSize = 4
+
+// maxBlockSize limits the size of a compressed block. Deflate never expands a frame this much.
+const maxBlockSize = 2 * (frameHeaderSize + mux.MaxFrameSize)
+
+// deflateStream compresses a transport stream using deflate. Every mux frame is compressed separately with
+// a fresh dictionary and sent as a length-prefixed block, so that the compressed size of one port's traffic
+// doesn't depend on what other ports sent before (which could leak secrets, as in CRIME).
+type deflateStream struct {
+	wire     *countingReadWriter
+	w        *flate.Writer
+	wbuf     bytes.Buffer
+	frame    []byte // mux frame being written
+	r        io.ReadCloser
+	rbuf     bytes.Buffer
+	block    []byte // compressed block being read
+	bytesOut atomic.Int64
+	bytesIn  atomic.Int64
+}
+
+func newDeflateStream(transport io.ReadWriter) *deflateStream {
+	var s = &deflateStream{
+		wire: &countingReadWriter{ReadWriter: transport},
+	}
+
+	s.w, _ = flate.NewWriter(&s.wbuf, flate.DefaultCompression)
+	s.r = flate.NewReader(bytes.NewReader(nil))
+
+	return s
+}
+
+// Write collects the header and data of a mux frame and sends the frame once it's complete
+func (s *deflateStream) Write(p []byte) (n int, err error) {
+	s.frame = append(s.frame, p...)
+	s.bytesOut.Add(int64(len(p)))
+
+	for len(s.frame) >= frameHeaderSize {
+		var size = frameHeaderSize + int(binary.BigEndian.Uint16(s.frame[2:4]))
+		if len(s.frame) < size {
+			break
+		}
+
+		if err = s.writeFrame(s.frame[:size]); err != nil {
+			return
+		}
+
+		s.frame = s.frame[:copy(s.frame, s.frame[size:])]
+	}
+
+	return len(p), nil
+}
+
+func (s *deflateStream) writeFrame(frame []byte) error {
+	s.wbuf.Reset()
+	s.wbuf.Write([]byte{0, 0, 0, 0})
+	s.w.Reset(&s.wbuf)
+
+	if _, err := s.w.Write(frame); err != nil {
+		return err
+	}
+	if err := s.w.Close(); err != nil {
+		return err
+	}
+
+	var block = s.wbuf.Bytes()
+	binary.BigEndian.PutUint32(block[0:4], uint32(len(block)-4))
+
+	// write the whole block at once, so that transports that encrypt each write process it in one go
+	_, err := s.wire.Write(block)
+	return err
+}
+
+func (s *deflateStream) Read(p []byte) (n int, err error) {
+	if s.rbuf.Len() == 0 {
+		if err = s.readBlock(); err != nil {
+			return
+		}
+	}
+
+	n, _ = s.rbuf.Read(p)
+	s.bytesIn.Add(int64(n))
+	return
+}
+
+// readBlock reads and decompresses the next block
+func (s *deflateStream) readBlock() error {
+	var size uint32
+	if err := binary.Read(s.wire, binary.BigEndian, &size); err != nil {
+		return err
+	}
+
+	if size > maxBlockSize {
+		return ErrProtocolError
+	}
+
+	if cap(s.block) < int(size) {
+		s.block = make([]byte, size)
+	}
+	s.block = s.block[:size]
+
+	if _, err := io.ReadFull(s.wire, s.block); err != nil {
+		return err
+	}
+
+	if err := s.r.(flate.Resetter).Reset(bytes.NewReader(s.block), nil); err != nil {
+		return err
+	}
+
+	s.rbuf.Reset()
+	_, err := io.Copy(&s.rbuf, io.LimitReader(s.r, frameHeaderSize+mux.MaxFrameSize+1))
+	if err != nil {
+		return err
+	}
+
+	if s.rbuf.Len() > frameHeaderSize+mux.MaxFrameSize {
+		return ErrProtocolError
+	}
+
+	return nil
+}
+
+func (s *deflateStream) Stats() CompressionStats {
+	return CompressionStats{
+		Algorithm:    featureDeflate,
+		BytesOut:     s.bytesOut.Load(),
+		BytesOutWire: s.wire.written.Load(),
+		BytesIn:      s.bytesIn.Load(),
+		BytesInWire:  s.wire.read.Load(),
+	}
+}
+
+type countingReadWriter struct {
+	io.ReadWriter
+	read    atomic.Int64
+	written atomic.Int64
+}
+
+func (c *countingReadWriter) Read(p []byte) (n int, err error) {
+	n, err = c.ReadWriter.Read(p)
+	c.read.Add(int64(n))
+	return
+}
+
+func (c *countingReadWriter) Write(p []byte) (n int, err error) {
+	n, err = c.ReadWriter.Write(p)
+	c.written.Add(int64(n))
+	return
+}
+
+func shouldCompress(network string) bool {
+	return slices.Contains(CompressNetworks, network)
+}
@@ -0,0 +1,109 @@
+package link
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"github.com/cryptopunkscc/astrald/auth/id"
+	"github.com/cryptopunkscc/astrald/mux"
+	"github.com/cryptopunkscc/astrald/net"
+	"github.com/cryptopunkscc/astrald/streams"
+	"io"
+	"testing"
+)
+
+type torConn struct {
+	FakeConn
+}
+
+func (c *torConn) RemoteEndpoint() net.Endpoint {
+	return net.NewGenericEndpoint("tor", []byte{0})
+}
+
+func TestDeflateStream(t *testing.T) {
+	var left, right = streams.Pipe()
+	var writer = mux.NewRawMux(newDeflateStream(left))
+	var reader = newDeflateStream(right)
+	var payload = bytes.Repeat([]byte("astral "), 1000)
+
+	go func() {
+		for i := 0; i < 10; i++ {
+			writer.Write(i, payload)
+		}
+	}()
+
+	var rawReader = mux.NewRawMux(reader)
+	for i := 0; i < 10; i++ {
+		port, frame, err := rawReader.Read()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if port != i {
+			t.Fatalf("expected port %d, got %d", i, port)
+		}
+		if !bytes.Equal(frame, payload) {
+			t.Fatal("frame data mismatch")
+		}
+	}
+
+	var stats = reader.Stats()
+	if stats.BytesInWire >= stats.BytesIn {
+		t.Fatalf("data not compressed (%d wire bytes for %d bytes)", stats.BytesInWire, stats.BytesIn)
+	}
+}
+
+func TestDeflateFramesIndependent(t *testing.T) {
+	var left, right = streams.Pipe()
+	var stream = newDeflateStream(left)
+	var writer = mux.NewRawMux(stream)
+	var secret = make([]byte, 1000)
+	rand.Read(secret)
+
+	go io.Copy(io.Discard, right)
+
+	// a frame repeating data sent on another port must not compress better than the original
+	writer.Write(1, secret)
+	var first = stream.Stats().BytesOutWire
+	writer.Write(2, secret)
+	var second = stream.Stats().BytesOutWire - first
+
+	if second < first {
+		t.Fatalf("frame compressed using another frame's data (%d < %d bytes)", second, first)
+	}
+}
+
+func TestOpenAcceptCompressed(t *testing.T) {
+	var left, right = streams.Pipe()
+	var leftID, _ = id.GenerateIdentity()
+	var rightID, _ = id.GenerateIdentity()
+	var ctx = context.Background()
+	var accepted = make(chan *CoreLink, 1)
+
+	go func() {
+		link, err := Accept(ctx, &FakeConn{ReadWriteCloser: left}, leftID)
+		if err != nil {
+			accepted <- nil
+			return
+		}
+		accepted <- link
+	}()
+
+	link, err := Open(ctx, &torConn{FakeConn{ReadWriteCloser: right, outbound: true}}, leftID, rightID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer link.Close()
+
+	remote := <-accepted
+	if remote == nil {
+		t.Fatal("accept failed")
+	}
+	defer remote.Close()
+
+	if _, ok := link.Compression(); !ok {
+		t.Fatal("outbound link is not compressed")
+	}
+	if _, ok := remote.Compression(); !ok {
+		t.Fatal("inbound link is not compressed")
+	}
+}
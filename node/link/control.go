@@ -3,6 +3,7 @@ package link
 import (
 	"bytes"
 	"context"
+	"fmt"
 	"github.com/cryptopunkscc/astrald/cslq"
 	"github.com/cryptopunkscc/astrald/debug"
 	"github.com/cryptopunkscc/astrald/mux"
@@ -64,7 +65,7 @@ func (c *Control) handleFrame(frame mux.Frame) {
 	case codeReset:
 		cslq.Invoke(r, c.handleReset)
 	case codeQuery:
-		cslq.Invoke(r, c.handleQuery)
+		c.handleQuery(r)
 	default:
 		c.CloseWithError(ErrProtocolError)
 	}
@@ -148,44 +149,86 @@ func (c *Control) handleReset(msg Reset) error {
 	return nil
 }
 
-// Query sends a Query messsage to the remote party
-func (c *Control) Query(nonce uint64, query string, localPort int) error {
+// Query sends a Query messsage to the remote party. Errors wrapping ErrInvalidQuery mean that the query could not
+// be sent and leave the link intact.
+func (c *Control) Query(query net.Query, localPort int, priority int) error {
 	var buf = &bytes.Buffer{}
-	cslq.Encode(buf, "cv", codeQuery, Query{
-		Query:  query,
+	err := cslq.Encode(buf, "cv", codeQuery, Query{
+		Query:  query.Query(),
 		Port:   localPort,
 		Buffer: portBufferSize,
-		Nonce:  nonce,
+		Nonce:  uint64(query.Nonce()),
 	})
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrInvalidQuery, err)
+	}
+
+	switch {
+	case c.extended:
+		err = cslq.Encode(buf, "v", QueryExt{
+			Priority: priority,
+			Params:   query.Params(),
+		})
+		if err != nil {
+			return fmt.Errorf("%w: %w", ErrInvalidQuery, err)
+		}
+
+	case len(query.Params()) > 0:
+		return fmt.Errorf("%w: %w", ErrInvalidQuery, ErrParamsNotSupported)
+	}
+
+	if buf.Len() > mux.MaxFrameSize {
+		return fmt.Errorf("%w: query too large", ErrInvalidQuery)
+	}
+
 	return c.mux.Write(mux.Frame{Data: buf.Bytes()})
 }
 
-func (c *Control) handleQuery(msg Query) error {
+func (c *Control) handleQuery(r io.Reader) {
+	var msg Query
+	if err := cslq.Decode(r, "v", &msg); err != nil {
+		c.CloseWithError(ErrProtocolError)
+		return
+	}
+
+	var ext QueryExt
+	if c.extended {
+		if err := cslq.Decode(r, "v", &ext); err != nil {
+			c.WriteResponse(msg.Port, &Response{Error: errRejected})
+			return
+		}
+	}
+
 	// queries can take a long time to finish, so run them in a goroutine
 	go func() {
 		defer debug.SaveLog(func(p any) {
 			c.Close()
 		})
-		c.executeQuery(msg)
+		c.executeQuery(msg, ext)
 	}()
-
-	return nil
 }
 
 // executeQuery executes an incoming query
-func (c *Control) executeQuery(msg Query) error {
+func (c *Control) executeQuery(msg Query, ext QueryExt) error {
 	var query = net.NewQueryNonce(c.RemoteIdentity(), c.LocalIdentity(), msg.Query, net.Nonce(msg.Nonce))
+	if len(ext.Params) > 0 {
+		query = net.WithParams(query, ext.Params)
+	}
 
 	var caller = NewPortWriter(c.CoreLink, msg.Port)
+	caller.SetPriority(ext.Priority)
 
 	// lock the port writer so that the target cannot write to it before we get a chance to send the query response
 	caller.Lock()
 	defer caller.Unlock()
 
 	// route the query upstream
-	target, err := c.uplink.RouteQuery(c.ctx, query, caller, net.DefaultHints().WithOrigin(net.OriginNetwork))
+	var hints = net.DefaultHints().WithOrigin(net.OriginNetwork).WithPriority(ext.Priority)
+
+	target, err := c.uplink.RouteQuery(c.ctx, query, caller, hints)
 	if err != nil {
-		return c.WriteResponse(msg.Port, &Response{Error: errRejected})
+		reason, message := net.RejectReason(err)
+		return c.writeReject(caller, reason, message)
 	}
 
 	c.remoteBuffers.grow(msg.Port, msg.Buffer)
@@ -194,10 +237,10 @@ func (c *Control) executeQuery(msg Query) error {
 	binding, err := c.BindAny(target)
 	if err != nil {
 		target.Close()
-		return c.WriteResponse(msg.Port, &Response{Error: errUnexpected})
+		return c.writeResponse(caller, &Response{Error: errUnexpected})
 	}
 
-	return c.WriteResponse(msg.Port, &Response{Port: int(binding.port.Load()), Buffer: portBufferSize})
+	return c.writeResponse(caller, &Response{Port: int(binding.port.Load()), Buffer: portBufferSize})
 }
 
 func (c *Control) WriteResponse(port int, r *Response) error {
@@ -212,3 +255,32 @@ func (c *Control) WriteResponse(port int, r *Response) error {
 		Data: buf.Bytes(),
 	})
 }
+
+// writeReject writes a rejection to the caller. The reason is sent only on links with extended messages.
+func (c *Control) writeReject(caller *PortWriter, reason int, message string) error {
+	var buf = &bytes.Buffer{}
+
+	if err := cslq.Encode(buf, "v", &Response{Error: errRejected}); err != nil {
+		return err
+	}
+
+	if c.extended {
+		if err := cslq.Encode(buf, "v", &ResponseExt{Reason: reason, Message: message}); err != nil {
+			return err
+		}
+	}
+
+	return c.writeLane(caller.pinLane(), caller.port, buf.Bytes())
+}
+
+// writeResponse writes the response to the caller's port over the lane its data will use, so that the data
+// cannot overtake the response
+func (c *Control) writeResponse(caller *PortWriter, r *Response) error {
+	var buf = &bytes.Buffer{}
+
+	if err := cslq.Encode(buf, "v", r); err != nil {
+		return err
+	}
+
+	return c.writeLane(caller.pinLane(), caller.port, buf.Bytes())
+}
@@ -0,0 +1,90 @@
+package link
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"github.com/cryptopunkscc/astrald/auth/id"
+	"github.com/cryptopunkscc/astrald/cslq"
+	"github.com/cryptopunkscc/astrald/mux"
+	"github.com/cryptopunkscc/astrald/net"
+	"github.com/cryptopunkscc/astrald/streams"
+	"strings"
+	"testing"
+)
+
+func TestQueryTooLarge(t *testing.T) {
+	var leftID, _ = id.GenerateIdentity()
+	var rightID, _ = id.GenerateIdentity()
+	var ctx = context.Background()
+	var accepted = make(chan *CoreLink, 1)
+
+	var left, right = streams.Pipe()
+	go func() {
+		link, _ := Accept(ctx, &FakeConn{ReadWriteCloser: left}, leftID)
+		accepted <- link
+	}()
+
+	link, err := Open(ctx, &FakeConn{ReadWriteCloser: right, outbound: true}, leftID, rightID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer link.Close()
+
+	remote := <-accepted
+	if remote == nil {
+		t.Fatal("accept failed")
+	}
+	defer remote.Close()
+
+	go link.Run(ctx)
+	go remote.Run(ctx)
+
+	var query = net.WithParams(net.NewQuery(rightID, leftID, "test"), net.Params{
+		"a": strings.Repeat("a", net.MaxParamValueLen),
+		"b": strings.Repeat("b", net.MaxParamValueLen),
+	})
+	var caller = net.NewSecurePipeWriter(&streams.NilWriteCloser{}, rightID)
+
+	_, err = link.RouteQuery(ctx, query, caller, net.DefaultHints())
+	var rnf *net.ErrRouteNotFound
+	if !errors.As(err, &rnf) || len(rnf.Fails) == 0 || !errors.Is(rnf.Fails[0], ErrInvalidQuery) {
+		t.Fatalf("expected %v, got %v", ErrInvalidQuery, err)
+	}
+
+	// the link survives a query that could not be sent
+	if _, err := link.Ping(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestResponseReason(t *testing.T) {
+	var handle = func(msgs ...any) (err error) {
+		var buf = &bytes.Buffer{}
+		for _, m := range msgs {
+			if err := cslq.Encode(buf, "v", m); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		var h = &ResponseHandler{Func: func(res Response, ext ResponseExt, herr error) {
+			if herr != nil {
+				t.Fatal(herr)
+			}
+			err = responseToError(res, ext)
+		}}
+		h.HandleFrame(mux.Frame{Data: buf.Bytes()})
+		return
+	}
+
+	// a response without the extension carries no reason
+	if err := handle(&Response{Error: errRejected}); err != net.ErrRejected {
+		t.Fatalf("expected %v, got %v", net.ErrRejected, err)
+	}
+
+	var rerr *net.RejectError
+	err := handle(&Response{Error: errRejected}, &ResponseExt{Reason: net.RejectCodeRateLimited, Message: "slow down"})
+	if !errors.As(err, &rerr) || rerr.Code != net.RejectCodeRateLimited || rerr.Message != "slow down" {
+		t.Fatalf("unexpected error %v", err)
+	}
+}
@@ -7,6 +7,7 @@ import (
 	"github.com/cryptopunkscc/astrald/net"
 	"github.com/cryptopunkscc/astrald/sig"
 	"github.com/cryptopunkscc/astrald/tasks"
+	"io"
 	"sync"
 	"time"
 )
@@ -21,26 +22,46 @@ const controlPort = 0
 type CoreLink struct {
 	sig.Activity
 	transport     net.SecureConn
+	compression   *deflateStream
 	uplink        net.Router
 	mux           *mux.FrameMux
 	control       *Control
 	remoteBuffers *remoteBuffers
+	scheduler     *scheduler
 	ctx           context.Context
 	cancelCtx     context.CancelFunc
 	mu            sync.Mutex
 	err           error
 	health        *health
 	running       chan struct{}
+	lanes         map[int]*lane
+	lanesMu       sync.Mutex
+	laneToken     uint64
+	extended      bool
 }
 
 func NewCoreLink(transport net.SecureConn) *CoreLink {
+	return newCoreLink(transport, transport)
+}
+
+// NewCompressedCoreLink returns a CoreLink that compresses all traffic sent over the transport
+func NewCompressedCoreLink(transport net.SecureConn) *CoreLink {
+	var stream = newDeflateStream(transport)
+	var link = newCoreLink(transport, stream)
+	link.compression = stream
+	return link
+}
+
+func newCoreLink(transport net.SecureConn, stream io.ReadWriter) *CoreLink {
 	link := &CoreLink{
 		transport: transport,
 		running:   make(chan struct{}),
+		lanes:     map[int]*lane{},
 	}
 
 	link.remoteBuffers = newRemoteBuffers(link)
-	link.mux = mux.NewFrameMux(transport, DefaultMuxHandler)
+	link.scheduler = newScheduler()
+	link.mux = mux.NewFrameMux(stream, DefaultMuxHandler)
 	link.control = NewControl(link)
 	link.health = newHealth(link)
 	if err := link.mux.Bind(controlPort, link.control.handleMux); err != nil {
@@ -72,6 +93,7 @@ func (link *CoreLink) CloseWithError(e error) error {
 	link.err = e
 
 	defer link.remoteBuffers.reset(0)
+	defer link.closeLanes()
 	if link.cancelCtx != nil {
 		defer link.cancelCtx()
 	}
@@ -145,6 +167,23 @@ func (link *CoreLink) Latency() time.Duration {
 	return link.health.Latency()
 }
 
+// Compression returns compression statistics of the link. If the link is not compressed, ok is false.
+func (link *CoreLink) Compression() (stats CompressionStats, ok bool) {
+	if link.compression == nil {
+		return CompressionStats{}, false
+	}
+	return link.compression.Stats(), true
+}
+
+// Paths returns the list of paths of a multipath link. If the link is not multipath, ok is false.
+func (link *CoreLink) Paths() (paths []PathInfo, ok bool) {
+	mp, ok := link.transport.(*Multipath)
+	if !ok {
+		return nil, false
+	}
+	return mp.Paths(), true
+}
+
 // Done returns a channel that will be closed when the link closes
 func (link *CoreLink) Done() <-chan struct{} {
 	<-link.running
@@ -156,19 +195,31 @@ func (link *CoreLink) Err() error {
 	return link.err
 }
 
-func (link *CoreLink) write(port int, frame []byte) error {
+// write writes a data frame to a remote port. Concurrent writes to the main transport are ordered by the link's
+// scheduler according to their priority. Frames of classes that have their own lane skip the scheduler.
+func (link *CoreLink) write(port int, priority int, l *lane, frame []byte) error {
+	if l == nil {
+		link.scheduler.acquire(priority)
+		defer link.scheduler.release()
+	}
+
 	link.mu.Lock()
-	defer link.mu.Unlock()
 
 	bufferSize, open := link.remoteBuffers.size(port)
-	if !open {
+	if !open || len(frame) > bufferSize {
+		link.mu.Unlock()
 		return ErrRemoteBufferOverflow
 	}
 
-	if len(frame) > bufferSize {
-		return ErrRemoteBufferOverflow
+	// a lane doesn't share the main transport, so writing to it doesn't need to hold the link
+	if l != nil {
+		link.remoteBuffers.grow(port, -len(frame))
+		link.mu.Unlock()
+		return l.raw.Write(port, frame)
 	}
 
+	defer link.mu.Unlock()
+
 	err := link.mux.Write(mux.Frame{
 		Port: port,
 		Data: frame,
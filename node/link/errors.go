@@ -12,3 +12,8 @@ var ErrPortClosed = errors.New("port closed")
 var ErrPingTimeout = errors.New("ping timeout")
 var ErrTooManyPings = errors.New("too many pings in progress")
 var ErrInvalidNonce = errors.New("invalid ping nonce")
+var ErrNoPaths = errors.New("all paths lost")
+var ErrPathJoined = errors.New("transport joined an existing link")
+var ErrNotResumable = errors.New("link is not resumable")
+var ErrInvalidQuery = errors.New("invalid query")
+var ErrParamsNotSupported = errors.New("remote party does not support query parameters")
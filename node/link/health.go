@@ -9,6 +9,7 @@ type health struct {
 	link    *CoreLink
 	sig     chan struct{}
 	latency time.Duration
+	lastOK  time.Time
 }
 
 func newHealth(link *CoreLink) *health {
@@ -16,6 +17,7 @@ func newHealth(link *CoreLink) *health {
 		link:    link,
 		sig:     make(chan struct{}, 1),
 		latency: -1,
+		lastOK:  time.Now(),
 	}
 }
 
@@ -25,7 +27,14 @@ func (h *health) Run(ctx context.Context) error {
 		case <-h.sig:
 			var err error
 			h.latency, err = h.link.control.Ping()
-			if err != nil {
+			switch {
+			case err == nil:
+				h.lastOK = time.Now()
+
+			case h.switchingPaths():
+				// pings fail while a multipath transport is switching paths
+
+			default:
 				h.link.CloseWithError(err)
 				return err
 			}
@@ -59,3 +68,14 @@ func (h *health) Check() {
 func (h *health) Latency() time.Duration {
 	return h.latency
 }
+
+// switchingPaths returns true if the link's transport is a multipath transport that may still recover. A
+// multipath transport gets as long as its grace period for missing paths, after which the link is closed even
+// if the transport still has paths that look alive.
+func (h *health) switchingPaths() bool {
+	mp, ok := h.link.transport.(*Multipath)
+	if !ok || mp.isClosed() {
+		return false
+	}
+	return time.Since(h.lastOK) < multipathGracePeriod
+}
@@ -0,0 +1,191 @@
+package link
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/cryptopunkscc/astrald/auth"
+	"github.com/cryptopunkscc/astrald/auth/id"
+	"github.com/cryptopunkscc/astrald/cslq"
+	"github.com/cryptopunkscc/astrald/mux"
+	"github.com/cryptopunkscc/astrald/net"
+	"slices"
+	"sync"
+)
+
+const featureLanes = "lanes"
+
+// StreamOpener is implemented by transports that can open more streams to the same peer, which don't block each
+// other when packets are lost (like QUIC streams). Links over such transports carry priority classes in separate
+// streams (lanes), so that a stalled bulk transfer doesn't hold up interactive traffic.
+type StreamOpener interface {
+	OpenStream(ctx context.Context) (net.Conn, error)
+}
+
+// laneClasses are the priority classes that get their own lanes. The normal class and the control port use
+// the main transport of the link.
+var laneClasses = []int{net.PriorityInteractive, net.PriorityBulk}
+
+var laneLinks = map[uint64]*CoreLink{}
+var laneLinksMu sync.Mutex
+
+type lane struct {
+	conn net.SecureConn
+	raw  *mux.RawMux
+}
+
+// lane returns the lane of the priority class or nil if the class uses the main transport
+func (link *CoreLink) lane(priority int) *lane {
+	link.lanesMu.Lock()
+	defer link.lanesMu.Unlock()
+
+	return link.lanes[priority]
+}
+
+// addLane attaches a conn as the lane of a priority class and starts reading frames from it
+func (link *CoreLink) addLane(priority int, conn net.SecureConn) error {
+	link.lanesMu.Lock()
+	defer link.lanesMu.Unlock()
+
+	if link.lanes == nil {
+		return ErrLinkClosed
+	}
+
+	if _, found := link.lanes[priority]; found {
+		return errors.New("lane already exists")
+	}
+
+	var l = &lane{conn: conn, raw: mux.NewRawMux(conn)}
+	link.lanes[priority] = l
+
+	go func() {
+		for {
+			port, data, err := l.raw.Read()
+			if err != nil {
+				link.CloseWithError(err)
+				return
+			}
+
+			link.mux.Dispatch(port, data)
+		}
+	}()
+
+	return nil
+}
+
+// writeLane writes a frame to the lane or to the main transport if the lane is nil. It bypasses flow control.
+func (link *CoreLink) writeLane(l *lane, port int, data []byte) error {
+	if l == nil {
+		return link.mux.Write(mux.Frame{Port: port, Data: data})
+	}
+	return l.raw.Write(port, data)
+}
+
+// closeLanes closes all lanes of the link and prevents new ones from being added
+func (link *CoreLink) closeLanes() {
+	link.lanesMu.Lock()
+	var lanes = link.lanes
+	link.lanes = nil
+	link.lanesMu.Unlock()
+
+	for _, l := range lanes {
+		l.conn.Close()
+	}
+
+	if link.laneToken != 0 {
+		laneLinksMu.Lock()
+		delete(laneLinks, link.laneToken)
+		laneLinksMu.Unlock()
+	}
+}
+
+// acceptLanes lets the remote party join lanes to the link using the token
+func (link *CoreLink) acceptLanes(token uint64) {
+	link.laneToken = token
+
+	laneLinksMu.Lock()
+	laneLinks[token] = link
+	laneLinksMu.Unlock()
+}
+
+// openLanes opens a lane for every lane class. Until a lane is open, its class uses the main transport.
+func (link *CoreLink) openLanes(opener StreamOpener, token uint64, remoteID id.Identity, localID id.Identity) {
+	ctx, cancel := context.WithTimeout(context.Background(), DefaultTimeout)
+	defer cancel()
+
+	for _, priority := range laneClasses {
+		if err := link.openLane(ctx, opener, token, priority, remoteID, localID); err != nil {
+			return
+		}
+	}
+}
+
+func (link *CoreLink) openLane(
+	ctx context.Context,
+	opener StreamOpener,
+	token uint64,
+	priority int,
+	remoteID id.Identity,
+	localID id.Identity,
+) (err error) {
+	conn, err := opener.OpenStream(ctx)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			conn.Close()
+		}
+	}()
+
+	secureConn, err := auth.HandshakeOutbound(ctx, conn, remoteID, localID)
+	if err != nil {
+		return fmt.Errorf("outbound handshake: %w", err)
+	}
+
+	var linkFeatures []string
+
+	err = cslq.Decode(secureConn, featureListFormat, &linkFeatures)
+	if err != nil {
+		return fmt.Errorf("read features: %w", err)
+	}
+
+	if !slices.Contains(linkFeatures, featureLanes) {
+		return errors.New("remote party does not support lanes")
+	}
+
+	err = cslq.Encode(secureConn, "[c]c cqc", featureLanes, true, token, priority)
+	if err != nil {
+		return fmt.Errorf("write lane: %w", err)
+	}
+
+	var errCode int
+	err = cslq.Decode(secureConn, "c", &errCode)
+	if err != nil {
+		return fmt.Errorf("read lane response: %w", err)
+	}
+	if errCode != 0 {
+		return errors.New("remote party rejected the lane")
+	}
+
+	return link.addLane(priority, secureConn)
+}
+
+// findLaneLink returns the link that issued the lane token to the remote party of the conn
+func findLaneLink(token uint64, priority int, conn net.SecureConn) (*CoreLink, error) {
+	laneLinksMu.Lock()
+	link, found := laneLinks[token]
+	laneLinksMu.Unlock()
+
+	switch {
+	case !found,
+		!link.RemoteIdentity().IsEqual(conn.RemoteIdentity()),
+		!link.LocalIdentity().IsEqual(conn.LocalIdentity()):
+		return nil, errors.New("invalid lane token")
+
+	case !slices.Contains(laneClasses, priority):
+		return nil, errors.New("invalid lane class")
+	}
+
+	return link, nil
+}
@@ -0,0 +1,92 @@
+package link
+
+import (
+	"context"
+	"github.com/cryptopunkscc/astrald/auth/id"
+	"github.com/cryptopunkscc/astrald/mux"
+	"github.com/cryptopunkscc/astrald/net"
+	"github.com/cryptopunkscc/astrald/streams"
+	"testing"
+	"time"
+)
+
+// streamConn is a FakeConn that opens new streams by accepting a link over a new pipe
+type streamConn struct {
+	*FakeConn
+	acceptID id.Identity
+}
+
+func (c *streamConn) OpenStream(ctx context.Context) (net.Conn, error) {
+	var left, right = streams.Pipe()
+	go Accept(ctx, &FakeConn{ReadWriteCloser: left}, c.acceptID)
+	return &FakeConn{ReadWriteCloser: right, outbound: true}, nil
+}
+
+func TestLanes(t *testing.T) {
+	var leftID, _ = id.GenerateIdentity()
+	var rightID, _ = id.GenerateIdentity()
+	var ctx = context.Background()
+	var accepted = make(chan *CoreLink, 1)
+
+	var left, right = streams.Pipe()
+	go func() {
+		link, _ := Accept(ctx, &FakeConn{ReadWriteCloser: left}, leftID)
+		accepted <- link
+	}()
+
+	var conn = &streamConn{FakeConn: &FakeConn{ReadWriteCloser: right, outbound: true}, acceptID: leftID}
+	link, err := Open(ctx, conn, leftID, rightID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer link.Close()
+
+	remote := <-accepted
+	if remote == nil {
+		t.Fatal("accept failed")
+	}
+	defer remote.Close()
+
+	go link.Run(ctx)
+	go remote.Run(ctx)
+
+	// wait for lanes on both sides
+	for i := 0; link.lane(net.PriorityBulk) == nil || remote.lane(net.PriorityBulk) == nil; i++ {
+		if i > 100 {
+			t.Fatal("lanes not open")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	if link.lane(net.PriorityNormal) != nil {
+		t.Fatal("normal class should use the main transport")
+	}
+
+	// frames sent over a lane reach the port handlers of the remote mux
+	var received = make(chan string, 1)
+	port, err := remote.mux.BindAny(func(event mux.Event) {
+		if frame, ok := event.(mux.Frame); ok {
+			received <- string(frame.Data)
+		}
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := link.writeLane(link.lane(net.PriorityBulk), port, []byte("bulk")); err != nil {
+		t.Fatal(err)
+	}
+
+	select {
+	case s := <-received:
+		if s != "bulk" {
+			t.Fatalf("unexpected frame %q", s)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("frame not received")
+	}
+
+	if _, err := link.Ping(); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -10,6 +10,7 @@ import (
 	"github.com/cryptopunkscc/astrald/net"
 	"github.com/cryptopunkscc/astrald/node/infra"
 	"github.com/cryptopunkscc/astrald/node/tracker"
+	"slices"
 	"sync"
 	"sync/atomic"
 	"time"
@@ -66,6 +67,84 @@ func Open(
 		return nil, errors.New("remote party does not support mux")
 	}
 
+	// request compression if the network is slow and the remote party supports it
+	var compress bool
+	if shouldCompress(connNetwork(conn)) && slices.Contains(linkFeatures, featureDeflate) {
+		err = cslq.Encode(secureConn, "[c]c", featureDeflate)
+		if err != nil {
+			return nil, fmt.Errorf("write deflate: %w", err)
+		}
+
+		var errCode int
+		err = cslq.Decode(secureConn, "c", &errCode)
+		if err != nil {
+			return nil, fmt.Errorf("read deflate response: %w", err)
+		}
+		compress = errCode == 0
+	}
+
+	// request extended messages if the remote party supports them
+	var extended bool
+	if slices.Contains(linkFeatures, featureExtended) {
+		err = cslq.Encode(secureConn, "[c]c", featureExtended)
+		if err != nil {
+			return nil, fmt.Errorf("write ext: %w", err)
+		}
+
+		var errCode int
+		err = cslq.Decode(secureConn, "c", &errCode)
+		if err != nil {
+			return nil, fmt.Errorf("read ext response: %w", err)
+		}
+		extended = errCode == 0
+	}
+
+	// request a resumption token, so that the link can be resumed over a new transport if its transport
+	// drops. A multipath link uses the token to add more paths.
+	var token uint64
+	var single = !MultipathEnabled
+	if (ResumeEnabled || MultipathEnabled) && slices.Contains(linkFeatures, featureResume) {
+		err = cslq.Encode(secureConn, "[c]c cc", featureResume, false, single)
+		if err != nil {
+			return nil, fmt.Errorf("write resume: %w", err)
+		}
+
+		var errCode int
+		err = cslq.Decode(secureConn, "c", &errCode)
+		if err != nil {
+			return nil, fmt.Errorf("read resume response: %w", err)
+		}
+		if errCode == 0 {
+			err = cslq.Decode(secureConn, "q", &token)
+			if err != nil {
+				return nil, fmt.Errorf("read resume token: %w", err)
+			}
+		}
+	}
+
+	// request lanes if the transport can open more streams. Lanes are not used by compressed and resumable
+	// links, which need a single stream of frames.
+	var laneToken uint64
+	opener, canOpen := conn.(StreamOpener)
+	if canOpen && !compress && token == 0 && slices.Contains(linkFeatures, featureLanes) {
+		err = cslq.Encode(secureConn, "[c]c c", featureLanes, false)
+		if err != nil {
+			return nil, fmt.Errorf("write lanes: %w", err)
+		}
+
+		var errCode int
+		err = cslq.Decode(secureConn, "c", &errCode)
+		if err != nil {
+			return nil, fmt.Errorf("read lanes response: %w", err)
+		}
+		if errCode == 0 {
+			err = cslq.Decode(secureConn, "q", &laneToken)
+			if err != nil {
+				return nil, fmt.Errorf("read lanes token: %w", err)
+			}
+		}
+	}
+
 	err = cslq.Encode(secureConn, "[c]c", featureMux)
 	if err != nil {
 		return nil, fmt.Errorf("write mux: %w", err)
@@ -77,7 +156,71 @@ func Open(
 		return nil, errors.New("link feature negotation error")
 	}
 
-	return NewCoreLink(secureConn), nil
+	var transport net.SecureConn = secureConn
+	if token != 0 {
+		transport = newMultipath(token, single, secureConn, localID)
+	}
+
+	if compress {
+		link = NewCompressedCoreLink(transport)
+		link.extended = extended
+		return link, nil
+	}
+
+	link = NewCoreLink(transport)
+	link.extended = extended
+	if laneToken != 0 {
+		go link.openLanes(opener, laneToken, remoteID, localID)
+	}
+
+	return link, nil
+}
+
+// AddPath negotiates a new path for a resumable link over the provided conn as the active party. If the link is
+// not multipath, the new path replaces the current transport of the link.
+func (link *CoreLink) AddPath(ctx context.Context, conn net.Conn) (err error) {
+	defer func() {
+		if err != nil {
+			conn.Close()
+		}
+	}()
+
+	mp, ok := link.transport.(*Multipath)
+	if !ok {
+		return ErrNotResumable
+	}
+
+	secureConn, err := auth.HandshakeOutbound(ctx, conn, mp.remoteID, mp.privateID)
+	if err != nil {
+		return fmt.Errorf("outbound handshake: %w", err)
+	}
+
+	var linkFeatures []string
+
+	err = cslq.Decode(secureConn, featureListFormat, &linkFeatures)
+	if err != nil {
+		return fmt.Errorf("read features: %w", err)
+	}
+
+	if !slices.Contains(linkFeatures, featureResume) {
+		return errors.New("remote party does not support resume")
+	}
+
+	err = cslq.Encode(secureConn, "[c]c cq", featureResume, true, mp.token)
+	if err != nil {
+		return fmt.Errorf("write resume: %w", err)
+	}
+
+	var errCode int
+	err = cslq.Decode(secureConn, "c", &errCode)
+	if err != nil {
+		return fmt.Errorf("read resume response: %w", err)
+	}
+	if errCode != 0 {
+		return errors.New("remote party rejected the resumption token")
+	}
+
+	return mp.AddPath(secureConn)
 }
 
 // Accept negotiaties a link over the provided conn as the passive party
@@ -87,7 +230,7 @@ func Accept(
 	localID id.Identity,
 ) (link *CoreLink, err error) {
 	defer func() {
-		if err != nil {
+		if err != nil && !errors.Is(err, ErrPathJoined) {
 			conn.Close()
 		}
 	}()
@@ -97,13 +240,18 @@ func Accept(
 		return
 	}
 
-	var linkFeatures = []string{featureMux}
+	var linkFeatures = []string{featureMux, featureDeflate, featureResume, featureLanes, featureExtended}
 
 	err = cslq.Encode(secureConn, featureListFormat, linkFeatures)
 	if err != nil {
 		return
 	}
 
+	var compress bool
+	var extended bool
+	var token uint64
+	var laneToken uint64
+	var single bool
 	for {
 		var feature string
 		err = cslq.Decode(secureConn, "[c]c", &feature)
@@ -112,9 +260,105 @@ func Accept(
 		}
 
 		switch feature {
+		case featureDeflate:
+			compress = true
+			cslq.Encode(secureConn, "c", 0)
+
+		case featureExtended:
+			extended = true
+			cslq.Encode(secureConn, "c", 0)
+
+		case featureResume:
+			var join bool
+			err = cslq.Decode(secureConn, "c", &join)
+			if err != nil {
+				return
+			}
+
+			// issue a new token
+			if !join {
+				err = cslq.Decode(secureConn, "c", &single)
+				if err != nil {
+					return
+				}
+				token = newResumeToken()
+				cslq.Encode(secureConn, "cq", 0, token)
+				continue
+			}
+
+			// attach the conn to an existing link
+			err = cslq.Decode(secureConn, "q", &token)
+			if err != nil {
+				return
+			}
+
+			mp := findMultipath(token, secureConn.LocalIdentity())
+			if mp == nil || !mp.RemoteIdentity().IsEqual(secureConn.RemoteIdentity()) {
+				cslq.Encode(secureConn, "c", 1)
+				return nil, errors.New("invalid resumption token")
+			}
+
+			cslq.Encode(secureConn, "c", 0)
+			if err = mp.AddPath(secureConn); err != nil {
+				return
+			}
+			return nil, ErrPathJoined
+
+		case featureLanes:
+			var join bool
+			err = cslq.Decode(secureConn, "c", &join)
+			if err != nil {
+				return
+			}
+
+			// issue a new token
+			if !join {
+				laneToken = newResumeToken()
+				cslq.Encode(secureConn, "cq", 0, laneToken)
+				continue
+			}
+
+			// attach the conn to an existing link as a lane
+			var joinToken uint64
+			var priority int
+			err = cslq.Decode(secureConn, "qc", &joinToken, &priority)
+			if err != nil {
+				return
+			}
+
+			link, err = findLaneLink(joinToken, priority, secureConn)
+			if err != nil {
+				cslq.Encode(secureConn, "c", 1)
+				return nil, err
+			}
+
+			// respond before adding the lane, so that frames don't overtake the response
+			cslq.Encode(secureConn, "c", 0)
+			if err = link.addLane(priority, secureConn); err != nil {
+				return nil, err
+			}
+			return nil, ErrPathJoined
+
 		case featureMux:
 			cslq.Encode(secureConn, "c", 0)
-			return NewCoreLink(secureConn), nil
+
+			var transport net.SecureConn = secureConn
+			if token != 0 {
+				transport = newMultipath(token, single, secureConn, localID)
+			}
+
+			if compress {
+				link = NewCompressedCoreLink(transport)
+				link.extended = extended
+				return link, nil
+			}
+
+			link = NewCoreLink(transport)
+			link.extended = extended
+			if laneToken != 0 {
+				link.acceptLanes(laneToken)
+			}
+			return link, nil
 
 		default:
 			cslq.Encode(secureConn, "c", 1)
@@ -222,5 +466,37 @@ func MakeLink(
 		return nil, errors.New("no endpoint could be reached")
 	}
 
+	// if the link is resumable, try to resume it over any known endpoint when all its paths are lost
+	if mp, ok := link.transport.(*Multipath); ok {
+		mp.setRedial(func(ctx context.Context) error {
+			endpoints, _ := node.Tracker().EndpointsByIdentity(remoteIdentity)
+			endpoints = append(endpoints, opts.Endpoints...)
+
+			for _, e := range endpoints {
+				conn, err := node.Infra().Dial(ctx, e)
+				if err != nil {
+					continue
+				}
+
+				if err = link.AddPath(ctx, conn); err == nil {
+					return nil
+				}
+			}
+
+			return errors.New("no endpoint could be reached")
+		})
+	}
+
 	return link, nil
 }
+
+// connNetwork returns the name of the network the conn belongs to or an empty string if it's unknown
+func connNetwork(conn net.Conn) string {
+	if e := conn.RemoteEndpoint(); e != nil {
+		return e.Network()
+	}
+	if e := conn.LocalEndpoint(); e != nil {
+		return e.Network()
+	}
+	return ""
+}
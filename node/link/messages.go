@@ -1,5 +1,7 @@
 package link
 
+import "github.com/cryptopunkscc/astrald/net"
+
 const (
 	codeQuery = iota
 	codeGrowBuffer
@@ -8,6 +10,10 @@ const (
 	codePong
 )
 
+// featureExtended is negotiated by parties that extend messages with fields added after the initial version of
+// the protocol. Parties without the feature exchange the original messages.
+const featureExtended = "ext"
+
 const (
 	errSuccess = iota
 	errRejected
@@ -43,8 +49,21 @@ type Query struct {
 	Nonce  uint64 `cslq:"q"`
 }
 
+// QueryExt follows a Query on links with extended messages
+type QueryExt struct {
+	Priority int        `cslq:"c"`
+	Params   net.Params `cslq:"v"`
+}
+
 type Response struct {
 	Error  int `cslq:"c"`
 	Port   int `cslq:"s"`
 	Buffer int `cslq:"l"`
 }
+
+// ResponseExt follows a rejected Response on links with extended messages. A Response without it carries
+// no reason.
+type ResponseExt struct {
+	Reason  int    `cslq:"c"`
+	Message string `cslq:"[c]c"`
+}
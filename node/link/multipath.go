@@ -0,0 +1,647 @@
+package link
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"github.com/cryptopunkscc/astrald/auth/id"
+	"github.com/cryptopunkscc/astrald/net"
+	"io"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// MultipathEnabled controls whether outbound links request a multipath transport
+var MultipathEnabled = false
+
+const (
+	multipathHeartbeatInterval = 2 * time.Second
+	multipathPathTimeout       = 3 * multipathHeartbeatInterval
+	multipathGracePeriod       = 30 * time.Second
+	multipathAckInterval       = 200 * time.Millisecond
+	multipathAckThreshold      = 1024 * 1024
+	multipathWindow            = 8 * 1024 * 1024
+	multipathRedialInterval    = 2 * time.Second
+)
+
+const (
+	segmentData = iota
+	segmentAck
+	segmentHeartbeat
+	segmentClose
+)
+
+// segmentHeaderSize - type (1 byte), sequence number (8 bytes), data length (4 bytes)
+const segmentHeaderSize = 13
+
+var _ net.SecureConn = &Multipath{}
+
+var multipaths = map[multipathKey]*Multipath{}
+var multipathsMu sync.Mutex
+
+type multipathKey struct {
+	token    uint64
+	localKey string
+}
+
+// Multipath is a transport that carries a single ordered stream over a set of paths (transports) between the
+// same pair of identities. Data is sent over one active path at a time and kept until the remote party
+// acknowledges it. When the active path fails, unacknowledged data is retransmitted over the next path, so that
+// the stream survives the loss of a transport. When all paths are lost, the stream waits for a new path for
+// a grace period before it closes. In single path mode, a new path replaces the current one, which is how
+// links are resumed after their transport drops.
+type Multipath struct {
+	token     uint64
+	single    bool
+	localID   id.Identity
+	remoteID  id.Identity
+	privateID id.Identity // local identity with the private key, used to establish new paths
+	outbound  bool
+
+	mu           sync.Mutex
+	cond         *sync.Cond
+	paths        []*path
+	active       *path
+	lastActive   *path
+	nextSeq      uint64
+	sent         uint64
+	unacked      []segment
+	unackedBytes int
+	graceTimer   *time.Timer
+	err          error
+	done         chan struct{}
+
+	dmu       sync.Mutex // serializes delivery to the pipe
+	rmu       sync.Mutex // guards acknowledgement state, never held while writing to the pipe
+	expected  uint64
+	ackBytes  int
+	ackSig    chan struct{}
+	pipeRead  *io.PipeReader
+	pipeWrite *io.PipeWriter
+
+	redial func(context.Context) error
+}
+
+type segment struct {
+	seq  uint64
+	data []byte
+}
+
+type path struct {
+	net.SecureConn
+	wmu      sync.Mutex
+	lastRecv atomic.Int64
+	busy     atomic.Bool // the path's reader is blocked delivering data to a slow local reader
+}
+
+// PathInfo describes a single path of a multipath transport
+type PathInfo struct {
+	Conn    net.SecureConn
+	Network string
+	Active  bool
+	Idle    time.Duration
+}
+
+func newMultipath(token uint64, single bool, conn net.SecureConn, privateID id.Identity) *Multipath {
+	mp := &Multipath{
+		token:     token,
+		single:    single,
+		localID:   conn.LocalIdentity(),
+		remoteID:  conn.RemoteIdentity(),
+		privateID: privateID,
+		outbound:  conn.Outbound(),
+		done:      make(chan struct{}),
+		ackSig:    make(chan struct{}, 1),
+	}
+	mp.cond = sync.NewCond(&mp.mu)
+	mp.pipeRead, mp.pipeWrite = io.Pipe()
+
+	multipathsMu.Lock()
+	multipaths[mp.key()] = mp
+	multipathsMu.Unlock()
+
+	mp.AddPath(conn)
+
+	go mp.sender()
+	go mp.keepalive()
+
+	return mp
+}
+
+// AddPath adds a new path to the transport. The path has to connect the same pair of identities. In single
+// path mode, the new path replaces all existing paths.
+func (mp *Multipath) AddPath(conn net.SecureConn) error {
+	if !conn.LocalIdentity().IsEqual(mp.localID) || !conn.RemoteIdentity().IsEqual(mp.remoteID) {
+		conn.Close()
+		return errors.New("identity mismatch")
+	}
+
+	var p = &path{SecureConn: conn}
+	p.lastRecv.Store(time.Now().UnixNano())
+
+	mp.mu.Lock()
+	defer mp.mu.Unlock()
+
+	if mp.err != nil {
+		conn.Close()
+		return mp.err
+	}
+
+	if mp.single {
+		// don't block other users of the transport while closing old paths
+		go closePaths(mp.paths)
+		mp.paths = nil
+		mp.active = nil
+	}
+
+	mp.paths = append(mp.paths, p)
+	if mp.graceTimer != nil {
+		mp.graceTimer.Stop()
+		mp.graceTimer = nil
+	}
+	if mp.active == nil {
+		mp.setActiveLocked(p)
+	}
+	mp.cond.Broadcast()
+
+	go mp.reader(p)
+
+	return nil
+}
+
+// Paths returns information about all paths of the transport
+func (mp *Multipath) Paths() []PathInfo {
+	mp.mu.Lock()
+	defer mp.mu.Unlock()
+
+	var list = make([]PathInfo, 0, len(mp.paths))
+	for _, p := range mp.paths {
+		list = append(list, PathInfo{
+			Conn:    p.SecureConn,
+			Network: connNetwork(p.SecureConn),
+			Active:  p == mp.active,
+			Idle:    time.Since(time.Unix(0, p.lastRecv.Load())),
+		})
+	}
+	return list
+}
+
+func (mp *Multipath) Read(p []byte) (n int, err error) {
+	return mp.pipeRead.Read(p)
+}
+
+// Write queues data for sending. It blocks only when the amount of unacknowledged data exceeds the window.
+func (mp *Multipath) Write(p []byte) (n int, err error) {
+	mp.mu.Lock()
+	defer mp.mu.Unlock()
+
+	for mp.err == nil && mp.unackedBytes >= multipathWindow {
+		mp.cond.Wait()
+	}
+	if mp.err != nil {
+		return 0, mp.err
+	}
+
+	mp.unacked = append(mp.unacked, segment{
+		seq:  mp.nextSeq,
+		data: slices.Clone(p),
+	})
+	mp.unackedBytes += len(p)
+	mp.nextSeq++
+	mp.cond.Broadcast()
+
+	return len(p), nil
+}
+
+// Close closes the transport and all of its paths
+func (mp *Multipath) Close() error {
+	mp.mu.Lock()
+	var paths = slices.Clone(mp.paths)
+	mp.mu.Unlock()
+
+	// notify the remote party, but don't let a stalled path block closing
+	var sent = make(chan struct{}, len(paths))
+	for _, p := range paths {
+		go func(p *path) {
+			p.writeSegment(segmentClose, 0, nil)
+			sent <- struct{}{}
+		}(p)
+	}
+	var timeout = time.After(time.Second)
+wait:
+	for range paths {
+		select {
+		case <-sent:
+		case <-timeout:
+			break wait
+		}
+	}
+
+	return mp.closeWithError(ErrLinkClosed)
+}
+
+func (mp *Multipath) isClosed() bool {
+	mp.mu.Lock()
+	defer mp.mu.Unlock()
+
+	return mp.err != nil
+}
+
+// Done returns a channel that will be closed when the transport closes
+func (mp *Multipath) Done() <-chan struct{} {
+	return mp.done
+}
+
+func (mp *Multipath) LocalIdentity() id.Identity {
+	return mp.localID
+}
+
+func (mp *Multipath) RemoteIdentity() id.Identity {
+	return mp.remoteID
+}
+
+// LocalEndpoint returns the local endpoint of the active path
+func (mp *Multipath) LocalEndpoint() net.Endpoint {
+	if p := mp.currentPath(); p != nil {
+		return p.LocalEndpoint()
+	}
+	return nil
+}
+
+// RemoteEndpoint returns the remote endpoint of the active path
+func (mp *Multipath) RemoteEndpoint() net.Endpoint {
+	if p := mp.currentPath(); p != nil {
+		return p.RemoteEndpoint()
+	}
+	return nil
+}
+
+func (mp *Multipath) Outbound() bool {
+	return mp.outbound
+}
+
+// currentPath returns the active path or the last active path if there's no active path
+func (mp *Multipath) currentPath() *path {
+	mp.mu.Lock()
+	defer mp.mu.Unlock()
+
+	if mp.active != nil {
+		return mp.active
+	}
+	return mp.lastActive
+}
+
+func (mp *Multipath) closeWithError(err error) error {
+	mp.mu.Lock()
+	if mp.err != nil {
+		mp.mu.Unlock()
+		return nil
+	}
+	mp.err = err
+	var paths = mp.paths
+	mp.paths = nil
+	mp.active = nil
+	if mp.graceTimer != nil {
+		mp.graceTimer.Stop()
+	}
+	close(mp.done)
+	mp.cond.Broadcast()
+	mp.mu.Unlock()
+
+	multipathsMu.Lock()
+	delete(multipaths, mp.key())
+	multipathsMu.Unlock()
+
+	for _, p := range paths {
+		p.Close()
+	}
+
+	if errors.Is(err, ErrLinkClosed) || errors.Is(err, ErrLinkClosedByPeer) {
+		err = io.EOF
+	}
+	mp.pipeWrite.CloseWithError(err)
+
+	return nil
+}
+
+func (mp *Multipath) setActiveLocked(p *path) {
+	mp.active = p
+	if p != nil {
+		mp.lastActive = p
+	}
+
+	// start (re)transmission from the first unacknowledged segment
+	mp.sent = mp.nextSeq
+	if len(mp.unacked) > 0 {
+		mp.sent = mp.unacked[0].seq
+	}
+}
+
+func (mp *Multipath) removePath(p *path) {
+	// closing a stalled conn can block, so close it after releasing the lock
+	defer p.Close()
+
+	mp.mu.Lock()
+	defer mp.mu.Unlock()
+
+	var i = slices.Index(mp.paths, p)
+	if i == -1 {
+		return
+	}
+	mp.paths = slices.Delete(mp.paths, i, i+1)
+
+	if p == mp.active {
+		var next *path
+		if len(mp.paths) > 0 {
+			next = mp.paths[0]
+		}
+		mp.setActiveLocked(next)
+		mp.cond.Broadcast()
+	}
+
+	if len(mp.paths) > 0 || mp.err != nil {
+		return
+	}
+
+	mp.graceTimer = time.AfterFunc(multipathGracePeriod, func() {
+		mp.closeWithError(ErrNoPaths)
+	})
+
+	if mp.redial != nil {
+		go mp.redialLoop(mp.redial)
+	}
+}
+
+// setRedial sets the function used to establish a new path when all paths are lost
+func (mp *Multipath) setRedial(fn func(context.Context) error) {
+	mp.mu.Lock()
+	defer mp.mu.Unlock()
+
+	mp.redial = fn
+}
+
+// redialLoop tries to establish a new path until one is added or the transport closes
+func (mp *Multipath) redialLoop(redial func(context.Context) error) {
+	ctx, cancel := context.WithTimeout(context.Background(), multipathGracePeriod)
+	defer cancel()
+
+	go func() {
+		select {
+		case <-mp.done:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	for {
+		if redial(ctx) == nil {
+			return
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(multipathRedialInterval):
+		}
+
+		mp.mu.Lock()
+		var found = len(mp.paths) > 0
+		mp.mu.Unlock()
+		if found {
+			return
+		}
+	}
+}
+
+// sender transmits queued segments over the active path
+func (mp *Multipath) sender() {
+	mp.mu.Lock()
+	defer mp.mu.Unlock()
+
+	for {
+		for mp.err == nil && (mp.active == nil || mp.sent >= mp.nextSeq) {
+			mp.cond.Wait()
+		}
+		if mp.err != nil {
+			return
+		}
+
+		var p = mp.active
+		var seg = mp.unacked[mp.sent-mp.unacked[0].seq]
+
+		mp.mu.Unlock()
+		err := p.writeSegment(segmentData, seg.seq, seg.data)
+		mp.mu.Lock()
+
+		if err != nil {
+			mp.mu.Unlock()
+			mp.removePath(p)
+			mp.mu.Lock()
+			continue
+		}
+
+		if p == mp.active && mp.sent == seg.seq {
+			mp.sent++
+		}
+	}
+}
+
+// reader processes segments received over a path
+func (mp *Multipath) reader(p *path) {
+	defer mp.removePath(p)
+
+	var buf []byte
+	for {
+		typ, seq, data, err := p.readSegment(buf)
+		if err != nil {
+			return
+		}
+		buf = data
+		p.lastRecv.Store(time.Now().UnixNano())
+
+		switch typ {
+		case segmentData:
+			p.busy.Store(true)
+			err := mp.deliver(seq, data)
+			p.busy.Store(false)
+			p.lastRecv.Store(time.Now().UnixNano())
+			if err != nil {
+				return
+			}
+
+		case segmentAck:
+			mp.acknowledge(seq)
+
+		case segmentHeartbeat:
+
+		case segmentClose:
+			mp.closeWithError(ErrLinkClosedByPeer)
+			return
+
+		default:
+			return
+		}
+	}
+}
+
+// deliver passes a data segment to the reader of the transport. Duplicates (retransmissions of segments that
+// were already delivered) are dropped. Delivery blocks while the local reader is slow, but acknowledgements and
+// heartbeats keep flowing.
+func (mp *Multipath) deliver(seq uint64, data []byte) error {
+	mp.dmu.Lock()
+	defer mp.dmu.Unlock()
+
+	switch {
+	case seq < mp.expected:
+		return nil
+	case seq > mp.expected:
+		return ErrProtocolError
+	}
+
+	if _, err := mp.pipeWrite.Write(data); err != nil {
+		return err
+	}
+
+	mp.rmu.Lock()
+	mp.expected++
+	mp.ackBytes += len(data)
+	var ack = mp.ackBytes >= multipathAckThreshold
+	mp.rmu.Unlock()
+
+	if ack {
+		select {
+		case mp.ackSig <- struct{}{}:
+		default:
+		}
+	}
+
+	return nil
+}
+
+// acknowledge drops all segments preceding seq from the retransmission queue
+func (mp *Multipath) acknowledge(seq uint64) {
+	mp.mu.Lock()
+	defer mp.mu.Unlock()
+
+	var n int
+	for n < len(mp.unacked) && mp.unacked[n].seq < seq {
+		mp.unackedBytes -= len(mp.unacked[n].data)
+		n++
+	}
+	if n == 0 {
+		return
+	}
+
+	mp.unacked = slices.Delete(mp.unacked, 0, n)
+	if mp.sent < seq {
+		mp.sent = seq
+	}
+	mp.cond.Broadcast()
+}
+
+// keepalive sends acknowledgements and heartbeats and drops paths that went silent
+func (mp *Multipath) keepalive() {
+	var ticker = time.NewTicker(multipathAckInterval)
+	defer ticker.Stop()
+
+	var lastAck uint64
+	var lastHeartbeat time.Time
+
+	for {
+		select {
+		case <-mp.done:
+			return
+		case <-ticker.C:
+		case <-mp.ackSig:
+		}
+
+		mp.rmu.Lock()
+		var expected = mp.expected
+		mp.ackBytes = 0
+		mp.rmu.Unlock()
+
+		mp.mu.Lock()
+		var paths = slices.Clone(mp.paths)
+		var ackPath = mp.active
+		mp.mu.Unlock()
+
+		if expected != lastAck && ackPath != nil {
+			if ackPath.writeSegment(segmentAck, expected, nil) == nil {
+				lastAck = expected
+			}
+		}
+
+		if time.Since(lastHeartbeat) < multipathHeartbeatInterval {
+			continue
+		}
+		lastHeartbeat = time.Now()
+
+		for _, p := range paths {
+			if !p.busy.Load() && time.Since(time.Unix(0, p.lastRecv.Load())) > multipathPathTimeout {
+				mp.removePath(p)
+				continue
+			}
+			p.writeSegment(segmentHeartbeat, 0, nil)
+		}
+	}
+}
+
+func (p *path) writeSegment(typ int, seq uint64, data []byte) error {
+	var buf = make([]byte, segmentHeaderSize+len(data))
+	buf[0] = byte(typ)
+	binary.BigEndian.PutUint64(buf[1:9], seq)
+	binary.BigEndian.PutUint32(buf[9:13], uint32(len(data)))
+	copy(buf[segmentHeaderSize:], data)
+
+	p.wmu.Lock()
+	defer p.wmu.Unlock()
+
+	_, err := p.Write(buf)
+	return err
+}
+
+func (p *path) readSegment(buf []byte) (typ int, seq uint64, data []byte, err error) {
+	var header [segmentHeaderSize]byte
+
+	if _, err = io.ReadFull(p, header[:]); err != nil {
+		return
+	}
+
+	typ = int(header[0])
+	seq = binary.BigEndian.Uint64(header[1:9])
+
+	var l = binary.BigEndian.Uint32(header[9:13])
+	if l > multipathWindow {
+		err = ErrProtocolError
+		return
+	}
+
+	// data is handed over to the pipe synchronously, so the buffer can be reused
+	if cap(buf) < int(l) {
+		buf = make([]byte, l)
+	}
+	data = buf[:l]
+
+	_, err = io.ReadFull(p, data)
+	return
+}
+
+func closePaths(paths []*path) {
+	for _, p := range paths {
+		p.Close()
+	}
+}
+
+func (mp *Multipath) key() multipathKey {
+	return multipathKey{token: mp.token, localKey: mp.localID.PublicKeyHex()}
+}
+
+// findMultipath returns the transport of the local identity with the provided resumption token
+func findMultipath(token uint64, localID id.Identity) *Multipath {
+	multipathsMu.Lock()
+	defer multipathsMu.Unlock()
+
+	return multipaths[multipathKey{token: token, localKey: localID.PublicKeyHex()}]
+}
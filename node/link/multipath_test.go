@@ -0,0 +1,117 @@
+package link
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/cryptopunkscc/astrald/auth/id"
+	"github.com/cryptopunkscc/astrald/streams"
+	"io"
+	"testing"
+)
+
+func TestMultipathFailover(t *testing.T) {
+	var leftID, _ = id.GenerateIdentity()
+	var rightID, _ = id.GenerateIdentity()
+
+	var newPath = func() (*SecureConn, *SecureConn) {
+		var l, r = streams.Pipe()
+		return NewSecureConn(leftID, rightID, l), NewSecureConn(rightID, leftID, r)
+	}
+
+	var l1, r1 = newPath()
+	var left = newMultipath(newResumeToken(), false, l1, leftID)
+	var right = newMultipath(newResumeToken(), false, r1, rightID)
+	defer left.Close()
+	defer right.Close()
+
+	var received = make(chan string, 20)
+	go func() {
+		var buf = make([]byte, 7)
+		for {
+			if _, err := io.ReadFull(right, buf); err != nil {
+				close(received)
+				return
+			}
+			received <- string(buf)
+		}
+	}()
+
+	for i := 0; i < 10; i++ {
+		fmt.Fprintf(left, "msg %03d", i)
+	}
+	for i := 0; i < 5; i++ {
+		if m := <-received; m != fmt.Sprintf("msg %03d", i) {
+			t.Fatalf("unexpected message %q", m)
+		}
+	}
+
+	// lose the only path and queue more data while there are no paths
+	l1.Close()
+	for i := 10; i < 20; i++ {
+		fmt.Fprintf(left, "msg %03d", i)
+	}
+
+	var l2, r2 = newPath()
+	if err := right.AddPath(r2); err != nil {
+		t.Fatal(err)
+	}
+	if err := left.AddPath(l2); err != nil {
+		t.Fatal(err)
+	}
+
+	for i := 5; i < 20; i++ {
+		if m := <-received; m != fmt.Sprintf("msg %03d", i) {
+			t.Fatalf("unexpected message %q, expected msg %03d", m, i)
+		}
+	}
+}
+
+func TestOpenAcceptMultipath(t *testing.T) {
+	MultipathEnabled = true
+	defer func() { MultipathEnabled = false }()
+
+	var leftID, _ = id.GenerateIdentity()
+	var rightID, _ = id.GenerateIdentity()
+	var ctx = context.Background()
+	var accepted = make(chan *CoreLink, 1)
+	var errs = make(chan error, 1)
+
+	var left, right = streams.Pipe()
+	go func() {
+		link, _ := Accept(ctx, &FakeConn{ReadWriteCloser: left}, leftID)
+		accepted <- link
+	}()
+
+	link, err := Open(ctx, &FakeConn{ReadWriteCloser: right, outbound: true}, leftID, rightID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer link.Close()
+
+	remote := <-accepted
+	if remote == nil {
+		t.Fatal("accept failed")
+	}
+	defer remote.Close()
+
+	left, right = streams.Pipe()
+	go func() {
+		_, err := Accept(ctx, &FakeConn{ReadWriteCloser: left}, leftID)
+		errs <- err
+	}()
+
+	if err := link.AddPath(ctx, &FakeConn{ReadWriteCloser: right, outbound: true}); err != nil {
+		t.Fatal(err)
+	}
+	if err := <-errs; !errors.Is(err, ErrPathJoined) {
+		t.Fatalf("expected %v, got %v", ErrPathJoined, err)
+	}
+
+	if paths, ok := link.Paths(); !ok || len(paths) != 2 {
+		t.Fatalf("expected 2 outbound paths, got %d", len(paths))
+	}
+	if paths, ok := remote.Paths(); !ok || len(paths) != 2 {
+		t.Fatalf("expected 2 inbound paths, got %d", len(paths))
+	}
+}
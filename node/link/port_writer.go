@@ -3,13 +3,14 @@ package link
 import (
 	"fmt"
 	"github.com/cryptopunkscc/astrald/auth/id"
-	"github.com/cryptopunkscc/astrald/mux"
 	"github.com/cryptopunkscc/astrald/net"
 	"sync"
+	"sync/atomic"
 	"time"
 )
 
 var _ net.SecureWriteCloser = &PortWriter{}
+var _ net.Prioritizer = &PortWriter{}
 
 const defaultMaxFrameSize = 1024 * 8
 const debugBufferUnderruns = false
@@ -21,6 +22,9 @@ type PortWriter struct {
 	port         int
 	err          error
 	maxFrameSize int
+	priority     atomic.Int32
+	lane         *lane
+	laneOnce     sync.Once
 }
 
 func NewPortWriter(link *CoreLink, port int) *PortWriter {
@@ -81,7 +85,7 @@ func (w *PortWriter) Write(p []byte) (n int, err error) {
 			return 0, err
 		}
 
-		if err = w.link.write(w.port, p[:frameLen]); err != nil {
+		if err = w.link.write(w.port, w.Priority(), w.pinLane(), p[:frameLen]); err != nil {
 			return n, err
 		}
 
@@ -105,6 +109,17 @@ func (w *PortWriter) SetMaxFrameSize(maxFrameSize int) {
 	w.maxFrameSize = maxFrameSize
 }
 
+// Priority returns the priority class of the writer's traffic
+func (w *PortWriter) Priority() int {
+	return int(w.priority.Load())
+}
+
+// SetPriority sets the priority class of the writer's traffic. It takes effect with the next frame, but
+// the frames keep using the lane chosen for the first frame, so that they arrive in order.
+func (w *PortWriter) SetPriority(priority int) {
+	w.priority.Store(int32(priority))
+}
+
 func (w *PortWriter) Close() error {
 	w.Lock()
 	defer w.Unlock()
@@ -115,7 +130,16 @@ func (w *PortWriter) Close() error {
 
 	w.err = ErrPortClosed
 
-	return w.link.mux.Write(mux.Frame{Port: w.port})
+	return w.link.writeLane(w.pinLane(), w.port, nil)
+}
+
+// pinLane returns the lane of the writer's priority class when called for the first time and the same lane
+// ever after. Every frame sent to the port, including the query response and the EOF, travels the same lane.
+func (w *PortWriter) pinLane() *lane {
+	w.laneOnce.Do(func() {
+		w.lane = w.link.lane(w.Priority())
+	})
+	return w.lane
 }
 
 func (w *PortWriter) Transport() net.SecureConn {
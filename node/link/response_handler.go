@@ -8,7 +8,7 @@ import (
 )
 
 type ResponseHandler struct {
-	Func func(response Response, err error)
+	Func func(response Response, ext ResponseExt, err error)
 }
 
 func (h *ResponseHandler) HandleMux(event mux.Event) {
@@ -20,13 +20,21 @@ func (h *ResponseHandler) HandleMux(event mux.Event) {
 
 func (h *ResponseHandler) HandleFrame(frame mux.Frame) {
 	if frame.IsEmpty() {
-		h.Func(Response{}, io.EOF)
+		h.Func(Response{}, ResponseExt{}, io.EOF)
 		return
 	}
 
 	var res Response
+	var ext ResponseExt
 	var r = bytes.NewReader(frame.Data)
 	var err = cslq.Decode(r, "v", &res)
 
-	h.Func(res, err)
+	// responses from parties without extended messages end here
+	if err == nil && r.Len() > 0 {
+		if cslq.Decode(r, "v", &ext) != nil {
+			ext = ResponseExt{}
+		}
+	}
+
+	h.Func(res, ext, err)
 }
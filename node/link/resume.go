@@ -0,0 +1,25 @@
+package link
+
+import (
+	"crypto/rand"
+	"encoding/binary"
+)
+
+const featureResume = "resume"
+
+// ResumeEnabled controls whether outbound links request a resumption token. A link with a resumption token
+// survives the loss of its transport for a grace period, during which a new transport between the same
+// identities can take over the link's ports and buffers. Resumable links run over a Multipath transport, which
+// adds framing, acknowledgements and heartbeats to all traffic, so resumption is disabled by default.
+var ResumeEnabled = false
+
+// newResumeToken returns a new random non-zero resumption token
+func newResumeToken() uint64 {
+	var b [8]byte
+	for {
+		rand.Read(b[:])
+		if token := binary.BigEndian.Uint64(b[:]); token != 0 {
+			return token
+		}
+	}
+}
@@ -0,0 +1,64 @@
+package link
+
+import (
+	"context"
+	"github.com/cryptopunkscc/astrald/auth/id"
+	"github.com/cryptopunkscc/astrald/streams"
+	"testing"
+)
+
+func TestResumeLink(t *testing.T) {
+	defer func(v bool) { ResumeEnabled = v }(ResumeEnabled)
+	ResumeEnabled = true
+
+	var leftID, _ = id.GenerateIdentity()
+	var rightID, _ = id.GenerateIdentity()
+	var ctx = context.Background()
+	var accepted = make(chan *CoreLink, 1)
+
+	var left, right = streams.Pipe()
+	go func() {
+		link, _ := Accept(ctx, &FakeConn{ReadWriteCloser: left}, leftID)
+		accepted <- link
+	}()
+
+	link, err := Open(ctx, &FakeConn{ReadWriteCloser: right, outbound: true}, leftID, rightID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer link.Close()
+
+	remote := <-accepted
+	if remote == nil {
+		t.Fatal("accept failed")
+	}
+	defer remote.Close()
+
+	go link.Run(ctx)
+	go remote.Run(ctx)
+
+	if _, err := link.Ping(); err != nil {
+		t.Fatal(err)
+	}
+
+	// drop the transport and resume the link over a new one
+	left.Close()
+
+	var newLeft, newRight = streams.Pipe()
+	go Accept(ctx, &FakeConn{ReadWriteCloser: newLeft}, leftID)
+
+	if err := link.AddPath(ctx, &FakeConn{ReadWriteCloser: newRight, outbound: true}); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := link.Ping(); err != nil {
+		t.Fatal(err)
+	}
+
+	if paths, _ := remote.Paths(); len(paths) != 1 {
+		t.Fatalf("expected 1 path, got %d", len(paths))
+	}
+	if remote.Err() != nil {
+		t.Fatalf("link closed: %v", remote.Err())
+	}
+}
@@ -31,7 +31,7 @@ func (link *CoreLink) RouteQuery(ctx context.Context, query net.Query, caller ne
 
 	// set up response handler
 	var done = make(chan struct{})
-	responseHandler.Func = func(res Response, herr error) {
+	responseHandler.Func = func(res Response, ext ResponseExt, herr error) {
 		defer close(done)
 
 		// we have the response, so unbind the port so that it can be bound to the caller
@@ -43,7 +43,7 @@ func (link *CoreLink) RouteQuery(ctx context.Context, query net.Query, caller ne
 		}
 
 		// check error response
-		err = codeToError(res.Error)
+		err = responseToError(res, ext)
 		if err != nil {
 			return
 		}
@@ -58,12 +58,18 @@ func (link *CoreLink) RouteQuery(ctx context.Context, query net.Query, caller ne
 		link.remoteBuffers.grow(res.Port, res.Buffer)
 
 		// prepare the target
-		target = NewPortWriter(link, res.Port)
+		var portWriter = NewPortWriter(link, res.Port)
+		portWriter.SetPriority(hints.Priority)
+		target = portWriter
 	}
 
 	// send the query to the remote peer
-	if err := link.control.Query(uint64(query.Nonce()), query.Query(), localPort); err != nil {
-		link.CloseWithError(err)
+	if err := link.control.Query(query, localPort, hints.Priority); err != nil {
+		link.Unbind(localPort)
+		// a query that could not be encoded doesn't affect the link
+		if !errors.Is(err, ErrInvalidQuery) {
+			link.CloseWithError(err)
+		}
 		return net.RouteNotFound(link, err)
 	}
 
@@ -86,14 +92,17 @@ func (link *CoreLink) RouteQuery(ctx context.Context, query net.Query, caller ne
 	}
 }
 
-func codeToError(code int) error {
-	switch code {
+func responseToError(res Response, ext ResponseExt) error {
+	switch res.Error {
 	case errSuccess:
 		return nil
 	case errRejected:
-		return net.ErrRejected
+		if ext.Reason == 0 {
+			return net.ErrRejected
+		}
+		return &net.RejectError{Code: ext.Reason, Message: ext.Message}
 	case errRouteNotFound:
-		return net.ErrRejected
+		return &net.RejectError{Code: net.RejectCodeRouteNotFound}
 	case errUnexpected:
 		return errors.New("unexpected error")
 	default:
@@ -0,0 +1,106 @@
+package link
+
+import (
+	"github.com/cryptopunkscc/astrald/net"
+	"sync"
+)
+
+// strideBase is the virtual time a class with weight 1 consumes per frame
+const strideBase = 1 << 16
+
+// classWeights defines how the link is shared between priority classes when all of them have frames to send
+var classWeights = map[int]uint64{
+	net.PriorityInteractive: 64,
+	net.PriorityNormal:      8,
+	net.PriorityBulk:        1,
+}
+
+// scheduler decides which port gets to write the next frame to the link. Ports are grouped into priority
+// classes. Since a port never has more than one frame waiting, serving a class in FIFO order is round-robin
+// between its ports. Classes share the link in proportion to their weights (stride scheduling), so frames of
+// interactive ports preempt bulk transfers, but bulk transfers are never starved completely.
+type scheduler struct {
+	mu      sync.Mutex
+	busy    bool
+	pass    uint64
+	classes map[int]*schedulerClass
+}
+
+type schedulerClass struct {
+	waiting []chan struct{}
+	stride  uint64
+	pass    uint64
+}
+
+func newScheduler() *scheduler {
+	var s = &scheduler{classes: map[int]*schedulerClass{}}
+	for priority, weight := range classWeights {
+		s.classes[priority] = &schedulerClass{stride: strideBase / weight}
+	}
+	return s
+}
+
+// acquire blocks until the caller is allowed to write a frame with the given priority. Every acquire
+// must be followed by a release.
+func (s *scheduler) acquire(priority int) {
+	s.mu.Lock()
+
+	var class = s.class(priority)
+
+	// a class that was idle cannot claim the time it didn't use
+	if len(class.waiting) == 0 && class.pass < s.pass {
+		class.pass = s.pass
+	}
+
+	if !s.busy {
+		s.busy = true
+		s.charge(class)
+		s.mu.Unlock()
+		return
+	}
+
+	var ch = make(chan struct{})
+	class.waiting = append(class.waiting, ch)
+	s.mu.Unlock()
+
+	<-ch
+}
+
+// release passes the link to the next waiting writer
+func (s *scheduler) release() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var next *schedulerClass
+	for _, class := range s.classes {
+		if len(class.waiting) == 0 {
+			continue
+		}
+		// on a tie, prefer the class with the higher weight
+		if next == nil || class.pass < next.pass || (class.pass == next.pass && class.stride < next.stride) {
+			next = class
+		}
+	}
+
+	if next == nil {
+		s.busy = false
+		return
+	}
+
+	var ch = next.waiting[0]
+	next.waiting = next.waiting[1:]
+	s.charge(next)
+	close(ch)
+}
+
+func (s *scheduler) charge(class *schedulerClass) {
+	s.pass = class.pass
+	class.pass += class.stride
+}
+
+func (s *scheduler) class(priority int) *schedulerClass {
+	if class, found := s.classes[priority]; found {
+		return class
+	}
+	return s.classes[net.PriorityNormal]
+}
@@ -0,0 +1,97 @@
+package link
+
+import (
+	"github.com/cryptopunkscc/astrald/net"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestSchedulerPreemptsBulk(t *testing.T) {
+	var s = newScheduler()
+	var mu sync.Mutex
+	var order []int
+	var wg sync.WaitGroup
+
+	// hold the link so that all writers have to wait
+	s.acquire(net.PriorityNormal)
+
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			s.acquire(net.PriorityBulk)
+			mu.Lock()
+			order = append(order, net.PriorityBulk)
+			mu.Unlock()
+			s.release()
+		}()
+	}
+	time.Sleep(10 * time.Millisecond)
+
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		s.acquire(net.PriorityInteractive)
+		mu.Lock()
+		order = append(order, net.PriorityInteractive)
+		mu.Unlock()
+		s.release()
+	}()
+	time.Sleep(10 * time.Millisecond)
+
+	s.release()
+	wg.Wait()
+
+	if len(order) != 5 {
+		t.Fatalf("expected 5 writes, got %d", len(order))
+	}
+	if order[0] != net.PriorityInteractive {
+		t.Fatalf("expected interactive frame first, got %s", net.PriorityName(order[0]))
+	}
+}
+
+func TestSchedulerDoesNotStarveBulk(t *testing.T) {
+	var s = newScheduler()
+	var bulk, interactive int
+
+	s.acquire(net.PriorityNormal)
+
+	var done = make(chan struct{})
+	var wg sync.WaitGroup
+	// several interactive writers keep the interactive class busy at all times
+	var writers = []int{net.PriorityBulk, net.PriorityInteractive, net.PriorityInteractive, net.PriorityInteractive}
+	for _, p := range writers {
+		p := p
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				select {
+				case <-done:
+					return
+				default:
+				}
+				s.acquire(p)
+				if p == net.PriorityBulk {
+					bulk++
+				} else {
+					interactive++
+				}
+				s.release()
+			}
+		}()
+	}
+	time.Sleep(10 * time.Millisecond)
+	s.release()
+	time.Sleep(50 * time.Millisecond)
+	close(done)
+	wg.Wait()
+
+	if bulk == 0 {
+		t.Fatal("bulk writer starved")
+	}
+	if interactive <= bulk {
+		t.Fatalf("interactive writer got %d frames, bulk writer got %d", interactive, bulk)
+	}
+}
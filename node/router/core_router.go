@@ -27,6 +27,8 @@ type CoreRouter struct {
 	logRouteTrace bool
 	enroute       map[string]struct{}
 	enrouteMu     sync.Mutex
+	history       *History
+	limiter       *Limiter
 }
 
 func NewCoreRouter(log *log.Logger, eventParent *events.Queue) *CoreRouter {
@@ -34,6 +36,7 @@ func NewCoreRouter(log *log.Logger, eventParent *events.Queue) *CoreRouter {
 		conns:   NewConnSet(),
 		routes:  make([]Route, 0),
 		enroute: map[string]struct{}{},
+		limiter: NewLimiter(),
 		log:     log,
 	}
 	router.events.SetParent(eventParent)
@@ -129,15 +132,32 @@ func (r *CoreRouter) routeMonitored(ctx context.Context, query net.Query, caller
 	var conn = NewMonitoredConn(callerMonitor, nil, query, hints)
 	r.conns.Add(conn)
 
+	// enforce limits on queries coming from the network
+	var session *LimiterSession
+	if hints.Origin == net.OriginNetwork {
+		session, err = r.limiter.Admit(query)
+		if err != nil {
+			// rejections are counted by the limiter and not recorded in the history
+			r.conns.Remove(conn)
+			return nil, err
+		}
+		callerMonitor.AfterWrite = r.chargeFunc(session, conn)
+	}
+
 	// route to next hop
 	target, err = r.routeQuery(ctx, query, callerMonitor, hints)
 	if err != nil {
 		r.conns.Remove(conn)
+		r.record(conn, err)
+		session.Done(0)
 		return nil, err
 	}
 
 	// monitor the target
 	var targetMonitor = NewMonitoredWriter(target)
+	if session != nil {
+		targetMonitor.AfterWrite = r.chargeFunc(session, conn)
+	}
 	conn.SetTarget(targetMonitor)
 
 	r.events.Emit(EventConnAdded{Conn: conn})
@@ -147,11 +167,23 @@ func (r *CoreRouter) routeMonitored(ctx context.Context, query net.Query, caller
 		<-conn.Done()
 		r.conns.Remove(conn)
 		r.events.Emit(EventConnRemoved{Conn: conn})
+		r.record(conn, nil)
+		session.Done(0)
 	}()
 
 	return targetMonitor, err
 }
 
+// chargeFunc returns a function that charges the session with bytes as they are written and closes the conn
+// once the transfer quota is exceeded
+func (r *CoreRouter) chargeFunc(session *LimiterSession, conn *MonitoredConn) func(int, error) {
+	return func(n int, _ error) {
+		if n > 0 && !session.Charge(int64(n)) {
+			go conn.Close()
+		}
+	}
+}
+
 func (r *CoreRouter) routeQuery(ctx context.Context, query net.Query, caller net.SecureWriteCloser, hints net.Hints) (target net.SecureWriteCloser, err error) {
 	var routes = MatchRoutes(r.Routes(), query.Caller(), query.Target())
 	var routingErrors []error
@@ -181,11 +213,11 @@ func (r *CoreRouter) AddRoute(caller id.Identity, target id.Identity, router net
 	defer r.mu.Unlock()
 
 	// update route priority if route already exists...
-	for _, route := range r.routes {
+	for i, route := range r.routes {
 		if route.Router == router &&
 			route.Caller.IsEqual(caller) &&
 			route.Target.IsEqual(target) {
-			route.Priority = priority
+			r.routes[i].Priority = priority
 			return nil
 		}
 	}
@@ -239,6 +271,51 @@ func (r *CoreRouter) Routes() []Route {
 	return routes
 }
 
+// Limiter returns the limiter enforced on queries coming from the network
+func (r *CoreRouter) Limiter() *Limiter {
+	return r.limiter
+}
+
+// SetHistory sets the history in which routed queries are recorded. Nil disables the history.
+func (r *CoreRouter) SetHistory(history *History) {
+	if history != nil {
+		history.OnError(func(err error) {
+			r.log.Errorv(1, "error saving routing history: %v", err)
+		})
+	}
+	r.history = history
+}
+
+// History returns the history of routed queries or nil if the history is disabled
+func (r *CoreRouter) History() *History {
+	return r.history
+}
+
+// record adds a finished connection to the history
+func (r *CoreRouter) record(conn *MonitoredConn, err error) {
+	if r.history == nil {
+		return
+	}
+
+	var entry = HistoryEntry{
+		Nonce:     conn.Query().Nonce(),
+		Caller:    conn.Query().Caller(),
+		Target:    conn.Query().Target(),
+		Query:     conn.Query().Query(),
+		StartedAt: conn.establishedAt,
+		Duration:  time.Since(conn.establishedAt),
+		BytesIn:   conn.BytesIn(),
+		BytesOut:  conn.BytesOut(),
+	}
+
+	if err != nil {
+		entry.Error = err.Error()
+	}
+
+	// entries are saved in the background and dropped under load, so that routing is never held up
+	r.history.Record(entry)
+}
+
 func (r *CoreRouter) LogRouteTrace() bool {
 	return r.logRouteTrace
 }
@@ -0,0 +1,176 @@
+package router
+
+import (
+	"github.com/cryptopunkscc/astrald/auth/id"
+	"github.com/cryptopunkscc/astrald/net"
+	"gorm.io/gorm"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const HistoryDatabaseName = "history.db"
+
+// pruneInterval sets how many entries are added between removals of entries over the limit
+const pruneInterval = 100
+
+// recordQueueSize sets how many recorded entries can wait to be saved before new ones are dropped
+const recordQueueSize = 256
+
+// HistoryEntry is a record of a routed query
+type HistoryEntry struct {
+	ID        int
+	Nonce     net.Nonce
+	Caller    id.Identity
+	Target    id.Identity
+	Query     string
+	StartedAt time.Time
+	Duration  time.Duration // lifetime of the connection or, if routing failed, time spent routing
+	BytesIn   int
+	BytesOut  int
+	Error     string
+}
+
+// HistoryFilter selects entries from the history. Zero fields match everything.
+type HistoryFilter struct {
+	Caller     id.Identity
+	Target     id.Identity
+	Query      string // query prefix
+	Since      time.Time
+	ErrorsOnly bool
+	Limit      int
+}
+
+// History keeps a persistent record of the most recent routed queries
+type History struct {
+	db      *gorm.DB
+	limit   int
+	mu      sync.Mutex
+	added   int
+	queue   chan HistoryEntry
+	once    sync.Once
+	dropped atomic.Int64
+	log     func(err error)
+}
+
+// NewHistory returns a History stored in db that keeps at most limit entries
+func NewHistory(db *gorm.DB, limit int) (*History, error) {
+	var h = &History{
+		db:    db,
+		limit: limit,
+		queue: make(chan HistoryEntry, recordQueueSize),
+	}
+
+	if err := db.AutoMigrate(&dbHistoryEntry{}); err != nil {
+		return nil, err
+	}
+
+	return h, nil
+}
+
+// Add appends an entry to the history and drops the oldest entries over the limit
+func (h *History) Add(entry HistoryEntry) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	var row = dbHistoryEntry{
+		Nonce:     uint64(entry.Nonce),
+		Caller:    entry.Caller.String(),
+		Target:    entry.Target.String(),
+		Query:     entry.Query,
+		StartedAt: entry.StartedAt,
+		Duration:  entry.Duration,
+		BytesIn:   entry.BytesIn,
+		BytesOut:  entry.BytesOut,
+		Error:     entry.Error,
+	}
+
+	if err := h.db.Create(&row).Error; err != nil {
+		return err
+	}
+
+	h.added++
+	if h.added%pruneInterval == 0 {
+		return h.db.Delete(&dbHistoryEntry{}, "id <= ?", row.ID-h.limit).Error
+	}
+
+	return nil
+}
+
+// Record queues an entry to be added to the history by a background writer. If the queue is full, the entry
+// is dropped and false is returned.
+func (h *History) Record(entry HistoryEntry) bool {
+	h.once.Do(func() {
+		go h.writer()
+	})
+
+	select {
+	case h.queue <- entry:
+		return true
+	default:
+		h.dropped.Add(1)
+		return false
+	}
+}
+
+// Dropped returns the number of recorded entries dropped because the writer couldn't keep up
+func (h *History) Dropped() int64 {
+	return h.dropped.Load()
+}
+
+// OnError sets the function called when the background writer fails to add an entry
+func (h *History) OnError(fn func(err error)) {
+	h.log = fn
+}
+
+func (h *History) writer() {
+	for entry := range h.queue {
+		if err := h.Add(entry); err != nil && h.log != nil {
+			h.log(err)
+		}
+	}
+}
+
+// Find returns entries matching the filter, newest first
+func (h *History) Find(filter HistoryFilter) ([]HistoryEntry, error) {
+	var rows []dbHistoryEntry
+	var tx = h.db.Order("id desc")
+
+	if !filter.Caller.IsZero() {
+		tx = tx.Where("caller = ?", filter.Caller.String())
+	}
+	if !filter.Target.IsZero() {
+		tx = tx.Where("target = ?", filter.Target.String())
+	}
+	if filter.Query != "" {
+		tx = tx.Where("query LIKE ? ESCAPE '\\'", escapeLike(filter.Query)+"%")
+	}
+	if !filter.Since.IsZero() {
+		tx = tx.Where("started_at >= ?", filter.Since)
+	}
+	if filter.ErrorsOnly {
+		tx = tx.Where("error <> ''")
+	}
+	if filter.Limit > 0 {
+		tx = tx.Limit(filter.Limit)
+	}
+
+	if err := tx.Find(&rows).Error; err != nil {
+		return nil, err
+	}
+
+	var entries = make([]HistoryEntry, 0, len(rows))
+	for _, row := range rows {
+		entries = append(entries, row.toEntry())
+	}
+
+	return entries, nil
+}
+
+// Clear removes all entries from the history
+func (h *History) Clear() error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	return h.db.Where("1 = 1").Delete(&dbHistoryEntry{}).Error
+}
@@ -0,0 +1,47 @@
+package router
+
+import (
+	"github.com/cryptopunkscc/astrald/auth/id"
+	"github.com/cryptopunkscc/astrald/net"
+	"strings"
+	"time"
+)
+
+type dbHistoryEntry struct {
+	ID        int `gorm:"primaryKey;autoIncrement"`
+	Nonce     uint64
+	Caller    string `gorm:"index"`
+	Target    string `gorm:"index"`
+	Query     string
+	StartedAt time.Time `gorm:"index"`
+	Duration  time.Duration
+	BytesIn   int
+	BytesOut  int
+	Error     string
+}
+
+func (dbHistoryEntry) TableName() string { return "history" }
+
+func (row dbHistoryEntry) toEntry() HistoryEntry {
+	caller, _ := id.ParsePublicKeyHex(row.Caller)
+	target, _ := id.ParsePublicKeyHex(row.Target)
+
+	return HistoryEntry{
+		ID:        row.ID,
+		Nonce:     net.Nonce(row.Nonce),
+		Caller:    caller,
+		Target:    target,
+		Query:     row.Query,
+		StartedAt: row.StartedAt,
+		Duration:  row.Duration,
+		BytesIn:   row.BytesIn,
+		BytesOut:  row.BytesOut,
+		Error:     row.Error,
+	}
+}
+
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+func escapeLike(s string) string {
+	return likeEscaper.Replace(s)
+}
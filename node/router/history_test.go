@@ -0,0 +1,123 @@
+package router
+
+import (
+	"github.com/cryptopunkscc/astrald/auth/id"
+	"github.com/cryptopunkscc/astrald/node/assets"
+	"github.com/cryptopunkscc/astrald/resources"
+	"testing"
+	"time"
+)
+
+func TestHistory(t *testing.T) {
+	db, err := assets.NewCoreAssets(resources.NewMemResources()).OpenDB(HistoryDatabaseName)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	history, err := NewHistory(db, pruneInterval)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer history.Clear()
+
+	alice, _ := id.GenerateIdentity()
+	bob, _ := id.GenerateIdentity()
+
+	var start = time.Now()
+	for i := 0; i < 2*pruneInterval; i++ {
+		var entry = HistoryEntry{
+			Caller:    alice,
+			Target:    bob,
+			Query:     "storage.read",
+			StartedAt: start.Add(time.Duration(i) * time.Second),
+		}
+		if i%2 == 1 {
+			entry.Caller, entry.Target = bob, alice
+			entry.Query = "100%_done"
+			entry.Error = "rejected"
+		}
+		if err := history.Add(entry); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	all, err := history.Find(HistoryFilter{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(all) != pruneInterval {
+		t.Fatalf("expected %d entries after pruning, got %d", pruneInterval, len(all))
+	}
+	if !all[0].StartedAt.After(all[1].StartedAt) {
+		t.Fatal("entries are not sorted newest first")
+	}
+
+	var tests = []struct {
+		filter   HistoryFilter
+		expected int
+	}{
+		{HistoryFilter{Caller: alice}, pruneInterval / 2},
+		{HistoryFilter{Target: alice, ErrorsOnly: true}, pruneInterval / 2},
+		{HistoryFilter{Caller: alice, ErrorsOnly: true}, 0},
+		{HistoryFilter{Query: "storage."}, pruneInterval / 2},
+		{HistoryFilter{Query: "100%"}, pruneInterval / 2},
+		{HistoryFilter{Query: "1000"}, 0},
+		{HistoryFilter{Since: start.Add(time.Duration(2*pruneInterval-10) * time.Second)}, 10},
+		{HistoryFilter{Limit: 3}, 3},
+	}
+
+	for i, test := range tests {
+		entries, err := history.Find(test.filter)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(entries) != test.expected {
+			t.Errorf("filter %d: expected %d entries, got %d", i, test.expected, len(entries))
+		}
+	}
+
+	if !all[0].Caller.IsEqual(bob) || all[0].Error != "rejected" {
+		t.Fatalf("unexpected entry: %+v", all[0])
+	}
+}
+
+func TestHistoryRecord(t *testing.T) {
+	db, err := assets.NewCoreAssets(resources.NewMemResources()).OpenDB(HistoryDatabaseName)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	history, err := NewHistory(db, pruneInterval)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer history.Clear()
+
+	alice, _ := id.GenerateIdentity()
+
+	// recording never blocks, entries over the queue size may be dropped
+	var recorded int
+	for i := 0; i < 2*recordQueueSize; i++ {
+		if history.Record(HistoryEntry{Caller: alice, Target: alice, StartedAt: time.Now()}) {
+			recorded++
+		}
+	}
+	if int64(recorded)+history.Dropped() != 2*recordQueueSize {
+		t.Fatalf("recorded %d and dropped %d entries", recorded, history.Dropped())
+	}
+
+	var expected = min(recorded, pruneInterval)
+	for i := 0; ; i++ {
+		entries, err := history.Find(HistoryFilter{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(entries) >= expected {
+			break
+		}
+		if i > 100 {
+			t.Fatalf("expected %d entries, got %d", expected, len(entries))
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
@@ -0,0 +1,338 @@
+package router
+
+import (
+	"cmp"
+	"github.com/cryptopunkscc/astrald/auth/id"
+	"github.com/cryptopunkscc/astrald/net"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+const quotaPeriod = 24 * time.Hour
+
+// idleUsageTimeout sets how long usage of an idle caller is kept before it's forgotten
+const idleUsageTimeout = quotaPeriod
+
+// Limit sets usage limits. Zero values mean no limit.
+type Limit struct {
+	QPS         float64 `yaml:"qps"`           // queries per second
+	Burst       int     `yaml:"burst"`         // number of queries allowed in a burst, defaults to QPS
+	Sessions    int     `yaml:"sessions"`      // number of concurrent sessions
+	BytesPerDay int64   `yaml:"bytes_per_day"` // number of bytes transferred in both directions per day
+}
+
+func (l Limit) IsZero() bool {
+	return l == Limit{}
+}
+
+func (l Limit) burst() int {
+	if l.Burst > 0 {
+		return l.Burst
+	}
+	return max(int(l.QPS), 1)
+}
+
+// Usage describes the current usage of a caller or a service
+type Usage struct {
+	Caller     id.Identity // set for caller usage
+	Service    string      // set for service usage
+	Limit      Limit
+	Sessions   int
+	BytesToday int64
+	Queries    int64
+	Rejected   int64
+}
+
+// Limiter enforces per-caller and per-service limits on queries. Callers without their own limit are subject
+// to the default limit, each one separately. Service limits are shared by all callers of the service.
+type Limiter struct {
+	mu            sync.Mutex
+	defaultLimit  Limit
+	callerLimits  map[string]Limit
+	serviceLimits map[string]Limit
+	callers       map[string]*usage
+	services      map[string]*usage
+}
+
+// LimiterSession holds resources taken by an admitted query
+type LimiterSession struct {
+	limiter *Limiter
+	caller  *usage
+	service *usage
+}
+
+type usage struct {
+	limit    Limit
+	tokens   float64
+	refillAt time.Time
+	sessions int
+	bytes    int64
+	periodAt time.Time
+	queries  int64
+	rejected int64
+}
+
+func NewLimiter() *Limiter {
+	return &Limiter{
+		callerLimits:  make(map[string]Limit),
+		serviceLimits: make(map[string]Limit),
+		callers:       make(map[string]*usage),
+		services:      make(map[string]*usage),
+	}
+}
+
+// SetDefaultLimit sets the limit for callers that have no limit of their own
+func (l *Limiter) SetDefaultLimit(limit Limit) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.defaultLimit = limit
+	for hex, u := range l.callers {
+		if _, found := l.callerLimits[hex]; !found {
+			u.limit = limit
+		}
+	}
+}
+
+// SetCallerLimit sets the limit for a caller
+func (l *Limiter) SetCallerLimit(caller id.Identity, limit Limit) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	var hex = caller.PublicKeyHex()
+
+	l.callerLimits[hex] = limit
+	if u, found := l.callers[hex]; found {
+		u.limit = limit
+	}
+}
+
+// SetServiceLimit sets the limit for a service. Like in the PrefixRouter, names ending with a "*" match all
+// queries that start with the rest of the name.
+func (l *Limiter) SetServiceLimit(service string, limit Limit) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.serviceLimits[service] = limit
+	if u, found := l.services[service]; found {
+		u.limit = limit
+	}
+}
+
+// Admit checks a query against the limits. If the query is admitted, the returned session has to be charged
+// with transferred bytes and closed when the connection ends. Otherwise, a rejection error with
+// RejectCodeRateLimited is returned.
+func (l *Limiter) Admit(query net.Query) (*LimiterSession, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	var now = time.Now()
+	var session = &LimiterSession{limiter: l}
+
+	var hex = query.Caller().PublicKeyHex()
+	if limit, found := l.callerLimits[hex]; found || !l.defaultLimit.IsZero() {
+		if !found {
+			limit = l.defaultLimit
+		}
+		session.caller = l.callers[hex]
+		if session.caller == nil {
+			session.caller = newUsage(limit, now)
+			l.callers[hex] = session.caller
+		}
+	}
+
+	if name, found := l.matchService(query.Query()); found {
+		session.service = l.services[name]
+		if session.service == nil {
+			session.service = newUsage(l.serviceLimits[name], now)
+			l.services[name] = session.service
+		}
+	}
+
+	// both limits have to pass before any of them is charged
+	for _, u := range []*usage{session.caller, session.service} {
+		if u == nil {
+			continue
+		}
+		if reason := u.check(now); reason != "" {
+			u.rejected++
+			_, err := net.RejectWithReason(net.RejectCodeRateLimited, reason)
+			return nil, err
+		}
+	}
+
+	for _, u := range []*usage{session.caller, session.service} {
+		if u != nil {
+			u.charge()
+		}
+	}
+
+	l.prune(now)
+
+	return session, nil
+}
+
+// Usage returns the current usage of all limited callers and services
+func (l *Limiter) Usage() []Usage {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	var now = time.Now()
+	var list = make([]Usage, 0, len(l.callers)+len(l.services))
+
+	for hex, u := range l.callers {
+		caller, _ := id.ParsePublicKeyHex(hex)
+		var info = u.info(now)
+		info.Caller = caller
+		list = append(list, info)
+	}
+
+	for name, u := range l.services {
+		var info = u.info(now)
+		info.Service = name
+		list = append(list, info)
+	}
+
+	slices.SortFunc(list, func(a, b Usage) int {
+		return cmp.Compare(b.Queries, a.Queries)
+	})
+
+	return list
+}
+
+// Charge charges the session with bytes transferred so far. It returns false if the transfer exceeded
+// the daily quota of the caller or the service.
+func (s *LimiterSession) Charge(bytes int64) bool {
+	if s == nil {
+		return true
+	}
+
+	s.limiter.mu.Lock()
+	defer s.limiter.mu.Unlock()
+
+	var now = time.Now()
+	var ok = true
+	for _, u := range []*usage{s.caller, s.service} {
+		if u == nil {
+			continue
+		}
+		u.resetPeriod(now)
+		u.bytes += bytes
+		if u.limit.BytesPerDay > 0 && u.bytes > u.limit.BytesPerDay {
+			ok = false
+		}
+	}
+
+	return ok
+}
+
+// Done releases the session and charges it with the number of transferred bytes not charged yet
+func (s *LimiterSession) Done(bytes int64) {
+	if s == nil {
+		return
+	}
+
+	s.limiter.mu.Lock()
+	defer s.limiter.mu.Unlock()
+
+	var now = time.Now()
+	for _, u := range []*usage{s.caller, s.service} {
+		if u == nil {
+			continue
+		}
+		u.sessions--
+		u.resetPeriod(now)
+		u.bytes += bytes
+	}
+}
+
+// matchService returns the name of the service limit matching the query. Exact matches take priority, then
+// the longest prefix wins.
+func (l *Limiter) matchService(query string) (string, bool) {
+	if _, found := l.serviceLimits[query]; found {
+		return query, true
+	}
+
+	var best string
+	var found bool
+	for name := range l.serviceLimits {
+		prefix, ok := strings.CutSuffix(name, "*")
+		if !ok || !strings.HasPrefix(query, prefix) {
+			continue
+		}
+		if !found || len(name) > len(best) {
+			best, found = name, true
+		}
+	}
+
+	return best, found
+}
+
+// prune forgets idle callers. Caller must hold l.mu.
+func (l *Limiter) prune(now time.Time) {
+	for hex, u := range l.callers {
+		if u.sessions == 0 && now.Sub(u.refillAt) > idleUsageTimeout {
+			delete(l.callers, hex)
+		}
+	}
+}
+
+func newUsage(limit Limit, now time.Time) *usage {
+	return &usage{
+		limit:    limit,
+		tokens:   float64(limit.burst()),
+		refillAt: now,
+		periodAt: now,
+	}
+}
+
+// check returns the reason why a new query would exceed the limit or an empty string if it would not
+func (u *usage) check(now time.Time) string {
+	u.resetPeriod(now)
+
+	if u.limit.QPS > 0 {
+		u.tokens += now.Sub(u.refillAt).Seconds() * u.limit.QPS
+		u.tokens = min(u.tokens, float64(u.limit.burst()))
+	}
+	u.refillAt = now
+
+	switch {
+	case u.limit.QPS > 0 && u.tokens < 1:
+		return "too many queries"
+	case u.limit.Sessions > 0 && u.sessions >= u.limit.Sessions:
+		return "too many sessions"
+	case u.limit.BytesPerDay > 0 && u.bytes >= u.limit.BytesPerDay:
+		return "daily transfer quota exceeded"
+	}
+
+	return ""
+}
+
+func (u *usage) charge() {
+	if u.limit.QPS > 0 {
+		u.tokens--
+	}
+	u.sessions++
+	u.queries++
+}
+
+func (u *usage) resetPeriod(now time.Time) {
+	if now.Sub(u.periodAt) >= quotaPeriod {
+		u.bytes = 0
+		u.periodAt = now
+	}
+}
+
+func (u *usage) info(now time.Time) Usage {
+	u.resetPeriod(now)
+
+	return Usage{
+		Limit:      u.limit,
+		Sessions:   u.sessions,
+		BytesToday: u.bytes,
+		Queries:    u.queries,
+		Rejected:   u.rejected,
+	}
+}
@@ -0,0 +1,113 @@
+package router
+
+import (
+	"errors"
+	"github.com/cryptopunkscc/astrald/auth/id"
+	"github.com/cryptopunkscc/astrald/net"
+	"testing"
+)
+
+func TestLimiter(t *testing.T) {
+	alice, _ := id.GenerateIdentity()
+	bob, _ := id.GenerateIdentity()
+	node, _ := id.GenerateIdentity()
+
+	var limiter = NewLimiter()
+	limiter.SetDefaultLimit(Limit{Sessions: 2})
+	limiter.SetCallerLimit(bob, Limit{QPS: 1, Burst: 3})
+	limiter.SetServiceLimit("storage.*", Limit{BytesPerDay: 100})
+
+	var admit = func(caller id.Identity, query string) (*LimiterSession, error) {
+		return limiter.Admit(net.NewQuery(caller, node, query))
+	}
+
+	// default limit applies to alice
+	s1, err := admit(alice, "ping")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = admit(alice, "ping"); err != nil {
+		t.Fatal(err)
+	}
+	_, err = admit(alice, "ping")
+	var rejectErr *net.RejectError
+	if !errors.As(err, &rejectErr) || rejectErr.Code != net.RejectCodeRateLimited {
+		t.Fatalf("expected a rate limit rejection, got %v", err)
+	}
+
+	// releasing a session makes room for a new one
+	s1.Done(0)
+	if _, err = admit(alice, "ping"); err != nil {
+		t.Fatal(err)
+	}
+
+	// bob's own limit allows a burst of 3
+	for i := 0; i < 3; i++ {
+		if _, err = admit(bob, "ping"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err = admit(bob, "ping"); !errors.Is(err, net.ErrRejected) {
+		t.Fatalf("expected rejection, got %v", err)
+	}
+
+	// service quota is shared by all callers
+	s, err := admit(bob, "storage.read")
+	if err == nil {
+		t.Fatal("bob should still be out of tokens")
+	}
+	limiter.SetCallerLimit(bob, Limit{})
+
+	s, err = admit(bob, "storage.read")
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.Done(100)
+
+	if _, err = admit(bob, "storage.write"); err == nil {
+		t.Fatal("expected the service quota to be exceeded")
+	}
+	if _, err = admit(bob, "ping"); err != nil {
+		t.Fatal(err)
+	}
+
+	// service limits only count queries that match
+	var found bool
+	for _, u := range limiter.Usage() {
+		if u.Service == "storage.*" {
+			found = true
+			if u.BytesToday != 100 || u.Queries != 1 || u.Rejected != 1 {
+				t.Fatalf("unexpected service usage: %+v", u)
+			}
+		}
+	}
+	if !found {
+		t.Fatal("service usage not found")
+	}
+}
+
+func TestLimiterCharge(t *testing.T) {
+	alice, _ := id.GenerateIdentity()
+	node, _ := id.GenerateIdentity()
+
+	var limiter = NewLimiter()
+	limiter.SetCallerLimit(alice, Limit{BytesPerDay: 100})
+
+	s, err := limiter.Admit(net.NewQuery(alice, node, "ping"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// bytes are charged while the session is still open
+	if !s.Charge(60) || !s.Charge(40) {
+		t.Fatal("transfer within the quota was refused")
+	}
+	if s.Charge(1) {
+		t.Fatal("transfer over the quota was allowed")
+	}
+
+	if _, err := limiter.Admit(net.NewQuery(alice, node, "ping")); err == nil {
+		t.Fatal("expected the quota to be exceeded before the session ended")
+	}
+	s.Done(0)
+}
@@ -26,6 +26,7 @@ type MonitoredConn struct {
 	establishedAt time.Time
 
 	closeMu      sync.Mutex
+	closeOnce    sync.Once
 	targetClosed bool
 	callerClosed bool
 	done         chan struct{}
@@ -134,6 +135,18 @@ func (conn *MonitoredConn) State() string {
 	panic("?")
 }
 
+// Close closes both sides of the connection
+func (conn *MonitoredConn) Close() {
+	conn.closeOnce.Do(func() {
+		if conn.target != nil {
+			conn.target.Close()
+		}
+		if conn.caller != nil {
+			conn.caller.Close()
+		}
+	})
+}
+
 func (conn *MonitoredConn) onTargetClosed() {
 	conn.closeMu.Lock()
 	defer conn.closeMu.Unlock()
@@ -39,7 +39,7 @@ func (router *PrefixRouter) RouteQuery(ctx context.Context, query net.Query, cal
 
 	if route == nil {
 		if router.Exclusive == true {
-			return net.Reject()
+			return net.RejectWithReason(net.RejectCodeRouteNotFound, "")
 		} else {
 			return net.RouteNotFound(router)
 		}
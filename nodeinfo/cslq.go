@@ -11,8 +11,10 @@ const infoPrefix = "node1"
 const netCodeTCP = 0
 const netCodeTor = 1
 const netCodeGateway = 2
+const netCodeQUIC = 3
 const netCodeOther = 255
 const netNameGateway = "gw"
+const netNameQUIC = "quic"
 
 func (info *NodeInfo) UnmarshalCSLQ(dec *cslq.Decoder) error {
 	var count int
@@ -68,6 +70,10 @@ func encodeAddr(enc *cslq.Encoder, addr net.Endpoint) error {
 		if err := enc.Encodef("c", netCodeGateway); err != nil {
 			return err
 		}
+	case netNameQUIC:
+		if err := enc.Encodef("c", netCodeQUIC); err != nil {
+			return err
+		}
 	default:
 		err := enc.Encodef("c[c]c", 255, addr.Network())
 		if err != nil {
@@ -98,6 +104,9 @@ func decodeAddr(dec *cslq.Decoder) (*net.GenericEndpoint, error) {
 	case netCodeGateway:
 		netName = netNameGateway
 
+	case netCodeQUIC:
+		netName = netNameQUIC
+
 	case netCodeOther:
 		if err := dec.Decodef("[c]c", &netName); err != nil {
 			return nil, err
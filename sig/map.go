@@ -108,3 +108,15 @@ func (m *Map[K, V]) Keys() (keys []K) {
 
 	return
 }
+
+// Values returns a list of the values in the map
+func (m *Map[K, V]) Values() (values []V) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	for _, v := range m.m {
+		values = append(values, v)
+	}
+
+	return
+}